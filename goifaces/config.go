@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFile is read from the working directory when -config is not
+// given.
+const defaultConfigFile = ".goifaces.yaml"
+
+// configOnlyFlags cannot be set from a config file: they select the file or
+// stop the run before it matters.
+var configOnlyFlags = map[string]bool{"config": true, "version": true}
+
+// configValue is one key of a config file with its raw values (several for
+// a YAML list) and the line it appeared on.
+type configValue struct {
+	values []string
+	line   int
+}
+
+// loadConfig reads the YAML config at path. With explicit unset (the default
+// .goifaces.yaml), a missing file is not an error and yields no values. Keys
+// are flag names without the dash (include-stdlib: true); values are
+// scalars, or lists for repeatable flags such as build-flag.
+func loadConfig(fs *flag.FlagSet, path string, explicit bool) (map[string]configValue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	return parseConfig(fs, path, data)
+}
+
+// parseConfig decodes a config file, rejecting keys that name no flag.
+func parseConfig(fs *flag.FlagSet, path string, data []byte) (map[string]configValue, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil // empty file
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d: config must be a mapping of flag names to values", path, root.Line)
+	}
+
+	cfg := make(map[string]configValue, len(root.Content)/2)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, val := root.Content[i], root.Content[i+1]
+		if fs.Lookup(key.Value) == nil || configOnlyFlags[key.Value] {
+			return nil, fmt.Errorf("%s:%d: unknown key %q%s", path, key.Line, key.Value, suggestKey(fs, key.Value))
+		}
+		v := configValue{line: key.Line}
+		switch val.Kind {
+		case yaml.ScalarNode:
+			v.values = []string{val.Value}
+		case yaml.SequenceNode:
+			for _, item := range val.Content {
+				if item.Kind != yaml.ScalarNode {
+					return nil, fmt.Errorf("%s:%d: %s: list items must be plain values", path, item.Line, key.Value)
+				}
+				v.values = append(v.values, item.Value)
+			}
+		default:
+			return nil, fmt.Errorf("%s:%d: %s: want a value or a list of values", path, val.Line, key.Value)
+		}
+		cfg[key.Value] = v
+	}
+	return cfg, nil
+}
+
+// applyConfigDefaults sets every flag in fs that is still unset — not given
+// on the command line nor by a GOIFACES_* variable, which must be applied
+// first — from cfg. Precedence is thus defaults < config < env < flags.
+func applyConfigDefaults(fs *flag.FlagSet, cfg map[string]configValue, path string) error {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	names := make([]string, 0, len(cfg))
+	for name := range cfg {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if set[name] {
+			continue
+		}
+		v := cfg[name]
+		for _, val := range v.values {
+			if err := fs.Set(name, val); err != nil {
+				return fmt.Errorf("%s:%d: invalid value %q for %s: %w", path, v.line, val, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// suggestKey returns a " (did you mean ...)" hint naming the flag closest to
+// an unknown config key, or "" when none is close.
+func suggestKey(fs *flag.FlagSet, key string) string {
+	if f := fs.Lookup(strings.ReplaceAll(key, "_", "-")); f != nil && !configOnlyFlags[f.Name] {
+		return fmt.Sprintf(" (did you mean %q?)", f.Name)
+	}
+	best, bestDist := "", 3 // suggest only within two edits
+	fs.VisitAll(func(f *flag.Flag) {
+		if configOnlyFlags[f.Name] {
+			return
+		}
+		if d := editDistance(key, f.Name); d < bestDist {
+			best, bestDist = f.Name, d
+		}
+	})
+	if best == "" {
+		return "; keys are flag names without the leading dash, see goifaces -h"
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), defaultConfigFile)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestConfig_SetsDefaults(t *testing.T) {
+	fs, filter, port, noBrowser, timeout := newTestFlagSet()
+	var buildFlags stringList
+	fs.Var(&buildFlags, "build-flag", "")
+	require.NoError(t, fs.Parse(nil))
+
+	path := writeConfig(t, `
+filter: example.com/app/internal
+port: 9090
+no-browser: true
+enrich-timeout: 30s
+build-flag:
+  - -tags=integration
+  - -mod=vendor
+`)
+	cfg, err := loadConfig(fs, path, true)
+	require.NoError(t, err)
+	require.NoError(t, applyConfigDefaults(fs, cfg, path))
+
+	assert.Equal(t, "example.com/app/internal", *filter)
+	assert.Equal(t, 9090, *port)
+	assert.True(t, *noBrowser)
+	assert.Equal(t, 30*time.Second, *timeout)
+	assert.Equal(t, stringList{"-tags=integration", "-mod=vendor"}, buildFlags)
+}
+
+func TestConfig_Precedence(t *testing.T) {
+	// defaults < config < env < flags
+	fs, filter, port, noBrowser, timeout := newTestFlagSet()
+	require.NoError(t, fs.Parse([]string{"-port", "7000"}))
+	require.NoError(t, applyEnvDefaults(fs, envLookup(map[string]string{
+		"GOIFACES_FILTER": "example.com/env",
+		"GOIFACES_PORT":   "9090",
+	})))
+
+	path := writeConfig(t, "filter: example.com/config\nport: 6000\nno-browser: true\n")
+	cfg, err := loadConfig(fs, path, true)
+	require.NoError(t, err)
+	require.NoError(t, applyConfigDefaults(fs, cfg, path))
+
+	assert.Equal(t, 7000, *port, "flag beats env and config")
+	assert.Equal(t, "example.com/env", *filter, "env beats config")
+	assert.True(t, *noBrowser, "config beats default")
+	assert.Equal(t, 2*time.Minute, *timeout, "default when set nowhere")
+}
+
+func TestConfig_UnknownKey(t *testing.T) {
+	fs, _, _, _, _ := newTestFlagSet()
+	fs.String("config", "", "")
+	fs.Bool("include-stdlib", false, "")
+
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"port: 1\ninclude_stdlib: true\n", `:2: unknown key "include_stdlib" (did you mean "include-stdlib"?)`},
+		{"filtr: x\n", `:1: unknown key "filtr" (did you mean "filter"?)`},
+		{"no_browser: true\n", `:1: unknown key "no_browser" (did you mean "no-browser"?)`},
+		{"colour: blue\n", `:1: unknown key "colour"; keys are flag names without the leading dash`},
+		{"config: other.yaml\n", `:1: unknown key "config"`},
+	}
+	for _, tt := range tests {
+		_, err := parseConfig(fs, "cfg.yaml", []byte(tt.content))
+		require.Error(t, err, tt.content)
+		assert.Contains(t, err.Error(), "cfg.yaml"+tt.want)
+	}
+}
+
+func TestConfig_InvalidContent(t *testing.T) {
+	fs, _, _, _, _ := newTestFlagSet()
+	require.NoError(t, fs.Parse(nil))
+
+	for _, content := range []string{"- port\n", "port: {a: 1}\n", "port: [[1]]\n", "port: [1\n"} {
+		_, err := parseConfig(fs, "cfg.yaml", []byte(content))
+		assert.Error(t, err, content)
+	}
+
+	cfg, err := parseConfig(fs, "cfg.yaml", []byte("filter: x\nport: not-a-number\n"))
+	require.NoError(t, err)
+	err = applyConfigDefaults(fs, cfg, "cfg.yaml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `cfg.yaml:2: invalid value "not-a-number" for port`)
+}
+
+func TestConfig_MissingFile(t *testing.T) {
+	fs, _, _, _, _ := newTestFlagSet()
+	missing := filepath.Join(t.TempDir(), defaultConfigFile)
+
+	cfg, err := loadConfig(fs, missing, false)
+	require.NoError(t, err, "the default file is optional")
+	assert.Empty(t, cfg)
+
+	_, err = loadConfig(fs, missing, true)
+	assert.Error(t, err, "an explicit -config must exist")
+
+	cfg, err = parseConfig(fs, "cfg.yaml", []byte("# nothing yet\n"))
+	require.NoError(t, err)
+	assert.Empty(t, cfg)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+)
+
+// coverageTargets merges the comma-separated -coverage list with the
+// -coverage-file entries (one per line, '#' starts a comment), dropping
+// blanks and duplicates while keeping first-seen order.
+func coverageTargets(list, file string) ([]string, error) {
+	raw := strings.Split(list, ",")
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading coverage file: %w", err)
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line, _, _ := strings.Cut(scanner.Text(), "#")
+			raw = append(raw, line)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("reading coverage file: %w", err)
+		}
+	}
+
+	var targets []string
+	seen := make(map[string]bool)
+	for _, spec := range raw {
+		spec = strings.TrimSpace(spec)
+		if spec == "" || seen[spec] {
+			continue
+		}
+		seen[spec] = true
+		targets = append(targets, spec)
+	}
+	return targets, nil
+}
+
+// writeCoverageReport prints report as a human-readable table followed by a
+// PASS/FAIL line, or as indented JSON when asJSON is set.
+func writeCoverageReport(w io.Writer, report analyzer.CoverageReport, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	for _, row := range report.Interfaces {
+		name := row.Interface
+		if name == "" {
+			name = row.Target
+		}
+		switch row.Status {
+		case analyzer.CoverageCovered:
+			fmt.Fprintf(w, "  ok         %s (%d implementers)\n", name, len(row.Implementers))
+		case analyzer.CoverageTestOnly:
+			fmt.Fprintf(w, "  test-only  %s (%d test implementers)\n", name, len(row.TestImplementers))
+		case analyzer.CoverageUncovered:
+			fmt.Fprintf(w, "  uncovered  %s\n", name)
+		default:
+			fmt.Fprintf(w, "  not-found  %s\n", row.Error)
+		}
+	}
+	verdict := "PASS"
+	if !report.Pass {
+		verdict = "FAIL"
+	}
+	fmt.Fprintf(w, "%s: %d/%d interfaces covered\n", verdict, report.Covered, report.Total)
+	return nil
+}
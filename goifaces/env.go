@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// envPrefix is prepended to upper-cased flag names to form environment
+// variable names (e.g. -log-level -> GOIFACES_LOG_LEVEL).
+const envPrefix = "GOIFACES_"
+
+// envVarName returns the environment variable that backs the named flag.
+func envVarName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// applyEnvDefaults sets every flag in fs that was not given on the command
+// line from its GOIFACES_* environment variable, if present. Flags passed
+// explicitly always win; lookup is os.LookupEnv outside of tests.
+func applyEnvDefaults(fs *flag.FlagSet, lookup func(string) (string, bool)) error {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	var firstErr error
+	fs.VisitAll(func(f *flag.Flag) {
+		if firstErr != nil || explicit[f.Name] {
+			return
+		}
+		name := envVarName(f.Name)
+		val, ok := lookup(name)
+		if !ok {
+			return
+		}
+		if err := fs.Set(f.Name, val); err != nil {
+			firstErr = fmt.Errorf("invalid value %q for %s: %w", val, name, err)
+		}
+	})
+	return firstErr
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func envLookup(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+}
+
+func newTestFlagSet() (*flag.FlagSet, *string, *int, *bool, *time.Duration) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	filter := fs.String("filter", "", "")
+	port := fs.Int("port", 8080, "")
+	noBrowser := fs.Bool("no-browser", false, "")
+	timeout := fs.Duration("enrich-timeout", 2*time.Minute, "")
+	return fs, filter, port, noBrowser, timeout
+}
+
+func TestEnvVarName(t *testing.T) {
+	assert.Equal(t, "GOIFACES_FILTER", envVarName("filter"))
+	assert.Equal(t, "GOIFACES_LOG_LEVEL", envVarName("log-level"))
+	assert.Equal(t, "GOIFACES_TREEMAP_MAX_NODES", envVarName("treemap-max-nodes"))
+}
+
+func TestApplyEnvDefaults_EnvOverridesDefault(t *testing.T) {
+	fs, filter, port, noBrowser, timeout := newTestFlagSet()
+	require.NoError(t, fs.Parse(nil))
+
+	err := applyEnvDefaults(fs, envLookup(map[string]string{
+		"GOIFACES_FILTER":         "example.com/app/internal",
+		"GOIFACES_PORT":           "9090",
+		"GOIFACES_NO_BROWSER":     "true",
+		"GOIFACES_ENRICH_TIMEOUT": "30s",
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "example.com/app/internal", *filter)
+	assert.Equal(t, 9090, *port)
+	assert.True(t, *noBrowser)
+	assert.Equal(t, 30*time.Second, *timeout)
+}
+
+func TestApplyEnvDefaults_FlagOverridesEnv(t *testing.T) {
+	fs, filter, port, _, _ := newTestFlagSet()
+	require.NoError(t, fs.Parse([]string{"-port", "7000", "-filter", "example.com/cli"}))
+
+	err := applyEnvDefaults(fs, envLookup(map[string]string{
+		"GOIFACES_FILTER": "example.com/env",
+		"GOIFACES_PORT":   "9090",
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "example.com/cli", *filter)
+	assert.Equal(t, 7000, *port)
+}
+
+func TestApplyEnvDefaults_ExplicitDefaultStillWins(t *testing.T) {
+	// Passing a flag with its default value is still an explicit choice.
+	fs, _, port, _, _ := newTestFlagSet()
+	require.NoError(t, fs.Parse([]string{"-port", "8080"}))
+
+	require.NoError(t, applyEnvDefaults(fs, envLookup(map[string]string{"GOIFACES_PORT": "9090"})))
+	assert.Equal(t, 8080, *port)
+}
+
+func TestApplyEnvDefaults_NoEnvKeepsDefault(t *testing.T) {
+	fs, filter, port, noBrowser, _ := newTestFlagSet()
+	require.NoError(t, fs.Parse(nil))
+
+	require.NoError(t, applyEnvDefaults(fs, envLookup(nil)))
+	assert.Empty(t, *filter)
+	assert.Equal(t, 8080, *port)
+	assert.False(t, *noBrowser)
+}
+
+func TestApplyEnvDefaults_InvalidValue(t *testing.T) {
+	fs, _, _, _, _ := newTestFlagSet()
+	require.NoError(t, fs.Parse(nil))
+
+	err := applyEnvDefaults(fs, envLookup(map[string]string{"GOIFACES_PORT": "not-a-number"}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "GOIFACES_PORT")
+}
@@ -14,42 +14,126 @@ import (
 	"golang.org/x/tools/go/types/typeutil"
 )
 
+// newLoadConfig returns the go/packages configuration Analyze loads with.
+// opts.BuildFlags are passed through to the go command unchanged; test files
+// are only loaded with opts.IncludeTests; opts.GOOS and opts.GOARCH
+// override the target platform through the go command's environment.
+func newLoadConfig(ctx context.Context, dir string, opts AnalyzeOptions) *packages.Config {
+	return &packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedSyntax |
+			packages.NeedTypesInfo | packages.NeedImports | packages.NeedModule |
+			packages.NeedFiles,
+		Dir:        dir,
+		Context:    ctx,
+		Env:        platformEnv(opts),
+		BuildFlags: append([]string(nil), opts.BuildFlags...),
+		Tests:      opts.IncludeTests,
+	}
+}
+
 // Analyze loads Go packages from dir and finds all interface-implementation relationships.
+// With opts.ResultCacheDir it returns the cached result instead while dir's
+// sources are unchanged; TypeObj fields are nil in such a result.
 func Analyze(ctx context.Context, dir string, opts AnalyzeOptions, logger *slog.Logger) (*Result, error) {
+	if opts.ResultCacheDir != "" {
+		return analyzeCached(ctx, dir, opts, logger)
+	}
+	return analyze(ctx, dir, opts, logger)
+}
+
+func analyze(ctx context.Context, dir string, opts AnalyzeOptions, logger *slog.Logger) (*Result, error) {
 	modulePath := readModulePath(dir)
-	if modulePath != "" {
+	patterns := []string{"./..."}
+	// In a go.work workspace root, load every workspace module; the module
+	// path becomes their common prefix so that they all count as local.
+	workspace := readWorkspaceModules(dir)
+	if len(workspace) > 0 {
+		patterns = make([]string, len(workspace))
+		for i, modPath := range workspace {
+			patterns[i] = modPath + "/..."
+		}
+		modulePath = commonModulePath(workspace)
+		logger.Info("detected workspace", "modules", workspace, "module_path", modulePath)
+	} else if modulePath != "" {
 		logger.Info("detected module", "module_path", modulePath)
 	}
+	if opts.Subdir != "" {
+		patterns = []string{"./" + opts.Subdir + "/..."}
+		logger.Info("loading subdirectory", "subdir", opts.Subdir)
+	}
 
-	cfg := &packages.Config{
-		Mode: packages.NeedName | packages.NeedTypes | packages.NeedSyntax |
-			packages.NeedTypesInfo | packages.NeedImports,
-		Dir:     dir,
-		Context: ctx,
+	cfg := newLoadConfig(ctx, dir, opts)
+	if len(cfg.BuildFlags) > 0 {
+		logger.Info("using extra build flags", "build_flags", cfg.BuildFlags)
+	}
+	if cfg.Env != nil {
+		if err := checkPlatform(ctx, dir, cfg.Env); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrLoadFailed, err)
+		}
+		logger.Info("using target platform", "goos", opts.GOOS, "goarch", opts.GOARCH)
 	}
 
-	pkgs, err := packages.Load(cfg, "./...")
+	loading := startPhase(opts.Progress, PhaseLoading, 1)
+	pkgs, err := packages.Load(cfg, patterns...)
+	// Without a go.mod, go list fails or reports the pattern as a package
+	// holding only an error — this is likely a non-Go directory.
+	noModule := modulePath == "" && len(workspace) == 0 && !goModExists(dir)
 	if err != nil {
-		if modulePath == "" && !goModExists(dir) {
-			// No go.mod and package loading failed — this is likely a non-Go directory.
-			logger.Warn("no Go packages found", "dir", dir, "error", err)
-			return &Result{}, nil
+		if noModule {
+			return nil, fmt.Errorf("%w in %s (no go.mod): %w", ErrNoPackages, dir, err)
 		}
-		return nil, fmt.Errorf("loading packages: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrLoadFailed, err)
+	}
+	if len(pkgs) == 0 || noModule && !hasGoFiles(pkgs) {
+		return nil, fmt.Errorf("%w in %s", ErrNoPackages, dir)
 	}
 
-	// When including stdlib, also load common stdlib packages that define interfaces
-	if opts.IncludeStdlib {
-		stdlibPatterns := []string{"fmt", "io", "io/fs", "encoding", "encoding/json", "sort", "hash", "context"}
-		stdPkgs, stdErr := packages.Load(cfg, stdlibPatterns...)
-		if stdErr != nil {
-			logger.Warn("failed to load stdlib packages", "error", stdErr)
+	// Also load modules replaced with a local directory (replace foo => ../foo)
+	// so that local-development monorepos include their sibling modules.
+	if replaced := readLocalReplaces(dir); len(replaced) > 0 {
+		patterns := make([]string, len(replaced))
+		for i, modPath := range replaced {
+			patterns[i] = modPath + "/..."
+		}
+		replPkgs, replErr := packages.Load(cfg, patterns...)
+		if replErr != nil {
+			logger.Warn("failed to load replaced modules", "modules", replaced, "error", replErr)
 		} else {
-			pkgs = append(pkgs, stdPkgs...)
+			pkgs = append(pkgs, replPkgs...)
 		}
 	}
 
+	// Guard: stop before collecting types from a huge monorepo. The count is
+	// of loaded packages, not of those that end up contributing nodes.
+	pkgCount := countPackages(pkgs, opts.Filter)
+	logger.Info("module packages loaded", "packages", pkgCount, "filter", opts.Filter, "max_packages", opts.MaxPackages)
+	if opts.MaxPackages > 0 && pkgCount > opts.MaxPackages {
+		return nil, fmt.Errorf("%w: %d packages exceeds limit %d; narrow with -filter", ErrTooManyPackages, pkgCount, opts.MaxPackages)
+	}
+
+	// When including stdlib, offer the interfaces of the stdlib packages the
+	// loaded ones import (see stdlibPackages)
+	var stdPkgs []*packages.Package
+	if opts.IncludeStdlib {
+		stdPkgs = stdlibPackages(cfg, pkgs, modulePath, logger)
+	}
+
+	if cfg.Tests {
+		pkgs = dedupeTestVariants(pkgs)
+	}
 	logger.Info("packages loaded", "packages_count", len(pkgs))
+	loading.report(1)
+
+	// Record modules whose source comes from a replace directive.
+	replacedModules := make(map[string]string)
+	for _, pkg := range pkgs {
+		if pkg.Module != nil && pkg.Module.Replace != nil {
+			replacedModules[pkg.Module.Path] = pkg.Module.Replace.Path
+		}
+	}
+	if len(replacedModules) > 0 {
+		logger.Info("replaced modules detected", "count", len(replacedModules))
+	}
 
 	// Log packages with errors but continue
 	for _, pkg := range pkgs {
@@ -62,14 +146,33 @@ func Analyze(ctx context.Context, dir string, opts AnalyzeOptions, logger *slog.
 	var ifaces []InterfaceDef
 	var namedTypes []TypeDef
 	seenIfaces := make(map[string]bool) // pkgPath.Name dedup
+	var fingerprints map[string]string  // pkgPath -> file fingerprint, for the match cache
+	if opts.MatchCache != nil {
+		fingerprints = make(map[string]string)
+	}
 
-	collectFromScope := func(scope *types.Scope, pkgPath, pkgName string, fset *token.FileSet, moduleRoot string) {
+	collectFromScope := func(pkg *packages.Package) {
+		scope := pkg.Types.Scope()
+		pkgPath, pkgName := pkg.PkgPath, pkg.Name
+		if fingerprints != nil {
+			if _, ok := fingerprints[pkgPath]; !ok {
+				fingerprints[pkgPath] = packageFingerprint(pkg)
+			}
+		}
 		for _, name := range scope.Names() {
 			obj := scope.Lookup(name)
 			tn, ok := obj.(*types.TypeName)
 			if !ok {
 				continue
 			}
+			if tn.IsAlias() {
+				if ifaceDef, ok := markerAliasDef(pkg, tn, dir); ok && !seenIfaces[pkgPath+"."+tn.Name()] {
+					seenIfaces[pkgPath+"."+tn.Name()] = true
+					ifaces = append(ifaces, ifaceDef)
+					logger.Debug("found marker alias", "name", tn.Name(), "package", pkgPath)
+				}
+				continue
+			}
 
 			named, ok := tn.Type().(*types.Named)
 			if !ok {
@@ -88,7 +191,10 @@ func Analyze(ctx context.Context, dir string, opts AnalyzeOptions, logger *slog.
 					PkgName:    pkgName,
 					Methods:    extractIfaceMethods(iface),
 					TypeObj:    iface,
-					SourceFile: resolveSourceFile(fset, tn.Pos(), moduleRoot),
+					SourceFile: resolvePackageSourceFile(pkg, tn.Pos(), dir),
+					Produces:   extractProduces(iface),
+					Embeds:     extractIfaceEmbeds(iface),
+					IsMarker:   isMarker(iface),
 				}
 				ifaces = append(ifaces, ifaceDef)
 				logger.Debug("found interface", "name", tn.Name(), "package", pkgPath, "methods", iface.NumMethods())
@@ -96,21 +202,30 @@ func Analyze(ctx context.Context, dir string, opts AnalyzeOptions, logger *slog.
 		}
 	}
 
-	for _, pkg := range pkgs {
+	collecting := startPhase(opts.Progress, PhaseCollecting, len(pkgs))
+	for i, pkg := range pkgs {
+		collecting.report(i)
 		if pkg.Types == nil {
 			continue
 		}
 
 		// Collect types from direct packages
-		scope := pkg.Types.Scope()
-		collectFromScope(scope, pkg.PkgPath, pkg.Name, pkg.Fset, dir)
+		collectFromScope(pkg)
 
+		scope := pkg.Types.Scope()
 		for _, name := range scope.Names() {
 			obj := scope.Lookup(name)
 			tn, ok := obj.(*types.TypeName)
 			if !ok {
 				continue
 			}
+			if tn.IsAlias() {
+				if typeDef, ok := aliasTypeDef(pkg, tn, dir); ok {
+					namedTypes = append(namedTypes, typeDef)
+					logger.Debug("found type alias", "name", tn.Name(), "package", pkg.PkgPath, "alias_of", typeDef.AliasOf)
+				}
+				continue
+			}
 			named, ok := tn.Type().(*types.Named)
 			if !ok {
 				continue
@@ -122,9 +237,10 @@ func Analyze(ctx context.Context, dir string, opts AnalyzeOptions, logger *slog.
 					PkgPath:    pkg.PkgPath,
 					PkgName:    pkg.Name,
 					IsStruct:   isStruct(named),
+					IsFunc:     isFunc(named),
 					Methods:    methods,
 					TypeObj:    named,
-					SourceFile: resolveSourceFile(pkg.Fset, tn.Pos(), dir),
+					SourceFile: resolvePackageSourceFile(pkg, tn.Pos(), dir),
 				}
 				namedTypes = append(namedTypes, typeDef)
 				logger.Debug("found type", "name", tn.Name(), "package", pkg.PkgPath, "methods", len(methods))
@@ -140,7 +256,16 @@ func Analyze(ctx context.Context, dir string, opts AnalyzeOptions, logger *slog.
 			if modulePath != "" && !strings.HasPrefix(imp.PkgPath, modulePath) {
 				continue
 			}
-			collectFromScope(imp.Types.Scope(), imp.PkgPath, imp.Name, imp.Fset, dir)
+			collectFromScope(imp)
+		}
+	}
+
+	collecting.report(len(pkgs))
+
+	// Stdlib packages contribute interfaces only, not their concrete types
+	for _, pkg := range stdPkgs {
+		if pkg.Types != nil {
+			collectFromScope(pkg)
 		}
 	}
 
@@ -164,14 +289,138 @@ func Analyze(ctx context.Context, dir string, opts AnalyzeOptions, logger *slog.
 		}
 	}
 
+	if !opts.IncludeTests {
+		ifaces, namedTypes = dropTestDecls(ifaces, namedTypes)
+	}
+
 	logger.Info("types collected", "interfaces", len(ifaces), "types", len(namedTypes))
 
+	// Guard: refuse to run the O(types × interfaces) match phase on huge inputs
+	if opts.MaxNodes > 0 {
+		if n := countNodes(ifaces, namedTypes, opts.Filter); n > opts.MaxNodes {
+			return nil, fmt.Errorf("%w: %d nodes exceeds limit %d; narrow with -filter", ErrTooManyNodes, n, opts.MaxNodes)
+		}
+	}
+
 	// Phase 3: Match implementations
+	relations := matchImplementations(namedTypes, ifaces, fingerprints, opts.MatchCache, opts.Progress, logger)
+
+	logger.Info("analysis complete", "relations", len(relations))
+
+	result := &Result{
+		Interfaces: ifaces,
+		Types:      namedTypes,
+		ModulePath: modulePath,
+		Relations:  relations,
+		Embeds:     extractEmbeds(namedTypes, ifaces),
+		References: countReferences(pkgs),
+	}
+	if len(replacedModules) > 0 {
+		result.ReplacedModules = replacedModules
+	}
+	return result, nil
+}
+
+// hasGoFiles reports whether any of pkgs has Go source files.
+func hasGoFiles(pkgs []*packages.Package) bool {
+	for _, pkg := range pkgs {
+		if len(pkg.GoFiles) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// countNodes returns how many interfaces and types take part in matching: all
+// of them, or with a filter prefix only those whose package is under it.
+func countNodes(ifaces []InterfaceDef, namedTypes []TypeDef, filter string) int {
+	if filter == "" {
+		return len(ifaces) + len(namedTypes)
+	}
+	n := 0
+	for i := range ifaces {
+		if strings.HasPrefix(ifaces[i].PkgPath, filter) {
+			n++
+		}
+	}
+	for i := range namedTypes {
+		if strings.HasPrefix(namedTypes[i].PkgPath, filter) {
+			n++
+		}
+	}
+	return n
+}
+
+// countPackages returns the number of distinct package paths in pkgs, only
+// counting those under filter when it is set. Test variants count with their
+// package and generated test mains not at all.
+func countPackages(pkgs []*packages.Package, filter string) int {
+	seen := make(map[string]bool, len(pkgs))
+	for _, pkg := range pkgs {
+		path := strings.TrimSuffix(pkg.PkgPath, "_test")
+		if strings.HasSuffix(path, ".test") || !strings.HasPrefix(path, filter) {
+			continue
+		}
+		seen[path] = true
+	}
+	return len(seen)
+}
+
+// matchImplementations pairs every named type with every non-empty interface
+// it implements. When cache is non-nil, pairs whose type and interface are
+// unchanged since the cached run (same package fingerprint and method-signature
+// hash) reuse the cached outcome; the cache is then rewritten to reflect this
+// run. progress, if set, counts the types matched (PhaseMatching).
+func matchImplementations(namedTypes []TypeDef, ifaces []InterfaceDef, fingerprints map[string]string, cache *MatchCache, progress func(phase string, done, total int), logger *slog.Logger) []Relation {
 	var methodSetCache typeutil.MethodSetCache
 	var relations []Relation
 
+	var ifaceHashes []string
+	var ifaceFresh []bool
+	next := NewMatchCache()
+	if cache != nil {
+		ifaceHashes = make([]string, len(ifaces))
+		ifaceFresh = make([]bool, len(ifaces))
+		for j := range ifaces {
+			iface := &ifaces[j]
+			key := iface.PkgPath + "." + iface.Name
+			ifaceHashes[j] = ifaceMethodHash(iface.TypeObj)
+			ifaceFresh[j] = cache.packageUnchanged(iface.PkgPath, fingerprints) && cache.Interfaces[key] == ifaceHashes[j]
+			next.Interfaces[key] = ifaceHashes[j]
+		}
+		cache.Reused, cache.Computed = 0, 0
+	}
+
+	total := 0
+	for i := range namedTypes {
+		if namedTypes[i].AliasOf == "" {
+			total++
+		}
+	}
+	matching := startPhase(progress, PhaseMatching, total)
+	done := 0
 	for i := range namedTypes {
 		t := &namedTypes[i]
+		if t.AliasOf != "" {
+			continue // its target is matched under its own name
+		}
+		typeKey := t.PkgPath + "." + t.Name
+
+		var typeHash string
+		var cached map[string]bool // interface key -> via pointer
+		typeFresh := false
+		if cache != nil {
+			typeHash = typeMethodHash(t.TypeObj, &methodSetCache)
+			if entry, ok := cache.Types[typeKey]; ok && entry.Hash == typeHash && cache.packageUnchanged(t.PkgPath, fingerprints) {
+				typeFresh = true
+				cached = make(map[string]bool, len(entry.Matches))
+				for _, m := range entry.Matches {
+					cached[m.Interface] = m.ViaPointer
+				}
+			}
+		}
+
+		var matches []cachedMatch
 		for j := range ifaces {
 			iface := &ifaces[j]
 
@@ -180,59 +429,179 @@ func Analyze(ctx context.Context, dir string, opts AnalyzeOptions, logger *slog.
 				continue
 			}
 
-			valType := t.TypeObj
-			valMethodSet := methodSetCache.MethodSet(valType)
-			ptrMethodSet := methodSetCache.MethodSet(types.NewPointer(valType))
-
-			if types.Implements(valType, iface.TypeObj) || matchesMethodSet(valMethodSet, iface.TypeObj) {
-				relations = append(relations, Relation{
-					Type:       t,
-					Interface:  iface,
-					ViaPointer: false,
-				})
-				logger.Debug("match found", "type", t.Name, "interface", iface.Name, "via_pointer", false)
-			} else if types.Implements(types.NewPointer(valType), iface.TypeObj) || matchesMethodSet(ptrMethodSet, iface.TypeObj) {
-				relations = append(relations, Relation{
-					Type:       t,
-					Interface:  iface,
-					ViaPointer: true,
-				})
-				logger.Debug("match found", "type", t.Name, "interface", iface.Name, "via_pointer", true)
+			var ok, viaPointer bool
+			if typeFresh && ifaceFresh[j] {
+				viaPointer, ok = cached[iface.PkgPath+"."+iface.Name]
+				cache.Reused++
+			} else {
+				ok, viaPointer = implements(t.TypeObj, iface.TypeObj, &methodSetCache)
+				if cache != nil {
+					cache.Computed++
+				}
+			}
+			if !ok {
+				continue
 			}
+			relations = append(relations, Relation{
+				Type:              t,
+				Interface:         iface,
+				ViaPointer:        viaPointer,
+				SatisfyingMethods: methodNames(satisfyingMethods(t.TypeObj, iface.TypeObj, viaPointer, &methodSetCache)),
+			})
+			matches = append(matches, cachedMatch{Interface: iface.PkgPath + "." + iface.Name, ViaPointer: viaPointer})
+			logger.Debug("match found", "type", t.Name, "interface", iface.Name, "via_pointer", viaPointer)
+		}
+
+		if cache != nil {
+			next.Types[typeKey] = cachedTypeInfo{Hash: typeHash, Matches: matches}
 		}
+		done++
+		matching.report(done)
 	}
 
-	logger.Info("analysis complete", "relations", len(relations))
+	if cache != nil {
+		for pkgPath, fp := range fingerprints {
+			next.Packages[pkgPath] = fp
+		}
+		cache.Packages, cache.Interfaces, cache.Types = next.Packages, next.Interfaces, next.Types
+		logger.Info("match cache applied", "reused", cache.Reused, "computed", cache.Computed)
+	}
+	return relations
+}
 
-	return &Result{
-		Interfaces: ifaces,
-		Types:      namedTypes,
-		ModulePath: modulePath,
-		Relations:  relations,
-	}, nil
+// aliasTypeDef returns the TypeDef for the type alias tn, with AliasOf set to
+// the key of the named type or interface it resolves to. Aliases of unnamed
+// or basic types (type Bytes = []byte) have no node to point at and are
+// skipped.
+func aliasTypeDef(pkg *packages.Package, tn *types.TypeName, dir string) (TypeDef, bool) {
+	named, ok := types.Unalias(tn.Type()).(*types.Named)
+	if !ok {
+		return TypeDef{}, false
+	}
+	target := named.Origin().Obj()
+	targetPath := "builtin"
+	if target.Pkg() != nil {
+		targetPath = target.Pkg().Path()
+	}
+	return TypeDef{
+		Name:       tn.Name(),
+		PkgPath:    pkg.PkgPath,
+		PkgName:    pkg.Name,
+		SourceFile: resolvePackageSourceFile(pkg, tn.Pos(), dir),
+		AliasOf:    targetPath + "." + target.Name(),
+	}, true
+}
+
+// isMarker reports whether iface has no methods. Constraint interfaces with
+// type terms (~int | ~string) have none either, but are not markers.
+func isMarker(iface *types.Interface) bool {
+	return iface.NumMethods() == 0 && iface.IsMethodSet()
+}
+
+// markerAliasDef returns the marker InterfaceDef for an alias of an unnamed
+// empty interface (type Any = interface{}, type Value = any). Aliases of
+// named types are aliasTypeDef's.
+func markerAliasDef(pkg *packages.Package, tn *types.TypeName, dir string) (InterfaceDef, bool) {
+	iface, ok := types.Unalias(tn.Type()).(*types.Interface)
+	if !ok || !isMarker(iface) {
+		return InterfaceDef{}, false
+	}
+	return InterfaceDef{
+		Name:       tn.Name(),
+		PkgPath:    pkg.PkgPath,
+		PkgName:    pkg.Name,
+		Methods:    []MethodSig{},
+		TypeObj:    iface,
+		SourceFile: resolvePackageSourceFile(pkg, tn.Pos(), dir),
+		IsMarker:   true,
+	}, true
+}
+
+// implements reports whether t implements iface directly, or only through *t.
+func implements(t *types.Named, iface *types.Interface, msets *typeutil.MethodSetCache) (ok, viaPointer bool) {
+	if types.Implements(t, iface) || matchesMethodSet(msets.MethodSet(t), iface) {
+		return true, false
+	}
+	ptr := types.NewPointer(t)
+	if types.Implements(ptr, iface) || matchesMethodSet(msets.MethodSet(ptr), iface) {
+		return true, true
+	}
+	return false, false
 }
 
 func extractIfaceMethods(iface *types.Interface) []MethodSig {
 	methods := make([]MethodSig, iface.NumMethods())
 	for i := 0; i < iface.NumMethods(); i++ {
 		m := iface.Method(i)
-		methods[i] = MethodSig{
-			Name:      m.Name(),
-			Signature: formatSignature(m),
-		}
+		methods[i] = newMethodSig(m.Name(), formatSignature(m))
 	}
 	return methods
 }
 
+// extractProduces returns the keys (pkgPath.Name) of named types that appear
+// in the results of an interface's methods, looking through pointers, slices
+// and arrays. Builtin types such as error are skipped.
+func extractProduces(iface *types.Interface) []string {
+	var produces []string
+	seen := make(map[string]bool)
+	for i := 0; i < iface.NumMethods(); i++ {
+		sig := iface.Method(i).Type().(*types.Signature)
+		results := sig.Results()
+		for j := 0; j < results.Len(); j++ {
+			named := namedElem(results.At(j).Type())
+			if named == nil || named.Obj().Pkg() == nil {
+				continue
+			}
+			key := named.Obj().Pkg().Path() + "." + named.Obj().Name()
+			if !seen[key] {
+				seen[key] = true
+				produces = append(produces, key)
+			}
+		}
+	}
+	return produces
+}
+
+// namedElem unwraps pointer, slice and array types down to a named type.
+// Returns nil if the element type is not named.
+func namedElem(t types.Type) *types.Named {
+	for {
+		switch u := t.(type) {
+		case *types.Pointer:
+			t = u.Elem()
+		case *types.Slice:
+			t = u.Elem()
+		case *types.Array:
+			t = u.Elem()
+		case *types.Named:
+			return u
+		default:
+			return nil
+		}
+	}
+}
+
+// extractTypeMethods returns the methods of *named: its declared methods
+// (value and pointer receivers) in source order, followed by methods promoted
+// from embedded fields, deduplicated by name and signature.
 func extractTypeMethods(named *types.Named) []MethodSig {
 	var methods []MethodSig
-	// Value receiver methods
+	seen := make(map[MethodSig]bool)
+	add := func(fn *types.Func) {
+		sig := newMethodSig(fn.Name(), formatSignature(fn))
+		if !seen[sig] {
+			seen[sig] = true
+			methods = append(methods, sig)
+		}
+	}
 	for i := 0; i < named.NumMethods(); i++ {
-		m := named.Method(i)
-		methods = append(methods, MethodSig{
-			Name:      m.Name(),
-			Signature: formatSignature(m),
-		})
+		add(named.Method(i))
+	}
+	mset := types.NewMethodSet(types.NewPointer(named))
+	for i := 0; i < mset.Len(); i++ {
+		if fn, ok := mset.At(i).Obj().(*types.Func); ok {
+			add(fn)
+		}
 	}
 	return methods
 }
@@ -281,6 +650,11 @@ func isStruct(named *types.Named) bool {
 	return ok
 }
 
+func isFunc(named *types.Named) bool {
+	_, ok := named.Underlying().(*types.Signature)
+	return ok
+}
+
 func matchesMethodSet(mset *types.MethodSet, iface *types.Interface) bool {
 	for i := 0; i < iface.NumMethods(); i++ {
 		m := iface.Method(i)
@@ -308,6 +682,64 @@ func readModulePath(dir string) string {
 	return ""
 }
 
+// readLocalReplaces returns the module paths in dir's go.mod that are
+// replaced by a local directory (the right-hand side starts with "./", "../"
+// or is absolute). Both single-line and block forms are recognised.
+func readLocalReplaces(dir string) []string {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return nil
+	}
+	var mods []string
+	inBlock := false
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if idx := strings.Index(line, "//"); idx >= 0 {
+			line = strings.TrimSpace(line[:idx])
+		}
+		switch {
+		case line == "replace (":
+			inBlock = true
+			continue
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case strings.HasPrefix(line, "replace "):
+			line = strings.TrimSpace(strings.TrimPrefix(line, "replace"))
+		case !inBlock:
+			continue
+		}
+		lhs, rhs, ok := strings.Cut(line, "=>")
+		if !ok {
+			continue
+		}
+		oldFields := strings.Fields(lhs)
+		newFields := strings.Fields(rhs)
+		if len(oldFields) == 0 || len(newFields) == 0 {
+			continue
+		}
+		target := newFields[0]
+		if strings.HasPrefix(target, "./") || strings.HasPrefix(target, "../") || filepath.IsAbs(target) {
+			mods = append(mods, oldFields[0])
+		}
+	}
+	return mods
+}
+
+// resolvePackageSourceFile resolves a position in pkg to a source path.
+// Packages from a module replaced with a local directory live outside the
+// analyzed module root, so their paths are reported relative to the replaced
+// module's directory and prefixed with its module path
+// (e.g. "example.com/lib/logger.go"). All other paths are relative to dir.
+func resolvePackageSourceFile(pkg *packages.Package, pos token.Pos, dir string) string {
+	if mod := pkg.Module; mod != nil && mod.Replace != nil && mod.Dir != "" {
+		if rel := resolveSourceFile(pkg.Fset, pos, mod.Dir); rel != "" && !filepath.IsAbs(rel) {
+			return mod.Path + "/" + filepath.ToSlash(rel)
+		}
+	}
+	return resolveSourceFile(pkg.Fset, pos, dir)
+}
+
 // resolveSourceFile resolves a token position to a file path relative to moduleRoot.
 func resolveSourceFile(fset *token.FileSet, pos token.Pos, moduleRoot string) string {
 	if fset == nil || !pos.IsValid() {
@@ -0,0 +1,184 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestNewLoadConfigBuildFlags(t *testing.T) {
+	flags := []string{"-tags=integration", "-gcflags=all=-N -l"}
+	cfg := newLoadConfig(context.Background(), "/src", AnalyzeOptions{BuildFlags: flags})
+	assert.Equal(t, "/src", cfg.Dir)
+	assert.Equal(t, flags, cfg.BuildFlags)
+
+	// The config owns its copy; later changes to the options don't leak in.
+	flags[0] = "-mod=vendor"
+	assert.Equal(t, "-tags=integration", cfg.BuildFlags[0])
+
+	assert.Empty(t, newLoadConfig(context.Background(), "/src", AnalyzeOptions{}).BuildFlags)
+}
+
+func TestNewLoadConfigTests(t *testing.T) {
+	assert.False(t, newLoadConfig(context.Background(), "/src", AnalyzeOptions{}).Tests, "tests are excluded by default")
+	assert.True(t, newLoadConfig(context.Background(), "/src", AnalyzeOptions{IncludeTests: true}).Tests)
+}
+
+func TestNewLoadConfigPlatform(t *testing.T) {
+	assert.Nil(t, newLoadConfig(context.Background(), "/src", AnalyzeOptions{}).Env, "host environment")
+
+	env := newLoadConfig(context.Background(), "/src", AnalyzeOptions{GOOS: "windows", GOARCH: "arm64"}).Env
+	require.GreaterOrEqual(t, len(env), 2)
+	assert.Equal(t, []string{"GOOS=windows", "GOARCH=arm64"}, env[len(env)-2:], "later entries win over the inherited environment")
+
+	env = newLoadConfig(context.Background(), "/src", AnalyzeOptions{GOARCH: "386"}).Env
+	assert.Equal(t, "GOARCH=386", env[len(env)-1])
+	assert.NotContains(t, env, "GOOS=")
+}
+
+func TestDedupeTestVariants(t *testing.T) {
+	plain := &packages.Package{ID: "example.com/m/store", PkgPath: "example.com/m/store", Name: "store",
+		GoFiles: []string{"store.go"}}
+	variant := &packages.Package{ID: "example.com/m/store [example.com/m/store.test]", PkgPath: "example.com/m/store", Name: "store",
+		GoFiles: []string{"store.go", "fake_test.go"}}
+	external := &packages.Package{ID: "example.com/m/store_test [example.com/m/store.test]", PkgPath: "example.com/m/store_test", Name: "store_test",
+		GoFiles: []string{"example_test.go"}}
+	testMain := &packages.Package{ID: "example.com/m/store.test", PkgPath: "example.com/m/store.test", Name: "main"}
+	other := &packages.Package{ID: "example.com/m/api", PkgPath: "example.com/m/api", Name: "api", GoFiles: []string{"api.go"}}
+
+	got := dedupeTestVariants([]*packages.Package{plain, variant, external, testMain, other})
+	assert.Equal(t, []*packages.Package{variant, external, other}, got,
+		"one package per path, preferring the test variant, without the test main")
+}
+
+func TestCountPackages(t *testing.T) {
+	pkgs := []*packages.Package{
+		{ID: "example.com/m/store", PkgPath: "example.com/m/store"},
+		{ID: "example.com/m/store [example.com/m/store.test]", PkgPath: "example.com/m/store"},
+		{ID: "example.com/m/store_test [example.com/m/store.test]", PkgPath: "example.com/m/store_test"},
+		{ID: "example.com/m/store.test", PkgPath: "example.com/m/store.test"},
+		{ID: "example.com/m/api", PkgPath: "example.com/m/api"},
+	}
+	assert.Equal(t, 2, countPackages(pkgs, ""), "test variants, external tests and test mains count with their package")
+	assert.Equal(t, 1, countPackages(pkgs, "example.com/m/store"))
+	assert.Equal(t, 0, countPackages(pkgs, "example.com/other"))
+}
+
+func TestDropTestDecls(t *testing.T) {
+	ifaces := []InterfaceDef{{Name: "Store", SourceFile: "store.go"}, {Name: "helper", SourceFile: "store_test.go"}}
+	typs := []TypeDef{{Name: "Real", SourceFile: "store.go"}, {Name: "Fake", SourceFile: "sub/fake_test.go"}}
+	gotIfaces, gotTypes := dropTestDecls(ifaces, typs)
+	assert.Equal(t, []InterfaceDef{{Name: "Store", SourceFile: "store.go"}}, gotIfaces)
+	assert.Equal(t, []TypeDef{{Name: "Real", SourceFile: "store.go"}}, gotTypes)
+}
+
+func TestReadWorkspaceModules(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"go.work":        "go 1.22\n\nuse (\n\t./api // HTTP layer\n\t\"./store\"\n\t./missing\n)\n\nuse ./tools\n",
+		"api/go.mod":     "module example.com/ws/api\n",
+		"store/go.mod":   "module example.com/ws/store\n",
+		"tools/go.mod":   "module example.com/ws/tools\n",
+		"ignored/go.mod": "module example.com/ws/ignored\n",
+		"api/handler.go": "package api\n",
+		"store/store.go": "package store\n",
+		"tools/main.go":  "package main\n",
+		"ignored/lib.go": "package lib\n",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	assert.Equal(t, []string{"example.com/ws/api", "example.com/ws/store", "example.com/ws/tools"}, readWorkspaceModules(dir))
+	assert.Nil(t, readWorkspaceModules(t.TempDir()))
+}
+
+func TestCommonModulePath(t *testing.T) {
+	assert.Equal(t, "example.com/ws", commonModulePath([]string{"example.com/ws/api", "example.com/ws/store"}))
+	assert.Equal(t, "example.com/ws", commonModulePath([]string{"example.com/ws", "example.com/ws/tools"}))
+	assert.Equal(t, "example.com/ws/api", commonModulePath([]string{"example.com/ws/api"}))
+	// Element boundaries, not string prefixes.
+	assert.Equal(t, "example.com", commonModulePath([]string{"example.com/app", "example.com/apply"}))
+	assert.Equal(t, "", commonModulePath([]string{"github.com/a/x", "gitlab.com/b/y"}))
+	assert.Equal(t, "", commonModulePath(nil))
+}
+
+func TestExcluded(t *testing.T) {
+	assert.True(t, Excluded("example.com/app/mocks", []string{"mocks"}), "plain patterns match substrings")
+	assert.True(t, Excluded("example.com/app/internal/testdata/gen", []string{"nothing", "testdata"}), "patterns are OR'd")
+	assert.False(t, Excluded("example.com/app/store", []string{"mocks", "testdata"}))
+	assert.False(t, Excluded("example.com/app/store", nil))
+
+	assert.True(t, Excluded("example.com/app/mocks", []string{"**/mocks/**"}), "** matches no segment too")
+	assert.True(t, Excluded("example.com/app/store/mocks/db", []string{"**/mocks/**"}))
+	assert.False(t, Excluded("example.com/app/mockserver", []string{"**/mocks/**"}), "globs match whole segments")
+	assert.True(t, Excluded("example.com/app/store_gen", []string{"example.com/app/*_gen"}))
+	assert.False(t, Excluded("example.com/app/store/x_gen", []string{"example.com/app/*_gen"}), "* stays within a segment")
+	assert.True(t, Excluded("example.com/app/v2", []string{"**/v?"}))
+
+	require.NoError(t, ValidateExclude("**/mocks/**"))
+	require.NoError(t, ValidateExclude("mocks"))
+	assert.ErrorIs(t, ValidateExclude(""), ErrInvalidExclude)
+	assert.ErrorIs(t, ValidateExclude("**/[mocks"), ErrInvalidExclude)
+}
+
+func TestPhaseProgress(t *testing.T) {
+	var calls [][2]int
+	record := func(phase string, done, total int) {
+		assert.Equal(t, PhaseMatching, phase)
+		calls = append(calls, [2]int{done, total})
+	}
+
+	p := startPhase(record, PhaseMatching, 1005)
+	for done := 1; done <= 1005; done++ {
+		p.report(done)
+	}
+	assert.Len(t, calls, 102, "start, every 10th of 1005, and the last")
+	assert.Equal(t, [2]int{0, 1005}, calls[0])
+	assert.Equal(t, [2]int{10, 1005}, calls[1])
+	assert.Equal(t, [2]int{1005, 1005}, calls[len(calls)-1])
+
+	calls = nil
+	p = startPhase(record, PhaseMatching, 3)
+	for done := 1; done <= 3; done++ {
+		p.report(done)
+	}
+	assert.Equal(t, [][2]int{{0, 3}, {1, 3}, {2, 3}, {3, 3}}, calls, "small phases report every step")
+
+	// A nil callback is a no-op
+	startPhase(nil, PhaseLoading, 1).report(1)
+}
+
+func TestMetrics(t *testing.T) {
+	reader := InterfaceDef{Name: "Reader", PkgPath: "example.com/io", PkgName: "io"}
+	unused := InterfaceDef{Name: "Closer", PkgPath: "example.com/io", PkgName: "io"}
+	errIface := InterfaceDef{Name: "error", PkgPath: "builtin", PkgName: "builtin"}
+	file := TypeDef{Name: "File", PkgPath: "example.com/fs", PkgName: "fs"}
+	buf := TypeDef{Name: "Buffer", PkgPath: "example.com/bytes", PkgName: "bytes"}
+	result := &Result{
+		Interfaces: []InterfaceDef{reader, unused, errIface},
+		Types:      []TypeDef{file, buf},
+		Relations: []Relation{
+			{Type: &file, Interface: &reader},
+			{Type: &buf, Interface: &reader},
+			{Type: &file, Interface: &errIface, ViaPointer: true},
+		},
+	}
+
+	metrics := Metrics(result)
+	assert.Equal(t, map[string]NodeMetrics{
+		"example.com/io.Reader":    {ImplementedBy: 2, IsInterface: true},
+		"example.com/io.Closer":    {IsInterface: true},
+		"builtin.error":            {ImplementedBy: 1, IsInterface: true, Builtin: true},
+		"example.com/fs.File":      {Implements: 2},
+		"example.com/bytes.Buffer": {Implements: 1},
+	}, metrics)
+	assert.Equal(t, 2, metrics["example.com/fs.File"].Degree())
+	assert.Zero(t, metrics["example.com/io.Closer"].Degree())
+}
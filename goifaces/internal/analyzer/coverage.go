@@ -0,0 +1,93 @@
+package analyzer
+
+import (
+	"sort"
+	"strings"
+)
+
+// CoverageStatus is the verdict for one target interface of a coverage report.
+type CoverageStatus string
+
+const (
+	// CoverageCovered means at least one non-test type implements the interface.
+	CoverageCovered CoverageStatus = "covered"
+	// CoverageTestOnly means every implementer is declared in a _test.go file.
+	CoverageTestOnly CoverageStatus = "test-only"
+	// CoverageUncovered means no analyzed type implements the interface.
+	CoverageUncovered CoverageStatus = "uncovered"
+	// CoverageNotFound means the target does not name exactly one interface.
+	CoverageNotFound CoverageStatus = "not-found"
+)
+
+// InterfaceCoverage is one row of a CoverageReport.
+type InterfaceCoverage struct {
+	Target           string         `json:"target"`              // the spec as given
+	Interface        string         `json:"interface,omitempty"` // resolved "pkgPath.Name"
+	Status           CoverageStatus `json:"status"`
+	Implementers     []string       `json:"implementers"`               // non-test implementers, "pkgPath.Name"
+	TestImplementers []string       `json:"testImplementers,omitempty"` // implementers declared in _test.go files
+	Error            string         `json:"error,omitempty"`            // why the target was not found
+}
+
+// CoverageReport summarizes how many target interfaces have real implementers.
+type CoverageReport struct {
+	Interfaces []InterfaceCoverage `json:"interfaces"`
+	Covered    int                 `json:"covered"`
+	Total      int                 `json:"total"`
+	Pass       bool                `json:"pass"` // every target is covered
+}
+
+// Coverage checks each target interface (Name, pkg.Name or
+// import/path.Name, as for FindInterface) against the relations of result.
+// Rows keep the order of targets; implementer lists are sorted.
+func Coverage(result *Result, targets []string) CoverageReport {
+	report := CoverageReport{Interfaces: []InterfaceCoverage{}, Total: len(targets)}
+	for _, target := range targets {
+		row := InterfaceCoverage{Target: target, Implementers: []string{}}
+		iface, err := FindInterface(result, target)
+		if err != nil {
+			row.Status = CoverageNotFound
+			row.Error = err.Error()
+			report.Interfaces = append(report.Interfaces, row)
+			continue
+		}
+		row.Interface = ifaceKey(iface)
+
+		seen := make(map[string]bool)
+		for _, rel := range result.Relations {
+			if ifaceKey(rel.Interface) != row.Interface {
+				continue
+			}
+			key := typeKey(rel.Type)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if isTestFile(rel.Type.SourceFile) {
+				row.TestImplementers = append(row.TestImplementers, key)
+			} else {
+				row.Implementers = append(row.Implementers, key)
+			}
+		}
+		sort.Strings(row.Implementers)
+		sort.Strings(row.TestImplementers)
+
+		switch {
+		case len(row.Implementers) > 0:
+			row.Status = CoverageCovered
+			report.Covered++
+		case len(row.TestImplementers) > 0:
+			row.Status = CoverageTestOnly
+		default:
+			row.Status = CoverageUncovered
+		}
+		report.Interfaces = append(report.Interfaces, row)
+	}
+	report.Pass = report.Covered == report.Total
+	return report
+}
+
+// isTestFile reports whether path names a Go test file.
+func isTestFile(path string) bool {
+	return strings.HasSuffix(path, "_test.go")
+}
@@ -0,0 +1,122 @@
+package analyzer
+
+import (
+	"go/types"
+	"sort"
+)
+
+// extractEmbeds returns an Embeds relation for every embedded field of the
+// struct types in namedTypes whose type is one of the collected interfaces or
+// types. Embedded pointers (*Base) are followed and flagged with ViaPointer.
+// Fields of types outside the result (e.g. sync.Mutex) are skipped. The
+// relations point into namedTypes and ifaces.
+func extractEmbeds(namedTypes []TypeDef, ifaces []InterfaceDef) []Relation {
+	ifaceIdx := make(map[string]int, len(ifaces))
+	for i := range ifaces {
+		ifaceIdx[ifaceKey(&ifaces[i])] = i
+	}
+	typeIdx := make(map[string]int, len(namedTypes))
+	for i := range namedTypes {
+		typeIdx[typeKey(&namedTypes[i])] = i
+	}
+
+	var embeds []Relation
+	for i := range namedTypes {
+		outer := &namedTypes[i]
+		if outer.TypeObj == nil {
+			continue
+		}
+		st, ok := outer.TypeObj.Underlying().(*types.Struct)
+		if !ok {
+			continue
+		}
+		for f := 0; f < st.NumFields(); f++ {
+			field := st.Field(f)
+			if !field.Embedded() {
+				continue
+			}
+			ft := types.Unalias(field.Type())
+			ptr, viaPointer := ft.(*types.Pointer)
+			if viaPointer {
+				ft = types.Unalias(ptr.Elem())
+			}
+			named, ok := ft.(*types.Named)
+			if !ok || named.Obj().Pkg() == nil {
+				continue
+			}
+			key := named.Obj().Pkg().Path() + "." + named.Obj().Name()
+			if j, ok := ifaceIdx[key]; ok {
+				embeds = append(embeds, Relation{Kind: RelationEmbeds, Type: outer, Interface: &ifaces[j], ViaPointer: viaPointer})
+			} else if j, ok := typeIdx[key]; ok {
+				embeds = append(embeds, Relation{Kind: RelationEmbeds, Type: outer, Embedded: &namedTypes[j], ViaPointer: viaPointer})
+			}
+		}
+	}
+	sort.SliceStable(embeds, func(a, b int) bool {
+		return typeKey(embeds[a].Type) < typeKey(embeds[b].Type)
+	})
+	return embeds
+}
+
+// extractIfaceEmbeds returns the keys (pkgPath.Name) of the named interfaces
+// that iface embeds directly, in declaration order. The builtin error is
+// keyed "builtin.error". Type-set constraints (comparable, unions, ~T and
+// other non-interface terms) are skipped. Only direct embeds are recorded, so
+// A embeds B embeds C yields the chain A->B, B->C and never A->C.
+func extractIfaceEmbeds(iface *types.Interface) []string {
+	var keys []string
+	for i := 0; i < iface.NumEmbeddeds(); i++ {
+		named, ok := types.Unalias(iface.EmbeddedType(i)).(*types.Named)
+		if !ok {
+			continue
+		}
+		embedded, ok := named.Underlying().(*types.Interface)
+		if !ok || !embedded.IsMethodSet() {
+			continue
+		}
+		obj := named.Obj()
+		pkgPath := "builtin"
+		if obj.Pkg() != nil {
+			pkgPath = obj.Pkg().Path()
+		} else if obj.Name() != "error" {
+			continue
+		}
+		keys = append(keys, pkgPath+"."+obj.Name())
+	}
+	return keys
+}
+
+// EmbedTargetKey returns the "pkgPath.Name" key of what rel's type embeds:
+// an interface or a concrete type.
+func (rel Relation) EmbedTargetKey() string {
+	if rel.Interface != nil {
+		return ifaceKey(rel.Interface)
+	}
+	if rel.Embedded != nil {
+		return typeKey(rel.Embedded)
+	}
+	return ""
+}
+
+// EmbedsBetween returns the embeds whose struct and embedded target are both
+// among ifaces and typs. Filters use it to carry embedding edges over to the
+// nodes they kept.
+func EmbedsBetween(embeds []Relation, ifaces []InterfaceDef, typs []TypeDef) []Relation {
+	if len(embeds) == 0 {
+		return nil
+	}
+	present := make(map[string]bool, len(ifaces)+len(typs))
+	for i := range ifaces {
+		present[ifaceKey(&ifaces[i])] = true
+	}
+	for i := range typs {
+		present[typeKey(&typs[i])] = true
+	}
+	var kept []Relation
+	for _, rel := range embeds {
+		if present[typeKey(rel.Type)] && present[rel.EmbedTargetKey()] {
+			kept = append(kept, rel)
+		}
+	}
+	return kept
+}
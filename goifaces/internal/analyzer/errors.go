@@ -0,0 +1,40 @@
+package analyzer
+
+import "errors"
+
+// Sentinel errors returned (wrapped) by Analyze. Check with errors.Is.
+var (
+	// ErrLoadFailed means go/packages could not load the module.
+	ErrLoadFailed = errors.New("loading packages")
+	// ErrNoPackages means the module loaded but contains no Go packages, or
+	// that there is no go.mod and loading failed.
+	ErrNoPackages = errors.New("no Go packages found")
+	// ErrTooManyNodes means more interfaces and types were collected than
+	// AnalyzeOptions.MaxNodes allows.
+	ErrTooManyNodes = errors.New("too many nodes to analyze")
+	// ErrTooManyPackages means more packages were loaded than
+	// AnalyzeOptions.MaxPackages allows.
+	ErrTooManyPackages = errors.New("too many packages to analyze")
+	// ErrUnsupportedPlatform means AnalyzeOptions.GOOS / GOARCH select a
+	// pair the go toolchain has no port for. It is wrapped in ErrLoadFailed.
+	ErrUnsupportedPlatform = errors.New("unsupported GOOS/GOARCH pair")
+)
+
+// Sentinel errors returned (wrapped) by FindType and FindInterface.
+var (
+	// ErrTypeNotFound means no analyzed type matches the requested name.
+	ErrTypeNotFound = errors.New("type not found")
+	// ErrInterfaceNotFound means no analyzed interface matches the requested name.
+	ErrInterfaceNotFound = errors.New("interface not found")
+	// ErrAmbiguousType means the requested name matches several types or
+	// interfaces.
+	ErrAmbiguousType = errors.New("ambiguous type name")
+)
+
+// ErrInvalidExclude is returned (wrapped) by ValidateExclude for an
+// AnalyzeOptions.Exclude pattern that is empty or not a valid glob.
+var ErrInvalidExclude = errors.New("invalid exclude pattern")
+
+// ErrUnsupportedSchema is returned (wrapped) by UnmarshalResult and
+// ResultJSON.Result for a schemaVersion other than ResultSchemaVersion.
+var ErrUnsupportedSchema = errors.New("unsupported result schema version")
@@ -0,0 +1,66 @@
+package analyzer
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Excluded reports whether pkgPath matches one of the AnalyzeOptions.Exclude
+// patterns. A pattern without glob characters matches any package path
+// containing it ("mocks"); one with them is matched against the whole path,
+// segment by segment, where "*", "?" and "[...]" stay within a segment (as in
+// path.Match) and "**" spans any number of segments, none included: so
+// "**/mocks/**" drops example.com/app/mocks and everything below it.
+func Excluded(pkgPath string, patterns []string) bool {
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		return matchExclude(pattern, pkgPath)
+	})
+}
+
+// ValidateExclude returns an error wrapping ErrInvalidExclude for an empty
+// pattern, which would exclude everything, or a malformed glob.
+func ValidateExclude(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("%w: empty pattern", ErrInvalidExclude)
+	}
+	for _, seg := range strings.Split(pattern, "/") {
+		if _, err := path.Match(seg, ""); err != nil {
+			return fmt.Errorf("%w: %q: %v", ErrInvalidExclude, pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchExclude reports whether pkgPath matches one exclude pattern (see
+// Excluded).
+func matchExclude(pattern, pkgPath string) bool {
+	if !strings.ContainsAny(pattern, "*?[") {
+		return strings.Contains(pkgPath, pattern)
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(pkgPath, "/"))
+}
+
+// matchSegments matches the "/"-separated segments of a glob against those of
+// a package path, "**" standing for zero or more path segments.
+func matchSegments(pattern, segs []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segs); i++ {
+				if matchSegments(pattern[1:], segs[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segs) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], segs[0]); !ok {
+			return false
+		}
+		pattern, segs = pattern[1:], segs[1:]
+	}
+	return len(segs) == 0
+}
@@ -8,7 +8,8 @@ import (
 // Filter applies filtering options to the analysis result.
 func Filter(result *Result, opts AnalyzeOptions) *Result {
 	filtered := &Result{
-		ModulePath: result.ModulePath,
+		ModulePath:      result.ModulePath,
+		ReplacedModules: result.ReplacedModules,
 	}
 
 	// Build sets of interfaces and types that participate in relations
@@ -20,25 +21,26 @@ func Filter(result *Result, opts AnalyzeOptions) *Result {
 		typ := rel.Type
 
 		// Filter: keep only local module packages (and optionally stdlib)
-		isLocal := result.ModulePath != "" && strings.HasPrefix(iface.PkgPath, result.ModulePath)
-		isStd := isStdlib(iface.PkgPath)
+		if !interfaceInScope(result, iface, opts) {
+			continue
+		}
 
-		if !isLocal {
-			// Skip stdlib unless explicitly included
-			if isStd && !opts.IncludeStdlib {
+		// Filter unexported
+		switch {
+		case opts.PublicInterfaces:
+			// Public API view: unexported implementers stay, unexported interfaces go
+			if isUnexported(iface.Name) {
 				continue
 			}
-			// Skip external (non-stdlib, non-local) packages
-			if !isStd && result.ModulePath != "" {
+		case !opts.IncludeUnexported:
+			if isUnexported(iface.Name) || isUnexported(typ.Name) {
 				continue
 			}
 		}
 
-		// Filter unexported
-		if !opts.IncludeUnexported {
-			if isUnexported(iface.Name) || isUnexported(typ.Name) {
-				continue
-			}
+		// Filter named function types
+		if opts.ExcludeFuncTypes && typ.IsFunc {
+			continue
 		}
 
 		// Filter by package prefix
@@ -50,29 +52,158 @@ func Filter(result *Result, opts AnalyzeOptions) *Result {
 			}
 		}
 
+		// Drop excluded packages, on either side of the relation
+		if Excluded(iface.PkgPath, opts.Exclude) || Excluded(typ.PkgPath, opts.Exclude) {
+			continue
+		}
+
 		filtered.Relations = append(filtered.Relations, rel)
 		ifaceSet[ifaceKey(iface)] = true
 		typeSet[typeKey(typ)] = true
 	}
 
-	// Include only interfaces and types that participate in relations (prune orphans)
+	// Include only interfaces and types that participate in relations (prune
+	// orphans), and marker interfaces with IncludeMarkers
 	for i := range result.Interfaces {
 		iface := &result.Interfaces[i]
-		if ifaceSet[ifaceKey(iface)] {
+		if ifaceSet[ifaceKey(iface)] || markerKept(result, iface, opts) {
 			filtered.Interfaces = append(filtered.Interfaces, *iface)
 		}
 	}
 
 	for i := range result.Types {
 		typ := &result.Types[i]
-		if typeSet[typeKey(typ)] {
+		if typeSet[typeKey(typ)] || aliasKept(typ, opts, ifaceSet, typeSet) {
 			filtered.Types = append(filtered.Types, *typ)
 		}
 	}
+	filtered.Embeds = EmbedsBetween(result.Embeds, filtered.Interfaces, filtered.Types)
 
 	return filtered
 }
 
+// markerKept reports whether Filter keeps the marker interface iface, which
+// has no relations to keep it: only with IncludeMarkers, and when it passes
+// the same scope, unexported, package filter and exclude rules as other
+// interfaces.
+func markerKept(result *Result, iface *InterfaceDef, opts AnalyzeOptions) bool {
+	if !opts.IncludeMarkers || !iface.IsMarker || !interfaceInScope(result, iface, opts) {
+		return false
+	}
+	if (opts.PublicInterfaces || !opts.IncludeUnexported) && isUnexported(iface.Name) {
+		return false
+	}
+	return strings.HasPrefix(iface.PkgPath, opts.Filter) && !Excluded(iface.PkgPath, opts.Exclude)
+}
+
+// aliasKept reports whether Filter keeps the type alias typ: its target
+// survived as an interface or type, its name passes the unexported rule and
+// its own package is not excluded. Aliases take part in no relation, so they
+// follow their target.
+func aliasKept(typ *TypeDef, opts AnalyzeOptions, ifaceSet, typeSet map[string]bool) bool {
+	if typ.AliasOf == "" || (!ifaceSet[typ.AliasOf] && !typeSet[typ.AliasOf]) || Excluded(typ.PkgPath, opts.Exclude) {
+		return false
+	}
+	return opts.IncludeUnexported || !isUnexported(typ.Name)
+}
+
+// PruneOrphans returns a copy of r without the interfaces and types that take
+// part in no relation, keeping the embeds between the survivors and the
+// aliases of surviving nodes. Steps that
+// drop relations (slide splitting, score pruning) use it to clean up the
+// nodes left behind.
+func PruneOrphans(r *Result) *Result {
+	used := make(map[string]bool, 2*len(r.Relations))
+	for _, rel := range r.Relations {
+		used[ifaceKey(rel.Interface)] = true
+		used[typeKey(rel.Type)] = true
+	}
+	out := *r
+	out.Interfaces = nil
+	for i := range r.Interfaces {
+		if used[ifaceKey(&r.Interfaces[i])] {
+			out.Interfaces = append(out.Interfaces, r.Interfaces[i])
+		}
+	}
+	out.Types = nil
+	for i := range r.Types {
+		if typ := &r.Types[i]; used[typeKey(typ)] || (typ.AliasOf != "" && used[typ.AliasOf]) {
+			out.Types = append(out.Types, r.Types[i])
+		}
+	}
+	out.Embeds = EmbedsBetween(r.Embeds, out.Interfaces, out.Types)
+	return &out
+}
+
+// FilterByMinConnections drops the interfaces and types that take part in
+// fewer than n relations, together with their relations, then prunes the
+// nodes left without any (PruneOrphans). Counts are taken once, over
+// r.Relations, so after Filter only in-scope relations count; a node kept
+// here may end up with fewer than n relations when its neighbours are
+// dropped. n = 1 removes isolated nodes only, and n <= 0 returns r as is.
+func FilterByMinConnections(r *Result, n int) *Result {
+	if n <= 0 {
+		return r
+	}
+	ifaceDegree := make(map[string]int, len(r.Interfaces))
+	typeDegree := make(map[string]int, len(r.Types))
+	for _, rel := range r.Relations {
+		ifaceDegree[ifaceKey(rel.Interface)]++
+		typeDegree[typeKey(rel.Type)]++
+	}
+	out := *r
+	out.Relations = nil
+	for _, rel := range r.Relations {
+		if ifaceDegree[ifaceKey(rel.Interface)] >= n && typeDegree[typeKey(rel.Type)] >= n {
+			out.Relations = append(out.Relations, rel)
+		}
+	}
+	return PruneOrphans(&out)
+}
+
+// FilterByMinImplementers keeps only the interfaces implemented by at least n
+// distinct types, with their relations, then prunes the types left without
+// any (PruneOrphans). Unlike FilterByMinConnections it counts implementers,
+// not edges: a type related to an interface more than once, such as through
+// both a value and a pointer receiver, counts once. Types are never dropped
+// for their own counts. n <= 0 returns r as is.
+func FilterByMinImplementers(r *Result, n int) *Result {
+	if n <= 0 {
+		return r
+	}
+	implementers := make(map[string]map[string]bool, len(r.Interfaces))
+	for _, rel := range r.Relations {
+		key := ifaceKey(rel.Interface)
+		if implementers[key] == nil {
+			implementers[key] = make(map[string]bool)
+		}
+		implementers[key][typeKey(rel.Type)] = true
+	}
+	out := *r
+	out.Relations = nil
+	for _, rel := range r.Relations {
+		if len(implementers[ifaceKey(rel.Interface)]) >= n {
+			out.Relations = append(out.Relations, rel)
+		}
+	}
+	return PruneOrphans(&out)
+}
+
+// interfaceInScope reports whether iface belongs to the analyzed module, a
+// module replaced with a local directory, or — with IncludeStdlib — the
+// standard library. External modules are out of scope.
+func interfaceInScope(result *Result, iface *InterfaceDef, opts AnalyzeOptions) bool {
+	isLocal := (result.ModulePath != "" && strings.HasPrefix(iface.PkgPath, result.ModulePath)) ||
+		result.IsReplaced(iface.PkgPath)
+	if isLocal {
+		return true
+	}
+	if isStdlib(iface.PkgPath) {
+		return opts.IncludeStdlib
+	}
+	return result.ModulePath == ""
+}
+
 func isStdlib(pkgPath string) bool {
 	// Stdlib packages have no dot in the first path element
 	firstSlash := strings.IndexByte(pkgPath, '/')
@@ -0,0 +1,164 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// matchCacheVersion is bumped whenever the on-disk format or the matching
+// rules change, so stale caches are discarded instead of misread.
+const matchCacheVersion = 1
+
+// MatchCache remembers which interfaces each type implemented in a previous
+// run so that unchanged types and interfaces skip the types.Implements checks
+// of the match phase. A cached entry is reused only when the owning package's
+// files have the same mod times and sizes and the method-signature hash of
+// both the type and the interface is unchanged. A MatchCache can be kept in
+// memory across runs in one process or persisted with Save / LoadMatchCache.
+type MatchCache struct {
+	Version    int                       `json:"version"`
+	Packages   map[string]string         `json:"packages"`   // pkgPath -> file fingerprint
+	Interfaces map[string]string         `json:"interfaces"` // pkgPath.Name -> method-signature hash
+	Types      map[string]cachedTypeInfo `json:"types"`      // pkgPath.Name -> hash + matches
+
+	// Reused and Computed count type/interface pairs in the last Analyze run.
+	Reused   int `json:"-"`
+	Computed int `json:"-"`
+}
+
+type cachedTypeInfo struct {
+	Hash    string        `json:"hash"`
+	Matches []cachedMatch `json:"matches,omitempty"`
+}
+
+type cachedMatch struct {
+	Interface  string `json:"interface"` // pkgPath.Name
+	ViaPointer bool   `json:"viaPointer,omitempty"`
+}
+
+// NewMatchCache returns an empty cache.
+func NewMatchCache() *MatchCache {
+	return &MatchCache{
+		Version:    matchCacheVersion,
+		Packages:   make(map[string]string),
+		Interfaces: make(map[string]string),
+		Types:      make(map[string]cachedTypeInfo),
+	}
+}
+
+// MatchCachePath returns the cache file under cacheDir (see
+// resolver.MatchCacheDir) for the module rooted at dir.
+func MatchCachePath(cacheDir, dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		abs = dir
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(cacheDir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// LoadMatchCache reads a cache written by Save. A missing file yields an
+// empty cache; an unreadable or outdated one yields an empty cache and an
+// error describing why it was discarded.
+func LoadMatchCache(path string) (*MatchCache, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NewMatchCache(), nil
+		}
+		return NewMatchCache(), fmt.Errorf("reading match cache: %w", err)
+	}
+	c := NewMatchCache()
+	if err := json.Unmarshal(data, c); err != nil {
+		return NewMatchCache(), fmt.Errorf("parsing match cache: %w", err)
+	}
+	if c.Version != matchCacheVersion {
+		return NewMatchCache(), fmt.Errorf("match cache version %d, want %d", c.Version, matchCacheVersion)
+	}
+	return c, nil
+}
+
+// Save writes the cache to path, creating parent directories as needed.
+func (c *MatchCache) Save(path string) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("encoding match cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating match cache dir: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing match cache: %w", err)
+	}
+	return nil
+}
+
+// packageUnchanged reports whether pkgPath's files match the cached
+// fingerprint. Packages without file information (the builtin scope) compare
+// equal and rely on the method-signature hash alone.
+func (c *MatchCache) packageUnchanged(pkgPath string, fingerprints map[string]string) bool {
+	cached, ok := c.Packages[pkgPath]
+	if !ok {
+		return pkgPath == "builtin"
+	}
+	return cached == fingerprints[pkgPath]
+}
+
+// packageFingerprint hashes the paths, mod times and sizes of pkg's Go files.
+// Packages without file information (e.g. import stubs) get "".
+func packageFingerprint(pkg *packages.Package) string {
+	if len(pkg.GoFiles) == 0 {
+		return ""
+	}
+	files := append([]string(nil), pkg.GoFiles...)
+	sort.Strings(files)
+	h := sha256.New()
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return ""
+		}
+		fmt.Fprintf(h, "%s|%d|%d\n", f, info.ModTime().UnixNano(), info.Size())
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// typeMethodHash hashes the method set of *T, marking which methods are also
+// in the method set of T (that decides ViaPointer).
+func typeMethodHash(t *types.Named, cache *typeutil.MethodSetCache) string {
+	valSet := cache.MethodSet(t)
+	ptrSet := cache.MethodSet(types.NewPointer(t))
+	lines := make([]string, 0, ptrSet.Len())
+	for i := 0; i < ptrSet.Len(); i++ {
+		obj := ptrSet.At(i).Obj()
+		onValue := valSet.Lookup(obj.Pkg(), obj.Name()) != nil
+		lines = append(lines, fmt.Sprintf("%s %s %t", obj.Id(), types.TypeString(obj.Type(), nil), onValue))
+	}
+	return hashLines(lines)
+}
+
+// ifaceMethodHash hashes an interface's full method set.
+func ifaceMethodHash(iface *types.Interface) string {
+	lines := make([]string, 0, iface.NumMethods())
+	for i := 0; i < iface.NumMethods(); i++ {
+		m := iface.Method(i)
+		lines = append(lines, m.Id()+" "+types.TypeString(m.Type(), nil))
+	}
+	return hashLines(lines)
+}
+
+func hashLines(lines []string) string {
+	sort.Strings(lines)
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:12])
+}
@@ -0,0 +1,44 @@
+package analyzer
+
+// NodeMetrics is the implementation degree of one interface or type: its
+// fan-in as an interface, its fan-out as a type.
+type NodeMetrics struct {
+	ImplementedBy int  `json:"implementedBy,omitempty"` // interfaces: the types implementing it
+	Implements    int  `json:"implements,omitempty"`    // types: the interfaces it implements
+	IsInterface   bool `json:"isInterface,omitempty"`
+	// Builtin marks the builtin error interface, whose fan-in mostly counts
+	// error values rather than architecture, so consumers can leave it out.
+	Builtin bool `json:"builtin,omitempty"`
+}
+
+// Degree returns the number of implementation relations of the node.
+func (m NodeMetrics) Degree() int {
+	return m.ImplementedBy + m.Implements
+}
+
+// Metrics computes the NodeMetrics of every interface and type in result
+// from its Relations, including nodes without any. Keys are "pkgPath.Name".
+func Metrics(result *Result) map[string]NodeMetrics {
+	metrics := make(map[string]NodeMetrics, len(result.Interfaces)+len(result.Types))
+	for i := range result.Interfaces {
+		iface := &result.Interfaces[i]
+		metrics[ifaceKey(iface)] = NodeMetrics{
+			IsInterface: true,
+			Builtin:     iface.PkgPath == "builtin",
+		}
+	}
+	for i := range result.Types {
+		metrics[typeKey(&result.Types[i])] = NodeMetrics{}
+	}
+	for _, rel := range result.Relations {
+		if m, ok := metrics[ifaceKey(rel.Interface)]; ok {
+			m.ImplementedBy++
+			metrics[ifaceKey(rel.Interface)] = m
+		}
+		if m, ok := metrics[typeKey(rel.Type)]; ok {
+			m.Implements++
+			metrics[typeKey(rel.Type)] = m
+		}
+	}
+	return metrics
+}
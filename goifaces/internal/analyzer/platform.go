@@ -0,0 +1,59 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// platformEnv returns the environment for loading packages as opts.GOOS /
+// opts.GOARCH, or nil (the host environment) when neither is set.
+func platformEnv(opts AnalyzeOptions) []string {
+	if opts.GOOS == "" && opts.GOARCH == "" {
+		return nil
+	}
+	env := os.Environ()
+	if opts.GOOS != "" {
+		env = append(env, "GOOS="+opts.GOOS)
+	}
+	if opts.GOARCH != "" {
+		env = append(env, "GOARCH="+opts.GOARCH)
+	}
+	return env
+}
+
+// checkPlatform fails with ErrUnsupportedPlatform when the GOOS/GOARCH pair
+// selected by env is not a port of the go toolchain (go tool dist list).
+// go list accepts any values and would just match no constrained files, so
+// a typo would otherwise produce a quietly different diagram.
+func checkPlatform(ctx context.Context, dir string, env []string) error {
+	run := func(args ...string) (string, error) {
+		cmd := exec.CommandContext(ctx, "go", args...)
+		cmd.Dir = dir
+		cmd.Env = env
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("go %s: %w", strings.Join(args, " "), err)
+		}
+		return string(out), nil
+	}
+	target, err := run("env", "GOOS", "GOARCH")
+	if err != nil {
+		return err
+	}
+	goos, goarch, _ := strings.Cut(strings.TrimSpace(target), "\n")
+	pair := goos + "/" + strings.TrimSpace(goarch)
+
+	ports, err := run("tool", "dist", "list")
+	if err != nil {
+		return err
+	}
+	for _, port := range strings.Fields(ports) {
+		if port == pair {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w %s (see go tool dist list)", ErrUnsupportedPlatform, pair)
+}
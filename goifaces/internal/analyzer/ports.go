@@ -0,0 +1,68 @@
+package analyzer
+
+// PortKind classifies an interface by where its implementers live relative to
+// the package that declares it.
+type PortKind int
+
+const (
+	// PortKindUnimplemented means no type in the result implements the interface.
+	PortKindUnimplemented PortKind = iota
+	// PortKindSamePackage means every implementer is in the interface's own package.
+	PortKindSamePackage
+	// PortKindMixed means implementers live both inside and outside the package.
+	PortKindMixed
+	// PortKindPort means every implementer lives in another package: the
+	// "port" of a ports-and-adapters (hexagonal) architecture.
+	PortKindPort
+)
+
+// String returns a short lowercase name for the kind.
+func (k PortKind) String() string {
+	switch k {
+	case PortKindSamePackage:
+		return "same-package"
+	case PortKindMixed:
+		return "mixed"
+	case PortKindPort:
+		return "port"
+	default:
+		return "unimplemented"
+	}
+}
+
+// ClassifyPorts classifies every interface in result by comparing its package
+// with the packages of its implementers. Keys are "pkgPath.Name".
+func ClassifyPorts(result *Result) map[string]PortKind {
+	type counts struct{ inside, outside int }
+	seen := make(map[string]*counts, len(result.Interfaces))
+	for i := range result.Interfaces {
+		seen[ifaceKey(&result.Interfaces[i])] = &counts{}
+	}
+	for _, rel := range result.Relations {
+		c, ok := seen[ifaceKey(rel.Interface)]
+		if !ok {
+			c = &counts{}
+			seen[ifaceKey(rel.Interface)] = c
+		}
+		if rel.Type.PkgPath == rel.Interface.PkgPath {
+			c.inside++
+		} else {
+			c.outside++
+		}
+	}
+
+	kinds := make(map[string]PortKind, len(seen))
+	for key, c := range seen {
+		switch {
+		case c.inside > 0 && c.outside > 0:
+			kinds[key] = PortKindMixed
+		case c.outside > 0:
+			kinds[key] = PortKindPort
+		case c.inside > 0:
+			kinds[key] = PortKindSamePackage
+		default:
+			kinds[key] = PortKindUnimplemented
+		}
+	}
+	return kinds
+}
@@ -0,0 +1,43 @@
+package analyzer
+
+// Phases reported through AnalyzeOptions.Progress, in order.
+const (
+	// PhaseLoading is the go command loading the packages: reported as
+	// (0, 1) before and (1, 1) after, since loading has no finer steps.
+	PhaseLoading = "loading"
+	// PhaseCollecting counts the loaded packages whose interfaces and types
+	// have been collected.
+	PhaseCollecting = "collecting"
+	// PhaseMatching counts the types matched against every interface.
+	PhaseMatching = "matching"
+)
+
+// progressSteps caps the intermediate reports of a phase, so that a large
+// matching loop calls Progress about once per percent.
+const progressSteps = 100
+
+// phaseProgress reports one phase through AnalyzeOptions.Progress. With a
+// nil callback every report is a no-op.
+type phaseProgress struct {
+	fn    func(phase string, done, total int)
+	phase string
+	total int
+	step  int // report only multiples of step, and the last one
+}
+
+// startPhase reports phase as (0, total) and returns its reporter.
+func startPhase(fn func(phase string, done, total int), phase string, total int) phaseProgress {
+	p := phaseProgress{fn: fn, phase: phase, total: total, step: max(1, total/progressSteps)}
+	if fn != nil {
+		fn(phase, 0, total)
+	}
+	return p
+}
+
+// report records that done of the phase's total steps are complete. Zero
+// was reported by startPhase.
+func (p phaseProgress) report(done int) {
+	if p.fn != nil && done > 0 && (done%p.step == 0 || done == p.total) {
+		p.fn(p.phase, done, p.total)
+	}
+}
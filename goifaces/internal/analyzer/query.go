@@ -0,0 +1,135 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// Satisfaction is one interface that a queried type implements.
+type Satisfaction struct {
+	Interface  *InterfaceDef
+	ViaPointer bool        // only *T implements the interface
+	Methods    []MethodSig // the type's methods that satisfy the interface, in interface order
+}
+
+// FindType resolves spec to a single analyzed type. spec may be a bare type
+// name ("PostgresRepo"), package name qualified ("store.PostgresRepo") or
+// fully qualified ("example.com/app/store.PostgresRepo").
+func FindType(result *Result, spec string) (*TypeDef, error) {
+	var matches []*TypeDef
+	for i := range result.Types {
+		t := &result.Types[i]
+		if spec == t.Name || spec == t.PkgName+"."+t.Name || spec == t.PkgPath+"."+t.Name {
+			matches = append(matches, t)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%w: %s", ErrTypeNotFound, spec)
+	case 1:
+		return matches[0], nil
+	}
+	candidates := make([]string, len(matches))
+	for i, t := range matches {
+		candidates[i] = t.PkgPath + "." + t.Name
+	}
+	sort.Strings(candidates)
+	return nil, fmt.Errorf("%w: %s matches %s", ErrAmbiguousType, spec, strings.Join(candidates, ", "))
+}
+
+// FindInterface resolves spec to a single analyzed interface, accepting the
+// same forms as FindType.
+func FindInterface(result *Result, spec string) (*InterfaceDef, error) {
+	var matches []*InterfaceDef
+	for i := range result.Interfaces {
+		iface := &result.Interfaces[i]
+		if spec == iface.Name || spec == iface.PkgName+"."+iface.Name || spec == iface.PkgPath+"."+iface.Name {
+			matches = append(matches, iface)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%w: %s", ErrInterfaceNotFound, spec)
+	case 1:
+		return matches[0], nil
+	}
+	candidates := make([]string, len(matches))
+	for i, iface := range matches {
+		candidates[i] = ifaceKey(iface)
+	}
+	sort.Strings(candidates)
+	return nil, fmt.Errorf("%w: %s matches %s", ErrAmbiguousType, spec, strings.Join(candidates, ", "))
+}
+
+// WhatImplements lists every interface typ satisfies, using the relations of
+// an unfiltered Analyze result. Interfaces are limited to the same scope as
+// Filter (local and locally replaced modules, plus stdlib with
+// IncludeStdlib; unexported ones only with IncludeUnexported) and sorted by
+// package path and name.
+func WhatImplements(result *Result, typ *TypeDef, opts AnalyzeOptions) []Satisfaction {
+	var out []Satisfaction
+	var msets typeutil.MethodSetCache
+	for _, rel := range result.Relations {
+		if rel.Type.PkgPath != typ.PkgPath || rel.Type.Name != typ.Name {
+			continue
+		}
+		if !interfaceInScope(result, rel.Interface, opts) {
+			continue
+		}
+		if !opts.IncludeUnexported && isUnexported(rel.Interface.Name) {
+			continue
+		}
+		out = append(out, Satisfaction{
+			Interface:  rel.Interface,
+			ViaPointer: rel.ViaPointer,
+			Methods:    satisfyingMethods(typ.TypeObj, rel.Interface.TypeObj, rel.ViaPointer, &msets),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i].Interface, out[j].Interface
+		if a.PkgPath != b.PkgPath {
+			return a.PkgPath < b.PkgPath
+		}
+		return a.Name < b.Name
+	})
+	return out
+}
+
+// satisfyingMethods looks up each of iface's methods in the method set of
+// named (or *named), including methods promoted from embedded fields.
+// iface's methods include those of the interfaces it embeds.
+func satisfyingMethods(named *types.Named, iface *types.Interface, viaPointer bool, msets *typeutil.MethodSetCache) []MethodSig {
+	if named == nil || iface == nil {
+		return nil
+	}
+	var recv types.Type = named
+	if viaPointer {
+		recv = types.NewPointer(named)
+	}
+	mset := msets.MethodSet(recv)
+	var methods []MethodSig
+	for i := 0; i < iface.NumMethods(); i++ {
+		m := iface.Method(i)
+		sel := mset.Lookup(m.Pkg(), m.Name())
+		if sel == nil {
+			continue
+		}
+		if fn, ok := sel.Obj().(*types.Func); ok {
+			methods = append(methods, newMethodSig(fn.Name(), formatSignature(fn)))
+		}
+	}
+	return methods
+}
+
+// methodNames returns the names of methods, in order.
+func methodNames(methods []MethodSig) []string {
+	names := make([]string, len(methods))
+	for i, m := range methods {
+		names[i] = m.Name
+	}
+	return names
+}
@@ -0,0 +1,241 @@
+package analyzer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// resultCacheVersion is bumped whenever the cache file format or what Analyze
+// puts into a Result changes, so older entries are recomputed instead of
+// misread.
+const resultCacheVersion = 3
+
+// resultCacheEntry is a cached Analyze result: the ResultJSON projection plus
+// the references UnusedExports needs, and the fingerprints of the sources it
+// was computed from.
+type resultCacheEntry struct {
+	Version     int               `json:"version"`
+	Fingerprint string            `json:"fingerprint"`        // sources under the analyzed dir
+	Replaced    map[string]string `json:"replaced,omitempty"` // locally replaced module dir -> fingerprint
+	References  map[string]int    `json:"references"`
+	Result      ResultJSON        `json:"result"`
+}
+
+// analyzeCached runs Analyze through the cache in opts.ResultCacheDir: a
+// stored result is returned as long as the fingerprints of the sources still
+// match, and anything else (no entry, changed files, a corrupt or outdated
+// file) falls through to a full analysis whose result replaces the entry.
+// Cache errors are logged, never returned.
+func analyzeCached(ctx context.Context, dir string, opts AnalyzeOptions, logger *slog.Logger) (*Result, error) {
+	path := resultCachePath(opts.ResultCacheDir, dir, opts)
+	fingerprint, err := sourceFingerprint(dir)
+	if err != nil {
+		logger.Warn("result cache disabled", "dir", dir, "error", err)
+		return analyze(ctx, dir, opts, logger)
+	}
+	if result := loadCachedResult(path, fingerprint, logger); result != nil {
+		logger.Info("using cached analysis", "path", path, "interfaces", len(result.Interfaces),
+			"types", len(result.Types), "relations", len(result.Relations))
+		return result, nil
+	}
+
+	result, err := analyze(ctx, dir, opts, logger)
+	if err != nil {
+		return nil, err
+	}
+	if err := saveCachedResult(path, dir, fingerprint, result); err != nil {
+		logger.Warn("failed to save result cache", "path", path, "error", err)
+	} else {
+		logger.Debug("saved result cache", "path", path)
+	}
+	return result, nil
+}
+
+// resultCachePath returns the cache file for dir analyzed with opts. There is
+// one file per module and option set, overwritten when the sources change.
+// The go command's platform environment is part of the key, since it picks
+// the files that are built.
+func resultCachePath(cacheDir, dir string, opts AnalyzeOptions) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		abs = dir
+	}
+	key, _ := json.Marshal(struct {
+		Dir               string
+		Subdir            string
+		Filter            string
+		IncludeStdlib     bool
+		IncludeUnexported bool
+		ExcludeFuncTypes  bool
+		PublicInterfaces  bool
+		MaxNodes          int
+		MaxPackages       int
+		BuildFlags        []string
+		IncludeTests      bool
+		GOOS, GOARCH      string
+		Env               []string
+	}{
+		abs, opts.Subdir, opts.Filter, opts.IncludeStdlib, opts.IncludeUnexported, opts.ExcludeFuncTypes, opts.PublicInterfaces,
+		opts.MaxNodes, opts.MaxPackages, opts.BuildFlags, opts.IncludeTests, opts.GOOS, opts.GOARCH,
+		[]string{os.Getenv("GOOS"), os.Getenv("GOARCH"), os.Getenv("GOFLAGS")},
+	})
+	sum := sha256.Sum256(key)
+	return filepath.Join(cacheDir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// sourceFingerprint hashes the relative path, mod time and size of every Go
+// file and go.mod, go.sum, go.work and go.work.sum under dir, skipping the
+// directories the go command ignores (testdata, and names starting with "."
+// or "_").
+func sourceFingerprint(dir string) (string, error) {
+	var lines []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != dir && (name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch {
+		case strings.HasSuffix(name, ".go"), name == "go.mod", name == "go.sum", name == "go.work", name == "go.work.sum":
+		default:
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, fmt.Sprintf("%s|%d|%d", filepath.ToSlash(rel), info.ModTime().UnixNano(), info.Size()))
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("fingerprinting sources: %w", err)
+	}
+	sort.Strings(lines)
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:16]), nil
+}
+
+// replacementDirs returns the directories of the modules result loaded
+// through local replace directives, resolved against dir.
+func replacementDirs(dir string, result *Result) []string {
+	var dirs []string
+	for _, target := range result.ReplacedModules {
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(dir, target)
+		}
+		dirs = append(dirs, target)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// loadCachedResult returns the result stored at path if it was computed from
+// sources with the given fingerprint, and its locally replaced modules are
+// unchanged too. It returns nil otherwise, logging why an existing entry was
+// not used.
+func loadCachedResult(path, fingerprint string, logger *slog.Logger) *Result {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warn("discarding result cache", "path", path, "error", err)
+		}
+		return nil
+	}
+	var entry resultCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		logger.Warn("discarding result cache", "path", path, "error", fmt.Errorf("parsing result cache: %w", err))
+		return nil
+	}
+	if entry.Version != resultCacheVersion {
+		logger.Info("discarding result cache", "path", path, "version", entry.Version, "want", resultCacheVersion)
+		return nil
+	}
+	if entry.Fingerprint != fingerprint {
+		logger.Info("sources changed since the cached analysis", "path", path)
+		return nil
+	}
+	for replDir, cached := range entry.Replaced {
+		if current, err := sourceFingerprint(replDir); err != nil || current != cached {
+			logger.Info("replaced module changed since the cached analysis", "path", path, "module_dir", replDir)
+			return nil
+		}
+	}
+	result, err := entry.Result.Result()
+	if err != nil {
+		logger.Warn("discarding result cache", "path", path, "error", err)
+		return nil
+	}
+	// A non-nil map marks the result as coming from Analyze (see
+	// UnusedExports), even when nothing was referenced.
+	result.References = entry.References
+	if result.References == nil {
+		result.References = make(map[string]int)
+	}
+	return result
+}
+
+// saveCachedResult stores result at path, together with the fingerprint of
+// dir's sources and of its locally replaced modules. The file is written to a
+// temporary name first, so a concurrent run never reads half an entry.
+func saveCachedResult(path, dir, fingerprint string, result *Result) error {
+	entry := resultCacheEntry{
+		Version:     resultCacheVersion,
+		Fingerprint: fingerprint,
+		References:  result.References,
+		Result:      NewResultJSON(result),
+	}
+	for _, replDir := range replacementDirs(dir, result) {
+		replFingerprint, err := sourceFingerprint(replDir)
+		if err != nil {
+			return err
+		}
+		if entry.Replaced == nil {
+			entry.Replaced = make(map[string]string)
+		}
+		entry.Replaced[replDir] = replFingerprint
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encoding result cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating result cache dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("writing result cache: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing result cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing result cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing result cache: %w", err)
+	}
+	return nil
+}
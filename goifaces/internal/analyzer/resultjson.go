@@ -0,0 +1,263 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// ResultSchemaVersion is the version of the ResultJSON envelope. It changes
+// only when a field is removed or changes meaning; new optional fields keep
+// the version.
+const ResultSchemaVersion = 1
+
+// ResultJSON is the serializable projection of a Result (-format json),
+// without the go/types objects. Nodes are identified by "pkgPath.Name", and
+// relations refer to them by that ID. Every list is sorted, so the same
+// Result always encodes to the same bytes.
+type ResultJSON struct {
+	SchemaVersion   int               `json:"schemaVersion"`
+	ModulePath      string            `json:"modulePath,omitempty"`
+	ReplacedModules map[string]string `json:"replacedModules,omitempty"`
+	Interfaces      []InterfaceJSON   `json:"interfaces"`
+	Types           []TypeJSON        `json:"types"`
+	Relations       []RelationJSON    `json:"relations"` // implementations, sorted by type, then interface
+	Embeds          []EmbedJSON       `json:"embeds"`    // struct embedding, sorted by type, then target
+}
+
+// InterfaceJSON is an interface in ResultJSON.
+type InterfaceJSON struct {
+	ID         string       `json:"id"`
+	Name       string       `json:"name"`
+	PkgPath    string       `json:"pkgPath"`
+	PkgName    string       `json:"pkgName"`
+	SourceFile string       `json:"sourceFile,omitempty"`
+	Methods    []MethodJSON `json:"methods"`
+	Embeds     []string     `json:"embeds,omitempty"`   // IDs of directly embedded interfaces, sorted
+	Produces   []string     `json:"produces,omitempty"` // IDs of named types its methods return, sorted
+	IsMarker   bool         `json:"isMarker,omitempty"` // no methods; takes part in no relation
+}
+
+// TypeJSON is a concrete named type in ResultJSON.
+type TypeJSON struct {
+	ID         string       `json:"id"`
+	Name       string       `json:"name"`
+	PkgPath    string       `json:"pkgPath"`
+	PkgName    string       `json:"pkgName"`
+	SourceFile string       `json:"sourceFile,omitempty"`
+	IsStruct   bool         `json:"isStruct"`
+	IsFunc     bool         `json:"isFunc"`
+	Methods    []MethodJSON `json:"methods"`
+	AliasOf    string       `json:"aliasOf,omitempty"` // ID of the aliased node, for type aliases
+}
+
+// MethodJSON is a method with its Go signature, e.g. "Get(key string) ([]byte, error)".
+type MethodJSON struct {
+	Name      string `json:"name"`
+	Signature string `json:"signature"`
+}
+
+// RelationJSON records that Type implements Interface; with ViaPointer only
+// *Type does. SatisfyingMethods names the methods of Type that fulfill it.
+type RelationJSON struct {
+	Type              string   `json:"type"`
+	Interface         string   `json:"interface"`
+	ViaPointer        bool     `json:"viaPointer"`
+	SatisfyingMethods []string `json:"satisfyingMethods,omitempty"`
+}
+
+// EmbedJSON records that struct Type embeds either Interface or Embedded (a
+// concrete type); ViaPointer means the field is a pointer.
+type EmbedJSON struct {
+	Type       string `json:"type"`
+	Interface  string `json:"interface,omitempty"`
+	Embedded   string `json:"embedded,omitempty"`
+	ViaPointer bool   `json:"viaPointer"`
+}
+
+// NewResultJSON projects r into its serializable form.
+func NewResultJSON(r *Result) ResultJSON {
+	out := ResultJSON{
+		SchemaVersion:   ResultSchemaVersion,
+		ModulePath:      r.ModulePath,
+		ReplacedModules: r.ReplacedModules,
+		Interfaces:      make([]InterfaceJSON, 0, len(r.Interfaces)),
+		Types:           make([]TypeJSON, 0, len(r.Types)),
+		Relations:       make([]RelationJSON, 0, len(r.Relations)),
+		Embeds:          make([]EmbedJSON, 0, len(r.Embeds)),
+	}
+	for _, iface := range r.Interfaces {
+		out.Interfaces = append(out.Interfaces, InterfaceJSON{
+			ID:         iface.PkgPath + "." + iface.Name,
+			Name:       iface.Name,
+			PkgPath:    iface.PkgPath,
+			PkgName:    iface.PkgName,
+			SourceFile: iface.SourceFile,
+			Methods:    methodsJSON(iface.Methods),
+			Embeds:     sortedKeys(iface.Embeds),
+			Produces:   sortedKeys(iface.Produces),
+			IsMarker:   iface.IsMarker,
+		})
+	}
+	for _, typ := range r.Types {
+		out.Types = append(out.Types, TypeJSON{
+			ID:         typ.PkgPath + "." + typ.Name,
+			Name:       typ.Name,
+			PkgPath:    typ.PkgPath,
+			PkgName:    typ.PkgName,
+			SourceFile: typ.SourceFile,
+			IsStruct:   typ.IsStruct,
+			IsFunc:     typ.IsFunc,
+			Methods:    methodsJSON(typ.Methods),
+			AliasOf:    typ.AliasOf,
+		})
+	}
+	for _, rel := range r.Relations {
+		out.Relations = append(out.Relations, RelationJSON{
+			Type:              rel.Type.PkgPath + "." + rel.Type.Name,
+			Interface:         rel.Interface.PkgPath + "." + rel.Interface.Name,
+			ViaPointer:        rel.ViaPointer,
+			SatisfyingMethods: rel.SatisfyingMethods,
+		})
+	}
+	for _, rel := range r.Embeds {
+		e := EmbedJSON{Type: rel.Type.PkgPath + "." + rel.Type.Name, ViaPointer: rel.ViaPointer}
+		if rel.Interface != nil {
+			e.Interface = rel.Interface.PkgPath + "." + rel.Interface.Name
+		} else if rel.Embedded != nil {
+			e.Embedded = rel.Embedded.PkgPath + "." + rel.Embedded.Name
+		}
+		out.Embeds = append(out.Embeds, e)
+	}
+
+	sort.Slice(out.Interfaces, func(i, j int) bool { return out.Interfaces[i].ID < out.Interfaces[j].ID })
+	sort.Slice(out.Types, func(i, j int) bool { return out.Types[i].ID < out.Types[j].ID })
+	sort.Slice(out.Relations, func(i, j int) bool {
+		a, b := out.Relations[i], out.Relations[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Interface < b.Interface
+	})
+	sort.Slice(out.Embeds, func(i, j int) bool {
+		a, b := out.Embeds[i], out.Embeds[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Interface+a.Embedded < b.Interface+b.Embedded
+	})
+	return out
+}
+
+// Result rebuilds a Result from the projection. TypeObj fields are nil and
+// References is unset, since neither is serialized. It fails with
+// ErrUnsupportedSchema for another schema version, and when a relation
+// refers to an ID that is not in Interfaces or Types.
+func (rj ResultJSON) Result() (*Result, error) {
+	if rj.SchemaVersion != ResultSchemaVersion {
+		return nil, fmt.Errorf("%w: %d (want %d)", ErrUnsupportedSchema, rj.SchemaVersion, ResultSchemaVersion)
+	}
+	r := &Result{
+		ModulePath:      rj.ModulePath,
+		ReplacedModules: rj.ReplacedModules,
+		Interfaces:      make([]InterfaceDef, len(rj.Interfaces)),
+		Types:           make([]TypeDef, len(rj.Types)),
+	}
+	ifaces := make(map[string]*InterfaceDef, len(rj.Interfaces))
+	for i, iface := range rj.Interfaces {
+		r.Interfaces[i] = InterfaceDef{
+			Name:       iface.Name,
+			PkgPath:    iface.PkgPath,
+			PkgName:    iface.PkgName,
+			SourceFile: iface.SourceFile,
+			Methods:    methodSigs(iface.Methods),
+			Embeds:     iface.Embeds,
+			Produces:   iface.Produces,
+			IsMarker:   iface.IsMarker,
+		}
+		ifaces[iface.ID] = &r.Interfaces[i]
+	}
+	typs := make(map[string]*TypeDef, len(rj.Types))
+	for i, typ := range rj.Types {
+		r.Types[i] = TypeDef{
+			Name:       typ.Name,
+			PkgPath:    typ.PkgPath,
+			PkgName:    typ.PkgName,
+			SourceFile: typ.SourceFile,
+			IsStruct:   typ.IsStruct,
+			IsFunc:     typ.IsFunc,
+			Methods:    methodSigs(typ.Methods),
+			AliasOf:    typ.AliasOf,
+		}
+		typs[typ.ID] = &r.Types[i]
+	}
+
+	for _, rel := range rj.Relations {
+		typ, iface := typs[rel.Type], ifaces[rel.Interface]
+		if typ == nil || iface == nil {
+			return nil, fmt.Errorf("relation %s -> %s: unknown type or interface", rel.Type, rel.Interface)
+		}
+		r.Relations = append(r.Relations, Relation{Type: typ, Interface: iface, ViaPointer: rel.ViaPointer, SatisfyingMethods: rel.SatisfyingMethods})
+	}
+	for _, e := range rj.Embeds {
+		rel := Relation{Kind: RelationEmbeds, Type: typs[e.Type], ViaPointer: e.ViaPointer}
+		if e.Interface != "" {
+			rel.Interface = ifaces[e.Interface]
+		} else {
+			rel.Embedded = typs[e.Embedded]
+		}
+		if rel.Type == nil || (rel.Interface == nil && rel.Embedded == nil) {
+			return nil, fmt.Errorf("embed %s -> %s%s: unknown type or interface", e.Type, e.Interface, e.Embedded)
+		}
+		r.Embeds = append(r.Embeds, rel)
+	}
+	return r, nil
+}
+
+// MarshalResult encodes r as indented ResultJSON.
+func MarshalResult(r *Result) ([]byte, error) {
+	data, err := json.MarshalIndent(NewResultJSON(r), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling result: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// UnmarshalResult decodes ResultJSON produced by MarshalResult back into a
+// Result.
+func UnmarshalResult(data []byte) (*Result, error) {
+	var rj ResultJSON
+	if err := json.Unmarshal(data, &rj); err != nil {
+		return nil, fmt.Errorf("unmarshaling result: %w", err)
+	}
+	return rj.Result()
+}
+
+func methodsJSON(sigs []MethodSig) []MethodJSON {
+	out := make([]MethodJSON, len(sigs))
+	for i, m := range sigs {
+		out[i] = MethodJSON{Name: m.Name, Signature: m.Signature}
+	}
+	return out
+}
+
+func methodSigs(methods []MethodJSON) []MethodSig {
+	if len(methods) == 0 {
+		return nil
+	}
+	out := make([]MethodSig, len(methods))
+	for i, m := range methods {
+		out[i] = newMethodSig(m.Name, m.Signature)
+	}
+	return out
+}
+
+// sortedKeys returns a sorted copy of keys, or nil when there are none.
+func sortedKeys(keys []string) []string {
+	if len(keys) == 0 {
+		return nil
+	}
+	out := append([]string(nil), keys...)
+	sort.Strings(out)
+	return out
+}
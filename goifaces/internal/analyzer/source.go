@@ -0,0 +1,30 @@
+package analyzer
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Source collects interfaces, types and their relations from the code in a
+// directory. Analyze is the Go implementation; other languages or schema
+// formats (e.g. protobuf services) can plug in by producing the same Result,
+// which Filter and the diagram and server layers consume unchanged.
+type Source interface {
+	Collect(ctx context.Context, dir string) (*Result, error)
+}
+
+// GoSource is the default Source: it runs Analyze with fixed options.
+type GoSource struct {
+	opts   AnalyzeOptions
+	logger *slog.Logger
+}
+
+// NewGoSource returns a Source that analyzes Go packages with opts.
+func NewGoSource(opts AnalyzeOptions, logger *slog.Logger) *GoSource {
+	return &GoSource{opts: opts, logger: logger}
+}
+
+// Collect implements Source by calling Analyze.
+func (s *GoSource) Collect(ctx context.Context, dir string) (*Result, error) {
+	return Analyze(ctx, dir, s.opts, s.logger)
+}
@@ -0,0 +1,106 @@
+package analyzer
+
+import (
+	"go/types"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// stdlibBaseline lists the standard library packages whose interfaces
+// (fmt.Stringer, io.Reader, ...) are loaded with IncludeStdlib even when no
+// analyzed package imports them: a type satisfies them without naming them.
+var stdlibBaseline = []string{"fmt", "io"}
+
+// stdlibPackages returns the standard library packages whose interfaces
+// Analyze collects with IncludeStdlib: those imported, directly or
+// transitively, by the loaded packages, plus stdlibBaseline. Imported ones
+// come from the type information pkgs were checked against, so nothing is
+// loaded for them and their interfaces are the very objects the analyzed code
+// refers to. A package only imported indirectly holds just the declarations
+// its importers' export data refers to, so baseline packages that are not
+// imported directly are loaded on their own, for type information only.
+// Internal and vendored packages are skipped, and the result is sorted by
+// package path.
+func stdlibPackages(cfg *packages.Config, pkgs []*packages.Package, modulePath string, logger *slog.Logger) []*packages.Package {
+	fset := cfg.Fset
+	seen := make(map[string]bool)
+	direct := make(map[string]bool) // imported by a loaded package, so complete
+	var imported []*types.Package
+	var walk func(*types.Package)
+	walk = func(tp *types.Package) {
+		if seen[tp.Path()] {
+			return
+		}
+		seen[tp.Path()] = true
+		if isStdlib(tp.Path()) && (modulePath == "" || !strings.HasPrefix(tp.Path(), modulePath)) {
+			imported = append(imported, tp)
+		}
+		for _, imp := range tp.Imports() {
+			walk(imp)
+		}
+	}
+	for _, pkg := range pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		if fset == nil {
+			fset = pkg.Fset
+		}
+		// The loaded packages themselves are walked for their imports only
+		seen[pkg.Types.Path()] = true
+		for _, imp := range pkg.Types.Imports() {
+			direct[imp.Path()] = true
+			walk(imp)
+		}
+	}
+
+	out := make([]*packages.Package, 0, len(imported)+len(stdlibBaseline))
+	for _, tp := range imported {
+		if !stdlibInterfacePackage(tp.Path()) || (!direct[tp.Path()] && isBaseline(tp.Path())) {
+			continue
+		}
+		out = append(out, &packages.Package{ID: tp.Path(), PkgPath: tp.Path(), Name: tp.Name(), Types: tp, Fset: fset})
+	}
+
+	var missing []string
+	for _, path := range stdlibBaseline {
+		if !direct[path] {
+			missing = append(missing, path)
+		}
+	}
+	logger.Info("stdlib packages imported", "packages", len(out), "baseline_missing", missing)
+	if len(missing) > 0 {
+		// Type information only; with NeedDeps the dependencies are checked
+		// from source rather than export data, which is not always at hand
+		// for standard library roots
+		baseCfg := *cfg
+		baseCfg.Mode = packages.NeedName | packages.NeedTypes | packages.NeedImports | packages.NeedDeps
+		baseCfg.Tests = false
+		basePkgs, err := packages.Load(&baseCfg, missing...)
+		if err != nil {
+			logger.Warn("failed to load stdlib packages", "packages", missing, "error", err)
+		} else {
+			out = append(out, basePkgs...)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].PkgPath < out[j].PkgPath })
+	return out
+}
+
+// isBaseline reports whether path is one of stdlibBaseline.
+func isBaseline(path string) bool {
+	return slices.Contains(stdlibBaseline, path)
+}
+
+// stdlibInterfacePackage reports whether the interfaces of the standard
+// library package path are offered with IncludeStdlib: internal and vendored
+// packages, which user code cannot name, are not.
+func stdlibInterfacePackage(path string) bool {
+	return path != "unsafe" && path != "internal" && !strings.HasPrefix(path, "internal/") &&
+		!strings.Contains(path, "/internal/") && !strings.HasSuffix(path, "/internal") &&
+		!strings.HasPrefix(path, "vendor/")
+}
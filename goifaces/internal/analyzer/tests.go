@@ -0,0 +1,49 @@
+package analyzer
+
+import (
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// dedupeTestVariants reduces packages loaded with Tests set to one package
+// per import path. go/packages returns each package with test files twice,
+// as "p" and as the test variant "p [p.test]" whose files are a superset, plus
+// a synthesized "p.test" main package; keeping only the variant with the most
+// files avoids duplicate types and relations. External test packages (p_test)
+// have their own path and are kept.
+func dedupeTestVariants(pkgs []*packages.Package) []*packages.Package {
+	best := make(map[string]int, len(pkgs))
+	var out []*packages.Package
+	for _, pkg := range pkgs {
+		if pkg.Name == "main" && strings.HasSuffix(pkg.PkgPath, ".test") {
+			continue
+		}
+		if i, ok := best[pkg.PkgPath]; ok {
+			if len(pkg.GoFiles) > len(out[i].GoFiles) {
+				out[i] = pkg
+			}
+			continue
+		}
+		best[pkg.PkgPath] = len(out)
+		out = append(out, pkg)
+	}
+	return out
+}
+
+// dropTestDecls removes the interfaces and types declared in _test.go files.
+func dropTestDecls(ifaces []InterfaceDef, namedTypes []TypeDef) ([]InterfaceDef, []TypeDef) {
+	keptIfaces := ifaces[:0]
+	for _, iface := range ifaces {
+		if !isTestFile(iface.SourceFile) {
+			keptIfaces = append(keptIfaces, iface)
+		}
+	}
+	keptTypes := namedTypes[:0]
+	for _, t := range namedTypes {
+		if !isTestFile(t.SourceFile) {
+			keptTypes = append(keptTypes, t)
+		}
+	}
+	return keptIfaces, keptTypes
+}
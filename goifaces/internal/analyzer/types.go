@@ -1,6 +1,9 @@
 package analyzer
 
-import "go/types"
+import (
+	"go/types"
+	"strings"
+)
 
 // InterfaceDef represents a discovered Go interface.
 type InterfaceDef struct {
@@ -10,6 +13,12 @@ type InterfaceDef struct {
 	Methods    []MethodSig
 	TypeObj    *types.Interface
 	SourceFile string
+	Produces   []string // keys (pkgPath.Name) of named types returned by its methods
+	Embeds     []string // keys (pkgPath.Name) of the interfaces it embeds directly
+	// IsMarker is set for an interface without methods (interface{}, any, or
+	// an alias of either). Every type satisfies it, so it is never matched
+	// and takes part in no relation.
+	IsMarker bool
 }
 
 // TypeDef represents a discovered named Go type.
@@ -18,30 +27,112 @@ type TypeDef struct {
 	PkgPath    string
 	PkgName    string
 	IsStruct   bool
+	IsFunc     bool // underlying type is a function signature (e.g. HandlerFunc)
 	Methods    []MethodSig
-	TypeObj    *types.Named
+	TypeObj    *types.Named // nil for aliases
 	SourceFile string
+	// AliasOf is set for a type alias (type Foo = bar.Baz): the "pkgPath.Name"
+	// key of the named type or interface it stands for. Aliases take part in
+	// no relation; the diagrams link them to their target instead.
+	AliasOf string
 }
 
 // MethodSig captures a method name and its signature string.
 type MethodSig struct {
 	Name      string
 	Signature string
+	Sanitized string // Signature made safe for Mermaid labels, see SanitizeSignature
 }
 
-// Relation captures that a concrete type implements an interface.
+// newMethodSig builds a MethodSig, sanitizing the signature once so every
+// generator reuses the same label.
+func newMethodSig(name, signature string) MethodSig {
+	return MethodSig{Name: name, Signature: signature, Sanitized: SanitizeSignature(signature)}
+}
+
+// MermaidSignature returns the Mermaid-safe signature, sanitizing on the fly
+// for MethodSigs that were not built by the analyzer.
+func (m MethodSig) MermaidSignature() string {
+	if m.Sanitized != "" || m.Signature == "" {
+		return m.Sanitized
+	}
+	return SanitizeSignature(m.Signature)
+}
+
+// SanitizeSignature removes characters in method signatures that break Mermaid syntax.
+// Mermaid treats {}, <>, and ~ as special in class diagram labels.
+// Uses only ASCII-safe replacements that work in both mmdc CLI and browser Mermaid.js.
+func SanitizeSignature(sig string) string {
+	// Replace <-chan with chan (drop direction indicator — Mermaid can't handle <).
+	sig = strings.ReplaceAll(sig, "<-chan", "chan")
+	// Replace interface{} with "any" BEFORE stripping braces — bare "interface"
+	// is a reserved keyword in browser Mermaid.js (<<interface>> tag parsing).
+	sig = strings.ReplaceAll(sig, "interface{}", "any")
+	// Strip remaining empty braces — in Go signatures these are empty type literals
+	// like struct{}, map[K]struct{}.
+	sig = strings.ReplaceAll(sig, "{}", "")
+	return sig
+}
+
+// RelationKind distinguishes implementation from embedding relations.
+type RelationKind int
+
+const (
+	// RelationImplements means Type implements Interface.
+	RelationImplements RelationKind = iota
+	// RelationEmbeds means struct Type has an embedded field of Interface or
+	// Embedded.
+	RelationEmbeds
+)
+
+// Relation captures that a concrete type implements an interface or, with
+// Kind RelationEmbeds, that a struct embeds an interface or another type.
 type Relation struct {
 	Type       *TypeDef
 	Interface  *InterfaceDef
-	ViaPointer bool // true if only *T (not T) satisfies the interface
+	ViaPointer bool // Implements: only *T (not T) satisfies the interface; Embeds: the field is *Embedded
+	Kind       RelationKind
+	Embedded   *TypeDef // Embeds only: the embedded concrete type (Interface is nil then)
+	// SatisfyingMethods (Implements only) names the methods of Type that
+	// satisfy Interface, embedded-interface methods included, from the
+	// method set of *T when ViaPointer.
+	SatisfyingMethods []string
 }
 
 // Result holds the complete analysis output.
 type Result struct {
 	Interfaces []InterfaceDef
 	Types      []TypeDef
-	Relations  []Relation
+	Relations  []Relation // RelationImplements only
+	// Embeds holds the RelationEmbeds relations of struct types. They are
+	// kept apart so that consumers of Relations only ever see implementations.
+	Embeds     []Relation
 	ModulePath string // module path from go.mod (e.g. "github.com/user/repo")
+	// ReplacedModules maps module paths loaded through a replace directive to
+	// their replacement (e.g. "example.com/lib" -> "../lib").
+	ReplacedModules map[string]string
+	// References counts the identifiers referring to each named type
+	// ("pkgPath.Name") across the loaded packages, excluding the receivers of
+	// its own methods. Only set by Analyze; used by UnusedExports.
+	References map[string]int
+}
+
+// ReplacedModule returns the module loaded through a replace directive that
+// pkgPath belongs to, or "" if there is none.
+func (r *Result) ReplacedModule(pkgPath string) string {
+	best := ""
+	for mod := range r.ReplacedModules {
+		if (pkgPath == mod || strings.HasPrefix(pkgPath, mod+"/")) && len(mod) > len(best) {
+			best = mod
+		}
+	}
+	return best
+}
+
+// IsReplaced reports whether pkgPath belongs to a module loaded through a
+// replace directive.
+func (r *Result) IsReplaced(pkgPath string) bool {
+	return r.ReplacedModule(pkgPath) != ""
 }
 
 // AnalyzeOptions controls analysis behavior.
@@ -49,4 +140,59 @@ type AnalyzeOptions struct {
 	Filter            string // package path prefix filter
 	IncludeStdlib     bool
 	IncludeUnexported bool
+	ExcludeFuncTypes  bool // drop named function types (e.g. HandlerFunc) from relations
+	// Exclude drops, in Filter and after the Filter prefix, the interfaces
+	// and types of packages matching any of these patterns, with their
+	// relations (see Excluded). Analyze itself ignores it.
+	Exclude []string
+	// PublicInterfaces keeps only exported interfaces but all of their
+	// implementers, exported or not. Takes precedence over IncludeUnexported.
+	PublicInterfaces bool
+	// IncludeMarkers makes Filter keep marker interfaces (InterfaceDef.IsMarker)
+	// in scope, although they have no relations.
+	IncludeMarkers bool
+	// Subdir, when set, loads only the packages in that slash-separated
+	// directory under the analyzed dir and below it ("./Subdir/..."), still
+	// resolved with the module's go.mod. Interfaces of other module packages
+	// they import are collected as usual, and source files stay relative to
+	// the analyzed dir.
+	Subdir string
+	// MatchCache, when set, reuses implementation matches for packages whose
+	// files and method signatures are unchanged since the cache was filled,
+	// and is updated in place with this run's matches.
+	MatchCache *MatchCache
+	// ResultCacheDir, when set, keeps each Result in a file in that
+	// directory, and Analyze returns the stored one without loading any
+	// package while the module's Go files, go.mod and go.sum (and those of
+	// locally replaced modules) have the same mod times and sizes and the
+	// options are the same. Results read from the cache carry no TypeObj.
+	ResultCacheDir string
+	// MaxNodes aborts Analyze with ErrTooManyNodes before the match phase
+	// when more interfaces and types than this (under Filter, if set) were
+	// collected. 0 means no limit.
+	MaxNodes int
+	// MaxPackages aborts Analyze with ErrTooManyPackages right after
+	// loading when more packages than this (under Filter, if set) were
+	// loaded from the module and its locally replaced modules. It counts
+	// loaded packages, including ones that declare no interfaces or types.
+	// 0 means no limit.
+	MaxPackages int
+	// BuildFlags are appended verbatim to the go command that loads packages
+	// (e.g. "-gcflags=all=-N"). An escape hatch: bad flags make loading fail.
+	BuildFlags []string
+	// IncludeTests also analyzes _test.go files (-include-tests). Left false,
+	// packages are loaded without their test variants and any interface or
+	// type declared in a test file is dropped.
+	IncludeTests bool
+	// GOOS and GOARCH load packages as for that target, so files behind
+	// build constraints such as //go:build windows are chosen the same way
+	// on every machine. Empty means the host's (or the environment's) value.
+	GOOS   string
+	GOARCH string
+	// Progress, when set, is called as Analyze moves through its phases
+	// (PhaseLoading, PhaseCollecting, PhaseMatching), each starting at
+	// done = 0 and ending at done = total. Within a phase it is called at
+	// most about a hundred times. A result read from ResultCacheDir reports
+	// no phases. It is called on the goroutine running Analyze.
+	Progress func(phase string, done, total int)
 }
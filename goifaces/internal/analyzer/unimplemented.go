@@ -0,0 +1,62 @@
+package analyzer
+
+import "sort"
+
+// UnimplementedInterfaces returns the interfaces with at least one method,
+// declared in the analyzed module (or a locally replaced one), that no type
+// implements, sorted by package path and name. These are often dead
+// abstractions or missing wiring.
+//
+// An interface counts as implemented when it appears in a Relation, when a
+// struct embeds it (a value is plugged in at runtime), or when it is embedded,
+// directly or not, in an interface that is implemented. The builtin error
+// interface is never reported.
+func UnimplementedInterfaces(result *Result) []InterfaceDef {
+	implemented := make(map[string]bool)
+	var queue []string
+	mark := func(key string) {
+		if !implemented[key] {
+			implemented[key] = true
+			queue = append(queue, key)
+		}
+	}
+	for _, rel := range result.Relations {
+		mark(ifaceKey(rel.Interface))
+	}
+	for _, rel := range result.Embeds {
+		if rel.Interface != nil {
+			mark(ifaceKey(rel.Interface))
+		}
+	}
+
+	embeds := make(map[string][]string, len(result.Interfaces))
+	for _, iface := range result.Interfaces {
+		embeds[ifaceKey(&iface)] = iface.Embeds
+	}
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+		for _, embedded := range embeds[key] {
+			mark(embedded)
+		}
+	}
+
+	var out []InterfaceDef
+	seen := make(map[string]bool)
+	for _, iface := range result.Interfaces {
+		key := ifaceKey(&iface)
+		if len(iface.Methods) == 0 || implemented[key] || seen[key] ||
+			(iface.PkgPath == "builtin" && iface.Name == "error") || !inModule(result, iface.PkgPath) {
+			continue
+		}
+		seen[key] = true
+		out = append(out, iface)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].PkgPath != out[j].PkgPath {
+			return out[i].PkgPath < out[j].PkgPath
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
@@ -0,0 +1,90 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// countReferences counts, per named type ("pkgPath.Name"), the identifiers in
+// pkgs that refer to it. Receivers of the type's own methods are not counted:
+// declaring methods on a type does not use it.
+func countReferences(pkgs []*packages.Package) map[string]int {
+	refs := make(map[string]int)
+	seen := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if pkg.TypesInfo == nil || seen[pkg.PkgPath] {
+			continue
+		}
+		seen[pkg.PkgPath] = true
+
+		receivers := make(map[*ast.Ident]bool)
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Recv == nil {
+					continue
+				}
+				for _, field := range fn.Recv.List {
+					ast.Inspect(field.Type, func(n ast.Node) bool {
+						if id, ok := n.(*ast.Ident); ok {
+							receivers[id] = true
+						}
+						return true
+					})
+				}
+			}
+		}
+
+		for id, obj := range pkg.TypesInfo.Uses {
+			tn, ok := obj.(*types.TypeName)
+			if !ok || tn.Pkg() == nil || receivers[id] {
+				continue
+			}
+			refs[tn.Pkg().Path()+"."+tn.Name()]++
+		}
+	}
+	return refs
+}
+
+// UnusedExports returns the "pkgPath.Name" keys, sorted, of exported
+// interfaces and types declared in the analyzed module (or a locally replaced
+// one) that no identifier in the loaded packages refers to. Code outside the
+// module is invisible to the analysis, so for libraries these are often
+// public API rather than dead code: treat the list as a hint. Returns nil for
+// results not produced by Analyze.
+func UnusedExports(result *Result) []string {
+	if result.References == nil {
+		return nil
+	}
+	var unused []string
+	check := func(pkgPath, name string) {
+		key := pkgPath + "." + name
+		if isUnexported(name) || !inModule(result, pkgPath) || result.References[key] > 0 {
+			return
+		}
+		unused = append(unused, key)
+	}
+	for _, iface := range result.Interfaces {
+		check(iface.PkgPath, iface.Name)
+	}
+	for _, typ := range result.Types {
+		check(typ.PkgPath, typ.Name)
+	}
+	sort.Strings(unused)
+	return unused
+}
+
+// inModule reports whether pkgPath belongs to the analyzed module or a module
+// replaced with a local directory. Without a module path every non-stdlib
+// package counts.
+func inModule(result *Result, pkgPath string) bool {
+	if result.ModulePath == "" {
+		return !isStdlib(pkgPath)
+	}
+	return pkgPath == result.ModulePath || strings.HasPrefix(pkgPath, result.ModulePath+"/") ||
+		result.IsReplaced(pkgPath)
+}
@@ -0,0 +1,68 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// readWorkspaceModules returns the module paths of the modules listed by the
+// use directives of dir's go.work, in file order. Both single-line and block
+// forms are recognised; modules without a readable go.mod are skipped. It
+// returns nil when dir has no go.work.
+func readWorkspaceModules(dir string) []string {
+	data, err := os.ReadFile(filepath.Join(dir, "go.work"))
+	if err != nil {
+		return nil
+	}
+	var mods []string
+	inBlock := false
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if idx := strings.Index(line, "//"); idx >= 0 {
+			line = strings.TrimSpace(line[:idx])
+		}
+		switch {
+		case line == "use (":
+			inBlock = true
+			continue
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case strings.HasPrefix(line, "use "):
+			line = strings.TrimSpace(strings.TrimPrefix(line, "use"))
+		case !inBlock:
+			continue
+		}
+		useDir := strings.Trim(line, `"`)
+		if useDir == "" {
+			continue
+		}
+		if !filepath.IsAbs(useDir) {
+			useDir = filepath.Join(dir, useDir)
+		}
+		if modPath := readModulePath(useDir); modPath != "" {
+			mods = append(mods, modPath)
+		}
+	}
+	return mods
+}
+
+// commonModulePath returns the longest path prefix, on element boundaries,
+// shared by all module paths: "example.com/ws" for "example.com/ws/api" and
+// "example.com/ws/store". It is "" when the modules share no element.
+func commonModulePath(mods []string) string {
+	if len(mods) == 0 {
+		return ""
+	}
+	prefix := strings.Split(mods[0], "/")
+	for _, mod := range mods[1:] {
+		elems := strings.Split(mod, "/")
+		n := 0
+		for n < len(prefix) && n < len(elems) && prefix[n] == elems[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return strings.Join(prefix, "/")
+}
@@ -0,0 +1,143 @@
+package diagram
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BookIndexFile is the file name of the book's landing page.
+const BookIndexFile = "index.md"
+
+// BookPage is one Markdown file of a multi-page architecture book.
+type BookPage struct {
+	FileName string
+	Title    string
+	Content  string
+}
+
+// WrapMermaidFence wraps Mermaid source in a fenced ```mermaid code block
+// so it renders in Markdown viewers such as GitHub.
+func WrapMermaidFence(src string) string {
+	return "```mermaid\n" + strings.TrimRight(src, "\n") + "\n```\n"
+}
+
+// slideBody returns the Markdown for a slide: its text as-is, or its Mermaid
+// source in a fence.
+func slideBody(s Slide) string {
+	if s.Text != "" {
+		return s.Text
+	}
+	return WrapMermaidFence(s.Mermaid)
+}
+
+// BuildBook turns slides into a navigable set of Markdown pages: an index
+// page (holding the package map slide, if present, and a table of contents)
+// followed by one NN-<title>.md page per remaining slide. Every page links to
+// its previous and next neighbour and back to the index.
+func BuildBook(slides []Slide) []BookPage {
+	var packageMap *Slide
+	chapters := slides
+	if len(slides) > 0 && slides[0].Title == "Package Map" {
+		packageMap = &slides[0]
+		chapters = slides[1:]
+	}
+
+	fileNames := make([]string, len(chapters))
+	for i, s := range chapters {
+		fileNames[i] = fmt.Sprintf("%02d-%s.md", i+1, slugify(s.Title))
+	}
+
+	// Index page
+	var idx strings.Builder
+	idx.WriteString("# Architecture\n")
+	if packageMap != nil {
+		idx.WriteString("\n## Package Map\n\n")
+		idx.WriteString(slideBody(*packageMap))
+	}
+	if len(chapters) > 0 {
+		idx.WriteString("\n## Contents\n\n")
+		for i, s := range chapters {
+			fmt.Fprintf(&idx, "%d. [%s](%s)\n", i+1, s.Title, fileNames[i])
+		}
+		fmt.Fprintf(&idx, "\n[Next →](%s)\n", fileNames[0])
+	}
+
+	pages := []BookPage{{FileName: BookIndexFile, Title: "Architecture", Content: idx.String()}}
+
+	for i, s := range chapters {
+		prev := BookIndexFile
+		if i > 0 {
+			prev = fileNames[i-1]
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "# %s\n\n", s.Title)
+		b.WriteString(slideBody(s))
+		b.WriteString("\n---\n\n")
+		fmt.Fprintf(&b, "[← Previous](%s) | [Index](%s)", prev, BookIndexFile)
+		if i+1 < len(chapters) {
+			fmt.Fprintf(&b, " | [Next →](%s)", fileNames[i+1])
+		}
+		b.WriteString("\n")
+
+		pages = append(pages, BookPage{FileName: fileNames[i], Title: s.Title, Content: b.String()})
+	}
+
+	return pages
+}
+
+// BuildSlideDeck renders slides as a single Markdown document: an
+// "Architecture" heading followed by one numbered section per slide, each
+// holding the slide's Mermaid block (or text) and separated by rules, so a
+// viewer that renders Mermaid shows the deck top to bottom.
+func BuildSlideDeck(slides []Slide) string {
+	var b strings.Builder
+	b.WriteString("# Architecture\n")
+	for i, s := range slides {
+		if i > 0 {
+			b.WriteString("\n---\n")
+		}
+		fmt.Fprintf(&b, "\n## %d. %s\n\n", i+1, s.Title)
+		b.WriteString(slideBody(s))
+	}
+	return b.String()
+}
+
+// WriteBook writes book pages into dir, creating it if needed.
+func WriteBook(dir string, pages []BookPage) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating book dir: %w", err)
+	}
+	for _, p := range pages {
+		if err := os.WriteFile(filepath.Join(dir, p.FileName), []byte(p.Content), 0o644); err != nil {
+			return fmt.Errorf("writing %s: %w", p.FileName, err)
+		}
+	}
+	return nil
+}
+
+// slugify converts a slide title into a lowercase, dash-separated file name
+// fragment, capped at 50 characters.
+func slugify(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	slug := strings.TrimRight(b.String(), "-")
+	if len(slug) > 50 {
+		slug = strings.TrimRight(slug[:50], "-")
+	}
+	if slug == "" {
+		slug = "slide"
+	}
+	return slug
+}
@@ -0,0 +1,90 @@
+package diagram
+
+import (
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+)
+
+// singleImpl is the sole implementer of an interface under
+// CollapseSingleImpl.
+type singleImpl struct {
+	typ        *analyzer.TypeDef
+	viaPointer bool // only *T implements the interface
+}
+
+// collapseSingleImpl merges every interface with exactly one implementing
+// type into that type: the implements edge is removed, and the type too when
+// no other relation is left to draw for it. A type is counted once however
+// many relations it has with the interface, so value and pointer receivers
+// never make two implementers. Pairs where the type takes part in an
+// embedding are left alone, since the embed edges need its node. It returns
+// the remaining relations and types, and the label of each collapsed
+// interface block ("pkg.Iface (impl: pkg.Type)") keyed by "pkgPath.Name".
+func collapseSingleImpl(rels []analyzer.Relation, typs []analyzer.TypeDef, embeds []analyzer.Relation) ([]analyzer.Relation, []analyzer.TypeDef, map[string]string) {
+	embedded := make(map[string]bool)
+	for _, e := range embeds {
+		embedded[typeKey(e.Type.PkgPath, e.Type.Name)] = true
+		if e.Embedded != nil {
+			embedded[typeKey(e.Embedded.PkgPath, e.Embedded.Name)] = true
+		}
+	}
+
+	implementers := make(map[string]map[string]*singleImpl) // interface key -> type key -> implementer
+	ifaceDefs := make(map[string]*analyzer.InterfaceDef)
+	for _, rel := range rels {
+		ifaceK := typeKey(rel.Interface.PkgPath, rel.Interface.Name)
+		typeK := typeKey(rel.Type.PkgPath, rel.Type.Name)
+		if implementers[ifaceK] == nil {
+			implementers[ifaceK] = make(map[string]*singleImpl)
+			ifaceDefs[ifaceK] = rel.Interface
+		}
+		if impl, ok := implementers[ifaceK][typeK]; ok {
+			impl.viaPointer = impl.viaPointer && rel.ViaPointer
+		} else {
+			implementers[ifaceK][typeK] = &singleImpl{typ: rel.Type, viaPointer: rel.ViaPointer}
+		}
+	}
+
+	labels := make(map[string]string)
+	for ifaceK, impls := range implementers {
+		if len(impls) != 1 {
+			continue
+		}
+		for typeK, impl := range impls {
+			if embedded[typeK] {
+				continue
+			}
+			iface := ifaceDefs[ifaceK]
+			name := impl.typ.PkgName + "." + impl.typ.Name
+			if impl.viaPointer {
+				name = "*" + name
+			}
+			labels[ifaceK] = iface.PkgName + "." + iface.Name + " (impl: " + name + ")"
+		}
+	}
+	if len(labels) == 0 {
+		return rels, typs, nil
+	}
+
+	var kept []analyzer.Relation
+	stillLinked := make(map[string]bool)
+	collapsedTypes := make(map[string]bool)
+	for _, rel := range rels {
+		typeK := typeKey(rel.Type.PkgPath, rel.Type.Name)
+		if _, ok := labels[typeKey(rel.Interface.PkgPath, rel.Interface.Name)]; ok {
+			collapsedTypes[typeK] = true
+			continue
+		}
+		kept = append(kept, rel)
+		stillLinked[typeK] = true
+	}
+
+	var keptTypes []analyzer.TypeDef
+	for _, typ := range typs {
+		key := typeKey(typ.PkgPath, typ.Name)
+		if collapsedTypes[key] && !stillLinked[key] {
+			continue
+		}
+		keptTypes = append(keptTypes, typ)
+	}
+	return kept, keptTypes, labels
+}
@@ -0,0 +1,78 @@
+package diagram
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+)
+
+// d2Directions maps Mermaid directions to D2's direction keyword.
+var d2Directions = map[string]string{
+	DirectionLR: "right",
+	DirectionRL: "left",
+	DirectionTB: "down",
+	DirectionBT: "up",
+}
+
+// d2PlainKey matches the node IDs D2 reads as one key without quoting.
+var d2PlainKey = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// GenerateD2 produces a D2 diagram from analysis results: interfaces are
+// ovals, concrete types are rectangles, and each implementation is a dashed
+// "implements" connection from the type to the interface, labeled
+// "implements (*)" when only *T implements it. D2 reads dots in keys as
+// nesting, so shapes are keyed by NodeID (or QualifiedNodeID), which has
+// none, and show "pkg.Name" as a quoted label with the full path as tooltip.
+// The order matches GenerateMermaid; only QualifiedIDs and Direction are
+// honored from opts. An empty result yields an empty (and valid) diagram.
+func GenerateD2(result *analyzer.Result, opts DiagramOptions) string {
+	ifaces, typs, rels := sortedResult(result)
+	if len(ifaces) == 0 && len(typs) == 0 {
+		return ""
+	}
+	opts = opts.withNodeIDs(ifaces, typs)
+
+	var b strings.Builder
+	b.WriteString("direction: " + d2Directions[opts.direction()] + "\n")
+
+	for _, iface := range ifaces {
+		id := opts.nodeID(iface.PkgPath, iface.PkgName, iface.Name)
+		fmt.Fprintf(&b, "\n%s: %s {\n  shape: oval\n  tooltip: %s\n}\n",
+			d2Key(id), d2Quote(iface.PkgName+"."+iface.Name), d2Quote(iface.PkgPath+"."+iface.Name))
+	}
+	for _, typ := range typs {
+		id := opts.nodeID(typ.PkgPath, typ.PkgName, typ.Name)
+		fmt.Fprintf(&b, "\n%s: %s {\n  shape: rectangle\n  tooltip: %s\n}\n",
+			d2Key(id), d2Quote(typ.PkgName+"."+typ.Name), d2Quote(typ.PkgPath+"."+typ.Name))
+	}
+
+	if len(rels) > 0 {
+		b.WriteString("\n")
+	}
+	for _, rel := range rels {
+		typeID := opts.nodeID(rel.Type.PkgPath, rel.Type.PkgName, rel.Type.Name)
+		ifaceID := opts.nodeID(rel.Interface.PkgPath, rel.Interface.PkgName, rel.Interface.Name)
+		label := "implements"
+		if rel.ViaPointer {
+			label += " (" + pointerLabel + ")"
+		}
+		fmt.Fprintf(&b, "%s -> %s: %s {style.stroke-dash: 3}\n", d2Key(typeID), d2Key(ifaceID), d2Quote(label))
+	}
+	return b.String()
+}
+
+// d2Key returns id as a D2 key, quoted unless it is a plain identifier.
+func d2Key(id string) string {
+	if d2PlainKey.MatchString(id) {
+		return id
+	}
+	return d2Quote(id)
+}
+
+// d2Quote returns s as a double-quoted D2 string, escaping backslashes and
+// quotes and replacing newlines with spaces.
+func d2Quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ").Replace(s) + `"`
+}
@@ -0,0 +1,34 @@
+package diagram
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Layout directions for DiagramOptions.Direction, spelled as in Mermaid.
+const (
+	DirectionLR = "LR" // left to right, the default
+	DirectionRL = "RL" // right to left
+	DirectionTB = "TB" // top to bottom
+	DirectionBT = "BT" // bottom to top
+)
+
+// ParseDirection validates a -direction value, ignoring case; "" is
+// DirectionLR. An unknown value returns DirectionLR with an error wrapping
+// ErrInvalidDirection, so callers can warn and carry on.
+func ParseDirection(s string) (string, error) {
+	switch d := strings.ToUpper(strings.TrimSpace(s)); d {
+	case "":
+		return DirectionLR, nil
+	case DirectionLR, DirectionRL, DirectionTB, DirectionBT:
+		return d, nil
+	}
+	return DirectionLR, fmt.Errorf("%w %q: want %s, %s, %s or %s", ErrInvalidDirection, s, DirectionLR, DirectionTB, DirectionRL, DirectionBT)
+}
+
+// direction returns the layout direction to emit: Direction when valid,
+// otherwise DirectionLR.
+func (o DiagramOptions) direction() string {
+	d, _ := ParseDirection(o.Direction)
+	return d
+}
@@ -0,0 +1,59 @@
+package diagram
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+)
+
+// GenerateDOT produces a Graphviz digraph from analysis results: interfaces
+// are ellipses, concrete types are boxes, and each implementation is a dashed
+// edge with an empty arrowhead from the type to the interface (the DOT form
+// of Mermaid's --|>). Node IDs come from NodeID (or QualifiedNodeID) and the
+// order matches GenerateMermaid. Only QualifiedIDs and Direction (as rankdir)
+// are honored from opts; an empty result yields "digraph {}".
+func GenerateDOT(result *analyzer.Result, opts DiagramOptions) string {
+	ifaces, typs, rels := sortedResult(result)
+	if len(ifaces) == 0 && len(typs) == 0 {
+		return "digraph {}\n"
+	}
+	opts = opts.withNodeIDs(ifaces, typs)
+
+	var b strings.Builder
+	b.WriteString("digraph {\n")
+	b.WriteString("    rankdir=" + opts.direction() + ";\n")
+	b.WriteString("    node [fontname=\"Helvetica\", style=filled];\n")
+
+	palette := opts.palette()
+	for _, iface := range ifaces {
+		id := opts.nodeID(iface.PkgPath, iface.PkgName, iface.Name)
+		fmt.Fprintf(&b, "    %s [shape=ellipse, fillcolor=\"%s\", color=\"%s\", fontcolor=\"%s\", label=%s, tooltip=%s];\n",
+			dotQuote(id), palette.Interface.Fill, palette.Interface.Stroke, palette.Interface.Text,
+			dotQuote(iface.PkgName+"."+iface.Name), dotQuote(iface.PkgPath+"."+iface.Name))
+	}
+	for _, typ := range typs {
+		id := opts.nodeID(typ.PkgPath, typ.PkgName, typ.Name)
+		fmt.Fprintf(&b, "    %s [shape=box, fillcolor=\"%s\", color=\"%s\", fontcolor=\"%s\", label=%s, tooltip=%s];\n",
+			dotQuote(id), palette.Impl.Fill, palette.Impl.Stroke, palette.Impl.Text,
+			dotQuote(typ.PkgName+"."+typ.Name), dotQuote(typ.PkgPath+"."+typ.Name))
+	}
+	for _, rel := range rels {
+		typeID := opts.nodeID(rel.Type.PkgPath, rel.Type.PkgName, rel.Type.Name)
+		ifaceID := opts.nodeID(rel.Interface.PkgPath, rel.Interface.PkgName, rel.Interface.Name)
+		attrs := "style=dashed, arrowhead=empty"
+		if score, ok := opts.relationScore(rel); ok {
+			attrs += fmt.Sprintf(", penwidth=%.1f", dotPenWidth(score))
+		}
+		fmt.Fprintf(&b, "    %s -> %s [%s];\n", dotQuote(typeID), dotQuote(ifaceID), attrs)
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotQuote returns s as a double-quoted DOT ID, escaping backslashes, quotes
+// and newlines.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
@@ -0,0 +1,76 @@
+package diagram
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+)
+
+// ErrorClusterID is the node ID of the collapsed cluster standing in for the
+// implementers of the builtin error interface.
+const ErrorClusterID = "builtin_error_cluster"
+
+// minErrorCluster is the smallest number of error implementers worth
+// collapsing; a single implementer is drawn as usual.
+const minErrorCluster = 2
+
+// isErrorInterface reports whether iface is the builtin error interface.
+func isErrorInterface(iface *analyzer.InterfaceDef) bool {
+	return iface != nil && iface.PkgPath == "builtin" && iface.Name == "error"
+}
+
+// errorCluster is the outcome of collapsing error implementers.
+type errorCluster struct {
+	count   int    // number of distinct error implementers
+	errorID string // node ID of the error interface
+}
+
+// clusterErrorRelations removes the "implements error" edges from rels and
+// drops types whose only relation was to error. It returns the remaining
+// relations and types plus the cluster to draw in their place; the cluster is
+// nil (and inputs are returned unchanged) when there are fewer than
+// minErrorCluster implementers.
+func clusterErrorRelations(rels []analyzer.Relation, typs []analyzer.TypeDef, opts DiagramOptions) ([]analyzer.Relation, []analyzer.TypeDef, *errorCluster) {
+	implementers := make(map[string]bool)
+	var errorID string
+	for _, rel := range rels {
+		if isErrorInterface(rel.Interface) {
+			implementers[typeKey(rel.Type.PkgPath, rel.Type.Name)] = true
+			errorID = opts.nodeID(rel.Interface.PkgPath, rel.Interface.PkgName, rel.Interface.Name)
+		}
+	}
+	if len(implementers) < minErrorCluster {
+		return rels, typs, nil
+	}
+
+	var kept []analyzer.Relation
+	stillLinked := make(map[string]bool)
+	for _, rel := range rels {
+		if isErrorInterface(rel.Interface) {
+			continue
+		}
+		kept = append(kept, rel)
+		stillLinked[typeKey(rel.Type.PkgPath, rel.Type.Name)] = true
+	}
+
+	var keptTypes []analyzer.TypeDef
+	for _, typ := range typs {
+		key := typeKey(typ.PkgPath, typ.Name)
+		if implementers[key] && !stillLinked[key] {
+			continue
+		}
+		keptTypes = append(keptTypes, typ)
+	}
+
+	return kept, keptTypes, &errorCluster{count: len(implementers), errorID: errorID}
+}
+
+// writeErrorCluster writes the collapsed cluster block and its single edge to
+// the error interface.
+func writeErrorCluster(b *strings.Builder, c *errorCluster) {
+	fmt.Fprintf(b, "\n    class %s[\"%d error implementations\"] {\n", ErrorClusterID, c.count)
+	b.WriteString("        <<cluster>>\n")
+	b.WriteString("    }")
+	fmt.Fprintf(b, "\n    %s ..|> %s", ErrorClusterID, c.errorID)
+}
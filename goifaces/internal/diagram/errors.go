@@ -0,0 +1,27 @@
+package diagram
+
+import "errors"
+
+// Sentinel errors returned (wrapped) by GenerateSVG, ParseDirection,
+// LoadTheme and FocusSubgraph.
+// Check with errors.Is.
+var (
+	// ErrMermaidCLINotFound means the mermaid-cli executable (MermaidCLI)
+	// is not on PATH.
+	ErrMermaidCLINotFound = errors.New("mermaid-cli (mmdc) not found on PATH")
+	// ErrRenderFailed means mermaid-cli ran but did not produce an SVG. The
+	// wrapping error carries its stderr.
+	ErrRenderFailed = errors.New("rendering SVG with mermaid-cli")
+	// ErrInvalidDirection means a layout direction is not one of LR, TB,
+	// RL or BT.
+	ErrInvalidDirection = errors.New("invalid direction")
+	// ErrUnknownTheme means a -theme value is neither a built-in theme nor a
+	// .json palette file.
+	ErrUnknownTheme = errors.New("unknown theme")
+	// ErrInvalidPalette means a palette file is malformed or sets a color
+	// that is not #rgb or #rrggbb.
+	ErrInvalidPalette = errors.New("invalid palette")
+	// ErrUnknownFocus means the FocusSubgraph key names no interface or type
+	// of the result. The wrapping error lists the closest keys.
+	ErrUnknownFocus = errors.New("unknown focus node")
+)
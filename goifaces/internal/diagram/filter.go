@@ -2,33 +2,50 @@ package diagram
 
 import (
 	"sort"
+	"strings"
 
 	"github.com/olehluchkiv/goifaces/internal/analyzer"
 )
 
 // InteractiveInterface holds pre-computed data for a single interface in the interactive UI.
 type InteractiveInterface struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	PkgName     string   `json:"pkgName"`
+	PkgPath     string   `json:"pkgPath"`
+	Methods     []string `json:"methods"`
+	MethodCount int      `json:"methodCount"` // before MaxMethodsPerBox truncation
+	SourceFile  string   `json:"sourceFile,omitempty"`
+	External    bool     `json:"external,omitempty"`   // outside the analyzed module (MarkExternal)
+	Port        bool     `json:"port,omitempty"`       // implemented only outside its own package (MarkPorts)
+	IsMarker    bool     `json:"isMarker,omitempty"`   // no methods, shown with a <<marker>> stereotype
+	Annotation  string   `json:"annotation,omitempty"` // description from DiagramOptions.Annotations, shown as a tooltip
+}
+
+// InteractiveType holds pre-computed data for a single implementation type in the interactive UI.
+type InteractiveType struct {
 	ID         string   `json:"id"`
 	Name       string   `json:"name"`
 	PkgName    string   `json:"pkgName"`
 	PkgPath    string   `json:"pkgPath"`
-	Methods    []string `json:"methods"`
+	Methods    []string `json:"methods,omitempty"` // method set of *T, including promoted methods
 	SourceFile string   `json:"sourceFile,omitempty"`
-}
-
-// InteractiveType holds pre-computed data for a single implementation type in the interactive UI.
-type InteractiveType struct {
-	ID         string `json:"id"`
-	Name       string `json:"name"`
-	PkgName    string `json:"pkgName"`
-	PkgPath    string `json:"pkgPath"`
-	SourceFile string `json:"sourceFile,omitempty"`
+	IsFunc     bool     `json:"isFunc,omitempty"`
+	External   bool     `json:"external,omitempty"`   // outside the analyzed module (MarkExternal)
+	Annotation string   `json:"annotation,omitempty"` // description from DiagramOptions.Annotations, shown as a tooltip
 }
 
 // InteractiveRelation maps a type to an interface it implements.
 type InteractiveRelation struct {
 	TypeID      string `json:"typeId"`
 	InterfaceID string `json:"interfaceId"`
+	ViaPointer  bool   `json:"viaPointer,omitempty"` // only *T implements the interface
+	// Methods names the type's methods that satisfy the interface
+	// (analyzer.Relation.SatisfyingMethods), shown when the edge is hovered.
+	Methods []string `json:"methods,omitempty"`
+	// Score is the relation's DiagramOptions.RelationScores entry, which
+	// sets the edge's weight; nil when unscored.
+	Score *float64 `json:"score,omitempty"`
 }
 
 // PackageMapNode represents a node in the package hierarchy for the HTML treemap.
@@ -39,6 +56,8 @@ type PackageMapNode struct {
 	Interfaces int               `json:"interfaces"`
 	Types      int               `json:"types"`
 	Value      int               `json:"value"`
+	Aggregated int               `json:"aggregated,omitempty"` // packages folded into an "(other)" node
+	Replaced   bool              `json:"replaced,omitempty"`   // belongs to a module loaded through a replace directive
 	Children   []*PackageMapNode `json:"children,omitempty"`
 }
 
@@ -49,6 +68,25 @@ type InteractiveData struct {
 	Types           []InteractiveType      `json:"types"`
 	Relations       []InteractiveRelation  `json:"relations"`
 	RepoAddress     string                 `json:"repoAddress"`
+	// ErrorInterfaceID is set when ClusterError is enabled and the builtin
+	// error interface is present; the UI then collapses its implementers.
+	ErrorInterfaceID string `json:"errorInterfaceId,omitempty"`
+	// Direction is DiagramOptions.Direction, validated; the Structures tab
+	// lays its class diagram out in it.
+	Direction string `json:"direction"`
+	// CollapseSingleImpl is DiagramOptions.CollapseSingleImpl; the
+	// Structures tab merges single-implementer interfaces like
+	// GenerateMermaid.
+	CollapseSingleImpl bool `json:"collapseSingleImpl,omitempty"`
+	// Patterns are DiagramOptions.Patterns resolved to nodes, for the
+	// Patterns tab; nil when pattern detection did not run.
+	Patterns []DetectedPattern `json:"patterns"`
+	// Metrics holds the implementation degree of every interface and type,
+	// keyed by node ID (analyzer.Metrics), e.g. to size nodes by it.
+	Metrics map[string]analyzer.NodeMetrics `json:"metrics,omitempty"`
+	// Palette is DiagramOptions.Palette, nil for the default; the page colors
+	// its nodes and package map with it.
+	Palette *Palette `json:"palette,omitempty"`
 }
 
 // PrepareInteractiveData converts an analyzer.Result into the data structure
@@ -62,7 +100,10 @@ func PrepareInteractiveData(result *analyzer.Result, opts DiagramOptions) Intera
 		if ifaces[i].PkgName != ifaces[j].PkgName {
 			return ifaces[i].PkgName < ifaces[j].PkgName
 		}
-		return ifaces[i].Name < ifaces[j].Name
+		if ifaces[i].Name != ifaces[j].Name {
+			return ifaces[i].Name < ifaces[j].Name
+		}
+		return ifaces[i].PkgPath < ifaces[j].PkgPath
 	})
 
 	// Sort types deterministically
@@ -72,27 +113,34 @@ func PrepareInteractiveData(result *analyzer.Result, opts DiagramOptions) Intera
 		if typs[i].PkgName != typs[j].PkgName {
 			return typs[i].PkgName < typs[j].PkgName
 		}
-		return typs[i].Name < typs[j].Name
+		if typs[i].Name != typs[j].Name {
+			return typs[i].Name < typs[j].Name
+		}
+		return typs[i].PkgPath < typs[j].PkgPath
 	})
 
+	ifaceIDs, typeIDs, _ := assignNodeIDs(ifaces, typs, opts)
+
+	var ports map[string]analyzer.PortKind
+	if opts.MarkPorts {
+		ports = analyzer.ClassifyPorts(result)
+	}
+
 	// Build interactive interfaces
 	interactiveIfaces := make([]InteractiveInterface, len(ifaces))
 	for i, iface := range ifaces {
-		limit := len(iface.Methods)
-		if opts.MaxMethodsPerBox > 0 && limit > opts.MaxMethodsPerBox {
-			limit = opts.MaxMethodsPerBox
-		}
-		methods := make([]string, limit)
-		for j := 0; j < limit; j++ {
-			methods[j] = SanitizeSignature(iface.Methods[j].Signature)
-		}
 		interactiveIfaces[i] = InteractiveInterface{
-			ID:         NodeID(iface.PkgName, iface.Name),
-			Name:       iface.PkgName + "." + iface.Name,
-			PkgName:    iface.PkgName,
-			PkgPath:    iface.PkgPath,
-			Methods:    methods,
-			SourceFile: iface.SourceFile,
+			ID:          ifaceIDs[typeKey(iface.PkgPath, iface.Name)],
+			Name:        iface.PkgName + "." + iface.Name,
+			PkgName:     iface.PkgName,
+			PkgPath:     iface.PkgPath,
+			Methods:     interactiveMethods(iface.Methods, opts),
+			MethodCount: len(iface.Methods),
+			SourceFile:  iface.SourceFile,
+			External:    opts.MarkExternal && isExternal(result.ModulePath, iface.PkgPath),
+			Port:        ports[iface.PkgPath+"."+iface.Name] == analyzer.PortKindPort,
+			IsMarker:    iface.IsMarker,
+			Annotation:  strings.TrimSpace(opts.Annotations[typeKey(iface.PkgPath, iface.Name)]),
 		}
 	}
 
@@ -100,11 +148,15 @@ func PrepareInteractiveData(result *analyzer.Result, opts DiagramOptions) Intera
 	interactiveTypes := make([]InteractiveType, len(typs))
 	for i, typ := range typs {
 		interactiveTypes[i] = InteractiveType{
-			ID:         NodeID(typ.PkgName, typ.Name),
+			ID:         typeIDs[typeKey(typ.PkgPath, typ.Name)],
 			Name:       typ.PkgName + "." + typ.Name,
 			PkgName:    typ.PkgName,
 			PkgPath:    typ.PkgPath,
+			Methods:    interactiveMethods(typ.Methods, opts),
 			SourceFile: typ.SourceFile,
+			IsFunc:     typ.IsFunc,
+			External:   opts.MarkExternal && isExternal(result.ModulePath, typ.PkgPath),
+			Annotation: strings.TrimSpace(opts.Annotations[typeKey(typ.PkgPath, typ.Name)]),
 		}
 	}
 
@@ -126,16 +178,55 @@ func PrepareInteractiveData(result *analyzer.Result, opts DiagramOptions) Intera
 	interactiveRels := make([]InteractiveRelation, len(rels))
 	for i, rel := range rels {
 		interactiveRels[i] = InteractiveRelation{
-			TypeID:      NodeID(rel.Type.PkgName, rel.Type.Name),
-			InterfaceID: NodeID(rel.Interface.PkgName, rel.Interface.Name),
+			TypeID:      typeIDs[typeKey(rel.Type.PkgPath, rel.Type.Name)],
+			InterfaceID: ifaceIDs[typeKey(rel.Interface.PkgPath, rel.Interface.Name)],
+			ViaPointer:  rel.ViaPointer,
+			Methods:     rel.SatisfyingMethods,
+		}
+		if score, ok := opts.relationScore(rel); ok {
+			interactiveRels[i].Score = &score
+		}
+	}
+
+	data := InteractiveData{
+		Interfaces:         interactiveIfaces,
+		Types:              interactiveTypes,
+		Relations:          interactiveRels,
+		Direction:          opts.direction(),
+		Palette:            opts.Palette,
+		CollapseSingleImpl: opts.CollapseSingleImpl,
+		Patterns:           resolvePatterns(opts.Patterns, ifaces, typs, ifaceIDs, typeIDs),
+		Metrics:            make(map[string]analyzer.NodeMetrics),
+	}
+	for key, m := range analyzer.Metrics(result) {
+		id := ifaceIDs[key]
+		if !m.IsInterface {
+			id = typeIDs[key]
+		}
+		data.Metrics[id] = m
+	}
+	if opts.ClusterError {
+		for _, iface := range ifaces {
+			if isErrorInterface(&iface) {
+				data.ErrorInterfaceID = ifaceIDs[typeKey(iface.PkgPath, iface.Name)]
+			}
 		}
 	}
+	return data
+}
 
-	return InteractiveData{
-		Interfaces: interactiveIfaces,
-		Types:      interactiveTypes,
-		Relations:  interactiveRels,
+// interactiveMethods returns sanitized signatures, capped at
+// opts.MaxMethodsPerBox.
+func interactiveMethods(sigs []analyzer.MethodSig, opts DiagramOptions) []string {
+	limit := len(sigs)
+	if opts.MaxMethodsPerBox > 0 && limit > opts.MaxMethodsPerBox {
+		limit = opts.MaxMethodsPerBox
+	}
+	methods := make([]string, limit)
+	for j := 0; j < limit; j++ {
+		methods[j] = sigs[j].MermaidSignature()
 	}
+	return methods
 }
 
 // FilterBySelection filters an analyzer.Result to include only the selected
@@ -153,20 +244,35 @@ func FilterBySelection(result *analyzer.Result, selectedTypeIDs, selectedIfaceID
 		selIfaces[id] = true
 	}
 
+	// IDs as PrepareInteractiveData assigns them
+	ifaceIDs, typeIDs, _ := assignNodeIDs(result.Interfaces, result.Types, DefaultDiagramOptions())
+	ifaceID := func(iface *analyzer.InterfaceDef) string {
+		if id, ok := ifaceIDs[typeKey(iface.PkgPath, iface.Name)]; ok {
+			return id
+		}
+		return NodeID(iface.PkgName, iface.Name)
+	}
+	typeID := func(typ *analyzer.TypeDef) string {
+		if id, ok := typeIDs[typeKey(typ.PkgPath, typ.Name)]; ok {
+			return id
+		}
+		return NodeID(typ.PkgName, typ.Name)
+	}
+
 	// Find all relations involving selected items, and collect related IDs
 	relatedTypes := make(map[string]bool)
 	relatedIfaces := make(map[string]bool)
 	var filteredRels []analyzer.Relation
 
 	for _, rel := range result.Relations {
-		typeID := NodeID(rel.Type.PkgName, rel.Type.Name)
-		ifaceID := NodeID(rel.Interface.PkgName, rel.Interface.Name)
+		relTypeID := typeID(rel.Type)
+		relIfaceID := ifaceID(rel.Interface)
 
 		// Include relation if either side is selected
-		if selTypes[typeID] || selIfaces[ifaceID] {
+		if selTypes[relTypeID] || selIfaces[relIfaceID] {
 			filteredRels = append(filteredRels, rel)
-			relatedTypes[typeID] = true
-			relatedIfaces[ifaceID] = true
+			relatedTypes[relTypeID] = true
+			relatedIfaces[relIfaceID] = true
 		}
 	}
 
@@ -190,7 +296,7 @@ func FilterBySelection(result *analyzer.Result, selectedTypeIDs, selectedIfaceID
 	// Filter interfaces
 	var filteredIfaces []analyzer.InterfaceDef
 	for _, iface := range result.Interfaces {
-		if includeIfaces[NodeID(iface.PkgName, iface.Name)] {
+		if includeIfaces[ifaceID(&iface)] {
 			filteredIfaces = append(filteredIfaces, iface)
 		}
 	}
@@ -198,15 +304,17 @@ func FilterBySelection(result *analyzer.Result, selectedTypeIDs, selectedIfaceID
 	// Filter types
 	var filteredTypes []analyzer.TypeDef
 	for _, typ := range result.Types {
-		if includeTypes[NodeID(typ.PkgName, typ.Name)] {
+		if includeTypes[typeID(&typ)] {
 			filteredTypes = append(filteredTypes, typ)
 		}
 	}
 
 	return &analyzer.Result{
-		Interfaces: filteredIfaces,
-		Types:      filteredTypes,
-		Relations:  filteredRels,
-		ModulePath: result.ModulePath,
+		Interfaces:      filteredIfaces,
+		Types:           filteredTypes,
+		Relations:       filteredRels,
+		Embeds:          analyzer.EmbedsBetween(result.Embeds, filteredIfaces, filteredTypes),
+		ModulePath:      result.ModulePath,
+		ReplacedModules: result.ReplacedModules,
 	}
 }
@@ -0,0 +1,152 @@
+package diagram
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+)
+
+// maxFocusSuggestions caps the close matches listed for an unknown focus key.
+const maxFocusSuggestions = 5
+
+// FocusSubgraph returns the part of result within depth implementation hops
+// of the interface or type with the given key ("pkgPath.Name"): a
+// breadth-first expansion over result.Relations from that node, which keeps
+// every relation between the nodes reached, the embeds between them and the
+// aliases of reached nodes. Depth 1 is the node and its direct neighbors; a
+// negative depth counts as 0, the node alone. An unknown key returns an error
+// wrapping ErrUnknownFocus that lists the closest keys.
+func FocusSubgraph(result *analyzer.Result, key string, depth int) (*analyzer.Result, error) {
+	if !hasNode(result, key) {
+		msg := fmt.Sprintf("%s (keys are pkgPath.Name)", key)
+		if matches := focusSuggestions(result, key); len(matches) > 0 {
+			msg += "; close matches: " + strings.Join(matches, ", ")
+		}
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFocus, msg)
+	}
+
+	neighbors := make(map[string][]string)
+	for _, rel := range result.Relations {
+		ifaceK := typeKey(rel.Interface.PkgPath, rel.Interface.Name)
+		typeK := typeKey(rel.Type.PkgPath, rel.Type.Name)
+		neighbors[ifaceK] = append(neighbors[ifaceK], typeK)
+		neighbors[typeK] = append(neighbors[typeK], ifaceK)
+	}
+	kept := map[string]bool{key: true}
+	frontier := []string{key}
+	for hop := 0; hop < depth && len(frontier) > 0; hop++ {
+		var next []string
+		for _, k := range frontier {
+			for _, n := range neighbors[k] {
+				if !kept[n] {
+					kept[n] = true
+					next = append(next, n)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	out := *result
+	out.Interfaces, out.Types, out.Relations = nil, nil, nil
+	for _, iface := range result.Interfaces {
+		if kept[typeKey(iface.PkgPath, iface.Name)] {
+			out.Interfaces = append(out.Interfaces, iface)
+		}
+	}
+	for _, typ := range result.Types {
+		if kept[typeKey(typ.PkgPath, typ.Name)] || (typ.AliasOf != "" && kept[typ.AliasOf]) {
+			out.Types = append(out.Types, typ)
+		}
+	}
+	for _, rel := range result.Relations {
+		if kept[typeKey(rel.Interface.PkgPath, rel.Interface.Name)] && kept[typeKey(rel.Type.PkgPath, rel.Type.Name)] {
+			out.Relations = append(out.Relations, rel)
+		}
+	}
+	out.Embeds = analyzer.EmbedsBetween(result.Embeds, out.Interfaces, out.Types)
+	return &out, nil
+}
+
+// hasNode reports whether result has an interface or type with the given
+// "pkgPath.Name" key.
+func hasNode(result *analyzer.Result, key string) bool {
+	for _, iface := range result.Interfaces {
+		if typeKey(iface.PkgPath, iface.Name) == key {
+			return true
+		}
+	}
+	for _, typ := range result.Types {
+		if typeKey(typ.PkgPath, typ.Name) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// focusSuggestions returns up to maxFocusSuggestions node keys close to an
+// unknown focus key, best first: nodes with the same name, ignoring case
+// (which also catches "pkg.Name" given for "pkgPath.Name"), then names within
+// two edits of it, and keys within three edits of the whole key.
+func focusSuggestions(result *analyzer.Result, key string) []string {
+	name := strings.ToLower(key[strings.LastIndex(key, ".")+1:])
+	lowerKey := strings.ToLower(key)
+	dist := make(map[string]int)
+	consider := func(pkgPath, nodeName string) {
+		k := typeKey(pkgPath, nodeName)
+		d := editDistance(name, strings.ToLower(nodeName))
+		if d > 2 {
+			// Whole-key matches rank after every name match
+			kd := editDistance(lowerKey, strings.ToLower(k))
+			if kd > 3 {
+				return
+			}
+			d = 3 + kd
+		}
+		dist[k] = d
+	}
+	for _, iface := range result.Interfaces {
+		consider(iface.PkgPath, iface.Name)
+	}
+	for _, typ := range result.Types {
+		consider(typ.PkgPath, typ.Name)
+	}
+
+	matches := make([]string, 0, len(dist))
+	for k := range dist {
+		matches = append(matches, k)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if dist[matches[i]] != dist[matches[j]] {
+			return dist[matches[i]] < dist[matches[j]]
+		}
+		return matches[i] < matches[j]
+	})
+	if len(matches) > maxFocusSuggestions {
+		matches = matches[:maxFocusSuggestions]
+	}
+	return matches
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,128 @@
+package diagram
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+)
+
+// Node and edge kinds used in graph JSON.
+const (
+	GraphNodeInterface = "interface"
+	GraphNodeType      = "type"
+
+	GraphEdgeRealization = "realization" // type implements interface
+	GraphEdgeEmbedding   = "embedding"   // interface embeds interface
+	GraphEdgeProduces    = "produces"    // interface method returns type
+)
+
+// Graph is a renderer-agnostic view of an analysis result, suitable for
+// Cytoscape, d3, vis.js or custom layout engines.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is an interface or a concrete type. ID is "pkgPath.Name".
+type GraphNode struct {
+	ID      string   `json:"id"`
+	Kind    string   `json:"kind"`
+	Pkg     string   `json:"pkg"`
+	Label   string   `json:"label"`
+	Methods []string `json:"methods,omitempty"`
+}
+
+// GraphEdge connects two node IDs.
+type GraphEdge struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Kind       string `json:"kind"`
+	ViaPointer bool   `json:"viaPointer,omitempty"` // realization through *T
+}
+
+// BuildGraph converts result into a Graph. Nodes are sorted by ID; edges by
+// kind, then endpoints. Embedding and produces edges are only emitted between
+// nodes present in the result.
+func BuildGraph(result *analyzer.Result) Graph {
+	g := Graph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	present := make(map[string]bool, len(result.Interfaces)+len(result.Types))
+
+	for _, iface := range result.Interfaces {
+		id := typeKey(iface.PkgPath, iface.Name)
+		if present[id] {
+			continue
+		}
+		present[id] = true
+		methods := make([]string, len(iface.Methods))
+		for i, m := range iface.Methods {
+			methods[i] = m.Signature
+		}
+		g.Nodes = append(g.Nodes, GraphNode{
+			ID: id, Kind: GraphNodeInterface, Pkg: iface.PkgPath,
+			Label: iface.PkgName + "." + iface.Name, Methods: methods,
+		})
+	}
+	for _, typ := range result.Types {
+		id := typeKey(typ.PkgPath, typ.Name)
+		if present[id] {
+			continue
+		}
+		present[id] = true
+		methods := make([]string, len(typ.Methods))
+		for i, m := range typ.Methods {
+			methods[i] = m.Signature
+		}
+		g.Nodes = append(g.Nodes, GraphNode{
+			ID: id, Kind: GraphNodeType, Pkg: typ.PkgPath,
+			Label: typ.PkgName + "." + typ.Name, Methods: methods,
+		})
+	}
+
+	seen := make(map[GraphEdge]bool)
+	addEdge := func(e GraphEdge) {
+		if !seen[e] {
+			seen[e] = true
+			g.Edges = append(g.Edges, e)
+		}
+	}
+	for _, rel := range result.Relations {
+		addEdge(GraphEdge{
+			From:       typeKey(rel.Type.PkgPath, rel.Type.Name),
+			To:         typeKey(rel.Interface.PkgPath, rel.Interface.Name),
+			Kind:       GraphEdgeRealization,
+			ViaPointer: rel.ViaPointer,
+		})
+	}
+	for _, iface := range result.Interfaces {
+		from := typeKey(iface.PkgPath, iface.Name)
+		for _, to := range iface.Embeds {
+			if present[to] && to != from {
+				addEdge(GraphEdge{From: from, To: to, Kind: GraphEdgeEmbedding})
+			}
+		}
+		for _, to := range iface.Produces {
+			if present[to] && to != from {
+				addEdge(GraphEdge{From: from, To: to, Kind: GraphEdgeProduces})
+			}
+		}
+	}
+
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	return g
+}
+
+// GenerateGraphJSON returns BuildGraph(result) as indented JSON.
+func GenerateGraphJSON(result *analyzer.Result) ([]byte, error) {
+	return json.MarshalIndent(BuildGraph(result), "", "  ")
+}
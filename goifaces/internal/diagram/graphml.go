@@ -0,0 +1,83 @@
+package diagram
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+)
+
+// graphMLHeader opens the document and declares the node and edge
+// attributes, so that every GenerateGraphML output is a well-formed GraphML
+// file, even without nodes.
+const graphMLHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="http://graphml.graphdrawing.org/xmlns http://graphml.graphdrawing.org/xmlns/1.0/graphml.xsd">
+  <key id="name" for="node" attr.name="name" attr.type="string"/>
+  <key id="pkg" for="node" attr.name="pkg" attr.type="string"/>
+  <key id="kind" for="node" attr.name="kind" attr.type="string"/>
+  <key id="viaPointer" for="edge" attr.name="viaPointer" attr.type="boolean">
+    <default>false</default>
+  </key>
+  <graph id="goifaces" edgedefault="directed">
+`
+
+// GenerateGraphML produces a GraphML document for graph tools such as yEd
+// and Gephi: one node per interface and type with "name" ("pkg.Name"),
+// "pkg" (the package path) and "kind" ("interface" or "type") attributes,
+// and one directed edge per relation from the type to the interface, with a
+// "viaPointer" attribute. Node ids come from NodeID; a name shared by two
+// packages falls back to QualifiedNodeID so ids stay unique. There is no
+// styling, and the order matches GenerateMermaid.
+func GenerateGraphML(result *analyzer.Result) string {
+	ifaces, typs, rels := sortedResult(result)
+
+	ids := make(map[string]string, len(ifaces)+len(typs)) // typeKey -> node id
+	used := make(map[string]bool, len(ifaces)+len(typs))
+	nodeID := func(pkgPath, pkgName, name string) string {
+		key := typeKey(pkgPath, name)
+		if id, ok := ids[key]; ok {
+			return id
+		}
+		id := NodeID(pkgName, name)
+		if used[id] {
+			id = QualifiedNodeID(pkgPath, name)
+		}
+		used[id] = true
+		ids[key] = id
+		return id
+	}
+
+	var b strings.Builder
+	b.WriteString(graphMLHeader)
+	for _, iface := range ifaces {
+		writeGraphMLNode(&b, nodeID(iface.PkgPath, iface.PkgName, iface.Name), iface.PkgName+"."+iface.Name, iface.PkgPath, GraphNodeInterface)
+	}
+	for _, typ := range typs {
+		writeGraphMLNode(&b, nodeID(typ.PkgPath, typ.PkgName, typ.Name), typ.PkgName+"."+typ.Name, typ.PkgPath, GraphNodeType)
+	}
+	for i, rel := range rels {
+		source := nodeID(rel.Type.PkgPath, rel.Type.PkgName, rel.Type.Name)
+		target := nodeID(rel.Interface.PkgPath, rel.Interface.PkgName, rel.Interface.Name)
+		fmt.Fprintf(&b, "    <edge id=\"e%d\" source=\"%s\" target=\"%s\">\n", i, xmlEscape(source), xmlEscape(target))
+		fmt.Fprintf(&b, "      <data key=\"viaPointer\">%t</data>\n", rel.ViaPointer)
+		b.WriteString("    </edge>\n")
+	}
+	b.WriteString("  </graph>\n</graphml>\n")
+	return b.String()
+}
+
+func writeGraphMLNode(b *strings.Builder, id, name, pkg, kind string) {
+	fmt.Fprintf(b, "    <node id=\"%s\">\n", xmlEscape(id))
+	fmt.Fprintf(b, "      <data key=\"name\">%s</data>\n", xmlEscape(name))
+	fmt.Fprintf(b, "      <data key=\"pkg\">%s</data>\n", xmlEscape(pkg))
+	fmt.Fprintf(b, "      <data key=\"kind\">%s</data>\n", kind)
+	b.WriteString("    </node>\n")
+}
+
+// xmlEscape escapes s for use in XML text and double-quoted attributes.
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
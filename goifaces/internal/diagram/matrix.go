@@ -0,0 +1,130 @@
+package diagram
+
+import (
+	"encoding/csv"
+	"strings"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+)
+
+// MatrixFormat is the text format of GenerateMatrix.
+type MatrixFormat string
+
+// Formats accepted by GenerateMatrix.
+const (
+	MatrixCSV      MatrixFormat = "csv"
+	MatrixMarkdown MatrixFormat = "markdown"
+)
+
+// Cell values of GenerateMatrix.
+const (
+	matrixImplements = "✓"
+	matrixViaPointer = "✓*"
+)
+
+// MatrixOptions controls GenerateMatrix.
+type MatrixOptions struct {
+	// IncludeEmpty keeps types and interfaces without any implementation
+	// relation as empty rows and columns; by default they are left out.
+	IncludeEmpty bool
+	// MarkPointer writes "✓*" instead of "✓" where only *T implements the
+	// interface.
+	MarkPointer bool
+}
+
+// GenerateMatrix renders the implementations of result as a table with a row
+// per type and a column per interface, and "✓" where the type implements the
+// interface. Headers are "pkg.Name", or "pkgPath.Name" for names that two
+// packages share, and rows and columns follow GenerateMermaid's order.
+// MatrixMarkdown gives a Markdown table; any other format gives CSV.
+func GenerateMatrix(result *analyzer.Result, format MatrixFormat, opts MatrixOptions) string {
+	ifaces, typs, rels := sortedResult(result)
+
+	cells := make(map[[2]string]string, len(rels))
+	usedIfaces := make(map[string]bool)
+	usedTypes := make(map[string]bool)
+	for _, rel := range rels {
+		typeK := typeKey(rel.Type.PkgPath, rel.Type.Name)
+		ifaceK := typeKey(rel.Interface.PkgPath, rel.Interface.Name)
+		cell := matrixImplements
+		if opts.MarkPointer && rel.ViaPointer {
+			cell = matrixViaPointer
+		}
+		cells[[2]string{typeK, ifaceK}] = cell
+		usedTypes[typeK] = true
+		usedIfaces[ifaceK] = true
+	}
+
+	var colKeys, colNames, rowKeys, rowNames []string
+	for _, iface := range ifaces {
+		key := typeKey(iface.PkgPath, iface.Name)
+		if opts.IncludeEmpty || usedIfaces[key] {
+			colKeys = append(colKeys, key)
+			colNames = append(colNames, iface.PkgName+"."+iface.Name)
+		}
+	}
+	for _, typ := range typs {
+		key := typeKey(typ.PkgPath, typ.Name)
+		if opts.IncludeEmpty || usedTypes[key] {
+			rowKeys = append(rowKeys, key)
+			rowNames = append(rowNames, typ.PkgName+"."+typ.Name)
+		}
+	}
+	colNames = disambiguateMatrixNames(colNames, colKeys)
+	rowNames = disambiguateMatrixNames(rowNames, rowKeys)
+
+	table := make([][]string, 0, len(rowKeys)+1)
+	table = append(table, append([]string{"Type"}, colNames...))
+	for i, rowKey := range rowKeys {
+		row := make([]string, 0, len(colKeys)+1)
+		row = append(row, rowNames[i])
+		for _, colKey := range colKeys {
+			row = append(row, cells[[2]string{rowKey, colKey}])
+		}
+		table = append(table, row)
+	}
+
+	if format == MatrixMarkdown {
+		return markdownTable(table)
+	}
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	_ = w.WriteAll(table) // a strings.Builder does not fail
+	return b.String()
+}
+
+// disambiguateMatrixNames replaces each "pkg.Name" in names that occurs
+// more than once with the matching "pkgPath.Name" from keys.
+func disambiguateMatrixNames(names, keys []string) []string {
+	count := make(map[string]int, len(names))
+	for _, name := range names {
+		count[name]++
+	}
+	for i, name := range names {
+		if count[name] > 1 {
+			names[i] = keys[i]
+		}
+	}
+	return names
+}
+
+// markdownTable renders table, whose first row is the header, as a
+// GitHub-flavored Markdown table with the cell columns centered.
+func markdownTable(table [][]string) string {
+	var b strings.Builder
+	for i, row := range table {
+		b.WriteString("|")
+		for _, cell := range row {
+			b.WriteString(" " + strings.ReplaceAll(cell, "|", `\|`) + " |")
+		}
+		b.WriteString("\n")
+		if i == 0 {
+			b.WriteString("|---|")
+			for range row[1:] {
+				b.WriteString(":-:|")
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
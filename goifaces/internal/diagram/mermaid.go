@@ -12,6 +12,77 @@ import (
 type DiagramOptions struct {
 	MaxMethodsPerBox int  // default 5, 0 means unlimited
 	IncludeInit      bool // include %%{init:}%% directive (for standalone .mmd files)
+	ShowProduces     bool // emit ..> dependency edges from interfaces to the types their methods return
+	QualifiedIDs     bool // build node IDs from full package paths (see QualifiedNodeID)
+	ClusterError     bool // collapse implementers of the builtin error interface into one cluster node
+	MarkExternal     bool // style nodes outside Result.ModulePath as third-party (gray fill, dashed border)
+	MarkPorts        bool // style interfaces implemented only outside their own package (see analyzer.ClassifyPorts)
+	ShowTypeMethods  bool // list a concrete type's methods in its class block, like an interface's
+	GroupByPackage   bool // wrap each package's class blocks in a Mermaid namespace block
+	// CollapseSingleImpl draws an interface with exactly one implementing
+	// type and that type as one "pkg.Iface (impl: pkg.Type)" block, without
+	// the edge between them (see collapseSingleImpl).
+	CollapseSingleImpl bool
+	// Direction is the layout direction (DirectionLR, DirectionTB,
+	// DirectionRL or DirectionBT) of class diagrams, package maps and DOT
+	// output; "" or an unknown value means DirectionLR.
+	Direction string
+	// Annotations maps "pkgPath.Name" to a description (enricher.Annotator);
+	// each annotated node gets a Mermaid note and an interactive tooltip.
+	Annotations map[string]string
+	// Patterns are detected design patterns (enricher.PatternDetector),
+	// listed in the interactive Patterns tab. Nil hides the tab; an empty
+	// slice shows it with nothing detected.
+	Patterns []Pattern
+	// Palette colors the interface, implementation and package map nodes;
+	// nil means DefaultPalette.
+	Palette *Palette
+	// RelationScores maps implements relations (see ScoresByRelation) to an
+	// importance score in [0,1] (enricher.Scorer). Each scored edge gets a
+	// Mermaid linkStyle, thicker and darker the higher the score; nil draws
+	// every edge alike.
+	RelationScores map[string]float64
+
+	ids       map[string]string // "pkgPath.Name" -> collision-free node ID, see withNodeIDs
+	collapsed map[string]string // "pkgPath.Name" -> label of a collapsed interface block
+}
+
+// Styles for third-party nodes under MarkExternal: gray fill and a dashed
+// border, keeping the interface/implementation stroke colors of the palette.
+const (
+	externalInterfaceClassDef = "classDef externalInterfaceStyle fill:#e8e8e8,stroke:%s,color:#333,stroke-width:2px,stroke-dasharray:5 5,font-weight:bold"
+	externalImplClassDef      = "classDef externalImplStyle fill:#e8e8e8,stroke:%s,color:#333,stroke-width:2px,stroke-dasharray:5 5"
+)
+
+// portClassDef styles port interfaces under MarkPorts: the palette's
+// interface fill and text with a thick amber border.
+const portClassDef = "classDef portStyle fill:%s,stroke:#f0a500,color:%s,stroke-width:4px,font-weight:bold"
+
+// isExternal reports whether pkgPath lies outside the module modulePath. With
+// no module path nothing is considered external.
+func isExternal(modulePath, pkgPath string) bool {
+	if modulePath == "" {
+		return false
+	}
+	return pkgPath != modulePath && !strings.HasPrefix(pkgPath, modulePath+"/")
+}
+
+// nodeID returns the node ID for a type or interface under these options:
+// the one assigned by withNodeIDs, if any, else baseNodeID.
+func (o DiagramOptions) nodeID(pkgPath, pkgName, name string) string {
+	if id, ok := o.ids[typeKey(pkgPath, name)]; ok {
+		return id
+	}
+	return o.baseNodeID(pkgPath, pkgName, name)
+}
+
+// baseNodeID returns NodeID, or QualifiedNodeID with QualifiedIDs, which
+// distinct nodes may share.
+func (o DiagramOptions) baseNodeID(pkgPath, pkgName, name string) string {
+	if o.QualifiedIDs {
+		return QualifiedNodeID(pkgPath, name)
+	}
+	return NodeID(pkgName, name)
 }
 
 // DefaultDiagramOptions returns sensible defaults for diagram generation.
@@ -23,39 +94,25 @@ func DefaultDiagramOptions() DiagramOptions {
 func GenerateMermaid(result *analyzer.Result, opts DiagramOptions) string {
 	var b strings.Builder
 
-	// Sort interfaces deterministically by (pkgName, name).
-	ifaces := make([]analyzer.InterfaceDef, len(result.Interfaces))
-	copy(ifaces, result.Interfaces)
-	sort.Slice(ifaces, func(i, j int) bool {
-		if ifaces[i].PkgName != ifaces[j].PkgName {
-			return ifaces[i].PkgName < ifaces[j].PkgName
-		}
-		return ifaces[i].Name < ifaces[j].Name
-	})
+	ifaces, typs, rels := sortedResult(result)
+	opts = opts.withNodeIDs(ifaces, typs)
 
-	// Sort types deterministically by (pkgName, name).
-	typs := make([]analyzer.TypeDef, len(result.Types))
-	copy(typs, result.Types)
-	sort.Slice(typs, func(i, j int) bool {
-		if typs[i].PkgName != typs[j].PkgName {
-			return typs[i].PkgName < typs[j].PkgName
-		}
-		return typs[i].Name < typs[j].Name
-	})
+	// Classify ports before error clustering drops any relations.
+	var ports map[string]analyzer.PortKind
+	if opts.MarkPorts {
+		ports = analyzer.ClassifyPorts(result)
+	}
 
-	// Sort relations deterministically by (type name, interface name).
-	rels := make([]analyzer.Relation, len(result.Relations))
-	copy(rels, result.Relations)
-	sort.Slice(rels, func(i, j int) bool {
-		typeKeyI := rels[i].Type.PkgName + "_" + rels[i].Type.Name
-		typeKeyJ := rels[j].Type.PkgName + "_" + rels[j].Type.Name
-		if typeKeyI != typeKeyJ {
-			return typeKeyI < typeKeyJ
-		}
-		ifaceKeyI := rels[i].Interface.PkgName + "_" + rels[i].Interface.Name
-		ifaceKeyJ := rels[j].Interface.PkgName + "_" + rels[j].Interface.Name
-		return ifaceKeyI < ifaceKeyJ
-	})
+	// Collapse error implementers into a single cluster node.
+	var errCluster *errorCluster
+	if opts.ClusterError {
+		rels, typs, errCluster = clusterErrorRelations(rels, typs, opts)
+	}
+
+	// Merge interfaces with a single implementer into one block.
+	if opts.CollapseSingleImpl {
+		rels, typs, opts.collapsed = collapseSingleImpl(rels, typs, result.Embeds)
+	}
 
 	// Header + style definitions.
 	if opts.IncludeInit {
@@ -64,64 +121,158 @@ func GenerateMermaid(result *analyzer.Result, opts DiagramOptions) string {
 	b.WriteString("classDiagram")
 	if len(ifaces) > 0 || len(typs) > 0 {
 		b.WriteString("\n")
-		b.WriteString("    direction LR\n")
-		b.WriteString("    classDef interfaceStyle fill:#2374ab,stroke:#1a5a8a,color:#fff,stroke-width:2px,font-weight:bold\n")
-		b.WriteString("    classDef implStyle fill:#4a9c6d,stroke:#357a50,color:#fff,stroke-width:2px")
+		b.WriteString("    direction " + opts.direction() + "\n")
+		palette := opts.palette()
+		fmt.Fprintf(&b, "    classDef interfaceStyle fill:%s,stroke:%s,color:%s,stroke-width:2px,font-weight:bold\n",
+			palette.Interface.Fill, palette.Interface.Stroke, palette.Interface.Text)
+		fmt.Fprintf(&b, "    classDef implStyle fill:%s,stroke:%s,color:%s,stroke-width:2px",
+			palette.Impl.Fill, palette.Impl.Stroke, palette.Impl.Text)
+		if opts.MarkExternal {
+			fmt.Fprintf(&b, "\n    "+externalInterfaceClassDef, palette.Interface.Stroke)
+			fmt.Fprintf(&b, "\n    "+externalImplClassDef, palette.Impl.Stroke)
+		}
+		if opts.MarkPorts {
+			fmt.Fprintf(&b, "\n    "+portClassDef, palette.Interface.Fill, palette.Interface.Text)
+		}
+		if errCluster != nil {
+			b.WriteString("\n    classDef clusterStyle fill:#eeeeee,stroke:#999999,color:#333,stroke-width:2px,stroke-dasharray:5 5")
+		}
 	}
 
-	// Interfaces section.
-	for _, iface := range ifaces {
-		b.WriteString("\n")
-		writeInterfaceBlock(&b, iface, opts)
+	if opts.GroupByPackage {
+		writeNamespaces(&b, ifaces, typs, opts)
+	} else {
+		// Interfaces section.
+		for _, iface := range ifaces {
+			b.WriteString("\n")
+			writeInterfaceBlock(&b, iface, opts)
+		}
+
+		// Types section (separated by blank line from interfaces if both exist).
+		if len(ifaces) > 0 && len(typs) > 0 {
+			b.WriteString("\n")
+		}
+		for _, typ := range typs {
+			b.WriteString("\n")
+			writeTypeBlock(&b, typ, opts)
+		}
 	}
 
-	// Types section (separated by blank line from interfaces if both exist).
-	if len(ifaces) > 0 && len(typs) > 0 {
+	// A struct embedding an interface implements it through the embedded
+	// field; draw only the composition edge for it.
+	embeds := diagramEmbeds(result.Embeds, ifaces, typs)
+	rels = dropEmbeddedImplements(rels, embeds)
+
+	// Relations section (separated by blank line from types if both exist).
+	if (len(ifaces) > 0 || len(typs) > 0) && len(rels)+len(embeds) > 0 {
 		b.WriteString("\n")
 	}
-	for _, typ := range typs {
+	for _, rel := range rels {
 		b.WriteString("\n")
-		writeTypeBlock(&b, typ)
+		writeRelation(&b, rel, opts)
 	}
-
-	// Relations section (separated by blank line from types if both exist).
-	if (len(ifaces) > 0 || len(typs) > 0) && len(rels) > 0 {
+	for _, rel := range embeds {
 		b.WriteString("\n")
+		writeEmbed(&b, rel, opts)
 	}
-	for _, rel := range rels {
+	writeInterfaceEmbeds(&b, ifaces, opts)
+	writeAliasLinks(&b, ifaces, typs, opts)
+
+	if errCluster != nil {
 		b.WriteString("\n")
-		writeRelation(&b, rel)
+		writeErrorCluster(&b, errCluster)
+	}
+
+	// Dependency edges from interfaces to the types they produce.
+	if opts.ShowProduces {
+		writeProducesEdges(&b, ifaces, typs, opts)
 	}
 
+	// Notes for annotated nodes.
+	writeNotes(&b, ifaces, typs, opts)
+
 	// Style assignments section.
 	if len(ifaces) > 0 || len(typs) > 0 {
 		b.WriteString("\n")
 		for _, iface := range ifaces {
-			id := NodeID(iface.PkgName, iface.Name)
-			b.WriteString(fmt.Sprintf("\n    cssClass \"%s\" interfaceStyle", id))
+			id := opts.nodeID(iface.PkgPath, iface.PkgName, iface.Name)
+			style := "interfaceStyle"
+			switch {
+			case opts.MarkExternal && isExternal(result.ModulePath, iface.PkgPath):
+				style = "externalInterfaceStyle"
+			case ports[iface.PkgPath+"."+iface.Name] == analyzer.PortKindPort:
+				style = "portStyle"
+			}
+			b.WriteString(fmt.Sprintf("\n    cssClass \"%s\" %s", id, style))
 		}
 		for _, typ := range typs {
-			id := NodeID(typ.PkgName, typ.Name)
-			b.WriteString(fmt.Sprintf("\n    cssClass \"%s\" implStyle", id))
+			id := opts.nodeID(typ.PkgPath, typ.PkgName, typ.Name)
+			style := "implStyle"
+			if opts.MarkExternal && isExternal(result.ModulePath, typ.PkgPath) {
+				style = "externalImplStyle"
+			}
+			b.WriteString(fmt.Sprintf("\n    cssClass \"%s\" %s", id, style))
+		}
+		if errCluster != nil {
+			b.WriteString(fmt.Sprintf("\n    cssClass \"%s\" clusterStyle", ErrorClusterID))
 		}
 	}
 
 	return b.String()
 }
 
+// sortedResult returns copies of result's interfaces and types sorted by
+// (pkgName, name, pkgPath) and its relations sorted by type, then interface,
+// so every generator emits nodes and edges in the same deterministic order.
+func sortedResult(result *analyzer.Result) ([]analyzer.InterfaceDef, []analyzer.TypeDef, []analyzer.Relation) {
+	// Sort interfaces deterministically by (pkgName, name).
+	ifaces := make([]analyzer.InterfaceDef, len(result.Interfaces))
+	copy(ifaces, result.Interfaces)
+	sort.Slice(ifaces, func(i, j int) bool {
+		if ifaces[i].PkgName != ifaces[j].PkgName {
+			return ifaces[i].PkgName < ifaces[j].PkgName
+		}
+		if ifaces[i].Name != ifaces[j].Name {
+			return ifaces[i].Name < ifaces[j].Name
+		}
+		return ifaces[i].PkgPath < ifaces[j].PkgPath
+	})
+
+	// Sort types deterministically by (pkgName, name).
+	typs := make([]analyzer.TypeDef, len(result.Types))
+	copy(typs, result.Types)
+	sort.Slice(typs, func(i, j int) bool {
+		if typs[i].PkgName != typs[j].PkgName {
+			return typs[i].PkgName < typs[j].PkgName
+		}
+		if typs[i].Name != typs[j].Name {
+			return typs[i].Name < typs[j].Name
+		}
+		return typs[i].PkgPath < typs[j].PkgPath
+	})
+
+	// Sort relations deterministically by (type name, interface name).
+	rels := make([]analyzer.Relation, len(result.Relations))
+	copy(rels, result.Relations)
+	sort.Slice(rels, func(i, j int) bool {
+		typeKeyI := rels[i].Type.PkgName + "_" + rels[i].Type.Name
+		typeKeyJ := rels[j].Type.PkgName + "_" + rels[j].Type.Name
+		if typeKeyI != typeKeyJ {
+			return typeKeyI < typeKeyJ
+		}
+		ifaceKeyI := rels[i].Interface.PkgName + "_" + rels[i].Interface.Name
+		ifaceKeyJ := rels[j].Interface.PkgName + "_" + rels[j].Interface.Name
+		return ifaceKeyI < ifaceKeyJ
+	})
+
+	return ifaces, typs, rels
+}
+
 // SanitizeSignature removes characters in method signatures that break Mermaid syntax.
-// Mermaid treats {}, <>, and ~ as special in class diagram labels.
-// Uses only ASCII-safe replacements that work in both mmdc CLI and browser Mermaid.js.
+// The analyzer already stores the result in MethodSig.Sanitized; this wrapper
+// is kept for callers that only have a signature string.
 func SanitizeSignature(sig string) string {
-	// Replace <-chan with chan (drop direction indicator — Mermaid can't handle <).
-	sig = strings.ReplaceAll(sig, "<-chan", "chan")
-	// Replace interface{} with "any" BEFORE stripping braces — bare "interface"
-	// is a reserved keyword in browser Mermaid.js (<<interface>> tag parsing).
-	sig = strings.ReplaceAll(sig, "interface{}", "any")
-	// Strip remaining empty braces — in Go signatures these are empty type literals
-	// like struct{}, map[K]struct{}.
-	sig = strings.ReplaceAll(sig, "{}", "")
-	return sig
+	return analyzer.SanitizeSignature(sig)
 }
 
 // sanitizeID replaces /, ., - with _ in node identifiers.
@@ -135,16 +286,35 @@ func NodeID(pkgName, name string) string {
 	return sanitizeID(pkgName + "_" + name)
 }
 
+// QualifiedNodeID builds a sanitized node ID from the full package path and
+// type/interface name (e.g. "github_com_foo_store_Repository"). Unlike NodeID
+// it never collides for packages sharing a short name and stays stable when
+// unrelated packages are renamed.
+func QualifiedNodeID(pkgPath, name string) string {
+	return sanitizeID(pkgPath + "_" + name)
+}
+
 // typeKey builds a unique key for a type from its package path and name.
 func typeKey(pkgPath, name string) string {
 	return pkgPath + "." + name
 }
 
-// writeInterfaceBlock writes a Mermaid class block for an interface.
+// writeInterfaceBlock writes a Mermaid class block for an interface. Marker
+// interfaces (no methods) carry a <<marker>> stereotype instead of
+// <<interface>>, and interfaces collapsed with their implementer are labeled
+// with both names.
 func writeInterfaceBlock(b *strings.Builder, iface analyzer.InterfaceDef, opts DiagramOptions) {
-	id := NodeID(iface.PkgName, iface.Name)
-	b.WriteString(fmt.Sprintf("    class %s {\n", id))
-	b.WriteString("        <<interface>>\n")
+	id := opts.nodeID(iface.PkgPath, iface.PkgName, iface.Name)
+	if label, ok := opts.collapsed[typeKey(iface.PkgPath, iface.Name)]; ok {
+		b.WriteString(fmt.Sprintf("    class %s[\"%s\"] {\n", id, label))
+	} else {
+		b.WriteString(fmt.Sprintf("    class %s {\n", id))
+	}
+	if iface.IsMarker {
+		b.WriteString("        <<marker>>\n")
+	} else {
+		b.WriteString("        <<interface>>\n")
+	}
 	if iface.SourceFile != "" {
 		b.WriteString("        %% file: " + iface.SourceFile + "\n")
 	}
@@ -153,18 +323,125 @@ func writeInterfaceBlock(b *strings.Builder, iface analyzer.InterfaceDef, opts D
 }
 
 // writeTypeBlock writes a Mermaid class block for a concrete type.
-// Only the type name is shown — methods are omitted because they're
-// already listed in the interface blocks this type implements.
-func writeTypeBlock(b *strings.Builder, typ analyzer.TypeDef) {
-	id := NodeID(typ.PkgName, typ.Name)
+// By default only the type name is shown — methods are omitted because
+// they're already listed in the interface blocks this type implements.
+// With ShowTypeMethods they are listed (and truncated) as for interfaces.
+// Named function types carry a <<func>> stereotype and type aliases an
+// <<alias>> one.
+func writeTypeBlock(b *strings.Builder, typ analyzer.TypeDef, opts DiagramOptions) {
+	id := opts.nodeID(typ.PkgPath, typ.PkgName, typ.Name)
 	b.WriteString(fmt.Sprintf("    class %s {\n", id))
+	switch {
+	case typ.AliasOf != "":
+		b.WriteString("        <<alias>>\n")
+	case typ.IsFunc:
+		b.WriteString("        <<func>>\n")
+	}
 	if typ.SourceFile != "" {
 		b.WriteString("        %% file: " + typ.SourceFile + "\n")
 	}
+	if opts.ShowTypeMethods {
+		writeMethodLines(b, typ.Methods, opts)
+	}
 	b.WriteString("    }")
 }
 
-// writeMethodLines writes method lines with optional truncation.
+// writeNotes writes a `note for` line per interface and type with a
+// non-empty annotation, preceded by a blank line. Nodes without one are
+// left alone.
+func writeNotes(b *strings.Builder, ifaces []analyzer.InterfaceDef, typs []analyzer.TypeDef, opts DiagramOptions) {
+	if len(opts.Annotations) == 0 {
+		return
+	}
+	var lines []string
+	addNote := func(pkgPath, pkgName, name string) {
+		if note := sanitizeNote(opts.Annotations[typeKey(pkgPath, name)]); note != "" {
+			lines = append(lines, fmt.Sprintf("    note for %s \"%s\"", opts.nodeID(pkgPath, pkgName, name), note))
+		}
+	}
+	for _, iface := range ifaces {
+		addNote(iface.PkgPath, iface.PkgName, iface.Name)
+	}
+	for _, typ := range typs {
+		addNote(typ.PkgPath, typ.PkgName, typ.Name)
+	}
+	if len(lines) == 0 {
+		return
+	}
+	b.WriteString("\n")
+	for _, line := range lines {
+		b.WriteString("\n" + line)
+	}
+}
+
+// sanitizeNote makes an annotation safe inside a double-quoted Mermaid
+// note: double quotes become single quotes, and newlines and other runs of
+// whitespace collapse to one space, since a note must stay on one line.
+func sanitizeNote(s string) string {
+	s = strings.ReplaceAll(s, `"`, "'")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// writeNamespaces writes the class blocks grouped into one namespace block
+// per package, ordered by package name, each holding the package's
+// interfaces and then its types. Node IDs keep their package prefix, since
+// Mermaid class names are global across namespaces. The namespace is named
+// after the package, or after its full path when several packages share a
+// name. Relations and style assignments stay outside the blocks.
+func writeNamespaces(b *strings.Builder, ifaces []analyzer.InterfaceDef, typs []analyzer.TypeDef, opts DiagramOptions) {
+	type pkgBlock struct {
+		name, path string
+		body       strings.Builder
+	}
+	blocks := make(map[string]*pkgBlock)
+	blockFor := func(pkgPath, pkgName string) *strings.Builder {
+		pb, ok := blocks[pkgPath]
+		if !ok {
+			pb = &pkgBlock{name: pkgName, path: pkgPath}
+			blocks[pkgPath] = pb
+		}
+		return &pb.body
+	}
+	for _, iface := range ifaces {
+		body := blockFor(iface.PkgPath, iface.PkgName)
+		body.WriteString("\n")
+		writeInterfaceBlock(body, iface, opts)
+	}
+	for _, typ := range typs {
+		body := blockFor(typ.PkgPath, typ.PkgName)
+		body.WriteString("\n")
+		writeTypeBlock(body, typ, opts)
+	}
+
+	sorted := make([]*pkgBlock, 0, len(blocks))
+	pathsPerName := make(map[string]int)
+	for _, pb := range blocks {
+		sorted = append(sorted, pb)
+		pathsPerName[pb.name]++
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].name != sorted[j].name {
+			return sorted[i].name < sorted[j].name
+		}
+		return sorted[i].path < sorted[j].path
+	})
+
+	for i, pb := range sorted {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		name := pb.name
+		if pathsPerName[name] > 1 {
+			name = pb.path
+		}
+		fmt.Fprintf(b, "\n    namespace %s {", sanitizeID(name))
+		b.WriteString(strings.ReplaceAll(pb.body.String(), "\n", "\n    "))
+		b.WriteString("\n    }")
+	}
+}
+
+// writeMethodLines writes method lines with optional truncation. A truncated
+// box ends with "... (N total)", N being the full method count.
 func writeMethodLines(b *strings.Builder, methods []MethodSig, opts DiagramOptions) {
 	limit := len(methods)
 	truncated := false
@@ -174,20 +451,143 @@ func writeMethodLines(b *strings.Builder, methods []MethodSig, opts DiagramOptio
 	}
 
 	for i := 0; i < limit; i++ {
-		b.WriteString(fmt.Sprintf("        +%s\n", SanitizeSignature(methods[i].Signature)))
+		b.WriteString(fmt.Sprintf("        +%s\n", methods[i].MermaidSignature()))
 	}
 	if truncated {
-		b.WriteString("        ...\n")
+		fmt.Fprintf(b, "        ... (%d total)\n", len(methods))
 	}
 }
 
-// writeRelation writes a single Mermaid relation line.
-func writeRelation(b *strings.Builder, rel analyzer.Relation) {
-	typeID := NodeID(rel.Type.PkgName, rel.Type.Name)
-	ifaceID := NodeID(rel.Interface.PkgName, rel.Interface.Name)
+// pointerLabel marks relations where only the pointer type implements the
+// interface.
+const pointerLabel = "*"
+
+// writeRelation writes a single Mermaid relation line. Implementations that
+// only *T satisfies are labeled "*", and scored ones with their score.
+func writeRelation(b *strings.Builder, rel analyzer.Relation, opts DiagramOptions) {
+	typeID := opts.nodeID(rel.Type.PkgPath, rel.Type.PkgName, rel.Type.Name)
+	ifaceID := opts.nodeID(rel.Interface.PkgPath, rel.Interface.PkgName, rel.Interface.Name)
 	line := fmt.Sprintf("    %s --|> %s", typeID, ifaceID)
+	if label := opts.relationLabel(rel); label != "" {
+		line += " : " + label
+	}
 	b.WriteString(line)
 }
 
+// writeEmbed writes a composition line from a struct to the interface or type
+// it embeds.
+func writeEmbed(b *strings.Builder, rel analyzer.Relation, opts DiagramOptions) {
+	outerID := opts.nodeID(rel.Type.PkgPath, rel.Type.PkgName, rel.Type.Name)
+	var targetID string
+	if rel.Interface != nil {
+		targetID = opts.nodeID(rel.Interface.PkgPath, rel.Interface.PkgName, rel.Interface.Name)
+	} else {
+		targetID = opts.nodeID(rel.Embedded.PkgPath, rel.Embedded.PkgName, rel.Embedded.Name)
+	}
+	b.WriteString(fmt.Sprintf("    %s *-- %s", outerID, targetID))
+}
+
+// writeInterfaceEmbeds writes a "..|> : embeds" line for every interface
+// that directly embeds another interface in the diagram. Only direct embeds
+// are drawn, so a chain A embeds B embeds C stays a chain.
+func writeInterfaceEmbeds(b *strings.Builder, ifaces []analyzer.InterfaceDef, opts DiagramOptions) {
+	nodeIDs := make(map[string]string, len(ifaces))
+	for _, iface := range ifaces {
+		nodeIDs[typeKey(iface.PkgPath, iface.Name)] = opts.nodeID(iface.PkgPath, iface.PkgName, iface.Name)
+	}
+	for _, iface := range ifaces {
+		ifaceID := nodeIDs[typeKey(iface.PkgPath, iface.Name)]
+		for _, key := range iface.Embeds {
+			targetID, ok := nodeIDs[key]
+			if !ok || targetID == ifaceID {
+				continue
+			}
+			fmt.Fprintf(b, "\n    %s ..|> %s : embeds", ifaceID, targetID)
+		}
+	}
+}
+
+// diagramEmbeds returns the embeds between nodes drawn in the diagram, sorted
+// like relations: by struct, then embedded target.
+func diagramEmbeds(embeds []analyzer.Relation, ifaces []analyzer.InterfaceDef, typs []analyzer.TypeDef) []analyzer.Relation {
+	kept := analyzer.EmbedsBetween(embeds, ifaces, typs)
+	sort.SliceStable(kept, func(i, j int) bool {
+		outerI := kept[i].Type.PkgName + "_" + kept[i].Type.Name
+		outerJ := kept[j].Type.PkgName + "_" + kept[j].Type.Name
+		if outerI != outerJ {
+			return outerI < outerJ
+		}
+		return kept[i].EmbedTargetKey() < kept[j].EmbedTargetKey()
+	})
+	return kept
+}
+
+// dropEmbeddedImplements removes the implements relations that an interface
+// embed already accounts for, so the pair is not drawn twice.
+func dropEmbeddedImplements(rels, embeds []analyzer.Relation) []analyzer.Relation {
+	if len(embeds) == 0 {
+		return rels
+	}
+	embedded := make(map[string]bool, len(embeds))
+	for _, e := range embeds {
+		if e.Interface != nil {
+			embedded[typeKey(e.Type.PkgPath, e.Type.Name)+"|"+typeKey(e.Interface.PkgPath, e.Interface.Name)] = true
+		}
+	}
+	out := rels[:0:0]
+	for _, rel := range rels {
+		if !embedded[typeKey(rel.Type.PkgPath, rel.Type.Name)+"|"+typeKey(rel.Interface.PkgPath, rel.Interface.Name)] {
+			out = append(out, rel)
+		}
+	}
+	return out
+}
+
+// writeProducesEdges writes a "..>" dependency line for every interface whose
+// methods return a type or interface present in the diagram.
+func writeProducesEdges(b *strings.Builder, ifaces []analyzer.InterfaceDef, typs []analyzer.TypeDef, opts DiagramOptions) {
+	nodeIDs := diagramNodeIDs(ifaces, typs, opts)
+	for _, iface := range ifaces {
+		ifaceID := opts.nodeID(iface.PkgPath, iface.PkgName, iface.Name)
+		for _, key := range iface.Produces {
+			targetID, ok := nodeIDs[key]
+			if !ok || targetID == ifaceID {
+				continue
+			}
+			b.WriteString(fmt.Sprintf("\n    %s ..> %s : produces", ifaceID, targetID))
+		}
+	}
+}
+
+// writeAliasLinks draws a dashed "alias" link from each type alias to the
+// interface or type it stands for, when that target is in the diagram.
+func writeAliasLinks(b *strings.Builder, ifaces []analyzer.InterfaceDef, typs []analyzer.TypeDef, opts DiagramOptions) {
+	var nodeIDs map[string]string
+	for _, typ := range typs {
+		if typ.AliasOf == "" {
+			continue
+		}
+		if nodeIDs == nil {
+			nodeIDs = diagramNodeIDs(ifaces, typs, opts)
+		}
+		if targetID, ok := nodeIDs[typ.AliasOf]; ok {
+			b.WriteString(fmt.Sprintf("\n    %s .. %s : alias", opts.nodeID(typ.PkgPath, typ.PkgName, typ.Name), targetID))
+		}
+	}
+}
+
+// diagramNodeIDs maps the "pkgPath.Name" key of every node in the diagram to
+// its node ID.
+func diagramNodeIDs(ifaces []analyzer.InterfaceDef, typs []analyzer.TypeDef, opts DiagramOptions) map[string]string {
+	nodeIDs := make(map[string]string, len(ifaces)+len(typs))
+	for _, iface := range ifaces {
+		nodeIDs[typeKey(iface.PkgPath, iface.Name)] = opts.nodeID(iface.PkgPath, iface.PkgName, iface.Name)
+	}
+	for _, typ := range typs {
+		nodeIDs[typeKey(typ.PkgPath, typ.Name)] = opts.nodeID(typ.PkgPath, typ.PkgName, typ.Name)
+	}
+	return nodeIDs
+}
+
 // MethodSig is a local alias to avoid repeating the package prefix.
 type MethodSig = analyzer.MethodSig
@@ -0,0 +1,137 @@
+package diagram
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+)
+
+// IDCollision is a group of distinct nodes that share a base node ID
+// (NodeID, or QualifiedNodeID with QualifiedIDs): two packages named config,
+// each with a Loader, both map to config_Loader. The generators give each
+// node its own ID (see assignNodeIDs).
+type IDCollision struct {
+	ID    string   // the shared base ID
+	Nodes []string // "pkgPath.Name" of every node sharing it, sorted
+}
+
+// NodeIDCollisions returns the groups of nodes in result whose base IDs
+// collide under opts, sorted by ID, so callers can warn about them.
+func NodeIDCollisions(result *analyzer.Result, opts DiagramOptions) []IDCollision {
+	_, _, collisions := assignNodeIDs(result.Interfaces, result.Types, opts)
+	return collisions
+}
+
+// NodeIDs returns the node ID of every interface and type in result under
+// opts, keyed by "pkgPath.Name": the IDs the generators and
+// PrepareInteractiveData use, collisions resolved.
+func NodeIDs(result *analyzer.Result, opts DiagramOptions) map[string]string {
+	ifaceIDs, typeIDs, _ := assignNodeIDs(result.Interfaces, result.Types, opts)
+	for key, id := range typeIDs {
+		ifaceIDs[key] = id
+	}
+	return ifaceIDs
+}
+
+// withNodeIDs returns a copy of o whose nodeID resolves the given interfaces
+// and types to their assignNodeIDs IDs, so every line of a generated
+// diagram (class blocks, relations, cssClass, notes) uses the same,
+// collision-free ID.
+func (o DiagramOptions) withNodeIDs(ifaces []analyzer.InterfaceDef, typs []analyzer.TypeDef) DiagramOptions {
+	ifaceIDs, typeIDs, _ := assignNodeIDs(ifaces, typs, o)
+	o.ids = make(map[string]string, len(ifaceIDs)+len(typeIDs))
+	for key, id := range ifaceIDs {
+		o.ids[key] = id
+	}
+	for key, id := range typeIDs {
+		o.ids[key] = id
+	}
+	return o
+}
+
+// assignNodeIDs computes a unique node ID for every interface and type, keyed
+// by pkgPath.Name. IDs normally come from baseNodeID; when an interface and a
+// type share one they are disambiguated with "i_" / "t_" prefixes. Nodes of
+// one kind that still share an ID all get a suffix hashed from their package
+// path (config_Loader_3f2a9c), so each ID depends only on the node and the
+// nodes it collides with, never on input order. Nodes sharing a base ID are
+// reported in collisions.
+func assignNodeIDs(ifaces []analyzer.InterfaceDef, typs []analyzer.TypeDef, opts DiagramOptions) (ifaceIDs, typeIDs map[string]string, collisions []IDCollision) {
+	type node struct{ key, pkgPath, base string }
+	var ifaceNodes, typeNodes []node
+	seen := make(map[string]bool, len(ifaces)+len(typs))
+	byBase := make(map[string][]string) // base ID -> keys
+	add := func(nodes []node, pkgPath, pkgName, name string) []node {
+		key := typeKey(pkgPath, name)
+		if seen[key] {
+			return nodes
+		}
+		seen[key] = true
+		base := opts.baseNodeID(pkgPath, pkgName, name)
+		byBase[base] = append(byBase[base], key)
+		return append(nodes, node{key: key, pkgPath: pkgPath, base: base})
+	}
+	for _, iface := range ifaces {
+		ifaceNodes = add(ifaceNodes, iface.PkgPath, iface.PkgName, iface.Name)
+	}
+	ifaceBase := make(map[string]bool, len(ifaceNodes))
+	for _, n := range ifaceNodes {
+		ifaceBase[n.base] = true
+	}
+	for _, typ := range typs {
+		typeNodes = add(typeNodes, typ.PkgPath, typ.PkgName, typ.Name)
+	}
+	typeBase := make(map[string]bool, len(typeNodes))
+	for _, n := range typeNodes {
+		typeBase[n.base] = true
+	}
+
+	used := make(map[string]bool, len(ifaceNodes)+len(typeNodes))
+	assign := func(nodes []node, prefix string, other map[string]bool) map[string]string {
+		candidates := make([]string, len(nodes))
+		count := make(map[string]int, len(nodes))
+		for i, n := range nodes {
+			candidates[i] = n.base
+			if other[n.base] {
+				candidates[i] = prefix + n.base
+			}
+			count[candidates[i]]++
+		}
+		ids := make(map[string]string, len(nodes))
+		for i, n := range nodes {
+			id := candidates[i]
+			if count[id] > 1 {
+				id += "_" + pathHash(n.pkgPath)
+			}
+			// A hashed ID can in theory still clash with another node's
+			candidate := id
+			for k := 2; used[candidate]; k++ {
+				candidate = fmt.Sprintf("%s_%d", id, k)
+			}
+			used[candidate] = true
+			ids[n.key] = candidate
+		}
+		return ids
+	}
+	ifaceIDs = assign(ifaceNodes, "i_", typeBase)
+	typeIDs = assign(typeNodes, "t_", ifaceBase)
+
+	for base, keys := range byBase {
+		if len(keys) > 1 {
+			sorted := append([]string(nil), keys...)
+			sort.Strings(sorted)
+			collisions = append(collisions, IDCollision{ID: base, Nodes: sorted})
+		}
+	}
+	sort.Slice(collisions, func(i, j int) bool { return collisions[i].ID < collisions[j].ID })
+	return ifaceIDs, typeIDs, collisions
+}
+
+// pathHash returns six hex digits of the SHA-256 of a package path.
+func pathHash(pkgPath string) string {
+	h := sha256.Sum256([]byte(pkgPath))
+	return hex.EncodeToString(h[:3])
+}
@@ -0,0 +1,167 @@
+package diagram
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// NodeColors are the fill, border and text colors of one kind of node.
+type NodeColors struct {
+	Fill   string `json:"fill"`
+	Stroke string `json:"stroke"`
+	Text   string `json:"text"`
+}
+
+// Palette holds the node colors shared by the generated diagrams and the
+// interactive page: interfaces, implementing types, and the package map,
+// whose packages cycle through Packages.
+type Palette struct {
+	Interface NodeColors   `json:"interface"`
+	Impl      NodeColors   `json:"impl"`
+	Packages  []NodeColors `json:"packages"`
+}
+
+// DefaultTheme is the -theme used when none is given.
+const DefaultTheme = "default"
+
+// pastelPackages are the muted package map colors of the default theme.
+var pastelPackages = []NodeColors{
+	{"#e8f4fd", "#b8d4e8", "#333333"}, // light blue
+	{"#e8f5e9", "#b8d8ba", "#333333"}, // light green
+	{"#fff3e0", "#e8c9a0", "#333333"}, // light orange
+	{"#f3e5f5", "#d1b3d8", "#333333"}, // light purple
+	{"#fce4ec", "#e8b0bf", "#333333"}, // light pink
+	{"#e0f2f1", "#b0d4d1", "#333333"}, // light teal
+	{"#fff9c4", "#e8dea0", "#333333"}, // light yellow
+	{"#e8eaf6", "#b8bce8", "#333333"}, // light indigo
+	{"#efebe9", "#c8b8ad", "#333333"}, // light brown
+	{"#f1f8e9", "#c4dba0", "#333333"}, // light lime
+}
+
+// themes are the built-in palettes selectable by name with -theme.
+var themes = map[string]func() Palette{
+	DefaultTheme: DefaultPalette,
+	// Blue and orange stay apart under the common color vision deficiencies.
+	"colorblind": func() Palette {
+		return Palette{
+			Interface: NodeColors{"#0072b2", "#004f7c", "#ffffff"},
+			Impl:      NodeColors{"#e69f00", "#a87400", "#000000"},
+			Packages:  clonePackages(pastelPackages),
+		}
+	},
+	// For printing and monochrome displays.
+	"grayscale": func() Palette {
+		return Palette{
+			Interface: NodeColors{"#404040", "#1a1a1a", "#ffffff"},
+			Impl:      NodeColors{"#d9d9d9", "#808080", "#000000"},
+			Packages: []NodeColors{
+				{"#f5f5f5", "#bdbdbd", "#333333"},
+				{"#e0e0e0", "#9e9e9e", "#333333"},
+			},
+		}
+	},
+}
+
+// DefaultPalette returns the default theme: blue interfaces, green
+// implementations and pastel packages.
+func DefaultPalette() Palette {
+	return Palette{
+		Interface: NodeColors{"#2374ab", "#1a5a8a", "#ffffff"},
+		Impl:      NodeColors{"#4a9c6d", "#357a50", "#ffffff"},
+		Packages:  clonePackages(pastelPackages),
+	}
+}
+
+func clonePackages(colors []NodeColors) []NodeColors {
+	return append([]NodeColors(nil), colors...)
+}
+
+// ThemeNames returns the names of the built-in themes, sorted.
+func ThemeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadTheme returns the palette selected by a -theme value: a built-in theme
+// name ("" is DefaultTheme), or the path of a JSON palette file. Colors the
+// file leaves out keep their default; a "packages" list replaces the whole
+// default list. An unknown name wraps ErrUnknownTheme; a malformed file or a
+// color that is not #rgb or #rrggbb wraps ErrInvalidPalette.
+func LoadTheme(theme string) (Palette, error) {
+	if theme == "" {
+		theme = DefaultTheme
+	}
+	if newPalette, ok := themes[theme]; ok {
+		return newPalette(), nil
+	}
+	if !strings.HasSuffix(strings.ToLower(theme), ".json") {
+		return Palette{}, fmt.Errorf("%w %q: want %s, or a .json palette file", ErrUnknownTheme, theme, strings.Join(ThemeNames(), ", "))
+	}
+
+	data, err := os.ReadFile(theme)
+	if err != nil {
+		return Palette{}, fmt.Errorf("reading palette file: %w", err)
+	}
+	p := DefaultPalette()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return Palette{}, fmt.Errorf("%w: %s: %w", ErrInvalidPalette, theme, err)
+	}
+	if err := p.Validate(); err != nil {
+		return Palette{}, fmt.Errorf("%s: %w", theme, err)
+	}
+	return p, nil
+}
+
+// hexColorPattern matches the color values a Palette accepts. Keeping to
+// hex colors means a value can never break out of a Mermaid classDef, a DOT
+// attribute or the page's CSS and JS.
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Validate reports the first color of p that is not #rgb or #rrggbb, naming
+// it by its JSON path ("impl.fill", "packages[2].stroke"), and an empty
+// Packages list. The error wraps ErrInvalidPalette.
+func (p Palette) Validate() error {
+	check := func(name string, c NodeColors) error {
+		for _, field := range []struct{ key, value string }{{"fill", c.Fill}, {"stroke", c.Stroke}, {"text", c.Text}} {
+			if !hexColorPattern.MatchString(field.value) {
+				return fmt.Errorf("%w: %s.%s: %q is not a #rgb or #rrggbb color", ErrInvalidPalette, name, field.key, field.value)
+			}
+		}
+		return nil
+	}
+	if err := check("interface", p.Interface); err != nil {
+		return err
+	}
+	if err := check("impl", p.Impl); err != nil {
+		return err
+	}
+	if len(p.Packages) == 0 {
+		return fmt.Errorf("%w: packages: need at least one color", ErrInvalidPalette)
+	}
+	for i, c := range p.Packages {
+		if err := check(fmt.Sprintf("packages[%d]", i), c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// palette returns the palette to render with: Palette, or DefaultPalette
+// when it is nil.
+func (o DiagramOptions) palette() Palette {
+	if o.Palette != nil {
+		return *o.Palette
+	}
+	return DefaultPalette()
+}
@@ -0,0 +1,64 @@
+package diagram
+
+import "github.com/olehluchkiv/goifaces/internal/analyzer"
+
+// Pattern is a design pattern found by an enricher.PatternDetector, as passed
+// in DiagramOptions.Patterns.
+type Pattern struct {
+	Name         string
+	Description  string
+	Participants []string // "pkgPath.Name" keys of the interfaces and types involved
+}
+
+// DetectedPattern is a Pattern as listed in the interactive Patterns tab,
+// with its participants resolved to diagram nodes.
+type DetectedPattern struct {
+	Name         string               `json:"name"`
+	Description  string               `json:"description,omitempty"`
+	Participants []PatternParticipant `json:"participants"`
+}
+
+// PatternParticipant is a node taking part in a DetectedPattern.
+type PatternParticipant struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"` // pkgName.Name, as in the sidebar
+	Interface bool   `json:"interface"`
+}
+
+// resolvePatterns maps each pattern's participant keys to the nodes of ifaces
+// and typs, whose IDs are in ifaceIDs and typeIDs. Keys that are not in the
+// diagram (filtered out, or pruned after detection) are dropped, and so are
+// patterns left with no participants. It returns nil only for nil patterns,
+// so the UI can tell "detection did not run" from "nothing detected".
+func resolvePatterns(patterns []Pattern, ifaces []analyzer.InterfaceDef, typs []analyzer.TypeDef, ifaceIDs, typeIDs map[string]string) []DetectedPattern {
+	if patterns == nil {
+		return nil
+	}
+	nodes := make(map[string]PatternParticipant, len(ifaces)+len(typs))
+	for _, iface := range ifaces {
+		key := typeKey(iface.PkgPath, iface.Name)
+		nodes[key] = PatternParticipant{ID: ifaceIDs[key], Name: iface.PkgName + "." + iface.Name, Interface: true}
+	}
+	for _, typ := range typs {
+		key := typeKey(typ.PkgPath, typ.Name)
+		nodes[key] = PatternParticipant{ID: typeIDs[key], Name: typ.PkgName + "." + typ.Name}
+	}
+
+	resolved := make([]DetectedPattern, 0, len(patterns))
+	for _, p := range patterns {
+		dp := DetectedPattern{Name: p.Name, Description: p.Description}
+		seen := make(map[string]bool, len(p.Participants))
+		for _, key := range p.Participants {
+			node, ok := nodes[key]
+			if !ok || seen[key] {
+				continue
+			}
+			seen[key] = true
+			dp.Participants = append(dp.Participants, node)
+		}
+		if len(dp.Participants) > 0 {
+			resolved = append(resolved, dp)
+		}
+	}
+	return resolved
+}
@@ -0,0 +1,107 @@
+package diagram
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+)
+
+// GeneratePlantUML produces a PlantUML class diagram from analysis results:
+// interfaces and concrete types are declared with their "pkg.Name" as the
+// display name and NodeID (or QualifiedNodeID) as the alias, and each
+// implementation is a "..|>" realization arrow from the type to the
+// interface, labeled "*" when only *T implements it. Method lists follow
+// MaxMethodsPerBox and ShowTypeMethods, and Direction TB or BT lays it out
+// top to bottom; the order matches GenerateMermaid.
+// An empty result yields a bare "@startuml"/"@enduml" pair.
+func GeneratePlantUML(result *analyzer.Result, opts DiagramOptions) string {
+	ifaces, typs, rels := sortedResult(result)
+	if len(ifaces) == 0 && len(typs) == 0 {
+		return "@startuml\n@enduml\n"
+	}
+	opts = opts.withNodeIDs(ifaces, typs)
+
+	var b strings.Builder
+	b.WriteString("@startuml\n")
+	// PlantUML only knows two directions
+	if d := opts.direction(); d == DirectionTB || d == DirectionBT {
+		b.WriteString("top to bottom direction\n")
+	} else {
+		b.WriteString("left to right direction\n")
+	}
+	b.WriteString("hide empty members\n")
+	palette := opts.palette()
+	fmt.Fprintf(&b, "skinparam interface {\n  BackgroundColor %s\n  BorderColor %s\n  FontColor %s\n}\n",
+		palette.Interface.Fill, palette.Interface.Stroke, palette.Interface.Text)
+	fmt.Fprintf(&b, "skinparam class {\n  BackgroundColor %s\n  BorderColor %s\n  FontColor %s\n}\n",
+		palette.Impl.Fill, palette.Impl.Stroke, palette.Impl.Text)
+
+	for _, iface := range ifaces {
+		id := opts.nodeID(iface.PkgPath, iface.PkgName, iface.Name)
+		fmt.Fprintf(&b, "\ninterface \"%s.%s\" as %s", iface.PkgName, iface.Name, id)
+		if iface.IsMarker {
+			b.WriteString(" <<marker>>")
+		}
+		writePlantUMLMembers(&b, iface.Methods, opts)
+	}
+	for _, typ := range typs {
+		id := opts.nodeID(typ.PkgPath, typ.PkgName, typ.Name)
+		fmt.Fprintf(&b, "\nclass \"%s.%s\" as %s", typ.PkgName, typ.Name, id)
+		if typ.IsFunc {
+			b.WriteString(" <<func>>")
+		}
+		if opts.ShowTypeMethods {
+			writePlantUMLMembers(&b, typ.Methods, opts)
+		} else {
+			b.WriteString("\n")
+		}
+	}
+
+	if len(rels) > 0 {
+		b.WriteString("\n")
+	}
+	for _, rel := range rels {
+		typeID := opts.nodeID(rel.Type.PkgPath, rel.Type.PkgName, rel.Type.Name)
+		ifaceID := opts.nodeID(rel.Interface.PkgPath, rel.Interface.PkgName, rel.Interface.Name)
+		fmt.Fprintf(&b, "%s ..|> %s", typeID, ifaceID)
+		if rel.ViaPointer {
+			b.WriteString(" : " + pointerLabel)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("@enduml\n")
+	return b.String()
+}
+
+// writePlantUMLMembers writes a member block, truncated like Mermaid boxes;
+// the truncation marker is a PlantUML separator line. Without methods the
+// declaration is left bodyless.
+func writePlantUMLMembers(b *strings.Builder, methods []MethodSig, opts DiagramOptions) {
+	if len(methods) == 0 {
+		b.WriteString("\n")
+		return
+	}
+	limit := len(methods)
+	if opts.MaxMethodsPerBox > 0 && limit > opts.MaxMethodsPerBox {
+		limit = opts.MaxMethodsPerBox
+	}
+	b.WriteString(" {\n")
+	for _, m := range methods[:limit] {
+		fmt.Fprintf(b, "  +%s\n", plantUMLSignature(m.Signature))
+	}
+	if limit < len(methods) {
+		fmt.Fprintf(b, "  .. %d more ..\n", len(methods)-limit)
+	}
+	b.WriteString("}\n")
+}
+
+// plantUMLSignature makes a method signature safe for a PlantUML member
+// line. It applies SanitizeSignature, then turns the braces of remaining
+// type literals (struct{ X int }) into parentheses, since PlantUML reads
+// "{...}" as a member modifier or the end of the class body.
+func plantUMLSignature(sig string) string {
+	sig = SanitizeSignature(sig)
+	return strings.NewReplacer("{", "(", "}", ")").Replace(sig)
+}
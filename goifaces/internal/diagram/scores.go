@@ -0,0 +1,62 @@
+package diagram
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+)
+
+// ScoresByRelation keys relation scores (enricher.Scorer, indexed into
+// result.Relations) by relation, for DiagramOptions.RelationScores, so that
+// they survive the sorting and splitting of relations by the generators.
+// Indices outside result.Relations are ignored.
+func ScoresByRelation(result *analyzer.Result, scores map[int]float64) map[string]float64 {
+	if len(scores) == 0 {
+		return nil
+	}
+	out := make(map[string]float64, len(scores))
+	for i, score := range scores {
+		if i >= 0 && i < len(result.Relations) {
+			out[relationKey(result.Relations[i])] = score
+		}
+	}
+	return out
+}
+
+// relationKey identifies an implements relation in
+// DiagramOptions.RelationScores: "typePkgPath.Type->ifacePkgPath.Iface".
+func relationKey(rel analyzer.Relation) string {
+	return typeKey(rel.Type.PkgPath, rel.Type.Name) + "->" + typeKey(rel.Interface.PkgPath, rel.Interface.Name)
+}
+
+// relationScore returns the score of rel under opts, if it has one.
+func (o DiagramOptions) relationScore(rel analyzer.Relation) (float64, bool) {
+	if len(o.RelationScores) == 0 {
+		return 0, false
+	}
+	score, ok := o.RelationScores[relationKey(rel)]
+	return score, ok
+}
+
+// relationLabel returns the Mermaid label of rel under opts: "*" for
+// relations only *T satisfies, followed by the score with two decimals when
+// rel has one. classDiagram has no per-edge styling (linkStyle is
+// flowchart-only), and its other arrows already mean embedding, so the score
+// is shown as text.
+func (o DiagramOptions) relationLabel(rel analyzer.Relation) string {
+	var parts []string
+	if rel.ViaPointer {
+		parts = append(parts, pointerLabel)
+	}
+	if score, ok := o.relationScore(rel); ok {
+		parts = append(parts, strconv.FormatFloat(score, 'f', 2, 64))
+	}
+	return strings.Join(parts, " ")
+}
+
+// dotPenWidth returns the DOT penwidth of an edge scored score, clamped to
+// [0,1]: from 1 for 0 up to 3 for 1.
+func dotPenWidth(score float64) float64 {
+	return 1 + 2*min(max(score, 0), 1)
+}
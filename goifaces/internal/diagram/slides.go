@@ -13,11 +13,13 @@ import (
 type Slide struct {
 	Title   string
 	Mermaid string
+	Text    string // plain-text body (e.g. the package tree), used instead of Mermaid when set
 }
 
 // SlideOptions controls slide deck generation.
 type SlideOptions struct {
-	Threshold int // node count above which slides activate; 0 = always single
+	Threshold      int  // node count above which slides activate; 0 = always single
+	PackageMapText bool // render the package map slide with GeneratePackageTree
 }
 
 // DefaultSlideOptions returns sensible defaults.
@@ -42,10 +44,13 @@ func BuildSlides(result *analyzer.Result, diagOpts DiagramOptions, splitter spli
 	var slides []Slide
 
 	// Slide 0: package map — shows repository package hierarchy
-	slides = append(slides, Slide{
-		Title:   "Package Map",
-		Mermaid: GeneratePackageMapMermaid(result, diagOpts),
-	})
+	packageMap := Slide{Title: "Package Map"}
+	if opts.PackageMapText {
+		packageMap.Text = GeneratePackageTree(result)
+	} else {
+		packageMap.Mermaid = GeneratePackageMapMermaid(result, diagOpts)
+	}
+	slides = append(slides, packageMap)
 
 	// Detail slides from splitter groups
 	groups := splitter.Split(result)
@@ -77,7 +82,7 @@ func subResultForSplitGroup(full *analyzer.Result, g split.Group) *analyzer.Resu
 		typeKeys[k] = true
 	}
 
-	sub := &analyzer.Result{}
+	sub := &analyzer.Result{ModulePath: full.ModulePath, ReplacedModules: full.ReplacedModules}
 
 	for i := range full.Interfaces {
 		ik := typeKey(full.Interfaces[i].PkgPath, full.Interfaces[i].Name)
@@ -106,50 +111,8 @@ func subResultForSplitGroup(full *analyzer.Result, g split.Group) *analyzer.Resu
 	// implementing type present, leaving orphaned nodes. Similarly, a spoke
 	// type may end up with no surviving relations if all its interfaces were
 	// placed on a different group.
-	usedIfaces := make(map[string]bool, len(sub.Relations))
-	usedTypes := make(map[string]bool, len(sub.Relations))
-	for _, rel := range sub.Relations {
-		usedIfaces[typeKey(rel.Interface.PkgPath, rel.Interface.Name)] = true
-		usedTypes[typeKey(rel.Type.PkgPath, rel.Type.Name)] = true
-	}
-	filteredIfaces := sub.Interfaces[:0]
-	for _, iface := range sub.Interfaces {
-		if usedIfaces[typeKey(iface.PkgPath, iface.Name)] {
-			filteredIfaces = append(filteredIfaces, iface)
-		}
-	}
-	sub.Interfaces = filteredIfaces
-
-	filteredTypes := sub.Types[:0]
-	for _, typ := range sub.Types {
-		if usedTypes[typeKey(typ.PkgPath, typ.Name)] {
-			filteredTypes = append(filteredTypes, typ)
-		}
-	}
-	sub.Types = filteredTypes
-
-	return sub
-}
-
-// pastelColor defines a muted color for package map nodes.
-type pastelColor struct {
-	Fill   string
-	Stroke string
-	Text   string
-}
-
-// pastelPalette contains ~10 muted/pastel colors for package map nodes.
-var pastelPalette = []pastelColor{
-	{"#e8f4fd", "#b8d4e8", "#333333"}, // light blue
-	{"#e8f5e9", "#b8d8ba", "#333333"}, // light green
-	{"#fff3e0", "#e8c9a0", "#333333"}, // light orange
-	{"#f3e5f5", "#d1b3d8", "#333333"}, // light purple
-	{"#fce4ec", "#e8b0bf", "#333333"}, // light pink
-	{"#e0f2f1", "#b0d4d1", "#333333"}, // light teal
-	{"#fff9c4", "#e8dea0", "#333333"}, // light yellow
-	{"#e8eaf6", "#b8bce8", "#333333"}, // light indigo
-	{"#efebe9", "#c8b8ad", "#333333"}, // light brown
-	{"#f1f8e9", "#c4dba0", "#333333"}, // light lime
+	sub.Embeds = full.Embeds
+	return analyzer.PruneOrphans(sub)
 }
 
 // nodeStyle records a node's color assignment for later style emission.
@@ -169,62 +132,22 @@ type pkgStats struct {
 // package hierarchy. Each package is a node displaying its name and counts of
 // interfaces and types. Packages with subpackages are rendered as subgraphs.
 func GeneratePackageMapMermaid(result *analyzer.Result, opts DiagramOptions) string {
-	// Collect stats per package path
-	stats := make(map[string]*pkgStats)
-	for _, iface := range result.Interfaces {
-		s, ok := stats[iface.PkgPath]
-		if !ok {
-			s = &pkgStats{}
-			stats[iface.PkgPath] = s
-		}
-		s.Interfaces++
+	stats, paths := packageStats(result)
+	if len(paths) == 0 {
+		return "flowchart " + opts.direction()
 	}
-	for _, typ := range result.Types {
-		s, ok := stats[typ.PkgPath]
-		if !ok {
-			s = &pkgStats{}
-			stats[typ.PkgPath] = s
-		}
-		s.Types++
-	}
-
-	if len(stats) == 0 {
-		return "flowchart LR"
-	}
-
-	// Collect and sort package paths
-	var paths []string
-	for p := range stats {
-		paths = append(paths, p)
-	}
-	sort.Strings(paths)
 
-	// Find common prefix to strip (module path)
-	prefix := longestCommonPrefix(paths)
-	// Trim to last slash to get module root
-	if idx := strings.LastIndex(prefix, "/"); idx >= 0 {
-		prefix = prefix[:idx+1]
-	}
-
-	// Build tree
-	root := &pkgNode{children: make(map[string]*pkgNode)}
-	for _, p := range paths {
-		rel := strings.TrimPrefix(p, prefix)
-		if rel == "" {
-			rel = lastSegment(p)
-		}
-		parts := strings.Split(rel, "/")
-		insertNode(root, parts, p, rel, stats[p])
-	}
+	root := buildPkgTree(result, paths, stats)
 
 	var b strings.Builder
 	if opts.IncludeInit {
 		b.WriteString("%%{init: {'theme': 'base', 'themeVariables': {'primaryColor': '#ffffff', 'primaryBorderColor': '#cccccc', 'primaryTextColor': '#000000', 'lineColor': '#555555'}}}%%\n")
 	}
-	b.WriteString("flowchart LR")
+	b.WriteString("flowchart " + opts.direction())
 
 	// Emit classDef for each palette color (used by subgraphs)
-	for i, c := range pastelPalette {
+	colors := opts.palette().Packages
+	for i, c := range colors {
 		b.WriteString(fmt.Sprintf("\n    classDef pkgColor%d fill:%s,stroke:%s,color:%s", i, c.Fill, c.Stroke, c.Text))
 	}
 
@@ -235,9 +158,9 @@ func GeneratePackageMapMermaid(result *analyzer.Result, opts DiagramOptions) str
 	// Emit style/class lines after all subgraph declarations are complete
 	for _, s := range styles {
 		if s.isSubgraph {
-			b.WriteString(fmt.Sprintf("\n    class %s pkgColor%d", s.id, s.colorIdx%len(pastelPalette)))
+			b.WriteString(fmt.Sprintf("\n    class %s pkgColor%d", s.id, s.colorIdx%len(colors)))
 		} else {
-			c := pastelPalette[s.colorIdx%len(pastelPalette)]
+			c := colors[s.colorIdx%len(colors)]
 			b.WriteString(fmt.Sprintf("\n    style %s fill:%s,stroke:%s,color:%s", s.id, c.Fill, c.Stroke, c.Text))
 		}
 	}
@@ -245,18 +168,134 @@ func GeneratePackageMapMermaid(result *analyzer.Result, opts DiagramOptions) str
 	return b.String()
 }
 
+// packageStats counts interfaces and types per package path and returns the
+// sorted paths that have any.
+func packageStats(result *analyzer.Result) (map[string]*pkgStats, []string) {
+	stats := make(map[string]*pkgStats)
+	get := func(pkgPath string) *pkgStats {
+		s, ok := stats[pkgPath]
+		if !ok {
+			s = &pkgStats{}
+			stats[pkgPath] = s
+		}
+		return s
+	}
+	for _, iface := range result.Interfaces {
+		get(iface.PkgPath).Interfaces++
+	}
+	for _, typ := range result.Types {
+		get(typ.PkgPath).Types++
+	}
+
+	paths := make([]string, 0, len(stats))
+	for p := range stats {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return stats, paths
+}
+
+// GeneratePackageTree renders the same package hierarchy as
+// GeneratePackageMapMermaid as an indented Markdown list, one package per
+// line with its interface and type counts. It is the plain-text alternative
+// for screen readers and copy-paste. Grouping nodes that are not packages
+// themselves end in "/".
+func GeneratePackageTree(result *analyzer.Result) string {
+	stats, paths := packageStats(result)
+	if len(paths) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	writePackageTree(&b, buildPkgTree(result, paths, stats), 0)
+	return b.String()
+}
+
+func writePackageTree(b *strings.Builder, node *pkgNode, depth int) {
+	var names []string
+	for name := range node.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	indent := strings.Repeat("  ", depth)
+	for _, name := range names {
+		child := node.children[name]
+		b.WriteString(indent + "- " + name)
+		if child.stats == nil {
+			b.WriteString("/")
+		} else {
+			fmt.Fprintf(b, " (%s)", pluralize(child.stats.Interfaces, "interface")+", "+pluralize(child.stats.Types, "type"))
+		}
+		b.WriteString("\n")
+		writePackageTree(b, child, depth+1)
+	}
+}
+
+// pluralize formats n followed by noun, adding "s" unless n is 1.
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// replacedLabel is appended to the group name of modules loaded through a
+// replace directive.
+const replacedLabel = " (replaced)"
+
 // pkgNode represents a node in the package hierarchy tree.
 type pkgNode struct {
 	name     string    // segment name (e.g. "api")
 	relPath  string    // module-relative path (e.g. "internal/analyzer")
 	pkgPath  string    // full package path (only set for leaf/actual packages)
 	stats    *pkgStats // non-nil for actual packages
+	replaced bool      // belongs to a module loaded through a replace directive
 	children map[string]*pkgNode
 }
 
+// buildPkgTree builds the package hierarchy for sorted paths. Packages of the
+// analyzed module are grouped by their path relative to the common prefix;
+// packages of replaced modules are grouped under one top-level node per
+// module, labeled "<module> (replaced)", since their import path says nothing
+// about where they live on disk.
+func buildPkgTree(result *analyzer.Result, paths []string, stats map[string]*pkgStats) *pkgNode {
+	var ownPaths []string
+	for _, p := range paths {
+		if !result.IsReplaced(p) {
+			ownPaths = append(ownPaths, p)
+		}
+	}
+
+	// Find common prefix to strip (module path)
+	prefix := longestCommonPrefix(ownPaths)
+	// Trim to last slash to get module root
+	if idx := strings.LastIndex(prefix, "/"); idx >= 0 {
+		prefix = prefix[:idx+1]
+	}
+
+	root := &pkgNode{children: make(map[string]*pkgNode)}
+	for _, p := range paths {
+		if mod := result.ReplacedModule(p); mod != "" {
+			parts := []string{mod + replacedLabel}
+			if rest := strings.TrimPrefix(strings.TrimPrefix(p, mod), "/"); rest != "" {
+				parts = append(parts, strings.Split(rest, "/")...)
+			}
+			insertNode(root, parts, p, p, stats[p], true)
+			continue
+		}
+		rel := strings.TrimPrefix(p, prefix)
+		if rel == "" {
+			rel = lastSegment(p)
+		}
+		parts := strings.Split(rel, "/")
+		insertNode(root, parts, p, rel, stats[p], false)
+	}
+	return root
+}
+
 // insertNode inserts a package into the hierarchy tree. Callers must sort paths
 // before calling so that parent nodes are created before their children.
-func insertNode(parent *pkgNode, parts []string, fullPath string, relPath string, s *pkgStats) {
+func insertNode(parent *pkgNode, parts []string, fullPath string, relPath string, s *pkgStats, replaced bool) {
 	if len(parts) == 0 {
 		return
 	}
@@ -266,12 +305,13 @@ func insertNode(parent *pkgNode, parts []string, fullPath string, relPath string
 		child = &pkgNode{name: name, children: make(map[string]*pkgNode)}
 		parent.children[name] = child
 	}
+	child.replaced = child.replaced || replaced
 	if len(parts) == 1 {
 		child.pkgPath = fullPath
 		child.relPath = relPath
 		child.stats = s
 	} else {
-		insertNode(child, parts[1:], fullPath, relPath, s)
+		insertNode(child, parts[1:], fullPath, relPath, s, replaced)
 	}
 }
 
@@ -298,6 +338,9 @@ func renderTree(b *strings.Builder, node *pkgNode, depth int, colorIdx *int, sty
 		if displayName == "" {
 			displayName = child.name
 		}
+		if child.replaced && !strings.HasSuffix(displayName, replacedLabel) {
+			displayName += replacedLabel
+		}
 
 		if hasChildren {
 			// Render as subgraph with nested children
@@ -372,54 +415,12 @@ func lastSegment(path string) string {
 // suitable for client-side treemap rendering. It reuses the same tree-building
 // logic as GeneratePackageMapMermaid but outputs a JSON-serializable structure.
 func PreparePackageMapData(result *analyzer.Result) []*PackageMapNode {
-	// Collect stats per package path
-	stats := make(map[string]*pkgStats)
-	for _, iface := range result.Interfaces {
-		s, ok := stats[iface.PkgPath]
-		if !ok {
-			s = &pkgStats{}
-			stats[iface.PkgPath] = s
-		}
-		s.Interfaces++
-	}
-	for _, typ := range result.Types {
-		s, ok := stats[typ.PkgPath]
-		if !ok {
-			s = &pkgStats{}
-			stats[typ.PkgPath] = s
-		}
-		s.Types++
-	}
-
-	if len(stats) == 0 {
+	stats, paths := packageStats(result)
+	if len(paths) == 0 {
 		return nil
 	}
 
-	// Collect and sort package paths
-	var paths []string
-	for p := range stats {
-		paths = append(paths, p)
-	}
-	sort.Strings(paths)
-
-	// Find common prefix to strip (module path)
-	prefix := longestCommonPrefix(paths)
-	if idx := strings.LastIndex(prefix, "/"); idx >= 0 {
-		prefix = prefix[:idx+1]
-	}
-
-	// Build tree
-	root := &pkgNode{children: make(map[string]*pkgNode)}
-	for _, p := range paths {
-		rel := strings.TrimPrefix(p, prefix)
-		if rel == "" {
-			rel = lastSegment(p)
-		}
-		parts := strings.Split(rel, "/")
-		insertNode(root, parts, p, rel, stats[p])
-	}
-
-	return convertPkgTree(root)
+	return convertPkgTree(buildPkgTree(result, paths, stats))
 }
 
 // convertPkgTree converts a pkgNode tree into a slice of PackageMapNode.
@@ -434,9 +435,10 @@ func convertPkgTree(node *pkgNode) []*PackageMapNode {
 	for _, name := range names {
 		child := node.children[name]
 		pmn := &PackageMapNode{
-			Name:    child.name,
-			RelPath: child.relPath,
-			PkgPath: child.pkgPath,
+			Name:     child.name,
+			RelPath:  child.relPath,
+			PkgPath:  child.pkgPath,
+			Replaced: child.replaced,
 		}
 		if child.stats != nil {
 			pmn.Interfaces = child.stats.Interfaces
@@ -474,3 +476,98 @@ func convertPkgTree(node *pkgNode) []*PackageMapNode {
 	}
 	return result
 }
+
+// OtherNodeName is the display name of the synthetic node that aggregates
+// small packages when the package map exceeds its node budget.
+const OtherNodeName = "(other)"
+
+// LimitPackageMapNodes caps the total number of nodes in a package map tree.
+// When the tree holds more than maxNodes nodes, the smallest leaf packages
+// (by Value) are folded into a per-parent "(other)" node whose counts and
+// value are the sum of the packages it replaces. Parent values are
+// preserved, so the treemap proportions stay the same. A maxNodes of 0 or
+// less disables the cap. The input tree is not modified.
+func LimitPackageMapNodes(nodes []*PackageMapNode, maxNodes int) []*PackageMapNode {
+	total := countPackageMapNodes(nodes)
+	if maxNodes <= 0 || total <= maxNodes {
+		return nodes
+	}
+
+	// Collect leaves with their parents (nil parent = top level).
+	type leafRef struct {
+		parent *PackageMapNode
+		node   *PackageMapNode
+	}
+	var leaves []leafRef
+	var collect func(parent *PackageMapNode, children []*PackageMapNode)
+	collect = func(parent *PackageMapNode, children []*PackageMapNode) {
+		for _, c := range children {
+			if len(c.Children) == 0 {
+				leaves = append(leaves, leafRef{parent: parent, node: c})
+			} else {
+				collect(c, c.Children)
+			}
+		}
+	}
+	collect(nil, nodes)
+	sort.SliceStable(leaves, func(i, j int) bool {
+		if leaves[i].node.Value != leaves[j].node.Value {
+			return leaves[i].node.Value < leaves[j].node.Value
+		}
+		return leaves[i].node.PkgPath < leaves[j].node.PkgPath
+	})
+
+	// Fold the smallest leaves until the budget is met. The first fold under
+	// a parent creates its "(other)" node, so it only pays off from the
+	// second fold onwards.
+	others := make(map[*PackageMapNode]*PackageMapNode)
+	folded := make(map[*PackageMapNode]bool)
+	for _, l := range leaves {
+		if total <= maxNodes {
+			break
+		}
+		other, ok := others[l.parent]
+		if !ok {
+			other = &PackageMapNode{Name: OtherNodeName}
+			others[l.parent] = other
+			total++
+		}
+		other.Interfaces += l.node.Interfaces
+		other.Types += l.node.Types
+		other.Value += l.node.Value
+		other.Aggregated++
+		folded[l.node] = true
+		total--
+	}
+
+	var rebuild func(parent *PackageMapNode, children []*PackageMapNode) []*PackageMapNode
+	rebuild = func(parent *PackageMapNode, children []*PackageMapNode) []*PackageMapNode {
+		other := others[parent]
+		var out []*PackageMapNode
+		for _, c := range children {
+			// A lone folded package is kept as-is rather than renamed.
+			if folded[c] && other.Aggregated > 1 {
+				continue
+			}
+			clone := *c
+			if len(c.Children) > 0 {
+				clone.Children = rebuild(c, c.Children)
+			}
+			out = append(out, &clone)
+		}
+		if other != nil && other.Aggregated > 1 {
+			out = append(out, other)
+		}
+		return out
+	}
+	return rebuild(nil, nodes)
+}
+
+// countPackageMapNodes returns the number of nodes in a package map tree.
+func countPackageMapNodes(nodes []*PackageMapNode) int {
+	n := 0
+	for _, node := range nodes {
+		n += 1 + countPackageMapNodes(node.Children)
+	}
+	return n
+}
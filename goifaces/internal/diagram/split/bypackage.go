@@ -0,0 +1,74 @@
+package split
+
+import (
+	"sort"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+)
+
+// ByPackage implements the one-slide-per-package splitting strategy.
+// Every package that declares an interface or a type gets its own group;
+// a relation that crosses packages appears in the groups of both ends.
+type ByPackage struct {
+	opts Options
+}
+
+// NewByPackage creates a by-package splitter. The hub threshold and chunk
+// size in opts do not apply to this strategy.
+func NewByPackage(opts Options) *ByPackage {
+	return &ByPackage{opts: opts}
+}
+
+// Split implements Splitter. Groups are sorted by package path and titled
+// with the package's short name. A group's HubKeys and SpokeKeys both hold
+// the package's interfaces and types, plus the nodes from other packages
+// they are related to, so cross-package relations stay visible on each side.
+func (p *ByPackage) Split(result *analyzer.Result) []Group {
+	names := make(map[string]string) // pkgPath -> short name
+	members := make(map[string]map[string]bool)
+	add := func(pkgPath, key string) {
+		if members[pkgPath] == nil {
+			members[pkgPath] = make(map[string]bool)
+		}
+		members[pkgPath][key] = true
+	}
+
+	for _, iface := range result.Interfaces {
+		names[iface.PkgPath] = iface.PkgName
+		add(iface.PkgPath, typeKey(iface.PkgPath, iface.Name))
+	}
+	for _, typ := range result.Types {
+		names[typ.PkgPath] = typ.PkgName
+		add(typ.PkgPath, typeKey(typ.PkgPath, typ.Name))
+	}
+
+	// Duplicate cross-package relations: each end joins the other's group.
+	for _, rel := range result.Relations {
+		if rel.Interface.PkgPath == rel.Type.PkgPath {
+			continue
+		}
+		ik := typeKey(rel.Interface.PkgPath, rel.Interface.Name)
+		tk := typeKey(rel.Type.PkgPath, rel.Type.Name)
+		add(rel.Interface.PkgPath, tk)
+		add(rel.Type.PkgPath, ik)
+	}
+
+	pkgPaths := make([]string, 0, len(names))
+	for pkgPath := range names {
+		pkgPaths = append(pkgPaths, pkgPath)
+	}
+	sort.Strings(pkgPaths)
+
+	var groups []Group
+	for _, pkgPath := range pkgPaths {
+		keys := sortedKeys(members[pkgPath])
+		spokes := make([]string, len(keys))
+		copy(spokes, keys)
+		groups = append(groups, Group{
+			Title:     names[pkgPath],
+			HubKeys:   keys,
+			SpokeKeys: spokes,
+		})
+	}
+	return groups
+}
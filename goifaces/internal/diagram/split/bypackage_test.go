@@ -0,0 +1,57 @@
+package split
+
+import (
+	"testing"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestByPackage_CrossPackageRelations(t *testing.T) {
+	// store.Postgres implements store.Repo and, across packages, api.Handler.
+	ifaces := []analyzer.InterfaceDef{
+		makeIface("Repo", "store"),
+		makeIface("Handler", "api"),
+	}
+	types := []analyzer.TypeDef{
+		makeType("Postgres", "store"),
+		makeType("Server", "api"),
+	}
+	rels := [][2]string{
+		{"store.Postgres", "store.Repo"},
+		{"store.Postgres", "api.Handler"},
+		{"api.Server", "api.Handler"},
+	}
+
+	result := buildResult(ifaces, types, rels)
+	groups := NewByPackage(DefaultOptions()).Split(result)
+
+	// Sorted by package path, titled with the short name.
+	require.Equal(t, 2, len(groups))
+	assert.Equal(t, "api", groups[0].Title)
+	assert.Equal(t, "store", groups[1].Title)
+
+	// The cross-package relation shows up on both slides.
+	assert.Equal(t, []string{"api.Handler", "api.Server", "store.Postgres"}, groups[0].HubKeys)
+	assert.Equal(t, groups[0].HubKeys, groups[0].SpokeKeys)
+	assert.Equal(t, []string{"api.Handler", "store.Postgres", "store.Repo"}, groups[1].HubKeys)
+	assert.Equal(t, groups[1].HubKeys, groups[1].SpokeKeys)
+}
+
+func TestByPackage_ShortNameTitle(t *testing.T) {
+	ifaces := []analyzer.InterfaceDef{
+		{Name: "Sender", PkgPath: "example.com/app/notify", PkgName: "notify"},
+	}
+	groups := NewByPackage(DefaultOptions()).Split(buildResult(ifaces, nil, nil))
+
+	require.Equal(t, 1, len(groups))
+	assert.Equal(t, "notify", groups[0].Title)
+	assert.Equal(t, []string{"example.com/app/notify.Sender"}, groups[0].HubKeys)
+}
+
+func TestByPackage_EmptyResult(t *testing.T) {
+	groups := NewByPackage(DefaultOptions()).Split(&analyzer.Result{})
+
+	assert.Nil(t, groups)
+}
@@ -0,0 +1,158 @@
+package split
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+)
+
+// ConnectedComponents implements the connected-components splitting
+// strategy: interfaces and types that are linked, directly or through other
+// nodes, share a slide, so disjoint subsystems never mix.
+type ConnectedComponents struct {
+	opts Options
+}
+
+// NewConnectedComponents creates a connected-components splitter. A
+// component with more than opts.ChunkSize types is split into several groups.
+func NewConnectedComponents(opts Options) *ConnectedComponents {
+	return &ConnectedComponents{opts: opts.WithDefaults()}
+}
+
+// Split implements Splitter. It joins the endpoints of every relation with
+// union-find, treating the graph as undirected, and emits one group per
+// component ordered by the component's smallest node key. Interfaces are
+// HubKeys and types SpokeKeys; large components are chunked by type, each
+// chunk keeping the component's interfaces its types implement. Nodes
+// without relations are collected into a final "Unconnected" group.
+func (c *ConnectedComponents) Split(result *analyzer.Result) []Group {
+	ifaceKeys := make(map[string]bool, len(result.Interfaces))
+	for _, iface := range result.Interfaces {
+		ifaceKeys[typeKey(iface.PkgPath, iface.Name)] = true
+	}
+	typeKeys := make(map[string]bool, len(result.Types))
+	for _, typ := range result.Types {
+		typeKeys[typeKey(typ.PkgPath, typ.Name)] = true
+	}
+
+	uf := newUnionFind()
+	typeIfaces := make(map[string][]string) // type key -> interfaces it implements
+	for _, rel := range result.Relations {
+		ik := typeKey(rel.Interface.PkgPath, rel.Interface.Name)
+		tk := typeKey(rel.Type.PkgPath, rel.Type.Name)
+		if !ifaceKeys[ik] || !typeKeys[tk] {
+			continue
+		}
+		uf.union(ik, tk)
+		typeIfaces[tk] = append(typeIfaces[tk], ik)
+	}
+
+	// Collect members per component root; unconnected nodes go aside.
+	components := make(map[string][]string)
+	var unconnectedIfaces, unconnectedTypes []string
+	for _, keys := range []map[string]bool{ifaceKeys, typeKeys} {
+		for k := range keys {
+			if !uf.has(k) {
+				if ifaceKeys[k] {
+					unconnectedIfaces = append(unconnectedIfaces, k)
+				} else {
+					unconnectedTypes = append(unconnectedTypes, k)
+				}
+				continue
+			}
+			root := uf.find(k)
+			components[root] = append(components[root], k)
+		}
+	}
+
+	sorted := make([][]string, 0, len(components))
+	for _, members := range components {
+		sort.Strings(members)
+		sorted = append(sorted, members)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i][0] < sorted[j][0] })
+
+	var groups []Group
+	for _, members := range sorted {
+		var ifaces, typs []string
+		for _, k := range members {
+			if ifaceKeys[k] {
+				ifaces = append(ifaces, k)
+			} else {
+				typs = append(typs, k)
+			}
+		}
+		if len(typs) <= c.opts.ChunkSize {
+			groups = append(groups, Group{Title: buildTitle(ifaces), HubKeys: ifaces, SpokeKeys: typs})
+			continue
+		}
+		chunks := chunkSlice(typs, c.opts.ChunkSize)
+		for i, chunk := range chunks {
+			hubs := make(map[string]bool)
+			for _, tk := range chunk {
+				for _, ik := range typeIfaces[tk] {
+					hubs[ik] = true
+				}
+			}
+			hubKeys := sortedKeys(hubs)
+			groups = append(groups, Group{
+				Title:     fmt.Sprintf("%s (%d/%d)", buildTitle(hubKeys), i+1, len(chunks)),
+				HubKeys:   hubKeys,
+				SpokeKeys: chunk,
+			})
+		}
+	}
+
+	if len(unconnectedIfaces) > 0 || len(unconnectedTypes) > 0 {
+		sort.Strings(unconnectedIfaces)
+		sort.Strings(unconnectedTypes)
+		groups = append(groups, Group{
+			Title:     "Unconnected",
+			HubKeys:   unconnectedIfaces,
+			SpokeKeys: unconnectedTypes,
+		})
+	}
+	return groups
+}
+
+// unionFind is a disjoint-set forest over node keys with path compression.
+type unionFind struct {
+	parent map[string]string
+}
+
+func newUnionFind() *unionFind {
+	return &unionFind{parent: make(map[string]string)}
+}
+
+// has reports whether k was ever part of a union.
+func (u *unionFind) has(k string) bool {
+	_, ok := u.parent[k]
+	return ok
+}
+
+func (u *unionFind) find(k string) string {
+	if _, ok := u.parent[k]; !ok {
+		u.parent[k] = k
+	}
+	root := k
+	for u.parent[root] != root {
+		root = u.parent[root]
+	}
+	for u.parent[k] != root {
+		u.parent[k], k = root, u.parent[k]
+	}
+	return root
+}
+
+// union merges the sets of a and b, keeping the smaller key as the root.
+func (u *unionFind) union(a, b string) {
+	ra, rb := u.find(a), u.find(b)
+	if ra == rb {
+		return
+	}
+	if rb < ra {
+		ra, rb = rb, ra
+	}
+	u.parent[rb] = ra
+}
@@ -0,0 +1,91 @@
+package split
+
+import (
+	"testing"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectedComponents_IsolatedCluster(t *testing.T) {
+	// One cluster around Indexer, a disjoint ResultIterator + FilterIterator
+	// pair, and two nodes with no relations at all.
+	pkg := "iso"
+	ifaces := []analyzer.InterfaceDef{
+		makeIface("Indexer", pkg),
+		makeIface("ResultIterator", pkg),
+		makeIface("Unused", pkg),
+	}
+	types := []analyzer.TypeDef{
+		makeType("Alpha", pkg),
+		makeType("Beta", pkg),
+		makeType("FilterIterator", pkg),
+		makeType("Gamma", pkg),
+		makeType("Lonely", pkg),
+	}
+	rels := [][2]string{
+		{pkg + ".Alpha", pkg + ".Indexer"},
+		{pkg + ".Beta", pkg + ".Indexer"},
+		{pkg + ".Gamma", pkg + ".Indexer"},
+		{pkg + ".FilterIterator", pkg + ".ResultIterator"},
+	}
+
+	result := buildResult(ifaces, types, rels)
+	groups := NewConnectedComponents(Options{ChunkSize: 3}).Split(result)
+
+	// Ordered by smallest key: iso.Alpha < iso.FilterIterator, then Unconnected.
+	require.Equal(t, 3, len(groups))
+	assert.Equal(t, Group{
+		Title:     "Indexer",
+		HubKeys:   []string{pkg + ".Indexer"},
+		SpokeKeys: []string{pkg + ".Alpha", pkg + ".Beta", pkg + ".Gamma"},
+	}, groups[0])
+	assert.Equal(t, Group{
+		Title:     "ResultIterator",
+		HubKeys:   []string{pkg + ".ResultIterator"},
+		SpokeKeys: []string{pkg + ".FilterIterator"},
+	}, groups[1])
+	assert.Equal(t, Group{
+		Title:     "Unconnected",
+		HubKeys:   []string{pkg + ".Unused"},
+		SpokeKeys: []string{pkg + ".Lonely"},
+	}, groups[2])
+}
+
+func TestConnectedComponents_ChunksLargeComponent(t *testing.T) {
+	// Reader and Writer are joined through ReadWriter, so all five types form
+	// one component that is chunked two at a time.
+	pkg := "io"
+	ifaces := []analyzer.InterfaceDef{makeIface("Reader", pkg), makeIface("Writer", pkg)}
+	types := []analyzer.TypeDef{
+		makeType("A", pkg), makeType("B", pkg), makeType("C", pkg),
+		makeType("D", pkg), makeType("ReadWriter", pkg),
+	}
+	rels := [][2]string{
+		{pkg + ".A", pkg + ".Reader"},
+		{pkg + ".B", pkg + ".Reader"},
+		{pkg + ".ReadWriter", pkg + ".Reader"},
+		{pkg + ".ReadWriter", pkg + ".Writer"},
+		{pkg + ".C", pkg + ".Writer"},
+		{pkg + ".D", pkg + ".Writer"},
+	}
+
+	groups := NewConnectedComponents(Options{ChunkSize: 2}).Split(buildResult(ifaces, types, rels))
+
+	require.Equal(t, 3, len(groups))
+	assert.Equal(t, []string{pkg + ".A", pkg + ".B"}, groups[0].SpokeKeys)
+	assert.Equal(t, []string{pkg + ".Reader"}, groups[0].HubKeys)
+	assert.Equal(t, "Reader (1/3)", groups[0].Title)
+	assert.Equal(t, []string{pkg + ".C", pkg + ".D"}, groups[1].SpokeKeys)
+	assert.Equal(t, []string{pkg + ".Writer"}, groups[1].HubKeys)
+	assert.Equal(t, []string{pkg + ".ReadWriter"}, groups[2].SpokeKeys)
+	assert.Equal(t, []string{pkg + ".Reader", pkg + ".Writer"}, groups[2].HubKeys)
+	assert.Equal(t, "Reader, Writer (3/3)", groups[2].Title)
+}
+
+func TestConnectedComponents_EmptyResult(t *testing.T) {
+	groups := NewConnectedComponents(DefaultOptions()).Split(&analyzer.Result{})
+
+	assert.Nil(t, groups)
+}
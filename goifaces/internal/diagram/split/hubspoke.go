@@ -15,14 +15,9 @@ type HubAndSpoke struct {
 }
 
 // NewHubAndSpoke creates a hub-and-spoke splitter with the given options.
+// Non-positive options fall back to their defaults.
 func NewHubAndSpoke(opts Options) *HubAndSpoke {
-	if opts.HubThreshold <= 0 {
-		opts.HubThreshold = DefaultOptions().HubThreshold
-	}
-	if opts.ChunkSize <= 0 {
-		opts.ChunkSize = DefaultOptions().ChunkSize
-	}
-	return &HubAndSpoke{opts: opts}
+	return &HubAndSpoke{opts: opts.WithDefaults()}
 }
 
 // Split implements Splitter. It identifies hub interfaces (those with
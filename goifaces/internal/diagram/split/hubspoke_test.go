@@ -276,3 +276,10 @@ func TestHubSpoke_EmptyResult(t *testing.T) {
 
 	assert.Nil(t, groups)
 }
+
+func TestOptions_WithDefaults(t *testing.T) {
+	assert.Equal(t, DefaultOptions(), Options{}.WithDefaults())
+	assert.Equal(t, DefaultOptions(), Options{HubThreshold: -2, ChunkSize: 0}.WithDefaults())
+	assert.Equal(t, Options{HubThreshold: 5, ChunkSize: 3}, Options{HubThreshold: 5, ChunkSize: -1}.WithDefaults())
+	assert.Equal(t, Options{HubThreshold: 1, ChunkSize: 8}, Options{HubThreshold: 1, ChunkSize: 8}.WithDefaults())
+}
@@ -25,3 +25,16 @@ type Options struct {
 func DefaultOptions() Options {
 	return Options{HubThreshold: 3, ChunkSize: 3}
 }
+
+// WithDefaults returns o with each non-positive field replaced by its
+// DefaultOptions value.
+func (o Options) WithDefaults() Options {
+	def := DefaultOptions()
+	if o.HubThreshold <= 0 {
+		o.HubThreshold = def.HubThreshold
+	}
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = def.ChunkSize
+	}
+	return o
+}
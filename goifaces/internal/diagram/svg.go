@@ -0,0 +1,71 @@
+package diagram
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+)
+
+// MermaidCLI is the mermaid-cli executable GenerateSVG runs.
+const MermaidCLI = "mmdc"
+
+// FindMermaidCLI returns the path of MermaidCLI on PATH, or an
+// ErrMermaidCLINotFound error telling the user how to install it.
+func FindMermaidCLI() (string, error) {
+	path, err := exec.LookPath(MermaidCLI)
+	if err != nil {
+		return "", fmt.Errorf("%w: install it with `npm install -g @mermaid-js/mermaid-cli`, or use -format mermaid and render the .mmd file elsewhere", ErrMermaidCLINotFound)
+	}
+	return path, nil
+}
+
+// GenerateSVG renders the class diagram to a self-contained SVG by running
+// the locally installed mermaid-cli on the GenerateMermaid output. The
+// %%{init:}%% directive is always included, so the theme and class styles
+// render the same as in the browser. A failed render wraps ErrRenderFailed
+// with mmdc's stderr.
+func GenerateSVG(ctx context.Context, result *analyzer.Result, opts DiagramOptions) ([]byte, error) {
+	mmdc, err := FindMermaidCLI()
+	if err != nil {
+		return nil, err
+	}
+	opts.IncludeInit = true
+	return renderMermaidSVG(ctx, mmdc, GenerateMermaid(result, opts))
+}
+
+// renderMermaidSVG runs mmdc on mermaid through a temporary input and output
+// file, which every mermaid-cli version supports (stdin/stdout do not).
+func renderMermaidSVG(ctx context.Context, mmdc, mermaid string) ([]byte, error) {
+	tmp, err := os.MkdirTemp("", "goifaces-svg-")
+	if err != nil {
+		return nil, fmt.Errorf("creating temp dir: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	in := filepath.Join(tmp, "diagram.mmd")
+	out := filepath.Join(tmp, "diagram.svg")
+	if err := os.WriteFile(in, []byte(mermaid), 0o644); err != nil {
+		return nil, fmt.Errorf("writing mermaid input: %w", err)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, mmdc, "-i", in, "-o", out)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %v: %s", ErrRenderFailed, err, msg)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrRenderFailed, err)
+	}
+	svg, err := os.ReadFile(out)
+	if err != nil {
+		return nil, fmt.Errorf("%w: no output written: %v", ErrRenderFailed, err)
+	}
+	return svg, nil
+}
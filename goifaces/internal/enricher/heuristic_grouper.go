@@ -0,0 +1,147 @@
+package enricher
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+)
+
+// LayerRule maps naming conventions to an architectural layer. A node
+// matches when its name ends in one of Suffixes or, failing every rule's
+// suffixes, when one of its methods is named in Methods.
+type LayerRule struct {
+	Layer    string
+	Suffixes []string
+	Methods  []string
+}
+
+// DefaultLayerRules is the keyword table used by NewHeuristicGrouper. Rules
+// are tried in order and the first match wins, so more specific layers come
+// first; add a row to teach the grouper a new convention.
+var DefaultLayerRules = []LayerRule{
+	{
+		Layer:    "Transport",
+		Suffixes: []string{"Handler", "HandlerFunc", "Server", "Router", "Middleware", "Controller", "Client", "Endpoint"},
+		Methods:  []string{"ServeHTTP", "Serve", "Handle", "ListenAndServe"},
+	},
+	{
+		Layer:    "Messaging",
+		Suffixes: []string{"Publisher", "Subscriber", "Producer", "Consumer", "Queue", "Broker", "Bus", "Listener"},
+		Methods:  []string{"Publish", "Subscribe", "Consume", "Emit"},
+	},
+	{
+		Layer:    "Service",
+		Suffixes: []string{"Service", "UseCase", "Interactor", "Manager", "Orchestrator"},
+	},
+	{
+		Layer:    "Data Access",
+		Suffixes: []string{"Repository", "Repo", "Store", "Storage", "DAO", "Cache", "DB", "Reader", "Writer", "Loader", "Saver", "Finder"},
+		Methods:  []string{"Get", "Find", "Save", "Load", "Insert", "Update", "Delete", "Query", "Put", "List"},
+	},
+	{
+		Layer:    "Configuration",
+		Suffixes: []string{"Config", "Configuration", "Options", "Settings"},
+	},
+}
+
+// HeuristicGrouper groups interfaces and types into architectural layers by
+// name and method patterns, without network calls. Types that match no rule
+// join the layer of an interface they implement; whatever is left is grouped
+// by package like DefaultGrouper.
+type HeuristicGrouper struct {
+	Rules []LayerRule
+}
+
+// NewHeuristicGrouper creates a grouper using DefaultLayerRules.
+func NewHeuristicGrouper() *HeuristicGrouper {
+	return &HeuristicGrouper{Rules: DefaultLayerRules}
+}
+
+func (g *HeuristicGrouper) Enrich(result *analyzer.Result) *analyzer.Result {
+	return result
+}
+
+// Group implements Grouper. Layer groups come first, in rule order, followed
+// by the package groups of unmatched nodes sorted by package name.
+func (g *HeuristicGrouper) Group(result *analyzer.Result) []SemanticGroup {
+	layers := make(map[string]*SemanticGroup)
+	layerOf := make(map[string]string) // interface key -> layer
+	leftover := &analyzer.Result{}
+
+	for _, iface := range result.Interfaces {
+		key := iface.PkgPath + "." + iface.Name
+		layer := g.match(iface.Name, iface.Methods)
+		if layer == "" {
+			leftover.Interfaces = append(leftover.Interfaces, iface)
+			continue
+		}
+		layerOf[key] = layer
+		groupFor(layers, layer).Interfaces = append(groupFor(layers, layer).Interfaces, key)
+	}
+
+	implements := make(map[string][]string) // type key -> interface keys
+	for _, rel := range result.Relations {
+		tk := rel.Type.PkgPath + "." + rel.Type.Name
+		implements[tk] = append(implements[tk], rel.Interface.PkgPath+"."+rel.Interface.Name)
+	}
+	for _, typ := range result.Types {
+		key := typ.PkgPath + "." + typ.Name
+		layer := g.match(typ.Name, typ.Methods)
+		if layer == "" {
+			for _, ik := range implements[key] {
+				if layer = layerOf[ik]; layer != "" {
+					break
+				}
+			}
+		}
+		if layer == "" {
+			leftover.Types = append(leftover.Types, typ)
+			continue
+		}
+		groupFor(layers, layer).Types = append(groupFor(layers, layer).Types, key)
+	}
+
+	var out []SemanticGroup
+	seen := make(map[string]bool)
+	for _, rule := range g.Rules {
+		if sg, ok := layers[rule.Layer]; ok && !seen[rule.Layer] {
+			seen[rule.Layer] = true
+			out = append(out, *sg)
+		}
+	}
+	byPkg := NewDefaultGrouper().Group(leftover)
+	sort.Slice(byPkg, func(i, j int) bool { return byPkg[i].Name < byPkg[j].Name })
+	return append(out, byPkg...)
+}
+
+// match returns the layer of the first rule whose suffixes match name, else
+// of the first rule naming one of methods, else "".
+func (g *HeuristicGrouper) match(name string, methods []analyzer.MethodSig) string {
+	for _, rule := range g.Rules {
+		for _, suffix := range rule.Suffixes {
+			if strings.HasSuffix(name, suffix) {
+				return rule.Layer
+			}
+		}
+	}
+	for _, rule := range g.Rules {
+		for _, m := range methods {
+			for _, want := range rule.Methods {
+				if m.Name == want {
+					return rule.Layer
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func groupFor(groups map[string]*SemanticGroup, name string) *SemanticGroup {
+	sg, ok := groups[name]
+	if !ok {
+		sg = &SemanticGroup{Name: name}
+		groups[name] = sg
+	}
+	return sg
+}
@@ -0,0 +1,68 @@
+package enricher_test
+
+import (
+	"testing"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+	"github.com/olehluchkiv/goifaces/internal/enricher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeuristicGrouper_SampleResult(t *testing.T) {
+	groups := enricher.NewHeuristicGrouper().Group(sampleResult())
+
+	require.Len(t, groups, 1)
+	assert.Equal(t, "Data Access", groups[0].Name)
+	assert.Equal(t, []string{"example.com/app/store.Repository"}, groups[0].Interfaces)
+	assert.Equal(t, []string{"example.com/app/store.PostgresRepo"}, groups[0].Types)
+}
+
+func TestHeuristicGrouper_Layers(t *testing.T) {
+	result := sampleResult()
+	handler := analyzer.InterfaceDef{Name: "Handler", PkgPath: "example.com/app/api", PkgName: "api"}
+	events := analyzer.InterfaceDef{
+		Name: "Events", PkgPath: "example.com/app/bus", PkgName: "bus",
+		Methods: []analyzer.MethodSig{{Name: "Publish"}},
+	}
+	clock := analyzer.InterfaceDef{Name: "Clock", PkgPath: "example.com/app/timeutil", PkgName: "timeutil"}
+	router := analyzer.TypeDef{Name: "Router", PkgPath: "example.com/app/api", PkgName: "api"}
+	users := analyzer.TypeDef{Name: "Users", PkgPath: "example.com/app/api", PkgName: "api"}
+	system := analyzer.TypeDef{Name: "System", PkgPath: "example.com/app/timeutil", PkgName: "timeutil"}
+	result.Interfaces = append(result.Interfaces, handler, events, clock)
+	result.Types = append(result.Types, router, users, system)
+	result.Relations = append(result.Relations,
+		analyzer.Relation{Type: &users, Interface: &handler},
+		analyzer.Relation{Type: &system, Interface: &clock},
+	)
+
+	groups := enricher.NewHeuristicGrouper().Group(result)
+
+	names := make([]string, len(groups))
+	for i, g := range groups {
+		names[i] = g.Name
+	}
+	assert.Equal(t, []string{"Transport", "Messaging", "Data Access", "timeutil"}, names,
+		"layers in rule order, then unmatched nodes by package")
+
+	transport := groups[0]
+	assert.Equal(t, []string{"example.com/app/api.Handler"}, transport.Interfaces)
+	assert.Equal(t, []string{"example.com/app/api.Router", "example.com/app/api.Users"}, transport.Types,
+		"Users joins the layer of the interface it implements")
+	assert.Equal(t, []string{"example.com/app/bus.Events"}, groups[1].Interfaces, "matched by method name")
+	assert.Equal(t, []string{"example.com/app/timeutil.Clock"}, groups[3].Interfaces)
+	assert.Equal(t, []string{"example.com/app/timeutil.System"}, groups[3].Types)
+}
+
+func TestHeuristicGrouper_CustomRules(t *testing.T) {
+	g := &enricher.HeuristicGrouper{Rules: []enricher.LayerRule{{Layer: "Persistence", Suffixes: []string{"Repository"}}}}
+	groups := g.Group(sampleResult())
+
+	require.Len(t, groups, 1)
+	assert.Equal(t, "Persistence", groups[0].Name)
+	assert.Equal(t, []string{"example.com/app/store.PostgresRepo"}, groups[0].Types)
+}
+
+func TestHeuristicGrouper_Empty(t *testing.T) {
+	assert.Empty(t, enricher.NewHeuristicGrouper().Group(&analyzer.Result{}))
+}
@@ -0,0 +1,68 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// AnthropicVersion is the anthropic-version header sent with FormatAnthropic.
+const AnthropicVersion = "2023-06-01"
+
+// MaxAnthropicTemperature is the upper sampling temperature bound of the
+// messages API; NewClient clamps higher values to it.
+const MaxAnthropicTemperature = 1.0
+
+// messagesRequest is the Anthropic messages request body. The system prompt
+// is a top-level field rather than a message, and max_tokens is required.
+// There is no JSON mode; the prompts ask for JSON themselves.
+type messagesRequest struct {
+	Model       string        `json:"model"`
+	System      string        `json:"system,omitempty"`
+	Messages    []chatMessage `json:"messages"`
+	MaxTokens   int           `json:"max_tokens"`
+	Temperature float64       `json:"temperature"`
+}
+
+// messagesResponse is the Anthropic messages response body.
+type messagesResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage *messagesUsage `json:"usage,omitempty"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+func newMessagesRequest(cfg Config, systemPrompt, userPrompt string) messagesRequest {
+	return messagesRequest{
+		Model:       cfg.Model,
+		System:      systemPrompt,
+		Messages:    []chatMessage{{Role: "user", Content: userPrompt}},
+		MaxTokens:   cfg.MaxTokens,
+		Temperature: cfg.Temperature,
+	}
+}
+
+// parseMessagesResponse returns the text of the first text content block,
+// normally content[0], and the token usage, nil when the response has none.
+func parseMessagesResponse(body []byte) (string, *Usage, error) {
+	var resp messagesResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	if resp.Error != nil {
+		return "", nil, &APIError{StatusCode: 200, Message: resp.Error.Message}
+	}
+	var usage *Usage
+	if u := resp.Usage; u != nil {
+		usage = &Usage{PromptTokens: u.InputTokens, CompletionTokens: u.OutputTokens, TotalTokens: u.InputTokens + u.OutputTokens}
+	}
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			return block.Text, usage, nil
+		}
+	}
+	return "", usage, ErrNoChoices
+}
@@ -4,36 +4,108 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
 )
 
+// Sampling temperature bounds accepted by the chat completions API.
+const (
+	DefaultTemperature = 0.2
+	MinTemperature     = 0.0
+	MaxTemperature     = 2.0
+)
+
+// Retry defaults: one retry after a one-second pause.
+const (
+	DefaultMaxRetries  = 1
+	DefaultBackoffBase = time.Second
+
+	// maxBackoff caps the doubled pause between attempts.
+	maxBackoff = time.Minute
+)
+
+// APIFormat selects the request and response shape the client speaks.
+type APIFormat string
+
+// Supported API formats.
+const (
+	// FormatOpenAI is the OpenAI-compatible chat completions API
+	// (POST {Endpoint}/chat/completions, Bearer auth), also served by most
+	// self-hosted gateways.
+	FormatOpenAI APIFormat = "openai"
+	// FormatAnthropic is Anthropic's messages API (POST {Endpoint}/messages,
+	// x-api-key and anthropic-version headers).
+	FormatAnthropic APIFormat = "anthropic"
+)
+
+// Per-format defaults used when Config leaves Endpoint or Model empty.
+const (
+	DefaultOpenAIEndpoint    = "https://api.openai.com/v1"
+	DefaultOpenAIModel       = "gpt-4o-mini"
+	DefaultAnthropicEndpoint = "https://api.anthropic.com/v1"
+	DefaultAnthropicModel    = "claude-3-5-haiku-latest"
+)
+
+// DefaultMaxTokens caps the length of a response. The Anthropic API requires
+// a limit; the OpenAI format sends none.
+const DefaultMaxTokens = 4096
+
+// ParseAPIFormat returns the APIFormat named s; "" means FormatOpenAI.
+func ParseAPIFormat(s string) (APIFormat, error) {
+	switch f := APIFormat(s); f {
+	case "":
+		return FormatOpenAI, nil
+	case FormatOpenAI, FormatAnthropic:
+		return f, nil
+	}
+	return "", fmt.Errorf("%w %q: want %s or %s", ErrUnsupportedFormat, s, FormatOpenAI, FormatAnthropic)
+}
+
 // Config holds LLM client configuration.
 type Config struct {
-	Endpoint string // API base URL (e.g., https://api.openai.com/v1)
-	APIKey   string
-	Model    string
-	Timeout  time.Duration
+	APIFormat   APIFormat // request/response shape; "" = FormatOpenAI
+	Endpoint    string    // API base URL (e.g., https://api.openai.com/v1); "" = the format's default
+	APIKey      string
+	Model       string  // "" = the format's default model
+	Temperature float64 // sampling temperature, clamped to [MinTemperature, MaxTemperature] (MaxAnthropicTemperature with FormatAnthropic); 0 = DefaultTemperature, negative = MinTemperature
+	MaxTokens   int     // response length limit, sent with FormatAnthropic; 0 = DefaultMaxTokens
+	Timeout     time.Duration
+	MaxRetries  int           // retries after a 429 or 5xx; 0 = DefaultMaxRetries, negative = none
+	BackoffBase time.Duration // pause before the first retry, doubled for each later one; 0 = DefaultBackoffBase
+	// Headers are added to every request after the auth headers, e.g.
+	// OpenAI-Organization or gateway routing headers. Content-Type cannot
+	// be set; NewClient drops it with a warning.
+	Headers map[string]string
 }
 
 // LogValue masks the API key when the config is logged via slog.
 func (c Config) LogValue() slog.Value {
 	return slog.GroupValue(
+		slog.String("api_format", string(c.APIFormat)),
 		slog.String("endpoint", c.Endpoint),
 		slog.String("model", c.Model),
+		slog.Float64("temperature", c.Temperature),
+		slog.Int("max_retries", c.MaxRetries),
+		slog.Duration("backoff_base", c.BackoffBase),
 		slog.String("api_key", "[REDACTED]"),
+		slog.Attr{Key: "headers", Value: headersLogValue(c.Headers)},
 	)
 }
 
-// Client speaks the OpenAI-compatible chat completions API.
+// Client speaks the OpenAI-compatible chat completions API or, with
+// FormatAnthropic, Anthropic's messages API. Retries and rate-limit handling
+// are the same for both.
 type Client struct {
 	cfg    Config
 	http   *http.Client
 	logger *slog.Logger
+	usage  usageCounter
 }
 
 // NewClient creates an LLM client with the given configuration.
@@ -41,11 +113,74 @@ func NewClient(cfg Config, logger *slog.Logger) *Client {
 	if cfg.Timeout == 0 {
 		cfg.Timeout = 30 * time.Second
 	}
+	switch {
+	case cfg.MaxRetries == 0:
+		cfg.MaxRetries = DefaultMaxRetries
+	case cfg.MaxRetries < 0:
+		cfg.MaxRetries = 0
+	}
+	if cfg.BackoffBase <= 0 {
+		cfg.BackoffBase = DefaultBackoffBase
+	}
+	if cfg.APIFormat == "" {
+		cfg.APIFormat = FormatOpenAI
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = DefaultOpenAIEndpoint
+		if cfg.APIFormat == FormatAnthropic {
+			cfg.Endpoint = DefaultAnthropicEndpoint
+		}
+	}
+	if cfg.Model == "" {
+		cfg.Model = DefaultOpenAIModel
+		if cfg.APIFormat == FormatAnthropic {
+			cfg.Model = DefaultAnthropicModel
+		}
+	}
+	if cfg.MaxTokens <= 0 {
+		cfg.MaxTokens = DefaultMaxTokens
+	}
+	logger = logger.With("component", "llm-client")
+	if cfg.Headers != nil {
+		headers := make(map[string]string, len(cfg.Headers))
+		for name, value := range cfg.Headers {
+			if err := checkHeader(name, value); err != nil {
+				logger.Warn("ignoring LLM header", "error", err)
+				continue
+			}
+			headers[http.CanonicalHeaderKey(name)] = value
+		}
+		cfg.Headers = headers
+	}
+	switch {
+	case cfg.Temperature == 0:
+		cfg.Temperature = DefaultTemperature
+	case cfg.Temperature < 0:
+		cfg.Temperature = MinTemperature
+	}
+	maxTemperature := MaxTemperature
+	if cfg.APIFormat == FormatAnthropic {
+		maxTemperature = MaxAnthropicTemperature
+	}
+	if t := clampTemperature(cfg.Temperature, maxTemperature); t != cfg.Temperature {
+		logger.Warn("LLM temperature out of range, clamping",
+			"requested", cfg.Temperature, "temperature", t)
+		cfg.Temperature = t
+	}
 	return &Client{
 		cfg:    cfg,
 		http:   &http.Client{Timeout: cfg.Timeout},
-		logger: logger.With("component", "llm-client"),
+		logger: logger,
+	}
+}
+
+// clampTemperature limits t to [MinTemperature, hi]; NaN becomes
+// DefaultTemperature.
+func clampTemperature(t, hi float64) float64 {
+	if math.IsNaN(t) {
+		return DefaultTemperature
 	}
+	return math.Min(math.Max(t, MinTemperature), hi)
 }
 
 // chatRequest is the OpenAI chat completions request body.
@@ -72,21 +207,45 @@ type chatResponse struct {
 			Content string `json:"content"`
 		} `json:"message"`
 	} `json:"choices"`
+	Usage *chatUsage `json:"usage,omitempty"`
 	Error *struct {
 		Message string `json:"message"`
 	} `json:"error,omitempty"`
 }
 
+// Config returns the effective configuration: cfg as passed to NewClient,
+// with defaults filled in and the temperature clamped.
+func (c *Client) Config() Config {
+	return c.cfg
+}
+
+// Usage returns the tokens consumed by the client's requests so far. It is
+// safe to call while requests are in flight.
+func (c *Client) Usage() Usage {
+	return c.usage.load()
+}
+
 // Complete sends a chat completion request and returns the raw JSON response content.
 func (c *Client) Complete(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
-	reqBody := chatRequest{
-		Model: c.cfg.Model,
-		Messages: []chatMessage{
-			{Role: "system", Content: systemPrompt},
-			{Role: "user", Content: userPrompt},
-		},
-		ResponseFormat: &responseFormat{Type: "json_object"},
-		Temperature:    0.2,
+	var reqBody any
+	var endpoint string
+	switch c.cfg.APIFormat {
+	case FormatOpenAI:
+		reqBody = chatRequest{
+			Model: c.cfg.Model,
+			Messages: []chatMessage{
+				{Role: "system", Content: systemPrompt},
+				{Role: "user", Content: userPrompt},
+			},
+			ResponseFormat: &responseFormat{Type: "json_object"},
+			Temperature:    c.cfg.Temperature,
+		}
+		endpoint = c.cfg.Endpoint + "/chat/completions"
+	case FormatAnthropic:
+		reqBody = newMessagesRequest(c.cfg, systemPrompt, userPrompt)
+		endpoint = c.cfg.Endpoint + "/messages"
+	default:
+		return "", fmt.Errorf("%w %q", ErrUnsupportedFormat, c.cfg.APIFormat)
 	}
 
 	data, err := json.Marshal(reqBody)
@@ -94,13 +253,22 @@ func (c *Client) Complete(ctx context.Context, systemPrompt, userPrompt string)
 		return "", fmt.Errorf("marshal request: %w", err)
 	}
 
-	endpoint := c.cfg.Endpoint + "/chat/completions"
-
-	// Try up to 2 times (initial + 1 retry on 5xx)
+	// Retry server errors and rate limits up to MaxRetries times
 	var lastErr error
-	for attempt := range 2 {
+	for attempt := range c.cfg.MaxRetries + 1 {
 		if attempt > 0 {
-			c.logger.Debug("retrying LLM request", "attempt", attempt+1)
+			wait := c.backoff(attempt)
+			// Respect Retry-After header if present
+			var re *serverError
+			if errors.As(lastErr, &re) && re.retryAfter > 0 {
+				wait = re.retryAfter
+			}
+			c.logger.Debug("retrying LLM request", "attempt", attempt+1, "wait", wait)
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
 		}
 
 		result, err := c.doRequest(ctx, endpoint, data)
@@ -113,24 +281,19 @@ func (c *Client) Complete(ctx context.Context, systemPrompt, userPrompt string)
 		if !isRetryable(err) {
 			return "", err
 		}
-
-		// Respect Retry-After header if present
-		if re, ok := err.(*serverError); ok && re.retryAfter > 0 {
-			select {
-			case <-time.After(re.retryAfter):
-			case <-ctx.Done():
-				return "", ctx.Err()
-			}
-		} else {
-			select {
-			case <-time.After(time.Second):
-			case <-ctx.Done():
-				return "", ctx.Err()
-			}
-		}
 	}
 
-	return "", fmt.Errorf("LLM request failed after retries: %w", lastErr)
+	return "", fmt.Errorf("%w: %w", ErrRetriesExhausted, lastErr)
+}
+
+// backoff returns the pause before the given retry (1-based): BackoffBase,
+// doubled for each retry after the first and capped at maxBackoff.
+func (c *Client) backoff(retry int) time.Duration {
+	wait := c.cfg.BackoffBase
+	for i := 1; i < retry && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxBackoff)
 }
 
 func (c *Client) doRequest(ctx context.Context, endpoint string, data []byte) (string, error) {
@@ -139,7 +302,15 @@ func (c *Client) doRequest(ctx context.Context, endpoint string, data []byte) (s
 		return "", fmt.Errorf("create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.cfg.APIKey)
+	if c.cfg.APIFormat == FormatAnthropic {
+		req.Header.Set("x-api-key", c.cfg.APIKey)
+		req.Header.Set("anthropic-version", AnthropicVersion)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.cfg.APIKey)
+	}
+	for name, value := range c.cfg.Headers {
+		req.Header.Set(name, value)
+	}
 
 	c.logger.Debug("sending LLM request", "endpoint", endpoint, "model", c.cfg.Model)
 
@@ -168,7 +339,19 @@ func (c *Client) doRequest(ctx context.Context, endpoint string, data []byte) (s
 
 	// Handle client errors (non-retryable)
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("LLM API error (status %d): %s", resp.StatusCode, string(body))
+		return "", &APIError{StatusCode: resp.StatusCode, Message: string(body)}
+	}
+
+	if c.cfg.APIFormat == FormatAnthropic {
+		content, usage, err := parseMessagesResponse(body)
+		if usage != nil || err == nil {
+			c.usage.add(usage)
+		}
+		if err != nil {
+			return "", err
+		}
+		c.logger.Debug("received LLM response", "length", len(content), "usage", usage)
+		return content, nil
 	}
 
 	var chatResp chatResponse
@@ -177,15 +360,25 @@ func (c *Client) doRequest(ctx context.Context, endpoint string, data []byte) (s
 	}
 
 	if chatResp.Error != nil {
-		return "", fmt.Errorf("LLM API error: %s", chatResp.Error.Message)
+		return "", &APIError{StatusCode: resp.StatusCode, Message: chatResp.Error.Message}
+	}
+
+	// Tokens are billed even when no choice came back
+	var usage *Usage
+	if u := chatResp.Usage; u != nil {
+		usage = &Usage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens, TotalTokens: u.TotalTokens}
 	}
 
 	if len(chatResp.Choices) == 0 {
-		return "", fmt.Errorf("LLM returned no choices")
+		if usage != nil {
+			c.usage.add(usage)
+		}
+		return "", ErrNoChoices
 	}
+	c.usage.add(usage)
 
 	content := chatResp.Choices[0].Message.Content
-	c.logger.Debug("received LLM response", "length", len(content))
+	c.logger.Debug("received LLM response", "length", len(content), "usage", usage)
 	return content, nil
 }
 
@@ -198,9 +391,20 @@ func (e *serverError) Error() string {
 	return fmt.Sprintf("server error: status %d", e.statusCode)
 }
 
+// Is reports whether the error matches ErrRateLimited (429) or ErrServerError (5xx).
+func (e *serverError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return e.statusCode == http.StatusTooManyRequests
+	case ErrServerError:
+		return e.statusCode >= 500
+	}
+	return false
+}
+
 func isRetryable(err error) bool {
-	_, ok := err.(*serverError)
-	return ok
+	var se *serverError
+	return errors.As(err, &se)
 }
 
 func parseRetryAfter(val string) time.Duration {
@@ -1,12 +1,14 @@
 package llm_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -90,6 +92,57 @@ func TestComplete_JSONMode(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestComplete_Temperature(t *testing.T) {
+	tests := []struct {
+		name string
+		in   float64
+		want float64
+	}{
+		{"default", llm.DefaultTemperature, 0.2},
+		{"in range", 0.7, 0.7},
+		{"unset", 0, llm.DefaultTemperature},
+		{"negative", -1, 0},
+		{"above max", 3.0, 2.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := mockServer(func(w http.ResponseWriter, r *http.Request) {
+				var req map[string]any
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+				assert.Equal(t, tt.want, req["temperature"])
+
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(chatResponse(`{}`))
+			})
+			defer server.Close()
+
+			client := llm.NewClient(llm.Config{
+				Endpoint:    server.URL,
+				APIKey:      "key",
+				Model:       "model",
+				Temperature: tt.in,
+			}, testLogger())
+
+			_, err := client.Complete(context.Background(), "sys", "usr")
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestNewClient_ClampWarns(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	llm.NewClient(llm.Config{Temperature: 3.0}, logger)
+	assert.Contains(t, buf.String(), "LLM temperature out of range")
+	assert.Contains(t, buf.String(), `"requested":3`)
+
+	buf.Reset()
+	llm.NewClient(llm.Config{Temperature: 1.0}, logger)
+	llm.NewClient(llm.Config{Temperature: -1}, logger)
+	assert.Empty(t, buf.String(), "a negative temperature asks for 0")
+}
+
 func TestComplete_ServerError_Retries(t *testing.T) {
 	var calls atomic.Int32
 	server := mockServer(func(w http.ResponseWriter, r *http.Request) {
@@ -117,24 +170,89 @@ func TestComplete_ServerError_Retries(t *testing.T) {
 }
 
 func TestComplete_ServerError_ExhaustedRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxRetries int
+		wantCalls  int32
+	}{
+		{"default", 0, 1 + llm.DefaultMaxRetries},
+		{"disabled", -1, 1},
+		{"three retries", 3, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := mockServer(func(w http.ResponseWriter, _ *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte("always failing"))
+			})
+			defer server.Close()
+
+			client := llm.NewClient(llm.Config{
+				Endpoint:    server.URL,
+				APIKey:      "key",
+				Model:       "model",
+				MaxRetries:  tt.maxRetries,
+				BackoffBase: time.Millisecond,
+			}, testLogger())
+
+			_, err := client.Complete(context.Background(), "sys", "usr")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "failed after retries")
+			assert.ErrorIs(t, err, llm.ErrRetriesExhausted)
+			assert.ErrorIs(t, err, llm.ErrServerError)
+			assert.NotErrorIs(t, err, llm.ErrRateLimited)
+			assert.Equal(t, tt.wantCalls, calls.Load())
+		})
+	}
+}
+
+func TestComplete_BackoffDoubles(t *testing.T) {
 	var calls atomic.Int32
 	server := mockServer(func(w http.ResponseWriter, _ *http.Request) {
 		calls.Add(1)
-		w.WriteHeader(http.StatusInternalServerError)
-		_, _ = w.Write([]byte("always failing"))
+		w.WriteHeader(http.StatusBadGateway)
 	})
 	defer server.Close()
 
 	client := llm.NewClient(llm.Config{
-		Endpoint: server.URL,
-		APIKey:   "key",
-		Model:    "model",
+		Endpoint:    server.URL,
+		APIKey:      "key",
+		Model:       "model",
+		MaxRetries:  3,
+		BackoffBase: 20 * time.Millisecond,
 	}, testLogger())
 
+	start := time.Now()
 	_, err := client.Complete(context.Background(), "sys", "usr")
+	require.ErrorIs(t, err, llm.ErrRetriesExhausted)
+	assert.Equal(t, int32(4), calls.Load())
+	// 20ms + 40ms + 80ms between the four attempts.
+	assert.GreaterOrEqual(t, time.Since(start), 140*time.Millisecond)
+}
+
+func TestComplete_BackoffHonorsContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := mockServer(func(w http.ResponseWriter, _ *http.Request) {
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer server.Close()
+
+	client := llm.NewClient(llm.Config{
+		Endpoint:    server.URL,
+		APIKey:      "key",
+		Model:       "model",
+		MaxRetries:  5,
+		BackoffBase: time.Minute,
+	}, testLogger())
+
+	start := time.Now()
+	_, err := client.Complete(ctx, "sys", "usr")
 	require.Error(t, err)
-	assert.Contains(t, err.Error(), "failed after retries")
-	assert.Equal(t, int32(2), calls.Load())
+	assert.Less(t, time.Since(start), 5*time.Second, "cancellation should interrupt the backoff sleep")
 }
 
 func TestComplete_ClientError_NoRetry(t *testing.T) {
@@ -155,6 +273,9 @@ func TestComplete_ClientError_NoRetry(t *testing.T) {
 	_, err := client.Complete(context.Background(), "sys", "usr")
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "status 400")
+	var apiErr *llm.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
 	assert.Equal(t, int32(1), calls.Load(), "should not retry on 4xx")
 }
 
@@ -212,6 +333,7 @@ func TestComplete_NoChoices(t *testing.T) {
 	_, err := client.Complete(context.Background(), "sys", "usr")
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "no choices")
+	assert.ErrorIs(t, err, llm.ErrNoChoices)
 }
 
 func TestComplete_APIError(t *testing.T) {
@@ -233,6 +355,9 @@ func TestComplete_APIError(t *testing.T) {
 	_, err := client.Complete(context.Background(), "sys", "usr")
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "quota exceeded")
+	var apiErr *llm.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "quota exceeded", apiErr.Message)
 }
 
 func TestComplete_ContextCanceled(t *testing.T) {
@@ -283,3 +408,210 @@ func TestComplete_RateLimitWithRetryAfter(t *testing.T) {
 	assert.Equal(t, `{"ok": true}`, result)
 	assert.Equal(t, int32(2), calls.Load())
 }
+
+func TestComplete_RateLimitExhausted(t *testing.T) {
+	server := mockServer(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte("slow down"))
+	})
+	defer server.Close()
+
+	client := llm.NewClient(llm.Config{
+		Endpoint: server.URL,
+		APIKey:   "key",
+		Model:    "model",
+	}, testLogger())
+
+	_, err := client.Complete(context.Background(), "sys", "usr")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, llm.ErrRateLimited)
+	assert.ErrorIs(t, err, llm.ErrRetriesExhausted)
+	assert.NotErrorIs(t, err, llm.ErrServerError)
+}
+
+func messagesResponse(text string) []byte {
+	data, _ := json.Marshal(map[string]any{
+		"type":    "message",
+		"role":    "assistant",
+		"content": []map[string]string{{"type": "text", "text": text}},
+	})
+	return data
+}
+
+func TestComplete_Anthropic(t *testing.T) {
+	server := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/messages", r.URL.Path)
+		assert.Equal(t, "test-key", r.Header.Get("x-api-key"))
+		assert.Equal(t, llm.AnthropicVersion, r.Header.Get("anthropic-version"))
+		assert.Empty(t, r.Header.Get("Authorization"))
+
+		var req map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "test-model", req["model"])
+		assert.Equal(t, "system", req["system"], "system prompt is a top-level field")
+		assert.Equal(t, []any{map[string]any{"role": "user", "content": "user"}}, req["messages"])
+		assert.InDelta(t, llm.DefaultMaxTokens, req["max_tokens"], 0)
+		assert.NotContains(t, req, "response_format")
+
+		_, _ = w.Write(messagesResponse(`{"result": "ok"}`))
+	})
+	defer server.Close()
+
+	client := llm.NewClient(llm.Config{
+		APIFormat: llm.FormatAnthropic,
+		Endpoint:  server.URL,
+		APIKey:    "test-key",
+		Model:     "test-model",
+	}, testLogger())
+
+	result, err := client.Complete(context.Background(), "system", "user")
+	require.NoError(t, err)
+	assert.Equal(t, `{"result": "ok"}`, result)
+}
+
+func TestUsage_Accumulates(t *testing.T) {
+	server := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"{}"}}],` +
+			`"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`))
+	})
+	defer server.Close()
+
+	client := llm.NewClient(llm.Config{Endpoint: server.URL, APIKey: "k"}, testLogger())
+	assert.Equal(t, llm.Usage{}, client.Usage())
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.Complete(context.Background(), "s", "u")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, llm.Usage{PromptTokens: 200, CompletionTokens: 100, TotalTokens: 300}, client.Usage())
+}
+
+func TestUsage_Missing(t *testing.T) {
+	server := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(chatResponse(`{}`))
+	})
+	defer server.Close()
+
+	client := llm.NewClient(llm.Config{Endpoint: server.URL, APIKey: "k"}, testLogger())
+	_, err := client.Complete(context.Background(), "s", "u")
+	require.NoError(t, err)
+	assert.Equal(t, llm.Usage{Unreported: 1}, client.Usage())
+}
+
+func TestUsage_Anthropic(t *testing.T) {
+	server := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"{}"}],"usage":{"input_tokens":7,"output_tokens":3}}`))
+	})
+	defer server.Close()
+
+	client := llm.NewClient(llm.Config{APIFormat: llm.FormatAnthropic, Endpoint: server.URL, APIKey: "k"}, testLogger())
+	_, err := client.Complete(context.Background(), "s", "u")
+	require.NoError(t, err)
+	assert.Equal(t, llm.Usage{PromptTokens: 7, CompletionTokens: 3, TotalTokens: 10}, client.Usage())
+}
+
+func TestUsage_Add(t *testing.T) {
+	sum := llm.Usage{PromptTokens: 1, CompletionTokens: 2, TotalTokens: 3}.Add(llm.Usage{PromptTokens: 4, TotalTokens: 4, Unreported: 1})
+	assert.Equal(t, llm.Usage{PromptTokens: 5, CompletionTokens: 2, TotalTokens: 7, Unreported: 1}, sum)
+}
+
+func TestComplete_AnthropicSharesRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := mockServer(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(529) // overloaded
+			return
+		}
+		_, _ = w.Write(messagesResponse(`{"retried": true}`))
+	})
+	defer server.Close()
+
+	client := llm.NewClient(llm.Config{
+		APIFormat:   llm.FormatAnthropic,
+		Endpoint:    server.URL,
+		APIKey:      "key",
+		BackoffBase: time.Millisecond,
+	}, testLogger())
+
+	result, err := client.Complete(context.Background(), "sys", "usr")
+	require.NoError(t, err)
+	assert.Equal(t, `{"retried": true}`, result)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestComplete_AnthropicNoText(t *testing.T) {
+	server := mockServer(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"type": "message", "content": []}`))
+	})
+	defer server.Close()
+
+	client := llm.NewClient(llm.Config{APIFormat: llm.FormatAnthropic, Endpoint: server.URL, APIKey: "key"}, testLogger())
+
+	_, err := client.Complete(context.Background(), "sys", "usr")
+	assert.ErrorIs(t, err, llm.ErrNoChoices)
+}
+
+func TestNewClient_AnthropicClampsTemperature(t *testing.T) {
+	var got float64
+	server := mockServer(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model       string  `json:"model"`
+			Temperature float64 `json:"temperature"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, llm.DefaultAnthropicModel, req.Model, "empty model selects the format's default")
+		got = req.Temperature
+		_, _ = w.Write(messagesResponse(`{}`))
+	})
+	defer server.Close()
+
+	client := llm.NewClient(llm.Config{
+		APIFormat:   llm.FormatAnthropic,
+		Endpoint:    server.URL,
+		APIKey:      "key",
+		Temperature: 1.5,
+	}, testLogger())
+
+	_, err := client.Complete(context.Background(), "sys", "usr")
+	require.NoError(t, err)
+	assert.InDelta(t, llm.MaxAnthropicTemperature, got, 0)
+}
+
+func TestParseAPIFormat(t *testing.T) {
+	for in, want := range map[string]llm.APIFormat{
+		"":          llm.FormatOpenAI,
+		"openai":    llm.FormatOpenAI,
+		"anthropic": llm.FormatAnthropic,
+	} {
+		got, err := llm.ParseAPIFormat(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	_, err := llm.ParseAPIFormat("gemini")
+	assert.ErrorIs(t, err, llm.ErrUnsupportedFormat)
+}
+
+func TestConfigLogValueMasksKey(t *testing.T) {
+	cfg := llm.Config{APIKey: "sk-s3cret", Endpoint: "https://llm.example.com", Model: "m"}
+	for name, newHandler := range map[string]func(*bytes.Buffer) slog.Handler{
+		"json": func(b *bytes.Buffer) slog.Handler { return slog.NewJSONHandler(b, nil) },
+		"text": func(b *bytes.Buffer) slog.Handler { return slog.NewTextHandler(b, nil) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			var logs bytes.Buffer
+			slog.New(newHandler(&logs)).With("request_id", "abc").Info("enrich", "config", cfg)
+			assert.NotContains(t, logs.String(), "sk-s3cret")
+			assert.Contains(t, logs.String(), "[REDACTED]")
+			assert.Contains(t, logs.String(), "https://llm.example.com")
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors returned (wrapped) by Client.Complete, ParseAPIFormat and
+// ParseHeaders. Check with errors.Is.
+var (
+	// ErrRateLimited means the API answered 429 Too Many Requests.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrServerError means the API answered with a 5xx status.
+	ErrServerError = errors.New("server error")
+	// ErrRetriesExhausted means every attempt failed with a retryable error.
+	ErrRetriesExhausted = errors.New("LLM request failed after retries")
+	// ErrNoChoices means the API response contained no completion choices
+	// (with FormatAnthropic, no text content block).
+	ErrNoChoices = errors.New("LLM returned no choices")
+	// ErrUnsupportedFormat means Config.APIFormat names no supported API.
+	ErrUnsupportedFormat = errors.New("unsupported LLM API format")
+	// ErrInvalidHeader means a custom header is malformed or would replace
+	// a protected one such as Content-Type.
+	ErrInvalidHeader = errors.New("invalid LLM header")
+)
+
+// APIError is a non-retryable error reported by the LLM API, either through
+// a 4xx status or an error object in the response body. Use errors.As to
+// inspect it.
+type APIError struct {
+	StatusCode int // HTTP status; 200 when the error came in the response body
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.StatusCode != 0 && e.StatusCode != 200 {
+		return fmt.Sprintf("LLM API error (status %d): %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("LLM API error: %s", e.Message)
+}
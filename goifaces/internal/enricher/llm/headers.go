@@ -0,0 +1,101 @@
+package llm
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// protectedHeaders are the headers Config.Headers cannot set: the request
+// body is always JSON.
+var protectedHeaders = map[string]bool{
+	"Content-Type": true,
+}
+
+// sensitiveHeaderWords mark a header name as carrying a credential.
+var sensitiveHeaderWords = []string{"auth", "key", "token", "secret", "password", "cookie", "session", "signature", "credential"}
+
+// sensitiveValuePrefixes mark a header value as a credential whatever the
+// header is called.
+var sensitiveValuePrefixes = []string{"bearer ", "basic ", "sk-"}
+
+// ParseHeaders parses a comma-separated list of name=value pairs, the format
+// of GOIFACES_LLM_HEADERS ("OpenAI-Organization=org-123,X-Route=eu"). Names
+// are canonicalized and blank entries skipped; a later pair for the same
+// name wins. Values cannot contain commas. A malformed pair, an invalid
+// name or a protected header such as Content-Type wraps ErrInvalidHeader.
+func ParseHeaders(list string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%w %q: want name=value", ErrInvalidHeader, entry)
+		}
+		if err := checkHeader(name, value); err != nil {
+			return nil, err
+		}
+		headers[http.CanonicalHeaderKey(name)] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
+
+// checkHeader rejects names that are not HTTP tokens, values with control
+// characters, and protected headers.
+func checkHeader(name, value string) error {
+	for _, r := range name {
+		if r > '~' || r <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r) {
+			return fmt.Errorf("%w: %q is not a valid header name", ErrInvalidHeader, name)
+		}
+	}
+	if strings.ContainsAny(value, "\r\n\x00") {
+		return fmt.Errorf("%w: value of %s contains a control character", ErrInvalidHeader, name)
+	}
+	if protectedHeaders[http.CanonicalHeaderKey(name)] {
+		return fmt.Errorf("%w: %s cannot be overridden", ErrInvalidHeader, http.CanonicalHeaderKey(name))
+	}
+	return nil
+}
+
+// isSensitiveHeader reports whether a header looks like it carries a
+// credential, by its name or its value, so LogValue masks it.
+func isSensitiveHeader(name, value string) bool {
+	lname := strings.ToLower(name)
+	for _, word := range sensitiveHeaderWords {
+		if strings.Contains(lname, word) {
+			return true
+		}
+	}
+	lvalue := strings.ToLower(strings.TrimSpace(value))
+	for _, prefix := range sensitiveValuePrefixes {
+		if strings.HasPrefix(lvalue, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// headersLogValue returns headers as a group sorted by name, with the values
+// of sensitive ones replaced by [REDACTED].
+func headersLogValue(headers map[string]string) slog.Value {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	attrs := make([]slog.Attr, 0, len(names))
+	for _, name := range names {
+		value := headers[name]
+		if isSensitiveHeader(name, value) {
+			value = "[REDACTED]"
+		}
+		attrs = append(attrs, slog.String(name, value))
+	}
+	return slog.GroupValue(attrs...)
+}
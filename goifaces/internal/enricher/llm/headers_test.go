@@ -0,0 +1,77 @@
+package llm_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/olehluchkiv/goifaces/internal/enricher/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := llm.ParseHeaders(" openai-organization=org-123 , X-Route=eu-west,, x-route=eu-central ")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"Openai-Organization": "org-123", "X-Route": "eu-central"}, headers)
+
+	headers, err = llm.ParseHeaders("")
+	require.NoError(t, err)
+	assert.Empty(t, headers)
+
+	for _, list := range []string{"X-Route", "=eu", "X Route=eu", "X-Route:=eu", "content-type=text/plain", "X-Route=eu\r\nX-Evil: 1"} {
+		_, err := llm.ParseHeaders(list)
+		assert.ErrorIs(t, err, llm.ErrInvalidHeader, list)
+	}
+}
+
+func TestComplete_CustomHeaders(t *testing.T) {
+	for _, format := range []llm.APIFormat{llm.FormatOpenAI, llm.FormatAnthropic} {
+		t.Run(string(format), func(t *testing.T) {
+			var got http.Header
+			server := mockServer(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Clone()
+				if format == llm.FormatAnthropic {
+					_, _ = w.Write(messagesResponse(`{}`))
+				} else {
+					_, _ = w.Write(chatResponse(`{}`))
+				}
+			})
+			defer server.Close()
+
+			client := llm.NewClient(llm.Config{
+				APIFormat: format,
+				Endpoint:  server.URL,
+				APIKey:    "test-key",
+				Headers: map[string]string{
+					"openai-organization": "org-123",
+					"X-Route":             "eu",
+					"content-type":        "text/plain",
+				},
+			}, testLogger())
+			assert.NotContains(t, client.Config().Headers, "Content-Type", "NewClient drops protected headers")
+
+			_, err := client.Complete(context.Background(), "s", "u")
+			require.NoError(t, err)
+			assert.Equal(t, "org-123", got.Get("OpenAI-Organization"))
+			assert.Equal(t, "eu", got.Get("X-Route"))
+			assert.Equal(t, "application/json", got.Get("Content-Type"))
+		})
+	}
+}
+
+func TestConfigLogValueMasksHeaders(t *testing.T) {
+	cfg := llm.Config{Headers: map[string]string{
+		"Openai-Organization": "org-123",
+		"X-Gateway-Token":     "gw-s3cret",
+		"X-Upstream":          "Bearer up-s3cret",
+	}}
+	var logs bytes.Buffer
+	slog.New(slog.NewTextHandler(&logs, nil)).Info("enrich", "config", cfg)
+	assert.Contains(t, logs.String(), "config.headers.Openai-Organization=org-123")
+	assert.Contains(t, logs.String(), "config.headers.X-Gateway-Token=[REDACTED]")
+	assert.Contains(t, logs.String(), "config.headers.X-Upstream=[REDACTED]")
+	assert.NotContains(t, logs.String(), "s3cret")
+}
@@ -0,0 +1,69 @@
+package llm
+
+import "sync/atomic"
+
+// Usage counts the tokens a Client's requests consumed, as reported by the
+// API.
+type Usage struct {
+	PromptTokens     int64 `json:"promptTokens"`
+	CompletionTokens int64 `json:"completionTokens"`
+	TotalTokens      int64 `json:"totalTokens"`
+	// Unreported counts successful responses without a usage object, whose
+	// tokens are missing from the totals. Some OpenAI-compatible backends
+	// never send one.
+	Unreported int64 `json:"unreported,omitempty"`
+}
+
+// Add returns the sum of u and o.
+func (u Usage) Add(o Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + o.PromptTokens,
+		CompletionTokens: u.CompletionTokens + o.CompletionTokens,
+		TotalTokens:      u.TotalTokens + o.TotalTokens,
+		Unreported:       u.Unreported + o.Unreported,
+	}
+}
+
+// chatUsage is the usage object of an OpenAI chat completions response.
+type chatUsage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
+
+// messagesUsage is the usage object of an Anthropic messages response, which
+// has no total.
+type messagesUsage struct {
+	InputTokens  int64 `json:"input_tokens"`
+	OutputTokens int64 `json:"output_tokens"`
+}
+
+// usageCounter accumulates Usage across concurrent requests.
+type usageCounter struct {
+	prompt, completion, total, unreported atomic.Int64
+}
+
+// add records the usage of one response; nil records a response without a
+// usage object. A missing total is taken to be prompt plus completion.
+func (c *usageCounter) add(u *Usage) {
+	if u == nil {
+		c.unreported.Add(1)
+		return
+	}
+	total := u.TotalTokens
+	if total == 0 {
+		total = u.PromptTokens + u.CompletionTokens
+	}
+	c.prompt.Add(u.PromptTokens)
+	c.completion.Add(u.CompletionTokens)
+	c.total.Add(total)
+}
+
+func (c *usageCounter) load() Usage {
+	return Usage{
+		PromptTokens:     c.prompt.Load(),
+		CompletionTokens: c.completion.Load(),
+		TotalTokens:      c.total.Load(),
+		Unreported:       c.unreported.Load(),
+	}
+}
@@ -376,6 +376,63 @@ func TestLLMScorer_Enrich(t *testing.T) {
 
 // --- Integration-style test: full pipeline with mock LLM ---
 
+// incidentalResult extends sampleResult with a PostgresRepo that also
+// implements error and fmt.Stringer, plus a type that only implements error.
+func incidentalResult() *analyzer.Result {
+	result := sampleResult()
+	errIface := analyzer.InterfaceDef{Name: "error", PkgPath: "builtin", PkgName: "builtin",
+		Methods: []analyzer.MethodSig{{Name: "Error", Signature: "Error() string"}}}
+	stringer := analyzer.InterfaceDef{Name: "Stringer", PkgPath: "fmt", PkgName: "fmt",
+		Methods: []analyzer.MethodSig{{Name: "String", Signature: "String() string"}}}
+	notFound := analyzer.TypeDef{Name: "NotFoundError", PkgPath: "example.com/app/store", PkgName: "store", IsStruct: true}
+	result.Interfaces = append(result.Interfaces, errIface, stringer)
+	result.Types = append(result.Types, notFound)
+	repo := &result.Types[0]
+	result.Relations = append(result.Relations,
+		analyzer.Relation{Type: repo, Interface: &result.Interfaces[1]},
+		analyzer.Relation{Type: repo, Interface: &result.Interfaces[2]},
+		analyzer.Relation{Type: &result.Types[1], Interface: &result.Interfaces[1]},
+	)
+	return result
+}
+
+func TestPruneByScore_DropsIncidentalRelations(t *testing.T) {
+	server := mockLLMServer(`{"scores": {"0": 0.9, "1": 0.1, "2": 0.2, "3": 0.15}}`)
+	defer server.Close()
+
+	result := incidentalResult()
+	scores := enricher.NewLLMScorer(bgCtx(), newTestClient(server.URL), enricher.NewDefaultScorer(), testLogger()).Score(result.Relations)
+	pruned := enricher.PruneByScore(result, scores, enricher.DefaultMinRelationScore)
+
+	require.Len(t, pruned.Relations, 1)
+	assert.Equal(t, "Repository", pruned.Relations[0].Interface.Name)
+	require.Len(t, pruned.Interfaces, 1, "error and Stringer are orphaned")
+	assert.Equal(t, "Repository", pruned.Interfaces[0].Name)
+	require.Len(t, pruned.Types, 1, "NotFoundError only implemented error")
+	assert.Equal(t, "PostgresRepo", pruned.Types[0].Name)
+	assert.Len(t, result.Relations, 4, "the input is not modified")
+}
+
+func TestReindexScores(t *testing.T) {
+	result := incidentalResult()
+	scores := map[int]float64{0: 0.9, 1: 0.1, 2: 0.5, 3: 0.15}
+	pruned := enricher.PruneByScore(result, scores, enricher.DefaultMinRelationScore)
+	require.Len(t, pruned.Relations, 2)
+
+	assert.Equal(t, map[int]float64{0: 0.9, 1: 0.5}, enricher.ReindexScores(result, pruned, scores))
+	assert.Equal(t, scores, enricher.ReindexScores(result, result, scores), "nothing pruned")
+	assert.Nil(t, enricher.ReindexScores(result, pruned, nil))
+}
+
+func TestPruneByScore_FallbackKeepsAll(t *testing.T) {
+	result := incidentalResult()
+	scores := enricher.NewDefaultScorer().Score(result.Relations)
+
+	assert.Same(t, result, enricher.PruneByScore(result, scores, enricher.DefaultMinRelationScore))
+	assert.Same(t, result, enricher.PruneByScore(result, nil, enricher.DefaultMinRelationScore), "no scorer ran")
+	assert.Same(t, result, enricher.PruneByScore(result, map[int]float64{1: 0.1}, 0), "threshold 0 disables pruning")
+}
+
 func TestLLMEnricherPipeline_WithMockServer(t *testing.T) {
 	ctx := context.Background()
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -32,12 +32,13 @@ Rules:
 type LLMGrouper struct {
 	ctx      context.Context
 	client   *llm.Client
-	fallback *DefaultGrouper
+	fallback Grouper
 	logger   *slog.Logger
 }
 
-// NewLLMGrouper creates an LLM-backed semantic grouper.
-func NewLLMGrouper(ctx context.Context, client *llm.Client, fallback *DefaultGrouper, logger *slog.Logger) *LLMGrouper {
+// NewLLMGrouper creates an LLM-backed semantic grouper. fallback answers when
+// the LLM call fails or returns nothing usable.
+func NewLLMGrouper(ctx context.Context, client *llm.Client, fallback Grouper, logger *slog.Logger) *LLMGrouper {
 	return &LLMGrouper{
 		ctx:      ctx,
 		client:   client,
@@ -103,7 +103,7 @@ func (s *LLMSimplifier) Simplify(result *analyzer.Result, maxNodes int) *analyze
 	}
 
 	// Filter result to only kept nodes
-	out := &analyzer.Result{}
+	out := &analyzer.Result{ModulePath: result.ModulePath, ReplacedModules: result.ReplacedModules}
 	for _, iface := range result.Interfaces {
 		if keep[iface.PkgPath+"."+iface.Name] {
 			out.Interfaces = append(out.Interfaces, iface)
@@ -121,5 +121,6 @@ func (s *LLMSimplifier) Simplify(result *analyzer.Result, maxNodes int) *analyze
 			out.Relations = append(out.Relations, rel)
 		}
 	}
+	out.Embeds = analyzer.EmbedsBetween(result.Embeds, out.Interfaces, out.Types)
 	return out
 }
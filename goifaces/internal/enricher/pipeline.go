@@ -0,0 +1,143 @@
+package enricher
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+)
+
+// DefaultConcurrency is the default number of analysis stages a Pipeline runs
+// at once.
+const DefaultConcurrency = 4
+
+// Enriched is the output of a Pipeline: the (possibly transformed) result plus
+// the side-channel contributions of the analysis stages. Keys follow the
+// "pkgPath.Name" convention used throughout the enrichers; Scores are keyed by
+// index into Result.Relations.
+type Enriched struct {
+	Result      *analyzer.Result
+	Groups      []SemanticGroup
+	Patterns    []DetectedPattern
+	Annotations map[string]string
+	Scores      map[int]float64
+}
+
+// PipelineOptions controls how a Pipeline runs its stages.
+type PipelineOptions struct {
+	Concurrency int // max analysis stages running at once; <= 0 means DefaultConcurrency
+}
+
+// Pipeline runs enrichers in two phases. Transforms (e.g. a Simplifier) run
+// sequentially, each receiving the previous one's output. The independent
+// analysis stages — Grouper, PatternDetector, Annotator and Scorer — then run
+// concurrently on the final result, bounded by Options.Concurrency, and their
+// outputs are merged into Enriched. Nil stages are skipped.
+type Pipeline struct {
+	Transforms      []Enricher
+	Grouper         Grouper
+	PatternDetector PatternDetector
+	Annotator       Annotator
+	Scorer          Scorer
+	Options         PipelineOptions
+	logger          *slog.Logger
+}
+
+// NewPipeline creates an empty pipeline; callers set the stages they need.
+func NewPipeline(opts PipelineOptions, logger *slog.Logger) *Pipeline {
+	return &Pipeline{
+		Options: opts,
+		logger:  logger.With("component", "enricher"),
+	}
+}
+
+// Run executes the pipeline. ctx bounds the analysis phase: when it is done,
+// Run stops waiting and leaves the outputs of unfinished stages empty. LLM
+// enrichers should be constructed with the same ctx so their requests are
+// cancelled at the same deadline.
+func (p *Pipeline) Run(ctx context.Context, result *analyzer.Result) *Enriched {
+	for _, t := range p.Transforms {
+		result = t.Enrich(result)
+	}
+	out := &Enriched{Result: result}
+
+	var stages []func()
+	var mu sync.Mutex
+	if p.Grouper != nil {
+		stages = append(stages, func() {
+			groups := p.Grouper.Group(result)
+			mu.Lock()
+			out.Groups = groups
+			mu.Unlock()
+		})
+	}
+	if p.PatternDetector != nil {
+		stages = append(stages, func() {
+			patterns := p.PatternDetector.Detect(result)
+			mu.Lock()
+			out.Patterns = patterns
+			mu.Unlock()
+		})
+	}
+	if p.Annotator != nil {
+		stages = append(stages, func() {
+			annotations := p.Annotator.Annotate(result)
+			mu.Lock()
+			out.Annotations = annotations
+			mu.Unlock()
+		})
+	}
+	if p.Scorer != nil {
+		stages = append(stages, func() {
+			scores := p.Scorer.Score(result.Relations)
+			mu.Lock()
+			out.Scores = scores
+			mu.Unlock()
+		})
+	}
+
+	limit := p.Options.Concurrency
+	if limit <= 0 {
+		limit = DefaultConcurrency
+	}
+	sem := make(chan struct{}, limit)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, stage := range stages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+			stage()
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		p.logger.Warn("enrichment deadline reached, using partial results", "error", ctx.Err())
+	}
+
+	// Snapshot under the lock: stages still running after a deadline must not
+	// race with the caller reading the output.
+	mu.Lock()
+	defer mu.Unlock()
+	snapshot := *out
+	p.logger.Info("enrichment complete",
+		"stages", len(stages),
+		"groups", len(snapshot.Groups),
+		"patterns", len(snapshot.Patterns),
+		"annotations", len(snapshot.Annotations),
+		"scores", len(snapshot.Scores))
+	return &snapshot
+}
@@ -0,0 +1,136 @@
+package enricher_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/olehluchkiv/goifaces/internal/enricher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowRoutingServer answers each enricher's prompt with a matching JSON
+// payload after delay, recording the peak number of in-flight requests.
+func slowRoutingServer(t *testing.T, delay time.Duration, peak *atomic.Int32) *httptest.Server {
+	var inFlight atomic.Int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+
+		var req map[string]any
+		if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&req)) {
+			return
+		}
+		userMsg := req["messages"].([]any)[1].(map[string]any)["content"].(string)
+
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+
+		var response string
+		switch {
+		case strings.Contains(userMsg, "architectural layers"):
+			response = `{"groups": [{"name": "Data Access", "interfaces": ["example.com/app/store.Repository"], "types": ["example.com/app/store.PostgresRepo"]}]}`
+		case strings.Contains(userMsg, "brief descriptions"):
+			response = `{"annotations": {"example.com/app/store.Repository": "Persists items"}}`
+		case strings.Contains(userMsg, "Score these"):
+			response = `{"scores": {"0": 0.8}}`
+		default:
+			response = `{}`
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(chatResponse(response))
+	}))
+}
+
+func newLLMPipeline(ctx context.Context, serverURL string, concurrency int) *enricher.Pipeline {
+	client := newTestClient(serverURL)
+	logger := testLogger()
+	p := enricher.NewPipeline(enricher.PipelineOptions{Concurrency: concurrency}, logger)
+	p.Transforms = []enricher.Enricher{
+		enricher.NewLLMSimplifier(ctx, client, enricher.NewDefaultSimplifier(), logger),
+	}
+	p.Grouper = enricher.NewLLMGrouper(ctx, client, enricher.NewDefaultGrouper(), logger)
+	p.Annotator = enricher.NewLLMAnnotator(ctx, client, enricher.NewDefaultAnnotator(), logger)
+	p.Scorer = enricher.NewLLMScorer(ctx, client, enricher.NewDefaultScorer(), logger)
+	return p
+}
+
+func TestPipeline_ConcurrentAggregation(t *testing.T) {
+	var peak atomic.Int32
+	server := slowRoutingServer(t, 300*time.Millisecond, &peak)
+	defer server.Close()
+
+	ctx := context.Background()
+	start := time.Now()
+	out := newLLMPipeline(ctx, server.URL, 4).Run(ctx, sampleResult())
+	elapsed := time.Since(start)
+
+	assert.Equal(t, int32(3), peak.Load(), "grouper, annotator and scorer should run concurrently")
+	assert.Less(t, elapsed, 800*time.Millisecond, "stages should overlap rather than run back to back")
+
+	require.NotNil(t, out.Result)
+	assert.Len(t, out.Result.Relations, 1)
+	require.Len(t, out.Groups, 1)
+	assert.Equal(t, "Data Access", out.Groups[0].Name)
+	assert.Equal(t, map[string]string{"example.com/app/store.Repository": "Persists items"}, out.Annotations)
+	assert.Equal(t, map[int]float64{0: 0.8}, out.Scores)
+	assert.Nil(t, out.Patterns, "stages that are not configured contribute nothing")
+}
+
+func TestPipeline_ConcurrencyLimit(t *testing.T) {
+	var peak atomic.Int32
+	server := slowRoutingServer(t, 50*time.Millisecond, &peak)
+	defer server.Close()
+
+	ctx := context.Background()
+	out := newLLMPipeline(ctx, server.URL, 1).Run(ctx, sampleResult())
+
+	assert.Equal(t, int32(1), peak.Load(), "concurrency 1 should serialize LLM calls")
+	assert.Len(t, out.Groups, 1)
+	assert.Len(t, out.Annotations, 1)
+	assert.Len(t, out.Scores, 1)
+}
+
+func TestPipeline_SharedDeadline(t *testing.T) {
+	var peak atomic.Int32
+	server := slowRoutingServer(t, 5*time.Second, &peak)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	out := newLLMPipeline(ctx, server.URL, 4).Run(ctx, sampleResult())
+
+	assert.Less(t, time.Since(start), 2*time.Second, "deadline should cut the pipeline short")
+	require.NotNil(t, out.Result)
+	assert.Len(t, out.Result.Interfaces, 1, "the result itself is never lost")
+}
+
+func TestPipeline_DefaultsOnly(t *testing.T) {
+	p := enricher.NewPipeline(enricher.PipelineOptions{}, testLogger())
+	p.Grouper = enricher.NewDefaultGrouper()
+	p.Scorer = enricher.NewDefaultScorer()
+
+	out := p.Run(context.Background(), sampleResult())
+
+	require.Len(t, out.Groups, 1)
+	assert.Equal(t, "store", out.Groups[0].Name)
+	assert.Equal(t, map[int]float64{0: 1.0}, out.Scores)
+	assert.Nil(t, out.Annotations)
+}
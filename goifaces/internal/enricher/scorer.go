@@ -23,3 +23,62 @@ func (s *DefaultScorer) Score(relations []analyzer.Relation) map[int]float64 {
 	}
 	return m
 }
+
+// DefaultMinRelationScore is the importance below which PruneByScore drops a
+// relation. The LLM scorer rates incidental implementations (error,
+// fmt.Stringer) 0.0-0.3.
+const DefaultMinRelationScore = 0.4
+
+// PruneByScore drops the relations of result whose score is below minScore,
+// then the interfaces and types left without any relation. scores is keyed
+// by index into result.Relations, as returned by Scorer.Score; relations
+// without a score are kept. A minScore <= 0, or no relation below it, returns
+// result unchanged, so the DefaultScorer's 1.0 weights never prune anything.
+func PruneByScore(result *analyzer.Result, scores map[int]float64, minScore float64) *analyzer.Result {
+	if minScore <= 0 || len(scores) == 0 {
+		return result
+	}
+	kept := make([]analyzer.Relation, 0, len(result.Relations))
+	for i, rel := range result.Relations {
+		if score, ok := scores[i]; ok && score < minScore {
+			continue
+		}
+		kept = append(kept, rel)
+	}
+	if len(kept) == len(result.Relations) {
+		return result
+	}
+	pruned := *result
+	pruned.Relations = kept
+	return analyzer.PruneOrphans(&pruned)
+}
+
+// ReindexScores re-keys scores, indexed into from.Relations, by index into
+// to.Relations, which must be a subsequence of from's as PruneByScore keeps
+// them. It returns scores itself when to is from.
+func ReindexScores(from, to *analyzer.Result, scores map[int]float64) map[int]float64 {
+	if from == to || len(scores) == 0 {
+		return scores
+	}
+	out := make(map[int]float64, len(to.Relations))
+	j := 0
+	for i, rel := range to.Relations {
+		for j < len(from.Relations) && !sameRelation(from.Relations[j], rel) {
+			j++
+		}
+		if j == len(from.Relations) {
+			break
+		}
+		if score, ok := scores[j]; ok {
+			out[i] = score
+		}
+		j++
+	}
+	return out
+}
+
+// sameRelation reports whether a and b relate the same type and interface
+// objects in the same way.
+func sameRelation(a, b analyzer.Relation) bool {
+	return a.Type == b.Type && a.Interface == b.Interface && a.ViaPointer == b.ViaPointer
+}
@@ -65,7 +65,7 @@ func (s *DefaultSimplifier) Simplify(result *analyzer.Result, maxNodes int) *ana
 	}
 
 	// Filter
-	out := &analyzer.Result{}
+	out := &analyzer.Result{ModulePath: result.ModulePath, ReplacedModules: result.ReplacedModules}
 	for _, iface := range result.Interfaces {
 		if keep[iface.PkgPath+"."+iface.Name] {
 			out.Interfaces = append(out.Interfaces, iface)
@@ -83,5 +83,6 @@ func (s *DefaultSimplifier) Simplify(result *analyzer.Result, maxNodes int) *ana
 			out.Relations = append(out.Relations, rel)
 		}
 	}
+	out.Embeds = analyzer.EmbedsBetween(result.Embeds, out.Interfaces, out.Types)
 	return out
 }
@@ -2,9 +2,13 @@ package internal_test
 
 import (
 	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
 	"testing"
@@ -80,7 +84,7 @@ func normalizeOutput(s string) string {
 				i++
 			}
 			blocks = append(blocks, strings.Join(block, "\n"))
-		} else if strings.Contains(trimmed, "..|>") || strings.Contains(trimmed, "--|>") {
+		} else if strings.Contains(trimmed, "..|>") || strings.Contains(trimmed, "--|>") || strings.Contains(trimmed, "..>") {
 			relations = append(relations, line)
 			i++
 		} else if strings.HasPrefix(trimmed, "cssClass ") || strings.HasPrefix(trimmed, "classDef ") {
@@ -188,7 +192,7 @@ func TestEndToEnd(t *testing.T) {
 			validate: func(t *testing.T, got string) {
 				assert.Contains(t, got, "db_Closer")
 				assert.Contains(t, got, "db_Connection")
-				assert.Contains(t, got, "db_Connection --|> db_Closer")
+				assert.Contains(t, got, "db_Connection --|> db_Closer : *")
 			},
 		},
 		{
@@ -254,6 +258,21 @@ func TestEndToEnd(t *testing.T) {
 				assert.NotContains(t, normalized, "--|>")
 			},
 		},
+		{
+			name: "08_empty_iface_markers",
+			dir:  testdataDir("08_empty_iface"),
+			opts: analyzer.AnalyzeOptions{IncludeMarkers: true},
+			validate: func(t *testing.T, got string) {
+				// Markers are shown, including aliases of any and interface{},
+				// but still without relations
+				for _, name := range []string{"Any", "Marker", "Value", "Payload"} {
+					assert.Contains(t, got, "class empty_"+name+" {\n        <<marker>>")
+				}
+				assert.NotContains(t, got, "empty_Number", "constraints are not markers")
+				assert.NotContains(t, got, "empty_Foo")
+				assert.NotContains(t, got, "--|>")
+			},
+		},
 		{
 			name: "09_unexported_default",
 			dir:  testdataDir("09_unexported"),
@@ -283,6 +302,22 @@ func TestEndToEnd(t *testing.T) {
 				assert.Contains(t, got, "internal_Cat --|> internal_Runner")
 			},
 		},
+		{
+			name: "09_unexported_public_interfaces",
+			dir:  testdataDir("09_unexported"),
+			opts: analyzer.AnalyzeOptions{PublicInterfaces: true},
+			validate: func(t *testing.T, got string) {
+				// Exported interface keeps its unexported implementer
+				assert.Contains(t, got, "internal_Runner")
+				assert.Contains(t, got, "internal_dog")
+				assert.Contains(t, got, "internal_Cat")
+				assert.Contains(t, got, "internal_dog --|> internal_Runner")
+				assert.Contains(t, got, "internal_Cat --|> internal_Runner")
+				// Unexported interface is dropped
+				assert.NotContains(t, got, "internal_walker")
+				assert.NotContains(t, got, "internal_dog --|> internal_walker")
+			},
+		},
 		{
 			name: "10_diamond",
 			dir:  testdataDir("10_diamond"),
@@ -298,6 +333,27 @@ func TestEndToEnd(t *testing.T) {
 				assert.Contains(t, got, "diamond_DB --|> diamond_Persister")
 			},
 		},
+		{
+			name: "12_func_type",
+			dir:  testdataDir("12_func_type"),
+			opts: analyzer.AnalyzeOptions{},
+			validate: func(t *testing.T, got string) {
+				assert.Contains(t, got, "handler_Handler")
+				assert.Contains(t, got, "handler_HandlerFunc --|> handler_Handler")
+				assert.Contains(t, got, "handler_Mux --|> handler_Handler")
+				assert.Contains(t, got, "class handler_HandlerFunc {\n        <<func>>")
+				assert.NotContains(t, got, "class handler_Mux {\n        <<func>>")
+			},
+		},
+		{
+			name: "12_func_type_excluded",
+			dir:  testdataDir("12_func_type"),
+			opts: analyzer.AnalyzeOptions{ExcludeFuncTypes: true},
+			validate: func(t *testing.T, got string) {
+				assert.Contains(t, got, "handler_Mux --|> handler_Handler")
+				assert.NotContains(t, got, "handler_HandlerFunc")
+			},
+		},
 		{
 			name: "11_source_file_path",
 			dir:  testdataDir("01_single_iface"),
@@ -322,8 +378,102 @@ func TestEndToEnd(t *testing.T) {
 	}
 }
 
-func TestHubAndSpokeSlides(t *testing.T) {
-	// Build synthetic go-memdb-like data: 4 hub interfaces, 12 types, 38 relations
+func TestAnalyzeErrNoPackages(t *testing.T) {
+	t.Run("empty module", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/empty\n\ngo 1.21\n"), 0o644))
+
+		_, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+		require.Error(t, err)
+		assert.ErrorIs(t, err, analyzer.ErrNoPackages)
+		assert.Contains(t, err.Error(), "no Go packages found")
+	})
+	t.Run("no go.mod", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# not Go\n"), 0o644))
+
+		result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+		assert.ErrorIs(t, err, analyzer.ErrNoPackages)
+		assert.Nil(t, result)
+	})
+}
+
+func TestAnalyzeReplacedModule(t *testing.T) {
+	// app/ requires example.com/lib, replaced with the sibling directory ../lib.
+	root := t.TempDir()
+	files := map[string]string{
+		"lib/go.mod":    "module example.com/lib\n\ngo 1.21\n",
+		"lib/logger.go": "package lib\n\ntype Logger interface {\n\tLog(msg string)\n}\n",
+		"app/go.mod": "module example.com/app\n\ngo 1.21\n\n" +
+			"require example.com/lib v0.0.0\n\nreplace example.com/lib => ../lib\n",
+		"app/svc/svc.go": "package svc\n\nimport \"example.com/lib\"\n\n" +
+			"type ConsoleLogger struct{}\n\nfunc (ConsoleLogger) Log(string) {}\n\nvar _ lib.Logger = ConsoleLogger{}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	opts := analyzer.AnalyzeOptions{}
+	result, err := analyzer.Analyze(context.Background(), filepath.Join(root, "app"), opts, testLogger())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"example.com/lib": "../lib"}, result.ReplacedModules)
+
+	filtered := analyzer.Filter(result, opts)
+	require.Len(t, filtered.Relations, 1, "replaced module interfaces count as local")
+	assert.Equal(t, "Logger", filtered.Relations[0].Interface.Name)
+	assert.Equal(t, "ConsoleLogger", filtered.Relations[0].Type.Name)
+	assert.Equal(t, "example.com/lib/logger.go", filtered.Relations[0].Interface.SourceFile)
+	assert.Equal(t, filepath.Join("svc", "svc.go"), filtered.Relations[0].Type.SourceFile)
+
+	nodes := diagram.PreparePackageMapData(filtered)
+	var lib *diagram.PackageMapNode
+	for _, n := range nodes {
+		if n.PkgPath == "example.com/lib" {
+			lib = n
+		} else {
+			assert.False(t, n.Replaced, "node %s", n.Name)
+		}
+	}
+	require.NotNil(t, lib)
+	assert.Equal(t, "example.com/lib (replaced)", lib.Name)
+	assert.True(t, lib.Replaced)
+
+	mermaid := diagram.GeneratePackageMapMermaid(filtered, diagram.DiagramOptions{})
+	assert.Contains(t, mermaid, "example.com/lib (replaced)")
+}
+
+func TestShowProduces(t *testing.T) {
+	ctx := context.Background()
+	logger := testLogger()
+	opts := analyzer.AnalyzeOptions{}
+
+	result, err := analyzer.Analyze(ctx, testdataDir("11_produces"), opts, logger)
+	require.NoError(t, err)
+	filtered := analyzer.Filter(result, opts)
+
+	// Factory.Make returns *Gadget, which is in the result set via Named.
+	var factory *analyzer.InterfaceDef
+	for i := range filtered.Interfaces {
+		if filtered.Interfaces[i].Name == "Factory" {
+			factory = &filtered.Interfaces[i]
+		}
+	}
+	require.NotNil(t, factory)
+	assert.Equal(t, []string{"example.com/testmod.Gadget"}, factory.Produces)
+
+	withEdges := diagram.GenerateMermaid(filtered, diagram.DiagramOptions{ShowProduces: true})
+	assert.Contains(t, withEdges, "factory_Factory ..> factory_Gadget : produces")
+	assert.Contains(t, withEdges, "+Make() *factory.Gadget", "method line keeps the return type")
+
+	withoutEdges := diagram.GenerateMermaid(filtered, diagram.DiagramOptions{})
+	assert.NotContains(t, withoutEdges, "..>")
+}
+
+// memdbLikeResult builds synthetic go-memdb-like data: 5 interfaces
+// (4 of them hubs), 12 types and 38 relations.
+func memdbLikeResult() *analyzer.Result {
 	pkg := "memdb"
 	makeIface := func(name string) analyzer.InterfaceDef {
 		return analyzer.InterfaceDef{Name: name, PkgPath: pkg, PkgName: pkg}
@@ -384,11 +534,15 @@ func TestHubAndSpokeSlides(t *testing.T) {
 		Interface: ifaceMap["ResultIterator"],
 	})
 
-	result := &analyzer.Result{
+	return &analyzer.Result{
 		Interfaces: ifaces,
 		Types:      types,
 		Relations:  rels,
 	}
+}
+
+func TestHubAndSpokeSlides(t *testing.T) {
+	result := memdbLikeResult()
 
 	diagOpts := diagram.DiagramOptions{MaxMethodsPerBox: 5}
 	splitter := split.NewHubAndSpoke(split.Options{HubThreshold: 3, ChunkSize: 3})
@@ -466,6 +620,85 @@ func TestHubAndSpokeSlides(t *testing.T) {
 	assert.Contains(t, pkgMap, "classDef pkgColor0", "package map should define color classes")
 }
 
+func TestMarkdownBook(t *testing.T) {
+	result := memdbLikeResult()
+	diagOpts := diagram.DiagramOptions{MaxMethodsPerBox: 5}
+	splitter := split.NewHubAndSpoke(split.Options{HubThreshold: 3, ChunkSize: 3})
+	slides := diagram.BuildSlides(result, diagOpts, splitter, diagram.SlideOptions{Threshold: 20})
+	require.Equal(t, 5, len(slides))
+
+	pages := diagram.BuildBook(slides)
+	dir := filepath.Join(t.TempDir(), "book")
+	require.NoError(t, diagram.WriteBook(dir, pages))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Equal(t, []string{
+		"01-boolfieldindex-compoundindex-compoundmultiindex.md",
+		"02-conditionalindex-fieldsetindex-filteriterator.md",
+		"03-intfieldindex-stringfieldindex-stringmapfieldindex.md",
+		"04-stringslicefieldindex-uuidfieldindex-uintfieldinde.md",
+		"index.md",
+	}, names)
+
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	// Index holds the package map and a table of contents.
+	index := read("index.md")
+	assert.Contains(t, index, "## Package Map\n\n```mermaid\nflowchart LR")
+	assert.Contains(t, index, "1. [BoolFieldIndex, CompoundIndex, CompoundMultiIndex](01-boolfieldindex-compoundindex-compoundmultiindex.md)")
+	assert.Contains(t, index, "4. [StringSliceFieldIndex, UUIDFieldIndex, UintFieldIndex](04-stringslicefieldindex-uuidfieldindex-uintfieldinde.md)")
+	assert.Contains(t, index, "[Next →](01-boolfieldindex-compoundindex-compoundmultiindex.md)")
+
+	// First page links back to the index, middle pages link both ways, last has no next.
+	first := read("01-boolfieldindex-compoundindex-compoundmultiindex.md")
+	assert.Contains(t, first, "```mermaid\nclassDiagram")
+	assert.Contains(t, first, "[← Previous](index.md)")
+	assert.Contains(t, first, "[Next →](02-conditionalindex-fieldsetindex-filteriterator.md)")
+
+	middle := read("02-conditionalindex-fieldsetindex-filteriterator.md")
+	assert.Contains(t, middle, "[← Previous](01-boolfieldindex-compoundindex-compoundmultiindex.md)")
+	assert.Contains(t, middle, "[Next →](03-intfieldindex-stringfieldindex-stringmapfieldindex.md)")
+
+	last := read("04-stringslicefieldindex-uuidfieldindex-uintfieldinde.md")
+	assert.Contains(t, last, "[← Previous](03-intfieldindex-stringfieldindex-stringmapfieldindex.md)")
+	assert.Contains(t, last, "[Index](index.md)")
+	assert.NotContains(t, last, "Next →")
+}
+
+func TestSlideDeck(t *testing.T) {
+	result := memdbLikeResult()
+	diagOpts := diagram.DiagramOptions{MaxMethodsPerBox: 5}
+	slides := diagram.BuildSlides(result, diagOpts, split.NewHubAndSpoke(split.Options{HubThreshold: 3, ChunkSize: 3}), diagram.SlideOptions{Threshold: 20})
+	require.Equal(t, 5, len(slides))
+
+	deck := diagram.BuildSlideDeck(slides)
+	assert.True(t, strings.HasPrefix(deck, "# Architecture\n\n## 1. Package Map\n\n```mermaid\n"))
+	assert.Equal(t, len(slides), strings.Count(deck, "```mermaid\n"), "one Mermaid block per slide")
+	assert.Equal(t, len(slides)-1, strings.Count(deck, "\n---\n"), "slides are separated by rules")
+	assert.Contains(t, deck, "\n## 2. "+slides[1].Title+"\n")
+
+	// A larger chunk size yields fewer detail slides.
+	wide := diagram.BuildSlides(result, diagOpts, split.NewHubAndSpoke(split.Options{HubThreshold: 3, ChunkSize: 6}), diagram.SlideOptions{Threshold: 20})
+	assert.Less(t, len(wide), len(slides))
+
+	// Non-positive options fall back to the defaults.
+	defaulted := diagram.BuildSlides(result, diagOpts, split.NewHubAndSpoke(split.Options{HubThreshold: -1}), diagram.SlideOptions{Threshold: 20})
+	assert.Equal(t, slides, defaulted)
+
+	// Text slides are written as-is.
+	text := diagram.BuildSlideDeck([]diagram.Slide{{Title: "Package Map", Text: "- app\n"}})
+	assert.Equal(t, "# Architecture\n\n## 1. Package Map\n\n- app\n", text)
+}
+
 func TestPackageMapMultiPackage(t *testing.T) {
 	// Create a result with types from multiple packages
 	ifaces := []analyzer.InterfaceDef{
@@ -879,6 +1112,786 @@ func TestPrepareInteractiveData(t *testing.T) {
 	assert.Equal(t, "test_MyIface", data.Relations[0].InterfaceID)
 }
 
+func TestMethodCountBadge(t *testing.T) {
+	big := analyzer.InterfaceDef{Name: "Store", PkgPath: "example.com/app/store", PkgName: "store",
+		Methods: []analyzer.MethodSig{
+			{Name: "Get", Signature: "Get(id string) error"},
+			{Name: "Put", Signature: "Put(id string) error"},
+			{Name: "Delete", Signature: "Delete(id string) error"},
+		}}
+	small := analyzer.InterfaceDef{Name: "Closer", PkgPath: "example.com/app/store", PkgName: "store",
+		Methods: []analyzer.MethodSig{{Name: "Close", Signature: "Close() error"}}}
+	result := &analyzer.Result{Interfaces: []analyzer.InterfaceDef{big, small}}
+	opts := diagram.DiagramOptions{MaxMethodsPerBox: 2}
+
+	got := diagram.GenerateMermaid(result, opts)
+	assert.Contains(t, got, "        +Put(id string) error\n        ... (3 total)\n    }")
+	assert.Contains(t, got, "        +Close() error\n    }", "boxes within the limit are unchanged")
+	assert.Equal(t, 1, strings.Count(got, "..."))
+
+	data := diagram.PrepareInteractiveData(result, opts)
+	require.Len(t, data.Interfaces, 2)
+	for _, iface := range data.Interfaces {
+		switch iface.Name {
+		case "store.Store":
+			assert.Len(t, iface.Methods, 2)
+			assert.Equal(t, 3, iface.MethodCount)
+		case "store.Closer":
+			assert.Len(t, iface.Methods, 1)
+			assert.Equal(t, 1, iface.MethodCount)
+		}
+	}
+}
+
+func TestGenerateMermaidAnnotationNotes(t *testing.T) {
+	dir := testdataDir("03_multi_iface")
+	result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+	result = analyzer.Filter(result, analyzer.AnalyzeOptions{})
+
+	plain := diagram.GenerateMermaid(result, diagram.DefaultDiagramOptions())
+	assert.NotContains(t, plain, "note for")
+
+	opts := diagram.DefaultDiagramOptions()
+	opts.Annotations = map[string]string{
+		"example.com/testmod.Reader":   "Reads \"raw\" bytes\nby key",
+		"example.com/testmod.MemStore": "  In-memory\tstore  ",
+		"example.com/testmod.Writer":   " \n ",
+		"example.com/other.Gone":       "not in the diagram",
+	}
+	got := diagram.GenerateMermaid(result, opts)
+	assert.Contains(t, got, "\n\n    note for store_Reader \"Reads 'raw' bytes by key\"\n    note for store_MemStore \"In-memory store\"\n")
+	assert.Equal(t, 2, strings.Count(got, "note for"), "blank and unknown annotations are skipped")
+
+	// Everything else is unchanged.
+	var withoutNotes []string
+	for _, line := range strings.Split(got, "\n") {
+		if !strings.HasPrefix(line, "    note for ") {
+			withoutNotes = append(withoutNotes, line)
+		}
+	}
+	assert.Equal(t, plain, strings.Replace(strings.Join(withoutNotes, "\n"), "\n\n\n", "\n\n", 1))
+
+	opts.QualifiedIDs = true
+	assert.Contains(t, diagram.GenerateMermaid(result, opts), "    note for example_com_testmod_Reader ")
+}
+
+func TestPrepareInteractiveDataAnnotations(t *testing.T) {
+	iface := analyzer.InterfaceDef{Name: "Store", PkgPath: "example.com/store", PkgName: "store"}
+	typ := analyzer.TypeDef{Name: "Mem", PkgPath: "example.com/store", PkgName: "store"}
+	other := analyzer.TypeDef{Name: "Disk", PkgPath: "example.com/store", PkgName: "store"}
+	result := &analyzer.Result{
+		Interfaces: []analyzer.InterfaceDef{iface},
+		Types:      []analyzer.TypeDef{typ, other},
+	}
+	opts := diagram.DefaultDiagramOptions()
+	opts.Annotations = map[string]string{
+		"example.com/store.Store": "Persists \"items\"\n",
+		"example.com/store.Mem":   "In-memory store",
+	}
+
+	data := diagram.PrepareInteractiveData(result, opts)
+	assert.Equal(t, `Persists "items"`, data.Interfaces[0].Annotation)
+	assert.Equal(t, "", data.Types[0].Annotation, "store.Disk has no annotation")
+	assert.Equal(t, "In-memory store", data.Types[1].Annotation)
+
+	raw, err := json.Marshal(data.Types[0])
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "annotation")
+}
+
+func TestPrepareInteractiveDataFuncTypes(t *testing.T) {
+	dir := testdataDir("12_func_type")
+	result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+	result = analyzer.Filter(result, analyzer.AnalyzeOptions{})
+
+	data := diagram.PrepareInteractiveData(result, diagram.DefaultDiagramOptions())
+	isFunc := make(map[string]bool)
+	for _, typ := range data.Types {
+		isFunc[typ.ID] = typ.IsFunc
+	}
+	assert.Equal(t, map[string]bool{"handler_HandlerFunc": true, "handler_Mux": false}, isFunc)
+	assert.Contains(t, data.Relations, diagram.InteractiveRelation{TypeID: "handler_HandlerFunc", InterfaceID: "handler_Handler", Methods: []string{"ServeHTTP"}},
+		"method-set matching links the func type to its interface")
+}
+
+func TestAnalyzeProgress(t *testing.T) {
+	type call struct {
+		phase       string
+		done, total int
+	}
+	var calls []call
+	opts := analyzer.AnalyzeOptions{Progress: func(phase string, done, total int) {
+		calls = append(calls, call{phase, done, total})
+	}}
+	result, err := analyzer.Analyze(context.Background(), testdataDir("03_multi_iface"), opts, testLogger())
+	require.NoError(t, err)
+
+	var phases []string
+	for i, c := range calls {
+		if c.done == 0 {
+			phases = append(phases, c.phase)
+		} else {
+			assert.Equal(t, calls[i-1].phase, c.phase, "phases do not interleave")
+			assert.Greater(t, c.done, calls[i-1].done)
+		}
+		assert.LessOrEqual(t, c.done, c.total)
+	}
+	assert.Equal(t, []string{analyzer.PhaseLoading, analyzer.PhaseCollecting, analyzer.PhaseMatching}, phases)
+
+	last := calls[len(calls)-1]
+	assert.Equal(t, analyzer.PhaseMatching, last.phase)
+	assert.Equal(t, len(result.Types), last.total, "every type is matched once")
+	assert.Equal(t, last.total, last.done, "matching reports completion")
+}
+
+func TestStdlibInterfacesFromImports(t *testing.T) {
+	dir := testdataDir("15_stdlib_imports")
+	result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{IncludeStdlib: true}, testLogger())
+	require.NoError(t, err)
+
+	ifacePkgs := make(map[string]bool)
+	for _, iface := range result.Interfaces {
+		ifacePkgs[iface.PkgPath] = true
+		assert.NotContains(t, iface.PkgPath, "internal", "internal stdlib packages cannot be named by user code")
+	}
+	assert.True(t, ifacePkgs["sort"], "imported directly")
+	assert.True(t, ifacePkgs["flag"], "imported directly")
+	assert.True(t, ifacePkgs["fmt"], "baseline")
+	assert.True(t, ifacePkgs["io"], "baseline")
+	assert.False(t, ifacePkgs["hash"], "nothing imports hash")
+	assert.False(t, ifacePkgs["encoding/json"], "no longer loaded unconditionally")
+
+	rels := make(map[string]bool)
+	for _, rel := range result.Relations {
+		rels[fmt.Sprintf("%s -> %s.%s (ptr=%t)", rel.Type.Name, rel.Interface.PkgPath, rel.Interface.Name, rel.ViaPointer)] = true
+	}
+	assert.True(t, rels["ByLen -> sort.Interface (ptr=false)"])
+	assert.True(t, rels["Level -> flag.Value (ptr=true)"])
+	assert.True(t, rels["Level -> fmt.Stringer (ptr=true)"])
+	assert.True(t, rels["Checksum -> io.Writer (ptr=false)"])
+
+	for _, typ := range result.Types {
+		assert.Equal(t, "example.com/testmod", typ.PkgPath, "stdlib packages contribute interfaces only")
+	}
+}
+
+func TestTypeAliases(t *testing.T) {
+	dir := testdataDir("14_type_alias")
+	result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+
+	aliases := make(map[string]string)
+	for _, typ := range result.Types {
+		if typ.AliasOf != "" {
+			aliases[typ.Name] = typ.AliasOf
+			assert.Nil(t, typ.TypeObj)
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"Cache":      "example.com/testmod.Mem",
+		"Repository": "example.com/testmod.Store",
+		"memAlias":   "example.com/testmod.Mem",
+	}, aliases, "aliases of unnamed types (Bytes) have no target")
+	for _, iface := range result.Interfaces {
+		assert.NotEqual(t, "Repository", iface.Name, "an alias of an interface is not a new interface")
+	}
+	assert.Equal(t, []string{"Mem -> Store (ptr=false)"}, relationKeys(result),
+		"aliases add no implementation relations")
+
+	filtered := analyzer.Filter(result, analyzer.AnalyzeOptions{})
+	var names []string
+	for _, typ := range filtered.Types {
+		names = append(names, typ.Name)
+	}
+	assert.ElementsMatch(t, []string{"Mem", "Cache", "Repository"}, names,
+		"aliases follow their target through Filter; unexported ones are dropped")
+	assert.Len(t, analyzer.PruneOrphans(filtered).Types, 3, "PruneOrphans keeps aliases of surviving nodes")
+
+	got := diagram.GenerateMermaid(filtered, diagram.DefaultDiagramOptions())
+	assert.Contains(t, got, "class store_Cache {\n        <<alias>>")
+	assert.Contains(t, got, "class store_Repository {\n        <<alias>>")
+	assert.NotContains(t, got, "class store_Repository {\n        <<interface>>")
+	assert.Contains(t, got, "store_Cache .. store_Mem : alias")
+	assert.Contains(t, got, "store_Repository .. store_Store : alias")
+	assert.NotContains(t, got, "store_Cache --|>")
+	assert.NotContains(t, got, "store_Repository --|>")
+
+	// The alias survives a JSON round trip.
+	raw, err := analyzer.MarshalResult(filtered)
+	require.NoError(t, err)
+	back, err := analyzer.UnmarshalResult(raw)
+	require.NoError(t, err)
+	assert.Equal(t, got, diagram.GenerateMermaid(back, diagram.DefaultDiagramOptions()))
+}
+
+func TestPrepareInteractiveDataIDCollision(t *testing.T) {
+	// "my-pkg" and "my_pkg" both sanitize to "my_pkg", so the interface and
+	// the type would share the ID "my_pkg_Store" without disambiguation.
+	iface := analyzer.InterfaceDef{Name: "Store", PkgPath: "example.com/my-pkg", PkgName: "my-pkg"}
+	typ := analyzer.TypeDef{Name: "Store", PkgPath: "example.com/my_pkg", PkgName: "my_pkg"}
+	other := analyzer.TypeDef{Name: "Cache", PkgPath: "example.com/my_pkg", PkgName: "my_pkg"}
+	result := &analyzer.Result{
+		Interfaces: []analyzer.InterfaceDef{iface},
+		Types:      []analyzer.TypeDef{typ, other},
+		Relations: []analyzer.Relation{
+			{Type: &typ, Interface: &iface},
+			{Type: &other, Interface: &iface},
+		},
+	}
+
+	data := diagram.PrepareInteractiveData(result, diagram.DefaultDiagramOptions())
+
+	require.Len(t, data.Interfaces, 1)
+	require.Len(t, data.Types, 2)
+	assert.Equal(t, "i_my_pkg_Store", data.Interfaces[0].ID)
+	assert.Equal(t, "my_pkg_Cache", data.Types[0].ID, "non-colliding IDs stay unprefixed")
+	assert.Equal(t, "t_my_pkg_Store", data.Types[1].ID)
+	assert.NotEqual(t, data.Interfaces[0].ID, data.Types[1].ID)
+
+	require.Len(t, data.Relations, 2)
+	assert.Equal(t, "my_pkg_Cache", data.Relations[0].TypeID)
+	assert.Equal(t, "t_my_pkg_Store", data.Relations[1].TypeID)
+	assert.Equal(t, "i_my_pkg_Store", data.Relations[1].InterfaceID)
+
+	// Deterministic across runs
+	again := diagram.PrepareInteractiveData(result, diagram.DefaultDiagramOptions())
+	assert.Equal(t, data, again)
+}
+
+func TestPrepareInteractiveDataMetrics(t *testing.T) {
+	// The interface and a type collide as in the test above; the metrics
+	// must follow the disambiguated IDs.
+	iface := analyzer.InterfaceDef{Name: "Store", PkgPath: "example.com/my-pkg", PkgName: "my-pkg"}
+	errIface := analyzer.InterfaceDef{Name: "error", PkgPath: "builtin", PkgName: "builtin"}
+	typ := analyzer.TypeDef{Name: "Store", PkgPath: "example.com/my_pkg", PkgName: "my_pkg"}
+	other := analyzer.TypeDef{Name: "Cache", PkgPath: "example.com/my_pkg", PkgName: "my_pkg"}
+	result := &analyzer.Result{
+		Interfaces: []analyzer.InterfaceDef{iface, errIface},
+		Types:      []analyzer.TypeDef{typ, other},
+		Relations: []analyzer.Relation{
+			{Type: &typ, Interface: &iface},
+			{Type: &other, Interface: &iface},
+			{Type: &other, Interface: &errIface},
+		},
+	}
+
+	data := diagram.PrepareInteractiveData(result, diagram.DefaultDiagramOptions())
+	assert.Equal(t, map[string]analyzer.NodeMetrics{
+		"i_my_pkg_Store": {ImplementedBy: 2, IsInterface: true},
+		"builtin_error":  {ImplementedBy: 1, IsInterface: true, Builtin: true},
+		"t_my_pkg_Store": {Implements: 1},
+		"my_pkg_Cache":   {Implements: 2},
+	}, data.Metrics)
+
+	ids := diagram.NodeIDs(result, diagram.DefaultDiagramOptions())
+	for _, iface := range data.Interfaces {
+		assert.Contains(t, data.Metrics, iface.ID)
+	}
+	assert.Equal(t, "t_my_pkg_Store", ids["example.com/my_pkg.Store"])
+	assert.Equal(t, "i_my_pkg_Store", ids["example.com/my-pkg.Store"])
+}
+
+func TestPrepareInteractiveDataPatterns(t *testing.T) {
+	store := analyzer.InterfaceDef{Name: "Store", PkgPath: "example.com/app/store", PkgName: "store"}
+	mem := analyzer.TypeDef{Name: "MemStore", PkgPath: "example.com/app/store", PkgName: "store"}
+	result := &analyzer.Result{
+		Interfaces: []analyzer.InterfaceDef{store},
+		Types:      []analyzer.TypeDef{mem},
+		Relations:  []analyzer.Relation{{Type: &mem, Interface: &store}},
+	}
+
+	assert.Nil(t, diagram.PrepareInteractiveData(result, diagram.DefaultDiagramOptions()).Patterns,
+		"without pattern detection Patterns should stay nil")
+
+	opts := diagram.DefaultDiagramOptions()
+	opts.Patterns = []diagram.Pattern{
+		{
+			Name:        "Strategy",
+			Description: "Interchangeable stores",
+			Participants: []string{
+				"example.com/app/store.Store",
+				"example.com/app/store.MemStore",
+				"example.com/app/store.Store", // duplicate
+				"example.com/app/cache.Redis", // not in the diagram
+			},
+		},
+		{Name: "Singleton", Participants: []string{"example.com/app/cache.Redis"}},
+	}
+	data := diagram.PrepareInteractiveData(result, opts)
+	assert.Equal(t, []diagram.DetectedPattern{{
+		Name:        "Strategy",
+		Description: "Interchangeable stores",
+		Participants: []diagram.PatternParticipant{
+			{ID: "store_Store", Name: "store.Store", Interface: true},
+			{ID: "store_MemStore", Name: "store.MemStore"},
+		},
+	}}, data.Patterns, "unknown participants and patterns left without any should be dropped")
+
+	opts.Patterns = []diagram.Pattern{}
+	assert.Equal(t, []diagram.DetectedPattern{}, diagram.PrepareInteractiveData(result, opts).Patterns,
+		"detection that found nothing should yield an empty, non-nil list")
+}
+
+func TestNodeIDCollisionAcrossPackages(t *testing.T) {
+	// Two packages named config, each with a Loader, share config_Loader.
+	loaderA := analyzer.InterfaceDef{Name: "Loader", PkgPath: "example.com/app/config", PkgName: "config", Methods: []analyzer.MethodSig{{Name: "Load"}}}
+	loaderB := analyzer.InterfaceDef{Name: "Loader", PkgPath: "example.com/lib/config", PkgName: "config", Methods: []analyzer.MethodSig{{Name: "Read"}}}
+	fileA := analyzer.TypeDef{Name: "File", PkgPath: "example.com/app/config", PkgName: "config"}
+	fileB := analyzer.TypeDef{Name: "File", PkgPath: "example.com/lib/config", PkgName: "config"}
+	result := &analyzer.Result{
+		Interfaces: []analyzer.InterfaceDef{loaderA, loaderB},
+		Types:      []analyzer.TypeDef{fileA, fileB},
+		Relations: []analyzer.Relation{
+			{Type: &fileA, Interface: &loaderA},
+			{Type: &fileB, Interface: &loaderB},
+		},
+	}
+
+	assert.Equal(t, []diagram.IDCollision{
+		{ID: "config_File", Nodes: []string{"example.com/app/config.File", "example.com/lib/config.File"}},
+		{ID: "config_Loader", Nodes: []string{"example.com/app/config.Loader", "example.com/lib/config.Loader"}},
+	}, diagram.NodeIDCollisions(result, diagram.DefaultDiagramOptions()))
+	assert.Empty(t, diagram.NodeIDCollisions(result, diagram.DiagramOptions{QualifiedIDs: true}))
+
+	data := diagram.PrepareInteractiveData(result, diagram.DefaultDiagramOptions())
+	ids := make(map[string]string) // pkgPath.Name -> ID; interactive names are pkgName.Name
+	for _, iface := range data.Interfaces {
+		ids[iface.PkgPath+".Loader"] = iface.ID
+	}
+	for _, typ := range data.Types {
+		ids[typ.PkgPath+".File"] = typ.ID
+	}
+	require.Len(t, ids, 4)
+	loaderAID, loaderBID := ids["example.com/app/config.Loader"], ids["example.com/lib/config.Loader"]
+	fileAID, fileBID := ids["example.com/app/config.File"], ids["example.com/lib/config.File"]
+	assert.Regexp(t, `^config_Loader_[0-9a-f]{6}$`, loaderAID)
+	assert.Regexp(t, `^config_Loader_[0-9a-f]{6}$`, loaderBID)
+	assert.NotEqual(t, loaderAID, loaderBID)
+	assert.NotEqual(t, fileAID, fileBID)
+	assert.ElementsMatch(t, []diagram.InteractiveRelation{
+		{TypeID: fileAID, InterfaceID: loaderAID},
+		{TypeID: fileBID, InterfaceID: loaderBID},
+	}, data.Relations)
+
+	// The hash depends on the package path only, not on input order.
+	reversed := &analyzer.Result{
+		Interfaces: []analyzer.InterfaceDef{loaderB, loaderA},
+		Types:      []analyzer.TypeDef{fileB, fileA},
+		Relations:  result.Relations,
+	}
+	assert.Equal(t, data, diagram.PrepareInteractiveData(reversed, diagram.DefaultDiagramOptions()))
+
+	// The Mermaid diagram uses the same IDs in blocks, relations and styles.
+	got := diagram.GenerateMermaid(result, diagram.DefaultDiagramOptions())
+	for _, id := range []string{loaderAID, loaderBID, fileAID, fileBID} {
+		assert.Contains(t, got, "class "+id+" {")
+		assert.Contains(t, got, `cssClass "`+id+`"`)
+	}
+	assert.Contains(t, got, fileAID+" --|> "+loaderAID)
+	assert.Contains(t, got, fileBID+" --|> "+loaderBID)
+	assert.NotContains(t, got, "class config_Loader {")
+	assert.Contains(t, diagram.GenerateDOT(result, diagram.DefaultDiagramOptions()), `"`+fileBID+`" -> "`+loaderBID+`"`)
+
+	// Selecting one Loader keeps its own implementer only.
+	selected := diagram.FilterBySelection(result, nil, []string{loaderAID})
+	require.Len(t, selected.Types, 1)
+	assert.Equal(t, "example.com/app/config", selected.Types[0].PkgPath)
+}
+
+func TestQualifiedIDs(t *testing.T) {
+	// Two packages share the short name "store".
+	repoA := analyzer.InterfaceDef{Name: "Repository", PkgPath: "github.com/foo/store", PkgName: "store"}
+	repoB := analyzer.InterfaceDef{Name: "Repository", PkgPath: "github.com/bar/store", PkgName: "store"}
+	impl := analyzer.TypeDef{Name: "Memory", PkgPath: "github.com/foo/store/mem", PkgName: "mem"}
+	result := &analyzer.Result{
+		Interfaces: []analyzer.InterfaceDef{repoA, repoB},
+		Types:      []analyzer.TypeDef{impl},
+		Relations:  []analyzer.Relation{{Type: &impl, Interface: &repoA}},
+	}
+
+	assert.Equal(t, diagram.NodeID(repoA.PkgName, repoA.Name), diagram.NodeID(repoB.PkgName, repoB.Name),
+		"short-name IDs collide")
+	idA := diagram.QualifiedNodeID(repoA.PkgPath, repoA.Name)
+	idB := diagram.QualifiedNodeID(repoB.PkgPath, repoB.Name)
+	assert.Equal(t, "github_com_foo_store_Repository", idA)
+	assert.Equal(t, "github_com_bar_store_Repository", idB)
+	assert.NotEqual(t, idA, idB)
+
+	got := diagram.GenerateMermaid(result, diagram.DiagramOptions{QualifiedIDs: true})
+	assert.Contains(t, got, "class github_com_foo_store_Repository {")
+	assert.Contains(t, got, "class github_com_bar_store_Repository {")
+	assert.Contains(t, got, "github_com_foo_store_mem_Memory --|> github_com_foo_store_Repository")
+	assert.NotContains(t, got, "store_Repository_2")
+	assert.Equal(t, got, diagram.GenerateMermaid(result, diagram.DiagramOptions{QualifiedIDs: true}), "output is stable")
+
+	data := diagram.PrepareInteractiveData(result, diagram.DiagramOptions{QualifiedIDs: true})
+	require.Len(t, data.Interfaces, 2)
+	assert.Equal(t, idB, data.Interfaces[0].ID)
+	assert.Equal(t, idA, data.Interfaces[1].ID)
+	require.Len(t, data.Relations, 1)
+	assert.Equal(t, idA, data.Relations[0].InterfaceID)
+}
+
+func TestClusterError(t *testing.T) {
+	errIface := analyzer.InterfaceDef{Name: "error", PkgPath: "builtin", PkgName: "builtin",
+		Methods: []analyzer.MethodSig{{Name: "Error", Signature: "Error() string"}}}
+	store := analyzer.InterfaceDef{Name: "Store", PkgPath: "example.com/app/store", PkgName: "store"}
+	notFound := analyzer.TypeDef{Name: "NotFoundError", PkgPath: "example.com/app/store", PkgName: "store"}
+	conflict := analyzer.TypeDef{Name: "ConflictError", PkgPath: "example.com/app/store", PkgName: "store"}
+	// MultiStore is both an error and a Store; it stays visible through Store.
+	multi := analyzer.TypeDef{Name: "MultiStore", PkgPath: "example.com/app/store", PkgName: "store"}
+	result := &analyzer.Result{
+		Interfaces: []analyzer.InterfaceDef{errIface, store},
+		Types:      []analyzer.TypeDef{notFound, conflict, multi},
+		Relations: []analyzer.Relation{
+			{Type: &notFound, Interface: &errIface},
+			{Type: &conflict, Interface: &errIface},
+			{Type: &multi, Interface: &errIface},
+			{Type: &multi, Interface: &store},
+		},
+	}
+
+	got := diagram.GenerateMermaid(result, diagram.DiagramOptions{ClusterError: true})
+	assert.Contains(t, got, `class builtin_error_cluster["3 error implementations"] {`)
+	assert.Contains(t, got, "<<cluster>>")
+	assert.Contains(t, got, "builtin_error_cluster ..|> builtin_error")
+	assert.Contains(t, got, `cssClass "builtin_error_cluster" clusterStyle`)
+	assert.NotContains(t, got, "--|> builtin_error", "individual error edges are suppressed")
+	assert.NotContains(t, got, "class store_NotFoundError", "error-only implementers are folded into the cluster")
+	assert.NotContains(t, got, "class store_ConflictError")
+	assert.Contains(t, got, "store_MultiStore --|> store_Store", "other relations are kept")
+
+	plain := diagram.GenerateMermaid(result, diagram.DiagramOptions{})
+	assert.Contains(t, plain, "store_NotFoundError --|> builtin_error")
+	assert.NotContains(t, plain, diagram.ErrorClusterID)
+
+	data := diagram.PrepareInteractiveData(result, diagram.DiagramOptions{ClusterError: true})
+	assert.Equal(t, "builtin_error", data.ErrorInterfaceID)
+	assert.Len(t, data.Relations, 4, "interactive data keeps every relation; the UI collapses them")
+	assert.Empty(t, diagram.PrepareInteractiveData(result, diagram.DiagramOptions{}).ErrorInterfaceID)
+}
+
+func TestClusterErrorSingleImplementer(t *testing.T) {
+	errIface := analyzer.InterfaceDef{Name: "error", PkgPath: "builtin", PkgName: "builtin"}
+	only := analyzer.TypeDef{Name: "OnlyError", PkgPath: "example.com/app", PkgName: "app"}
+	result := &analyzer.Result{
+		Interfaces: []analyzer.InterfaceDef{errIface},
+		Types:      []analyzer.TypeDef{only},
+		Relations:  []analyzer.Relation{{Type: &only, Interface: &errIface}},
+	}
+
+	got := diagram.GenerateMermaid(result, diagram.DiagramOptions{ClusterError: true})
+	assert.Contains(t, got, "app_OnlyError --|> builtin_error", "a lone implementer is not clustered")
+	assert.NotContains(t, got, diagram.ErrorClusterID)
+}
+
+func TestCollapseSingleImpl(t *testing.T) {
+	const pkg = "example.com/app/store"
+	store := analyzer.InterfaceDef{Name: "Store", PkgPath: pkg, PkgName: "store"}
+	closer := analyzer.InterfaceDef{Name: "Closer", PkgPath: pkg, PkgName: "store"}
+	flusher := analyzer.InterfaceDef{Name: "Flusher", PkgPath: pkg, PkgName: "store"}
+	logger := analyzer.InterfaceDef{Name: "Logger", PkgPath: pkg, PkgName: "store"}
+	mem := analyzer.TypeDef{Name: "Mem", PkgPath: pkg, PkgName: "store"}
+	disk := analyzer.TypeDef{Name: "Disk", PkgPath: pkg, PkgName: "store"}
+	buf := analyzer.TypeDef{Name: "Buffer", PkgPath: pkg, PkgName: "store"}
+	stdout := analyzer.TypeDef{Name: "Stdout", PkgPath: pkg, PkgName: "store"}
+	result := &analyzer.Result{
+		Interfaces: []analyzer.InterfaceDef{store, closer, flusher, logger},
+		Types:      []analyzer.TypeDef{mem, disk, buf, stdout},
+		Relations: []analyzer.Relation{
+			// Store has two implementers and is left alone
+			{Type: &mem, Interface: &store},
+			{Type: &disk, Interface: &store},
+			// Closer's only implementer is Disk, through T and *T
+			{Type: &disk, Interface: &closer},
+			{Type: &disk, Interface: &closer, ViaPointer: true},
+			// Buffer implements nothing but Flusher, and only through *T
+			{Type: &buf, Interface: &flusher, ViaPointer: true},
+			{Type: &stdout, Interface: &logger},
+		},
+		// Stdout embeds Logger, so the pair keeps its nodes for the embed edge
+		Embeds: []analyzer.Relation{{Type: &stdout, Interface: &logger, Kind: analyzer.RelationEmbeds}},
+	}
+
+	got := diagram.GenerateMermaid(result, diagram.DiagramOptions{CollapseSingleImpl: true})
+	assert.Contains(t, got, "class store_Store {", "interfaces with two implementers are untouched")
+	assert.Contains(t, got, "store_Mem --|> store_Store")
+	assert.Contains(t, got, "store_Disk --|> store_Store")
+
+	assert.Contains(t, got, `class store_Closer["store.Closer (impl: store.Disk)"] {`,
+		"value and pointer relations of one type count as one implementer")
+	assert.NotContains(t, got, "--|> store_Closer")
+	assert.Contains(t, got, "class store_Disk {", "a type with other relations keeps its block")
+
+	assert.Contains(t, got, `class store_Flusher["store.Flusher (impl: *store.Buffer)"] {`)
+	assert.NotContains(t, got, "class store_Buffer", "the sole implementer is merged into the interface block")
+	assert.NotContains(t, got, `cssClass "store_Buffer"`)
+
+	assert.Contains(t, got, "class store_Logger {")
+	assert.Contains(t, got, "class store_Stdout {")
+
+	plain := diagram.GenerateMermaid(result, diagram.DiagramOptions{})
+	assert.Contains(t, plain, "store_Buffer --|> store_Flusher : *")
+	assert.NotContains(t, plain, "(impl: ")
+
+	grouped := diagram.GenerateMermaid(result, diagram.DiagramOptions{CollapseSingleImpl: true, GroupByPackage: true})
+	assert.Contains(t, grouped, `class store_Flusher["store.Flusher (impl: *store.Buffer)"] {`)
+
+	data := diagram.PrepareInteractiveData(result, diagram.DiagramOptions{CollapseSingleImpl: true})
+	assert.True(t, data.CollapseSingleImpl)
+	assert.Len(t, data.Relations, 6, "interactive data keeps every relation; the UI collapses them")
+}
+
+func TestFocusSubgraph(t *testing.T) {
+	const pkg = "example.com/app/store"
+	repo := analyzer.InterfaceDef{Name: "Repository", PkgPath: pkg, PkgName: "store"}
+	closer := analyzer.InterfaceDef{Name: "Closer", PkgPath: pkg, PkgName: "store"}
+	logger := analyzer.InterfaceDef{Name: "Logger", PkgPath: pkg, PkgName: "store"}
+	pg := analyzer.TypeDef{Name: "Postgres", PkgPath: pkg, PkgName: "store"}
+	file := analyzer.TypeDef{Name: "File", PkgPath: pkg, PkgName: "store"}
+	stdout := analyzer.TypeDef{Name: "Stdout", PkgPath: pkg, PkgName: "store"}
+	db := analyzer.TypeDef{Name: "DB", PkgPath: pkg, PkgName: "store", AliasOf: pkg + ".Postgres"}
+	// Repository <- Postgres -> Closer <- File -> Logger <- Stdout
+	result := &analyzer.Result{
+		Interfaces: []analyzer.InterfaceDef{repo, closer, logger},
+		Types:      []analyzer.TypeDef{pg, file, stdout, db},
+		Relations: []analyzer.Relation{
+			{Type: &pg, Interface: &repo},
+			{Type: &pg, Interface: &closer},
+			{Type: &file, Interface: &closer},
+			{Type: &file, Interface: &logger},
+			{Type: &stdout, Interface: &logger},
+		},
+		Embeds: []analyzer.Relation{{Type: &file, Embedded: &pg, Kind: analyzer.RelationEmbeds}},
+	}
+	names := func(r *analyzer.Result) []string {
+		var out []string
+		for _, iface := range r.Interfaces {
+			out = append(out, iface.Name)
+		}
+		for _, typ := range r.Types {
+			out = append(out, typ.Name)
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	got, err := diagram.FocusSubgraph(result, pkg+".Repository", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DB", "Postgres", "Repository"}, names(got), "one hop, plus the alias of a reached type")
+	assert.Len(t, got.Relations, 1, "Postgres -> Closer leaves the subgraph")
+	assert.Empty(t, got.Embeds)
+
+	got, err = diagram.FocusSubgraph(result, pkg+".Repository", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Closer", "DB", "Postgres", "Repository"}, names(got))
+	assert.Len(t, got.Relations, 2)
+
+	got, err = diagram.FocusSubgraph(result, pkg+".Repository", 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Closer", "DB", "File", "Postgres", "Repository"}, names(got))
+	assert.Len(t, got.Relations, 3, "relations between reached nodes are all kept")
+	assert.Len(t, got.Embeds, 1, "embeds between reached types are kept")
+
+	got, err = diagram.FocusSubgraph(result, pkg+".File", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Closer", "File", "Logger"}, names(got), "a type expands to the interfaces it implements")
+	mermaid := diagram.GenerateMermaid(got, diagram.DiagramOptions{})
+	assert.Contains(t, mermaid, "store_File --|> store_Logger")
+	assert.NotContains(t, mermaid, "store_Stdout")
+
+	got, err = diagram.FocusSubgraph(result, pkg+".Logger", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Logger"}, names(got))
+	assert.Empty(t, got.Relations)
+
+	_, err = diagram.FocusSubgraph(result, "store.Repository", 1)
+	require.ErrorIs(t, err, diagram.ErrUnknownFocus)
+	assert.Contains(t, err.Error(), "close matches: "+pkg+".Repository", "a package name qualified key suggests the full key")
+
+	_, err = diagram.FocusSubgraph(result, pkg+".Loger", 1)
+	require.ErrorIs(t, err, diagram.ErrUnknownFocus)
+	assert.Contains(t, err.Error(), "close matches: "+pkg+".Logger")
+
+	_, err = diagram.FocusSubgraph(result, "example.com/other.Widget", 1)
+	require.ErrorIs(t, err, diagram.ErrUnknownFocus)
+	assert.NotContains(t, err.Error(), "close matches")
+}
+
+func TestMarkExternal(t *testing.T) {
+	store := analyzer.InterfaceDef{Name: "Store", PkgPath: "example.com/app/store", PkgName: "store"}
+	closer := analyzer.InterfaceDef{Name: "Closer", PkgPath: "io", PkgName: "io"}
+	repo := analyzer.TypeDef{Name: "Repo", PkgPath: "example.com/app/store", PkgName: "store"}
+	// Shares the module path as a string prefix but is a different module.
+	client := analyzer.TypeDef{Name: "Client", PkgPath: "example.com/apply/sdk", PkgName: "sdk"}
+	result := &analyzer.Result{
+		ModulePath: "example.com/app",
+		Interfaces: []analyzer.InterfaceDef{store, closer},
+		Types:      []analyzer.TypeDef{repo, client},
+		Relations: []analyzer.Relation{
+			{Type: &repo, Interface: &store},
+			{Type: &repo, Interface: &closer},
+			{Type: &client, Interface: &closer},
+		},
+	}
+
+	got := diagram.GenerateMermaid(result, diagram.DiagramOptions{MarkExternal: true})
+	assert.Contains(t, got, "classDef externalInterfaceStyle")
+	assert.Contains(t, got, "classDef externalImplStyle")
+	assert.Contains(t, got, `cssClass "io_Closer" externalInterfaceStyle`)
+	assert.Contains(t, got, `cssClass "sdk_Client" externalImplStyle`)
+	assert.Contains(t, got, `cssClass "store_Store" interfaceStyle`)
+	assert.Contains(t, got, `cssClass "store_Repo" implStyle`)
+
+	plain := diagram.GenerateMermaid(result, diagram.DiagramOptions{})
+	assert.NotContains(t, plain, "external")
+	assert.Contains(t, plain, `cssClass "io_Closer" interfaceStyle`)
+
+	data := diagram.PrepareInteractiveData(result, diagram.DiagramOptions{MarkExternal: true})
+	external := make(map[string]bool)
+	for _, iface := range data.Interfaces {
+		external[iface.ID] = iface.External
+	}
+	for _, typ := range data.Types {
+		external[typ.ID] = typ.External
+	}
+	assert.Equal(t, map[string]bool{
+		"io_Closer":   true,
+		"store_Store": false,
+		"sdk_Client":  true,
+		"store_Repo":  false,
+	}, external)
+}
+
+func TestClassifyPorts(t *testing.T) {
+	// core.Repository is a port: its only implementer is an adapter elsewhere.
+	repo := analyzer.InterfaceDef{Name: "Repository", PkgPath: "example.com/app/core", PkgName: "core"}
+	// core.Clock is implemented next to its declaration.
+	clock := analyzer.InterfaceDef{Name: "Clock", PkgPath: "example.com/app/core", PkgName: "core"}
+	// core.Notifier has implementers on both sides.
+	notifier := analyzer.InterfaceDef{Name: "Notifier", PkgPath: "example.com/app/core", PkgName: "core"}
+	unused := analyzer.InterfaceDef{Name: "Unused", PkgPath: "example.com/app/core", PkgName: "core"}
+	pg := analyzer.TypeDef{Name: "PostgresRepo", PkgPath: "example.com/app/adapters/postgres", PkgName: "postgres"}
+	sys := analyzer.TypeDef{Name: "SystemClock", PkgPath: "example.com/app/core", PkgName: "core"}
+	mail := analyzer.TypeDef{Name: "Mailer", PkgPath: "example.com/app/adapters/mail", PkgName: "mail"}
+	result := &analyzer.Result{
+		ModulePath: "example.com/app",
+		Interfaces: []analyzer.InterfaceDef{repo, clock, notifier, unused},
+		Types:      []analyzer.TypeDef{pg, sys, mail},
+		Relations: []analyzer.Relation{
+			{Type: &pg, Interface: &repo},
+			{Type: &sys, Interface: &clock},
+			{Type: &sys, Interface: &notifier},
+			{Type: &mail, Interface: &notifier},
+		},
+	}
+
+	assert.Equal(t, map[string]analyzer.PortKind{
+		"example.com/app/core.Repository": analyzer.PortKindPort,
+		"example.com/app/core.Clock":      analyzer.PortKindSamePackage,
+		"example.com/app/core.Notifier":   analyzer.PortKindMixed,
+		"example.com/app/core.Unused":     analyzer.PortKindUnimplemented,
+	}, analyzer.ClassifyPorts(result))
+	assert.Equal(t, "port", analyzer.PortKindPort.String())
+
+	got := diagram.GenerateMermaid(result, diagram.DiagramOptions{MarkPorts: true})
+	assert.Contains(t, got, "classDef portStyle")
+	assert.Contains(t, got, `cssClass "core_Repository" portStyle`)
+	assert.Contains(t, got, `cssClass "core_Clock" interfaceStyle`)
+	assert.Contains(t, got, `cssClass "core_Notifier" interfaceStyle`)
+	assert.NotContains(t, diagram.GenerateMermaid(result, diagram.DiagramOptions{}), "portStyle")
+
+	data := diagram.PrepareInteractiveData(result, diagram.DiagramOptions{MarkPorts: true})
+	for _, iface := range data.Interfaces {
+		assert.Equal(t, iface.ID == "core_Repository", iface.Port, iface.ID)
+	}
+}
+
+func TestSatisfyingMethods(t *testing.T) {
+	// ReadCloser embeds Reader and Closer; MyFile has value receivers
+	result, err := analyzer.Analyze(context.Background(), testdataDir("05_embedded_iface"), analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+	result = analyzer.Filter(result, analyzer.AnalyzeOptions{})
+	got := make(map[string][]string)
+	for _, rel := range result.Relations {
+		assert.False(t, rel.ViaPointer, rel.Interface.Name)
+		got[rel.Interface.Name] = rel.SatisfyingMethods
+	}
+	assert.Equal(t, map[string][]string{
+		"Reader":     {"Read"},
+		"Closer":     {"Close"},
+		"ReadCloser": {"Close", "Read"},
+	}, got)
+
+	data := diagram.PrepareInteractiveData(result, diagram.DiagramOptions{})
+	for _, rel := range data.Relations {
+		if rel.InterfaceID == "io2_ReadCloser" {
+			assert.Equal(t, []string{"Close", "Read"}, rel.Methods)
+		}
+	}
+
+	// Only *Connection has Close
+	result, err = analyzer.Analyze(context.Background(), testdataDir("04_pointer_receiver"), analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+	result = analyzer.Filter(result, analyzer.AnalyzeOptions{})
+	require.Len(t, result.Relations, 1)
+	assert.True(t, result.Relations[0].ViaPointer)
+	assert.Equal(t, []string{"Close"}, result.Relations[0].SatisfyingMethods)
+
+	out, err := json.Marshal(analyzer.NewResultJSON(result))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"satisfyingMethods":["Close"]`)
+}
+
+func TestGenerateGraphJSON(t *testing.T) {
+	// ReadCloser embeds Reader and Closer; MyFile implements all three.
+	result, err := analyzer.Analyze(context.Background(), testdataDir("05_embedded_iface"), analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+	result = analyzer.Filter(result, analyzer.AnalyzeOptions{})
+
+	out, err := diagram.GenerateGraphJSON(result)
+	require.NoError(t, err)
+	var graph diagram.Graph
+	require.NoError(t, json.Unmarshal(out, &graph))
+
+	require.Len(t, graph.Nodes, 4)
+	kinds := make(map[string]string)
+	for _, n := range graph.Nodes {
+		kinds[n.Label] = n.Kind
+		assert.Equal(t, "example.com/testmod", n.Pkg)
+		assert.NotEmpty(t, n.Methods, n.ID)
+	}
+	assert.Equal(t, map[string]string{
+		"io2.Reader":     diagram.GraphNodeInterface,
+		"io2.Closer":     diagram.GraphNodeInterface,
+		"io2.ReadCloser": diagram.GraphNodeInterface,
+		"io2.MyFile":     diagram.GraphNodeType,
+	}, kinds)
+
+	edgeKinds := make(map[string]int)
+	for _, e := range graph.Edges {
+		edgeKinds[e.Kind]++
+	}
+	assert.Equal(t, map[string]int{
+		diagram.GraphEdgeRealization: 3,
+		diagram.GraphEdgeEmbedding:   2,
+	}, edgeKinds)
+	assert.Contains(t, graph.Edges, diagram.GraphEdge{
+		From: "example.com/testmod.ReadCloser", To: "example.com/testmod.Reader", Kind: diagram.GraphEdgeEmbedding,
+	})
+	assert.Contains(t, graph.Edges, diagram.GraphEdge{
+		From: "example.com/testmod.MyFile", To: "example.com/testmod.ReadCloser", Kind: diagram.GraphEdgeRealization,
+	})
+}
+
+func TestGenerateGraphJSONEmpty(t *testing.T) {
+	out, err := diagram.GenerateGraphJSON(&analyzer.Result{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"nodes": [], "edges": []}`, string(out))
+}
+
 func TestNodeIDExported(t *testing.T) {
 	assert.Equal(t, "pkg_MyType", diagram.NodeID("pkg", "MyType"))
 	assert.Equal(t, "my_pkg_MyType", diagram.NodeID("my-pkg", "MyType"))
@@ -962,6 +1975,65 @@ func TestOrphanedTypesRemovedFromSlides(t *testing.T) {
 	}
 }
 
+func TestFilterDropsNonImplementers(t *testing.T) {
+	// Filter keeps only types with an in-scope relation, so types that
+	// implement nothing never reach the Structures list or the package map.
+	store := analyzer.InterfaceDef{Name: "Store", PkgPath: "example.com/app/store", PkgName: "store"}
+	mem := analyzer.TypeDef{Name: "MemStore", PkgPath: "example.com/app/store", PkgName: "store"}
+	result := &analyzer.Result{
+		ModulePath: "example.com/app",
+		Interfaces: []analyzer.InterfaceDef{store},
+		Types: []analyzer.TypeDef{
+			mem,
+			{Name: "Config", PkgPath: "example.com/app/store", PkgName: "store", IsStruct: true},
+			{Name: "User", PkgPath: "example.com/app/model", PkgName: "model", IsStruct: true},
+		},
+		Relations: []analyzer.Relation{{Type: &mem, Interface: &store}},
+	}
+
+	filtered := analyzer.Filter(result, analyzer.AnalyzeOptions{})
+	require.Len(t, filtered.Types, 1)
+	assert.Equal(t, "MemStore", filtered.Types[0].Name)
+
+	nodes := diagram.PreparePackageMapData(filtered)
+	require.Len(t, nodes, 1, "a package whose types implement nothing gets no node")
+	assert.Equal(t, "example.com/app/store", nodes[0].PkgPath)
+	assert.Equal(t, 1, nodes[0].Types, "Config does not count")
+	assert.Equal(t, 1, nodes[0].Interfaces)
+}
+
+func TestMarkerInterfaces(t *testing.T) {
+	result, err := analyzer.Analyze(context.Background(), testdataDir("08_empty_iface"), analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+
+	markers := make(map[string]bool)
+	for _, iface := range result.Interfaces {
+		markers[iface.Name] = iface.IsMarker
+	}
+	assert.Equal(t, map[string]bool{"Any": true, "Marker": true, "Value": true, "Payload": true, "Number": false, "error": false}, markers)
+	assert.Empty(t, result.Relations, "markers are never matched, not even against Foo")
+
+	// Excluded by default, counts included
+	filtered := analyzer.Filter(result, analyzer.AnalyzeOptions{})
+	assert.Empty(t, filtered.Interfaces)
+	assert.Empty(t, diagram.PreparePackageMapData(filtered))
+
+	withMarkers := analyzer.Filter(result, analyzer.AnalyzeOptions{IncludeMarkers: true})
+	assert.Len(t, withMarkers.Interfaces, 4)
+	assert.Empty(t, withMarkers.Types)
+	withMarkers = analyzer.Filter(result, analyzer.AnalyzeOptions{IncludeMarkers: true, Filter: "example.com/other"})
+	assert.Empty(t, withMarkers.Interfaces, "markers follow -filter")
+
+	// The flag survives the JSON round trip used by the result cache
+	data, err := analyzer.MarshalResult(result)
+	require.NoError(t, err)
+	decoded, err := analyzer.UnmarshalResult(data)
+	require.NoError(t, err)
+	for _, iface := range decoded.Interfaces {
+		assert.Equal(t, markers[iface.Name], iface.IsMarker, iface.Name)
+	}
+}
+
 func TestPreparePackageMapData(t *testing.T) {
 	// Multi-package input:
 	//   example.com/mylib/io          — 2 interfaces, 1 type
@@ -1021,6 +2093,62 @@ func TestPreparePackageMapData(t *testing.T) {
 	assert.Equal(t, 3, httpNode.Value, "parent value = own interfaces+types + sum of children values")
 }
 
+// manyPackagesResult builds a result with n leaf packages under a single
+// parent, where package i holds i+1 types.
+func manyPackagesResult(n int) *analyzer.Result {
+	result := &analyzer.Result{
+		Interfaces: []analyzer.InterfaceDef{
+			{Name: "Root", PkgPath: "example.com/app/svc", PkgName: "svc"},
+		},
+	}
+	for i := 0; i < n; i++ {
+		pkg := fmt.Sprintf("example.com/app/svc/p%03d", i)
+		for j := 0; j <= i; j++ {
+			result.Types = append(result.Types, analyzer.TypeDef{
+				Name: fmt.Sprintf("T%d", j), PkgPath: pkg, PkgName: fmt.Sprintf("p%03d", i),
+			})
+		}
+	}
+	return result
+}
+
+func TestLimitPackageMapNodes(t *testing.T) {
+	nodes := diagram.PreparePackageMapData(manyPackagesResult(10))
+	// svc + 10 children = 11 nodes
+	require.Len(t, nodes, 1)
+	require.Len(t, nodes[0].Children, 10)
+	origValue := nodes[0].Value
+
+	// Within budget: unchanged
+	assert.Equal(t, nodes, diagram.LimitPackageMapNodes(nodes, 11))
+	assert.Equal(t, nodes, diagram.LimitPackageMapNodes(nodes, 0), "0 disables the cap")
+
+	// Over budget: smallest packages fold into "(other)"
+	limited := diagram.LimitPackageMapNodes(nodes, 6)
+	require.Len(t, limited, 1)
+	children := limited[0].Children
+	require.Len(t, children, 5, "svc + 5 children fits a budget of 6")
+
+	other := children[len(children)-1]
+	assert.Equal(t, diagram.OtherNodeName, other.Name)
+	assert.Empty(t, other.PkgPath, "aggregate node is not a real package")
+	assert.Equal(t, 6, other.Aggregated, "p000..p005 folded")
+	assert.Equal(t, 1+2+3+4+5+6, other.Types)
+	assert.Equal(t, "p006", children[0].Name, "largest packages survive")
+
+	// Proportions are preserved and the input is left untouched.
+	assert.Equal(t, origValue, limited[0].Value)
+	assert.Len(t, nodes[0].Children, 10)
+}
+
+func BenchmarkPreparePackageMapData(b *testing.B) {
+	result := manyPackagesResult(200)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		diagram.LimitPackageMapNodes(diagram.PreparePackageMapData(result), 100)
+	}
+}
+
 func TestPreparePackageMapDataEmpty(t *testing.T) {
 	result := &analyzer.Result{}
 	nodes := diagram.PreparePackageMapData(result)
@@ -1053,3 +2181,1836 @@ func TestPreparePackageMapDataSinglePackage(t *testing.T) {
 	assert.Equal(t, 3, node.Value, "value = 1 interface + 2 types = 3")
 	assert.Nil(t, node.Children)
 }
+
+// writeMatchCacheModule writes a single-package module with nIfaces
+// interfaces and nTypes types; type i implements interfaces i%nIfaces and
+// (i+1)%nIfaces, the second through a pointer receiver.
+func writeMatchCacheModule(tb testing.TB, dir string, nIfaces, nTypes int) {
+	tb.Helper()
+	var b strings.Builder
+	b.WriteString("package shapes\n")
+	for i := 0; i < nIfaces; i++ {
+		fmt.Fprintf(&b, "\ntype Iface%d interface {\n\tMethod%d() int\n}\n", i, i)
+	}
+	for i := 0; i < nTypes; i++ {
+		fmt.Fprintf(&b, "\ntype Impl%d struct{}\n\nfunc (Impl%d) Method%d() int { return 0 }\n\nfunc (*Impl%d) Method%d() int { return 0 }\n",
+			i, i, i%nIfaces, i, (i+1)%nIfaces)
+	}
+	require.NoError(tb, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/shapes\n\ngo 1.21\n"), 0o644))
+	require.NoError(tb, os.WriteFile(filepath.Join(dir, "shapes.go"), []byte(b.String()), 0o644))
+}
+
+func relationKeys(result *analyzer.Result) []string {
+	keys := make([]string, 0, len(result.Relations))
+	for _, rel := range result.Relations {
+		keys = append(keys, fmt.Sprintf("%s -> %s (ptr=%t)", rel.Type.Name, rel.Interface.Name, rel.ViaPointer))
+	}
+	return keys
+}
+
+func TestMatchCache(t *testing.T) {
+	dir := t.TempDir()
+	writeMatchCacheModule(t, dir, 3, 6)
+	ctx := context.Background()
+
+	cold, err := analyzer.Analyze(ctx, dir, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+
+	cache := analyzer.NewMatchCache()
+	opts := analyzer.AnalyzeOptions{MatchCache: cache}
+	first, err := analyzer.Analyze(ctx, dir, opts, testLogger())
+	require.NoError(t, err)
+	assert.Equal(t, relationKeys(cold), relationKeys(first))
+	assert.Zero(t, cache.Reused, "an empty cache reuses nothing")
+
+	// Round-trip through disk, as the CLI does between runs.
+	path := filepath.Join(t.TempDir(), "matches.json")
+	require.NoError(t, cache.Save(path))
+	loaded, err := analyzer.LoadMatchCache(path)
+	require.NoError(t, err)
+
+	opts.MatchCache = loaded
+	warm, err := analyzer.Analyze(ctx, dir, opts, testLogger())
+	require.NoError(t, err)
+	assert.Equal(t, relationKeys(cold), relationKeys(warm))
+	assert.Zero(t, loaded.Computed, "unchanged package should be fully reused")
+	assert.Positive(t, loaded.Reused)
+
+	// Changing a file invalidates its package.
+	src := filepath.Join(dir, "shapes.go")
+	data, err := os.ReadFile(src)
+	require.NoError(t, err)
+	data = append(data, []byte("\ntype Extra struct{}\n\nfunc (Extra) Method0() int { return 0 }\n")...)
+	require.NoError(t, os.WriteFile(src, data, 0o644))
+
+	changed, err := analyzer.Analyze(ctx, dir, opts, testLogger())
+	require.NoError(t, err)
+	assert.Contains(t, relationKeys(changed), "Extra -> Iface0 (ptr=false)")
+	assert.Len(t, changed.Relations, len(cold.Relations)+1)
+	assert.Zero(t, loaded.Reused, "modified package must be recomputed")
+}
+
+func TestResultCache(t *testing.T) {
+	dir := t.TempDir()
+	writeMatchCacheModule(t, dir, 3, 6)
+	ctx := context.Background()
+	opts := analyzer.AnalyzeOptions{ResultCacheDir: t.TempDir()}
+
+	cold, err := analyzer.Analyze(ctx, dir, opts, testLogger())
+	require.NoError(t, err)
+	require.NotNil(t, cold.Types[0].TypeObj, "the first run analyzes")
+
+	warm, err := analyzer.Analyze(ctx, dir, opts, testLogger())
+	require.NoError(t, err)
+	assert.Nil(t, warm.Types[0].TypeObj, "an unchanged module comes from the cache")
+	assert.ElementsMatch(t, relationKeys(cold), relationKeys(warm))
+	assert.Equal(t, analyzer.UnusedExports(cold), analyzer.UnusedExports(warm))
+
+	// Other options do not share the entry.
+	other, err := analyzer.Analyze(ctx, dir, analyzer.AnalyzeOptions{ResultCacheDir: opts.ResultCacheDir, ExcludeFuncTypes: true}, testLogger())
+	require.NoError(t, err)
+	assert.NotNil(t, other.Types[0].TypeObj)
+
+	// Changing a file invalidates the entry.
+	src := filepath.Join(dir, "shapes.go")
+	data, err := os.ReadFile(src)
+	require.NoError(t, err)
+	data = append(data, []byte("\ntype Extra struct{}\n\nfunc (Extra) Method0() int { return 0 }\n")...)
+	require.NoError(t, os.WriteFile(src, data, 0o644))
+
+	changed, err := analyzer.Analyze(ctx, dir, opts, testLogger())
+	require.NoError(t, err)
+	assert.NotNil(t, changed.Types[0].TypeObj)
+	assert.Contains(t, relationKeys(changed), "Extra -> Iface0 (ptr=false)")
+
+	// A corrupt entry falls through to a full analysis and is replaced.
+	entries, err := filepath.Glob(filepath.Join(opts.ResultCacheDir, "*.json"))
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	for _, entry := range entries {
+		require.NoError(t, os.WriteFile(entry, []byte("{not json"), 0o644))
+	}
+	recovered, err := analyzer.Analyze(ctx, dir, opts, testLogger())
+	require.NoError(t, err)
+	assert.NotNil(t, recovered.Types[0].TypeObj)
+	assert.ElementsMatch(t, relationKeys(changed), relationKeys(recovered))
+
+	cached, err := analyzer.Analyze(ctx, dir, opts, testLogger())
+	require.NoError(t, err)
+	assert.Nil(t, cached.Types[0].TypeObj, "the corrupt entry was rewritten")
+}
+
+func TestAnalyzeMaxNodes(t *testing.T) {
+	dir := t.TempDir()
+	writeMatchCacheModule(t, dir, 3, 6) // 3 interfaces + builtin error + 6 types
+	ctx := context.Background()
+
+	_, err := analyzer.Analyze(ctx, dir, analyzer.AnalyzeOptions{MaxNodes: 5}, testLogger())
+	require.Error(t, err)
+	assert.ErrorIs(t, err, analyzer.ErrTooManyNodes)
+	assert.Contains(t, err.Error(), "10 nodes exceeds limit 5; narrow with -filter")
+
+	result, err := analyzer.Analyze(ctx, dir, analyzer.AnalyzeOptions{MaxNodes: 10}, testLogger())
+	require.NoError(t, err, "the limit itself is allowed")
+	assert.NotEmpty(t, result.Relations)
+
+	// Only nodes under the filter count, and filtering skips no relation that
+	// Filter would keep.
+	opts := analyzer.AnalyzeOptions{MaxNodes: 5, Filter: "example.com/shapes"}
+	_, err = analyzer.Analyze(ctx, dir, opts, testLogger())
+	assert.ErrorIs(t, err, analyzer.ErrTooManyNodes)
+	opts.Filter = "example.com/other"
+	filtered, err := analyzer.Analyze(ctx, dir, opts, testLogger())
+	require.NoError(t, err)
+	assert.Empty(t, analyzer.Filter(filtered, opts).Relations)
+}
+
+func TestAnalyzeMaxPackages(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{"go.mod": "module example.com/mono\n\ngo 1.21\n"}
+	for _, pkg := range []string{"api", "store", "tools/gen"} {
+		name := filepath.Base(pkg)
+		files[pkg+"/"+name+".go"] = "package " + name + "\n\ntype Thing interface{ Do() }\n"
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	ctx := context.Background()
+
+	_, err := analyzer.Analyze(ctx, dir, analyzer.AnalyzeOptions{MaxPackages: 2}, testLogger())
+	require.ErrorIs(t, err, analyzer.ErrTooManyPackages)
+	assert.Contains(t, err.Error(), "3 packages exceeds limit 2; narrow with -filter")
+
+	_, err = analyzer.Analyze(ctx, dir, analyzer.AnalyzeOptions{MaxPackages: 3}, testLogger())
+	require.NoError(t, err, "the limit itself is allowed")
+
+	// Only packages under the filter count
+	_, err = analyzer.Analyze(ctx, dir, analyzer.AnalyzeOptions{MaxPackages: 1, Filter: "example.com/mono/tools"}, testLogger())
+	require.NoError(t, err)
+
+	result, err := analyzer.Analyze(ctx, dir, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err, "0 means no limit")
+	assert.Len(t, result.Interfaces, 4, "three Things and the builtin error")
+}
+
+func TestLoadMatchCache(t *testing.T) {
+	dir := t.TempDir()
+
+	cache, err := analyzer.LoadMatchCache(filepath.Join(dir, "missing.json"))
+	require.NoError(t, err, "a missing cache is not an error")
+	assert.NotNil(t, cache)
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	require.NoError(t, os.WriteFile(corrupt, []byte("{not json"), 0o644))
+	cache, err = analyzer.LoadMatchCache(corrupt)
+	assert.Error(t, err)
+	assert.NotNil(t, cache, "a corrupt cache is replaced by an empty one")
+}
+
+func BenchmarkAnalyzeMatchCache(b *testing.B) {
+	dir := b.TempDir()
+	writeMatchCacheModule(b, dir, 150, 600)
+	ctx := context.Background()
+	logger := testLogger()
+
+	b.Run("cold", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			opts := analyzer.AnalyzeOptions{MatchCache: analyzer.NewMatchCache()}
+			if _, err := analyzer.Analyze(ctx, dir, opts, logger); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("warm", func(b *testing.B) {
+		opts := analyzer.AnalyzeOptions{MatchCache: analyzer.NewMatchCache()}
+		if _, err := analyzer.Analyze(ctx, dir, opts, logger); err != nil {
+			b.Fatal(err)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := analyzer.Analyze(ctx, dir, opts, logger); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestTypeMethodsIncludePointerAndPromoted(t *testing.T) {
+	dir := t.TempDir()
+	src := `package store
+
+type Saver interface {
+	Save(key string) error
+}
+
+type Closer interface {
+	Close() error
+}
+
+type base struct{}
+
+func (*base) Close() error { return nil }
+
+// PtrOnly implements Saver only through its pointer.
+type PtrOnly struct{}
+
+func (*PtrOnly) Save(key string) error { return nil }
+
+// Wrapped gets Close promoted from base and redeclares nothing.
+type Wrapped struct {
+	base
+	PtrOnly
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\ngo 1.21\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "store.go"), []byte(src), 0o644))
+
+	result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+
+	methods := make(map[string][]string)
+	for _, typ := range result.Types {
+		for _, m := range typ.Methods {
+			methods[typ.Name] = append(methods[typ.Name], m.Signature)
+		}
+	}
+	assert.Equal(t, []string{"Save(string) error"}, methods["PtrOnly"])
+	assert.Equal(t, []string{"Close() error", "Save(string) error"}, methods["Wrapped"],
+		"promoted methods from embedded fields are collected")
+	assert.Equal(t, []string{"Close() error"}, methods["base"], "no duplicates between declared and method-set methods")
+
+	data := diagram.PrepareInteractiveData(result, diagram.DefaultDiagramOptions())
+	for _, typ := range data.Types {
+		if typ.Name == "store.PtrOnly" {
+			assert.Equal(t, []string{"Save(string) error"}, typ.Methods)
+		}
+	}
+}
+
+func TestWhatImplements(t *testing.T) {
+	dir := t.TempDir()
+	src := `package store
+
+type Reader interface {
+	Get(id string) (string, error)
+}
+
+type Writer interface {
+	Put(id, value string) error
+}
+
+type Closer interface {
+	Close() error
+}
+
+type pinger interface {
+	Ping() bool
+}
+
+type Unrelated interface {
+	Frobnicate()
+}
+
+type base struct{}
+
+func (base) Close() error { return nil }
+
+type PostgresRepo struct {
+	base
+}
+
+func (PostgresRepo) Get(id string) (string, error) { return "", nil }
+
+func (*PostgresRepo) Put(id, value string) error { return nil }
+
+func (PostgresRepo) Ping() bool { return true }
+
+type MemoryRepo struct{}
+
+func (MemoryRepo) Get(id string) (string, error) { return "", nil }
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\ngo 1.21\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "store.go"), []byte(src), 0o644))
+
+	opts := analyzer.AnalyzeOptions{}
+	result, err := analyzer.Analyze(context.Background(), dir, opts, testLogger())
+	require.NoError(t, err)
+
+	for _, spec := range []string{"PostgresRepo", "store.PostgresRepo", "example.com/app.PostgresRepo"} {
+		typ, err := analyzer.FindType(result, spec)
+		require.NoError(t, err, spec)
+		assert.Equal(t, "PostgresRepo", typ.Name)
+	}
+	_, err = analyzer.FindType(result, "MissingRepo")
+	assert.ErrorIs(t, err, analyzer.ErrTypeNotFound)
+
+	typ, err := analyzer.FindType(result, "PostgresRepo")
+	require.NoError(t, err)
+	sats := analyzer.WhatImplements(result, typ, opts)
+
+	var got []string
+	for _, s := range sats {
+		var methods []string
+		for _, m := range s.Methods {
+			methods = append(methods, m.Signature)
+		}
+		got = append(got, fmt.Sprintf("%s ptr=%t [%s]", s.Interface.Name, s.ViaPointer, strings.Join(methods, "; ")))
+	}
+	assert.Equal(t, []string{
+		"Closer ptr=false [Close() error]",
+		"Reader ptr=false [Get(string) (string, error)]",
+		"Writer ptr=true [Put(string, string) error]",
+	}, got, "unexported pinger is hidden by default; Close is promoted from base")
+
+	opts.IncludeUnexported = true
+	assert.Len(t, analyzer.WhatImplements(result, typ, opts), 4)
+}
+
+func TestCoverage(t *testing.T) {
+	dir := testdataDir("03_multi_iface")
+	result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+
+	t.Run("fully covered", func(t *testing.T) {
+		report := analyzer.Coverage(result, []string{"Reader", "store.Writer"})
+		assert.True(t, report.Pass)
+		assert.Equal(t, 2, report.Covered)
+		assert.Equal(t, 2, report.Total)
+		require.Len(t, report.Interfaces, 2)
+		assert.Equal(t, analyzer.CoverageCovered, report.Interfaces[0].Status)
+		assert.Len(t, report.Interfaces[0].Implementers, 2, "MemStore and ReadOnlyCache")
+		assert.Equal(t, analyzer.CoverageCovered, report.Interfaces[1].Status)
+	})
+
+	t.Run("partially covered", func(t *testing.T) {
+		report := analyzer.Coverage(result, []string{"Reader", "Missing"})
+		assert.False(t, report.Pass)
+		assert.Equal(t, 1, report.Covered)
+		assert.Equal(t, analyzer.CoverageNotFound, report.Interfaces[1].Status)
+		assert.Contains(t, report.Interfaces[1].Error, "interface not found")
+	})
+
+	// Hand-built result: analysis of test files is not needed to classify them.
+	port := analyzer.InterfaceDef{Name: "Port", PkgPath: "example.com/app/core", PkgName: "core"}
+	orphan := analyzer.InterfaceDef{Name: "Orphan", PkgPath: "example.com/app/core", PkgName: "core"}
+	fake := analyzer.TypeDef{Name: "fakePort", PkgPath: "example.com/app/core", PkgName: "core", SourceFile: "core/port_test.go"}
+	synthetic := &analyzer.Result{
+		Interfaces: []analyzer.InterfaceDef{port, orphan},
+		Types:      []analyzer.TypeDef{fake},
+		Relations:  []analyzer.Relation{{Type: &fake, Interface: &port}},
+	}
+
+	t.Run("uncovered", func(t *testing.T) {
+		report := analyzer.Coverage(synthetic, []string{"core.Port", "example.com/app/core.Orphan"})
+		assert.False(t, report.Pass)
+		assert.Equal(t, 0, report.Covered)
+		assert.Equal(t, analyzer.CoverageTestOnly, report.Interfaces[0].Status)
+		assert.Empty(t, report.Interfaces[0].Implementers)
+		assert.Equal(t, []string{"example.com/app/core.fakePort"}, report.Interfaces[0].TestImplementers)
+		assert.Equal(t, analyzer.CoverageUncovered, report.Interfaces[1].Status)
+		assert.Equal(t, "example.com/app/core.Orphan", report.Interfaces[1].Interface)
+
+		out, err := json.Marshal(report)
+		require.NoError(t, err)
+		assert.Contains(t, string(out), `"status":"test-only"`)
+		assert.Contains(t, string(out), `"pass":false`)
+	})
+}
+
+func TestMethodSigSanitizedOnce(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/sanitize\n\ngo 1.21\n"), 0o644))
+	src := `package sanitize
+
+type Pump interface {
+	Drain(in <-chan interface{}, done map[string]struct{}) error
+}
+
+type Impl struct{}
+
+func (Impl) Drain(in <-chan interface{}, done map[string]struct{}) error { return nil }
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pump.go"), []byte(src), 0o644))
+
+	result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+	iface, err := analyzer.FindInterface(result, "Pump")
+	require.NoError(t, err)
+	typ, err := analyzer.FindType(result, "Impl")
+	require.NoError(t, err)
+	for _, m := range append(iface.Methods, typ.Methods...) {
+		assert.Equal(t, "Drain(chan any, map[string]struct) error", m.Sanitized)
+		assert.Equal(t, diagram.SanitizeSignature(m.Signature), m.Sanitized)
+	}
+}
+
+// manySignaturesResult builds n interfaces, each implemented by one type, with
+// methods whose signatures need sanitizing. Sanitized is filled in as the
+// analyzer would unless raw is set.
+func manySignaturesResult(n int, raw bool) *analyzer.Result {
+	result := &analyzer.Result{ModulePath: "example.com/big"}
+	sigs := func(i int) []analyzer.MethodSig {
+		methods := make([]analyzer.MethodSig, 8)
+		for j := range methods {
+			sig := fmt.Sprintf("M%d(in <-chan interface{}, seen map[int%d]struct{}) error", j, i)
+			methods[j] = analyzer.MethodSig{Name: fmt.Sprintf("M%d", j), Signature: sig}
+			if !raw {
+				methods[j].Sanitized = analyzer.SanitizeSignature(sig)
+			}
+		}
+		return methods
+	}
+	for i := 0; i < n; i++ {
+		pkg := fmt.Sprintf("p%03d", i%20)
+		result.Interfaces = append(result.Interfaces, analyzer.InterfaceDef{
+			Name: fmt.Sprintf("I%d", i), PkgPath: "example.com/big/" + pkg, PkgName: pkg, Methods: sigs(i),
+		})
+		result.Types = append(result.Types, analyzer.TypeDef{
+			Name: fmt.Sprintf("T%d", i), PkgPath: "example.com/big/" + pkg, PkgName: pkg, Methods: sigs(i),
+		})
+	}
+	for i := range result.Types {
+		result.Relations = append(result.Relations, analyzer.Relation{Type: &result.Types[i], Interface: &result.Interfaces[i]})
+	}
+	return result
+}
+
+func TestSanitizedSignaturesOutputUnchanged(t *testing.T) {
+	// Hand-built MethodSigs without Sanitized fall back to sanitizing on the
+	// fly; both paths must render identically.
+	pre, raw := manySignaturesResult(30, false), manySignaturesResult(30, true)
+	assert.Equal(t, diagram.GenerateMermaid(raw, diagram.DiagramOptions{}), diagram.GenerateMermaid(pre, diagram.DiagramOptions{}))
+	assert.Equal(t, diagram.PrepareInteractiveData(raw, diagram.DiagramOptions{}), diagram.PrepareInteractiveData(pre, diagram.DiagramOptions{}))
+	assert.NotContains(t, diagram.GenerateMermaid(pre, diagram.DiagramOptions{}), "interface{}")
+}
+
+func BenchmarkPrepareInteractiveDataSignatures(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		raw  bool
+	}{{"presanitized", false}, {"raw", true}} {
+		result := manySignaturesResult(2000, bc.raw)
+		b.Run(bc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				diagram.PrepareInteractiveData(result, diagram.DiagramOptions{})
+				diagram.GenerateMermaid(result, diagram.DiagramOptions{})
+			}
+		})
+	}
+}
+
+func TestUnusedExports(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/deadcode\n\ngo 1.21\n"), 0o644))
+	src := `package deadcode
+
+type Store interface {
+	Get(id string) string
+}
+
+type Referenced struct{}
+
+func (r *Referenced) Get(id string) string { return id }
+
+// Unreferenced only has methods; its receivers don't count as uses.
+type Unreferenced struct{}
+
+func (u Unreferenced) Get(id string) string { return id }
+
+type unexported struct{}
+
+func New() Store { return &Referenced{} }
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "deadcode.go"), []byte(src), 0o644))
+
+	result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+	assert.Positive(t, result.References["example.com/deadcode.Referenced"])
+	assert.Equal(t, []string{"example.com/deadcode.Unreferenced"}, analyzer.UnusedExports(result))
+
+	assert.Nil(t, analyzer.UnusedExports(&analyzer.Result{}), "hand-built results carry no reference counts")
+}
+
+func TestUnimplementedInterfaces(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/wiring\n\ngo 1.21\n"), 0o644))
+	src := `package wiring
+
+type Store interface {
+	Get(id string) string
+}
+
+type Mem struct{}
+
+func (Mem) Get(id string) string { return id }
+
+// Notifier has no implementation anywhere.
+type Notifier interface {
+	Notify(msg string) error
+}
+
+// Any has no methods, so there is nothing to implement.
+type Any interface{}
+
+// Plugin is only ever supplied from outside, through an embedded field.
+type Plugin interface {
+	Run()
+}
+
+type Host struct {
+	Plugin
+}
+
+type failure struct{}
+
+func (failure) Error() string { return "failure" }
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "wiring.go"), []byte(src), 0o644))
+
+	result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+	got := analyzer.UnimplementedInterfaces(result)
+	require.Len(t, got, 1)
+	assert.Equal(t, "Notifier", got[0].Name)
+	assert.Equal(t, "wiring.go", got[0].SourceFile)
+}
+
+func TestUnimplementedInterfacesTransitive(t *testing.T) {
+	// Closer is only embedded: its sole implementer is reached through
+	// ReadCloser, which a struct embeds, so neither is reported.
+	closer := analyzer.InterfaceDef{Name: "Closer", PkgPath: "example.com/m", PkgName: "m",
+		Methods: []analyzer.MethodSig{{Name: "Close"}}}
+	readCloser := analyzer.InterfaceDef{Name: "ReadCloser", PkgPath: "example.com/m", PkgName: "m",
+		Methods: []analyzer.MethodSig{{Name: "Read"}, {Name: "Close"}}, Embeds: []string{"example.com/m.Closer"}}
+	orphan := analyzer.InterfaceDef{Name: "Orphan", PkgPath: "example.com/m", PkgName: "m",
+		Methods: []analyzer.MethodSig{{Name: "Do"}}}
+	errIface := analyzer.InterfaceDef{Name: "error", PkgPath: "builtin", PkgName: "builtin",
+		Methods: []analyzer.MethodSig{{Name: "Error"}}}
+	stream := analyzer.TypeDef{Name: "Stream", PkgPath: "example.com/m", PkgName: "m", IsStruct: true}
+	result := &analyzer.Result{
+		ModulePath: "example.com/m",
+		Interfaces: []analyzer.InterfaceDef{closer, readCloser, orphan, errIface},
+		Types:      []analyzer.TypeDef{stream},
+		Embeds:     []analyzer.Relation{{Type: &stream, Interface: &readCloser, Kind: analyzer.RelationEmbeds}},
+	}
+
+	got := analyzer.UnimplementedInterfaces(result)
+	require.Len(t, got, 1)
+	assert.Equal(t, "Orphan", got[0].Name)
+}
+
+func TestGeneratePackageTree(t *testing.T) {
+	result := &analyzer.Result{
+		Interfaces: []analyzer.InterfaceDef{
+			{Name: "API", PkgPath: "example.com/app/api", PkgName: "api"},
+			{Name: "Store", PkgPath: "example.com/app/internal/db", PkgName: "db"},
+			{Name: "Handler", PkgPath: "example.com/app/internal/http/middleware/auth", PkgName: "auth"},
+		},
+		Types: []analyzer.TypeDef{
+			{Name: "PGStore", PkgPath: "example.com/app/internal/db", PkgName: "db"},
+			{Name: "MemStore", PkgPath: "example.com/app/internal/db", PkgName: "db"},
+			{Name: "Server", PkgPath: "example.com/app/internal/http", PkgName: "http"},
+			{Name: "JWTAuth", PkgPath: "example.com/app/internal/http/middleware/auth", PkgName: "auth"},
+		},
+	}
+
+	want := `- api (1 interface, 0 types)
+- internal/
+  - db (1 interface, 2 types)
+  - http (0 interfaces, 1 type)
+    - middleware/
+      - auth (1 interface, 1 type)
+`
+	assert.Equal(t, want, diagram.GeneratePackageTree(result))
+	assert.Empty(t, diagram.GeneratePackageTree(&analyzer.Result{}))
+
+	// -package-map text puts the tree on the book's index page instead of a flowchart.
+	splitter := split.NewHubAndSpoke(split.DefaultOptions())
+	slides := diagram.BuildSlides(result, diagram.DiagramOptions{}, splitter, diagram.SlideOptions{PackageMapText: true})
+	require.NotEmpty(t, slides)
+	assert.Equal(t, want, slides[0].Text)
+	assert.Empty(t, slides[0].Mermaid)
+	index := diagram.BuildBook(slides)[0].Content
+	assert.Contains(t, index, "## Package Map\n\n"+want)
+	assert.NotContains(t, index, "flowchart")
+}
+
+// fakeSource is an analyzer.Source that returns a fixed result, standing in
+// for a non-Go source such as a protobuf or TypeScript collector.
+type fakeSource struct {
+	result *analyzer.Result
+	dirs   []string
+}
+
+func (s *fakeSource) Collect(_ context.Context, dir string) (*analyzer.Result, error) {
+	s.dirs = append(s.dirs, dir)
+	return s.result, nil
+}
+
+func TestSourcePipeline(t *testing.T) {
+	svc := analyzer.InterfaceDef{
+		Name: "UserService", PkgPath: "example.com/proto/users", PkgName: "users",
+		Methods: []analyzer.MethodSig{{Name: "GetUser", Signature: "GetUser(req GetUserRequest) (User, error)"}},
+	}
+	impl := analyzer.TypeDef{Name: "UserServer", PkgPath: "example.com/proto/users/server", PkgName: "server", IsStruct: true}
+	src := &fakeSource{result: &analyzer.Result{
+		ModulePath: "example.com/proto",
+		Interfaces: []analyzer.InterfaceDef{svc},
+		Types:      []analyzer.TypeDef{impl},
+		Relations:  []analyzer.Relation{{Type: &impl, Interface: &svc}},
+	}}
+
+	var source analyzer.Source = src
+	result, err := source.Collect(context.Background(), "/repo/proto")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/repo/proto"}, src.dirs)
+
+	filtered := analyzer.Filter(result, analyzer.AnalyzeOptions{})
+	require.Len(t, filtered.Relations, 1)
+
+	mermaid := diagram.GenerateMermaid(filtered, diagram.DiagramOptions{})
+	assert.Contains(t, mermaid, "users_UserService")
+	assert.Contains(t, mermaid, "server_UserServer --|> users_UserService")
+	assert.Contains(t, mermaid, "+GetUser(req GetUserRequest) (User, error)")
+
+	data := diagram.PrepareInteractiveData(filtered, diagram.DiagramOptions{})
+	require.Len(t, data.Relations, 1)
+	assert.Equal(t, "users_UserService", data.Relations[0].InterfaceID)
+	assert.NotEmpty(t, diagram.PreparePackageMapData(filtered))
+}
+
+func TestGoSourceMatchesAnalyze(t *testing.T) {
+	dir := testdataDir("03_multi_iface")
+	want, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+	got, err := analyzer.NewGoSource(analyzer.AnalyzeOptions{}, testLogger()).Collect(context.Background(), dir)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, relationKeys(want), relationKeys(got))
+}
+
+func TestAnalyzeTargetPlatform(t *testing.T) {
+	dir := testdataDir("13_build_constraints")
+	implementers := func(goos string) []string {
+		t.Helper()
+		result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{GOOS: goos, GOARCH: "amd64"}, testLogger())
+		require.NoError(t, err)
+		var names []string
+		for _, rel := range result.Relations {
+			names = append(names, rel.Type.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"LinuxStore"}, implementers("linux"), "_linux.go file suffix")
+	assert.Equal(t, []string{"WindowsStore"}, implementers("windows"), "//go:build windows")
+	assert.Empty(t, implementers("darwin"))
+
+	_, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{GOOS: "windos"}, testLogger())
+	require.ErrorIs(t, err, analyzer.ErrLoadFailed)
+	require.ErrorIs(t, err, analyzer.ErrUnsupportedPlatform)
+	assert.Contains(t, err.Error(), "windos/")
+}
+
+func TestGenerateDOT(t *testing.T) {
+	dir := testdataDir("03_multi_iface")
+	result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+	result = analyzer.Filter(result, analyzer.AnalyzeOptions{})
+
+	got := diagram.GenerateDOT(result, diagram.DefaultDiagramOptions())
+	assert.True(t, strings.HasPrefix(got, "digraph {\n"))
+	assert.True(t, strings.HasSuffix(got, "}\n"))
+	assert.Contains(t, got, `"store_Reader" [shape=ellipse,`)
+	assert.Contains(t, got, `label="store.Reader", tooltip="example.com/testmod.Reader"`)
+	assert.Contains(t, got, `"store_MemStore" [shape=box,`)
+	assert.Contains(t, got, `"store_MemStore" -> "store_Reader" [style=dashed, arrowhead=empty];`)
+	assert.Equal(t, 4, strings.Count(got, " -> "))
+
+	// Same deterministic order as the Mermaid generator.
+	assert.Less(t, strings.Index(got, `"store_ReadWriter" [`), strings.Index(got, `"store_Reader" [`))
+	assert.Equal(t, got, diagram.GenerateDOT(result, diagram.DefaultDiagramOptions()))
+
+	qualified := diagram.GenerateDOT(result, diagram.DiagramOptions{QualifiedIDs: true})
+	assert.Contains(t, qualified, `"example_com_testmod_MemStore" -> "example_com_testmod_Reader"`)
+}
+
+func TestDiagramDirection(t *testing.T) {
+	dir := testdataDir("03_multi_iface")
+	result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+	result = analyzer.Filter(result, analyzer.AnalyzeOptions{})
+
+	lr := diagram.DefaultDiagramOptions()
+	assert.Contains(t, diagram.GenerateMermaid(result, lr), "    direction LR\n", "LR is the default")
+	assert.True(t, strings.HasPrefix(diagram.GeneratePackageMapMermaid(result, lr), "flowchart LR"))
+
+	tb := diagram.DefaultDiagramOptions()
+	tb.Direction = diagram.DirectionTB
+	assert.Contains(t, diagram.GenerateMermaid(result, tb), "    direction TB\n")
+	assert.True(t, strings.HasPrefix(diagram.GeneratePackageMapMermaid(result, tb), "flowchart TB"))
+	assert.True(t, strings.HasPrefix(diagram.GeneratePackageMapMermaid(&analyzer.Result{}, tb), "flowchart TB"))
+	assert.Contains(t, diagram.GenerateDOT(result, tb), "    rankdir=TB;\n")
+	assert.Contains(t, diagram.GeneratePlantUML(result, tb), "top to bottom direction\n")
+	assert.Equal(t, diagram.DirectionTB, diagram.PrepareInteractiveData(result, tb).Direction)
+
+	rl := diagram.DiagramOptions{Direction: diagram.DirectionRL}
+	assert.Contains(t, diagram.GeneratePlantUML(result, rl), "left to right direction\n", "PlantUML has no RL")
+
+	bad := diagram.DiagramOptions{Direction: "diagonal"}
+	assert.Contains(t, diagram.GenerateMermaid(result, bad), "    direction LR\n", "invalid values fall back to LR")
+	assert.Equal(t, diagram.DirectionLR, diagram.PrepareInteractiveData(result, bad).Direction)
+}
+
+func TestParseDirection(t *testing.T) {
+	for in, want := range map[string]string{"": "LR", "lr": "LR", "TB": "TB", " bt ": "BT", "RL": "RL"} {
+		got, err := diagram.ParseDirection(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	got, err := diagram.ParseDirection("TD")
+	assert.ErrorIs(t, err, diagram.ErrInvalidDirection)
+	assert.Equal(t, diagram.DirectionLR, got)
+}
+
+func TestGenerateDOTEscaping(t *testing.T) {
+	iface := analyzer.InterfaceDef{Name: "Quoter", PkgPath: `example.com/we"ird\pkg`, PkgName: `we"ird`}
+	result := &analyzer.Result{Interfaces: []analyzer.InterfaceDef{iface}}
+	got := diagram.GenerateDOT(result, diagram.DiagramOptions{})
+	assert.Contains(t, got, `label="we\"ird.Quoter"`)
+	assert.Contains(t, got, `tooltip="example.com/we\"ird\\pkg.Quoter"`)
+
+	assert.Equal(t, "digraph {}\n", diagram.GenerateDOT(&analyzer.Result{}, diagram.DiagramOptions{}))
+}
+
+func TestGenerateMatrix(t *testing.T) {
+	store := analyzer.InterfaceDef{Name: "Store", PkgPath: "example.com/app/store", PkgName: "store"}
+	legacyStore := analyzer.InterfaceDef{Name: "Store", PkgPath: "example.com/app/legacy/store", PkgName: "store"}
+	closer := analyzer.InterfaceDef{Name: "Closer", PkgPath: "example.com/app/io", PkgName: "io"}
+	unused := analyzer.InterfaceDef{Name: "Unused", PkgPath: "example.com/app/io", PkgName: "io"}
+	mem := analyzer.TypeDef{Name: "Mem", PkgPath: "example.com/app/store", PkgName: "store"}
+	disk := analyzer.TypeDef{Name: "Disk", PkgPath: "example.com/app/store", PkgName: "store"}
+	lonely := analyzer.TypeDef{Name: "Lonely", PkgPath: "example.com/app/io", PkgName: "io"}
+	result := &analyzer.Result{
+		Interfaces: []analyzer.InterfaceDef{store, legacyStore, closer, unused},
+		Types:      []analyzer.TypeDef{mem, disk, lonely},
+		Relations: []analyzer.Relation{
+			{Type: &mem, Interface: &store},
+			{Type: &disk, Interface: &store},
+			{Type: &disk, Interface: &legacyStore},
+			{Type: &disk, Interface: &closer, ViaPointer: true},
+		},
+	}
+
+	assert.Equal(t, "Type,io.Closer,example.com/app/legacy/store.Store,example.com/app/store.Store\n"+
+		"store.Disk,✓,✓,✓\n"+
+		"store.Mem,,,✓\n",
+		diagram.GenerateMatrix(result, diagram.MatrixCSV, diagram.MatrixOptions{}),
+		"types and interfaces without relations are left out")
+
+	assert.Equal(t, "| Type | io.Closer | io.Unused | example.com/app/legacy/store.Store | example.com/app/store.Store |\n"+
+		"|---|:-:|:-:|:-:|:-:|\n"+
+		"| io.Lonely |  |  |  |  |\n"+
+		"| store.Disk | ✓* |  | ✓ | ✓ |\n"+
+		"| store.Mem |  |  |  | ✓ |\n",
+		diagram.GenerateMatrix(result, diagram.MatrixMarkdown, diagram.MatrixOptions{IncludeEmpty: true, MarkPointer: true}))
+
+	assert.Equal(t, "Type\n", diagram.GenerateMatrix(&analyzer.Result{}, diagram.MatrixCSV, diagram.MatrixOptions{}))
+}
+
+func TestGenerateGraphML(t *testing.T) {
+	dir := testdataDir("03_multi_iface")
+	result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+	result = analyzer.Filter(result, analyzer.AnalyzeOptions{})
+
+	got := diagram.GenerateGraphML(result)
+	var doc struct {
+		Keys []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"key"`
+		Graph struct {
+			EdgeDefault string `xml:"edgedefault,attr"`
+			Nodes       []struct {
+				ID   string `xml:"id,attr"`
+				Data []struct {
+					Key   string `xml:"key,attr"`
+					Value string `xml:",chardata"`
+				} `xml:"data"`
+			} `xml:"node"`
+			Edges []struct {
+				Source string `xml:"source,attr"`
+				Target string `xml:"target,attr"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(got), &doc))
+	assert.Len(t, doc.Keys, 4)
+	assert.Equal(t, "directed", doc.Graph.EdgeDefault)
+	assert.Len(t, doc.Graph.Nodes, len(result.Interfaces)+len(result.Types))
+	assert.Len(t, doc.Graph.Edges, 4)
+	assert.Contains(t, got, "    <node id=\"store_Reader\">\n      <data key=\"name\">store.Reader</data>\n      <data key=\"pkg\">example.com/testmod</data>\n      <data key=\"kind\">interface</data>\n")
+	assert.Contains(t, got, "<data key=\"kind\">type</data>")
+	assert.Contains(t, got, `source="store_MemStore" target="store_Reader"`)
+	assert.Equal(t, got, diagram.GenerateGraphML(result))
+}
+
+func TestGenerateGraphMLEdgeCases(t *testing.T) {
+	empty := diagram.GenerateGraphML(&analyzer.Result{})
+	require.NoError(t, xml.Unmarshal([]byte(empty), new(struct{})), "empty result is well-formed")
+	assert.Contains(t, empty, "<graph id=\"goifaces\" edgedefault=\"directed\">\n  </graph>\n</graphml>\n")
+
+	// Markup in names is escaped, and same-named nodes from packages sharing
+	// a short name get distinct ids.
+	a := analyzer.InterfaceDef{Name: "Store", PkgPath: "example.com/a/store", PkgName: "store"}
+	b := analyzer.InterfaceDef{Name: "Store", PkgPath: "example.com/b/store", PkgName: "store"}
+	odd := analyzer.TypeDef{Name: "T", PkgPath: "example.com/<&>", PkgName: "odd"}
+	result := &analyzer.Result{Interfaces: []analyzer.InterfaceDef{a, b}, Types: []analyzer.TypeDef{odd}}
+	got := diagram.GenerateGraphML(result)
+	require.NoError(t, xml.Unmarshal([]byte(got), new(struct{})))
+	assert.Contains(t, got, `<node id="store_Store">`)
+	assert.Contains(t, got, `<node id="example_com_b_store_Store">`)
+	assert.Contains(t, got, "<data key=\"pkg\">example.com/&lt;&amp;&gt;</data>")
+}
+
+// fakeMermaidCLI puts an mmdc shell script running body first on PATH, with
+// $2 the input and $4 the output file.
+func fakeMermaidCLI(t *testing.T, body string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake mmdc is a shell script")
+	}
+	bin := t.TempDir()
+	script := "#!/bin/sh\n" + body + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(bin, diagram.MermaidCLI), []byte(script), 0o755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestGenerateSVG(t *testing.T) {
+	dir := testdataDir("03_multi_iface")
+	result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+	result = analyzer.Filter(result, analyzer.AnalyzeOptions{})
+
+	// The fake wraps its input in an <svg> element, so the test sees what
+	// mmdc was fed.
+	fakeMermaidCLI(t, `[ "$1" = -i ] && [ "$3" = -o ] || exit 2
+{ echo '<svg xmlns="http://www.w3.org/2000/svg"><!--'; cat "$2"; echo '--></svg>'; } > "$4"`)
+	opts := diagram.DefaultDiagramOptions()
+	require.False(t, opts.IncludeInit)
+
+	svg, err := diagram.GenerateSVG(context.Background(), result, opts)
+	require.NoError(t, err)
+	got := string(svg)
+	assert.True(t, strings.HasPrefix(got, "<svg "))
+	assert.Contains(t, got, "%%{init:", "init directive is always included")
+	opts.IncludeInit = true
+	assert.Contains(t, got, diagram.GenerateMermaid(result, opts))
+}
+
+func TestGenerateSVGErrors(t *testing.T) {
+	result := &analyzer.Result{}
+
+	t.Run("mmdc missing", func(t *testing.T) {
+		t.Setenv("PATH", t.TempDir())
+		_, err := diagram.GenerateSVG(context.Background(), result, diagram.DiagramOptions{})
+		require.ErrorIs(t, err, diagram.ErrMermaidCLINotFound)
+		assert.Contains(t, err.Error(), "npm install -g @mermaid-js/mermaid-cli")
+	})
+
+	t.Run("stderr surfaced", func(t *testing.T) {
+		fakeMermaidCLI(t, `echo "Error: Parse error on line 3" >&2; exit 1`)
+		_, err := diagram.GenerateSVG(context.Background(), result, diagram.DiagramOptions{})
+		require.ErrorIs(t, err, diagram.ErrRenderFailed)
+		assert.Contains(t, err.Error(), "Parse error on line 3")
+	})
+
+	t.Run("no output", func(t *testing.T) {
+		fakeMermaidCLI(t, `exit 0`)
+		_, err := diagram.GenerateSVG(context.Background(), result, diagram.DiagramOptions{})
+		require.ErrorIs(t, err, diagram.ErrRenderFailed)
+	})
+}
+
+func TestGeneratePlantUML(t *testing.T) {
+	dir := testdataDir("03_multi_iface")
+	result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+	result = analyzer.Filter(result, analyzer.AnalyzeOptions{})
+
+	got := diagram.GeneratePlantUML(result, diagram.DefaultDiagramOptions())
+	assert.True(t, strings.HasPrefix(got, "@startuml\n"))
+	assert.True(t, strings.HasSuffix(got, "@enduml\n"))
+	assert.Contains(t, got, "\ninterface \"store.Reader\" as store_Reader {\n  +Read(")
+	assert.Contains(t, got, "\nclass \"store.MemStore\" as store_MemStore\n")
+	assert.Contains(t, got, "\nstore_MemStore ..|> store_Reader")
+	assert.Equal(t, 4, strings.Count(got, " ..|> "))
+
+	// Same deterministic order as the Mermaid generator.
+	assert.Less(t, strings.Index(got, "as store_ReadWriter"), strings.Index(got, "as store_Reader "))
+	assert.Equal(t, got, diagram.GeneratePlantUML(result, diagram.DefaultDiagramOptions()))
+
+	qualified := diagram.GeneratePlantUML(result, diagram.DiagramOptions{QualifiedIDs: true})
+	assert.Contains(t, qualified, "example_com_testmod_MemStore ..|> example_com_testmod_Reader")
+}
+
+func TestGeneratePlantUMLEdgeCases(t *testing.T) {
+	assert.Equal(t, "@startuml\n@enduml\n", diagram.GeneratePlantUML(&analyzer.Result{}, diagram.DiagramOptions{}))
+
+	iface := analyzer.InterfaceDef{Name: "Codec", PkgPath: "example.com/codec", PkgName: "codec",
+		Methods: []analyzer.MethodSig{
+			{Name: "Decode", Signature: "Decode(v interface{}) (struct{ N int }, error)"},
+			{Name: "Done", Signature: "Done() <-chan struct{}"},
+			{Name: "Encode", Signature: "Encode(v any) []byte"},
+		}}
+	typ := analyzer.TypeDef{Name: "JSON", PkgPath: "example.com/codec", PkgName: "codec", IsFunc: true}
+	result := &analyzer.Result{
+		Interfaces: []analyzer.InterfaceDef{iface},
+		Types:      []analyzer.TypeDef{typ},
+		Relations:  []analyzer.Relation{{Type: &typ, Interface: &iface, ViaPointer: true}},
+	}
+	got := diagram.GeneratePlantUML(result, diagram.DiagramOptions{MaxMethodsPerBox: 2})
+	assert.Contains(t, got, `interface "codec.Codec" as codec_Codec {`)
+	assert.Contains(t, got, "  +Decode(v any) (struct( N int ), error)\n")
+	assert.Contains(t, got, "  +Done() chan struct\n")
+	assert.Contains(t, got, "  .. 1 more ..\n}")
+	assert.Contains(t, got, `class "codec.JSON" as codec_JSON <<func>>`)
+	assert.Contains(t, got, "codec_JSON ..|> codec_Codec : *")
+}
+
+func TestGenerateD2(t *testing.T) {
+	dir := testdataDir("03_multi_iface")
+	result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+	result = analyzer.Filter(result, analyzer.AnalyzeOptions{})
+
+	got := diagram.GenerateD2(result, diagram.DefaultDiagramOptions())
+	assert.True(t, strings.HasPrefix(got, "direction: right\n"))
+	// Dotted names only appear quoted, never as keys D2 would nest
+	assert.Contains(t, got, "\nstore_Reader: \"store.Reader\" {\n  shape: oval\n  tooltip: \"example.com/testmod.Reader\"\n}\n")
+	assert.Contains(t, got, "\nstore_MemStore: \"store.MemStore\" {\n  shape: rectangle\n")
+	assert.Contains(t, got, "\nstore_MemStore -> store_Reader: \"implements\" {style.stroke-dash: 3}\n")
+	assert.Equal(t, 4, strings.Count(got, " -> "))
+
+	// Same deterministic order as the Mermaid generator.
+	assert.Less(t, strings.Index(got, "store_ReadWriter:"), strings.Index(got, "store_Reader:"))
+	assert.Equal(t, got, diagram.GenerateD2(result, diagram.DefaultDiagramOptions()))
+
+	qualified := diagram.GenerateD2(result, diagram.DiagramOptions{QualifiedIDs: true, Direction: diagram.DirectionTB})
+	assert.True(t, strings.HasPrefix(qualified, "direction: down\n"))
+	assert.Contains(t, qualified, "example_com_testmod_MemStore -> example_com_testmod_Reader")
+}
+
+func TestGenerateD2EdgeCases(t *testing.T) {
+	assert.Empty(t, diagram.GenerateD2(&analyzer.Result{}, diagram.DiagramOptions{}), "an empty file is a valid D2 diagram")
+
+	iface := analyzer.InterfaceDef{Name: "Codec", PkgPath: `example.com/co"dec`, PkgName: "codec"}
+	typ := analyzer.TypeDef{Name: "JSON", PkgPath: `example.com/co"dec`, PkgName: "codec"}
+	result := &analyzer.Result{
+		Interfaces: []analyzer.InterfaceDef{iface},
+		Types:      []analyzer.TypeDef{typ},
+		Relations:  []analyzer.Relation{{Type: &typ, Interface: &iface, ViaPointer: true}},
+	}
+	got := diagram.GenerateD2(result, diagram.DiagramOptions{})
+	assert.Contains(t, got, `tooltip: "example.com/co\"dec.Codec"`)
+	assert.Contains(t, got, `codec_JSON -> codec_Codec: "implements (*)" {style.stroke-dash: 3}`)
+
+	// IDs with characters NodeID keeps are quoted as keys
+	got = diagram.GenerateD2(result, diagram.DiagramOptions{QualifiedIDs: true})
+	assert.Contains(t, got, `"example_com_co\"dec_JSON" -> "example_com_co\"dec_Codec"`)
+}
+
+func TestInterfaceEmbedding(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/chain\n\ngo 1.21\n"), 0o644))
+	src := `package chain
+
+type Closer interface {
+	Close() error
+}
+
+type ReadCloser interface {
+	Closer
+	Read(p []byte) (int, error)
+}
+
+// ReadWriteCloser embeds ReadCloser, which embeds Closer.
+type ReadWriteCloser interface {
+	ReadCloser
+	Write(p []byte) (int, error)
+}
+
+type Failer interface {
+	error
+	Retry() bool
+}
+
+type Number interface {
+	~int | ~float64
+}
+
+// Key carries only type-set constraints.
+type Key interface {
+	comparable
+	Number
+}
+
+type File struct{}
+
+func (File) Close() error                { return nil }
+func (File) Read(p []byte) (int, error)  { return 0, nil }
+func (File) Write(p []byte) (int, error) { return 0, nil }
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "chain.go"), []byte(src), 0o644))
+
+	result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+
+	embeds := make(map[string][]string)
+	for _, iface := range result.Interfaces {
+		embeds[iface.Name] = iface.Embeds
+	}
+	assert.Empty(t, embeds["Closer"])
+	assert.Equal(t, []string{"example.com/chain.Closer"}, embeds["ReadCloser"])
+	assert.Equal(t, []string{"example.com/chain.ReadCloser"}, embeds["ReadWriteCloser"], "only direct embeds are recorded")
+	assert.Equal(t, []string{"builtin.error"}, embeds["Failer"])
+	assert.Empty(t, embeds["Key"], "comparable and type-set constraints are skipped")
+
+	got := diagram.GenerateMermaid(analyzer.Filter(result, analyzer.AnalyzeOptions{}), diagram.DefaultDiagramOptions())
+	assert.Contains(t, got, "chain_ReadCloser ..|> chain_Closer : embeds")
+	assert.Contains(t, got, "chain_ReadWriteCloser ..|> chain_ReadCloser : embeds")
+	assert.NotContains(t, got, "chain_ReadWriteCloser ..|> chain_Closer", "transitive embeds are not drawn")
+	assert.NotContains(t, got, "chain_Closer ..|>", "embedding never points back up the chain")
+}
+
+func TestEmbedRelations(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/embeds\n\ngo 1.21\n"), 0o644))
+	src := `package svc
+
+type Logger interface {
+	Log(msg string)
+}
+
+type Store interface {
+	Get(id string) string
+}
+
+type Base struct{}
+
+func (b *Base) Get(id string) string { return id }
+
+// Service embeds a pointer to a concrete type and an interface.
+type Service struct {
+	*Base
+	Logger
+}
+
+// Repo embeds Base by value; only *Repo gets the pointer method Get.
+type Repo struct {
+	Base
+	name string
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "svc.go"), []byte(src), 0o644))
+
+	result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+
+	type embed struct {
+		outer, target string
+		viaPointer    bool
+	}
+	var embeds []embed
+	for _, rel := range result.Embeds {
+		assert.Equal(t, analyzer.RelationEmbeds, rel.Kind)
+		embeds = append(embeds, embed{rel.Type.Name, rel.EmbedTargetKey(), rel.ViaPointer})
+	}
+	assert.ElementsMatch(t, []embed{
+		{"Repo", "example.com/embeds.Base", false},
+		{"Service", "example.com/embeds.Base", true},
+		{"Service", "example.com/embeds.Logger", false},
+	}, embeds)
+	for _, rel := range result.Relations {
+		assert.Equal(t, analyzer.RelationImplements, rel.Kind, "Relations holds implementations only")
+	}
+	assert.Contains(t, relationKeys(result), "Service -> Logger (ptr=false)", "embedding an interface still implements it")
+
+	filtered := analyzer.Filter(result, analyzer.AnalyzeOptions{})
+	assert.Len(t, filtered.Embeds, 3, "all endpoints take part in implementations")
+
+	got := diagram.GenerateMermaid(filtered, diagram.DefaultDiagramOptions())
+	assert.Contains(t, got, "svc_Service *-- svc_Logger")
+	assert.NotContains(t, got, "svc_Service --|> svc_Logger", "an embedded interface is not drawn twice")
+	assert.Contains(t, got, "svc_Service --|> svc_Store")
+	assert.Contains(t, got, "svc_Service *-- svc_Base")
+	assert.Contains(t, got, "svc_Repo *-- svc_Base")
+	assert.Equal(t, 3, strings.Count(got, "*--"))
+}
+
+func TestAnalyzeTestFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/withtests\n\ngo 1.21\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "store.go"), []byte(`package store
+
+type Store interface {
+	Get(id string) string
+}
+
+type Real struct{}
+
+func (Real) Get(id string) string { return id }
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "store_test.go"), []byte(`package store
+
+type FakeStore struct{}
+
+func (FakeStore) Get(id string) string { return "" }
+`), 0o644))
+
+	t.Run("excluded", func(t *testing.T) {
+		result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"Real -> Store (ptr=false)"}, relationKeys(result))
+	})
+
+	t.Run("included", func(t *testing.T) {
+		result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{IncludeTests: true}, testLogger())
+		require.NoError(t, err)
+		// The test variant replaces the plain package: no duplicates.
+		assert.ElementsMatch(t, []string{"Real -> Store (ptr=false)", "FakeStore -> Store (ptr=false)"}, relationKeys(result))
+		var stores int
+		for _, iface := range result.Interfaces {
+			if iface.Name == "Store" {
+				stores++
+			}
+		}
+		assert.Equal(t, 1, stores)
+	})
+}
+
+func TestByPackageSlides(t *testing.T) {
+	repo := analyzer.InterfaceDef{Name: "Repo", PkgPath: "example.com/app/store", PkgName: "store"}
+	handler := analyzer.InterfaceDef{Name: "Handler", PkgPath: "example.com/app/api", PkgName: "api"}
+	postgres := analyzer.TypeDef{Name: "Postgres", PkgPath: "example.com/app/store", PkgName: "store"}
+	srv := analyzer.TypeDef{Name: "Server", PkgPath: "example.com/app/api", PkgName: "api"}
+	result := &analyzer.Result{
+		Interfaces: []analyzer.InterfaceDef{repo, handler},
+		Types:      []analyzer.TypeDef{postgres, srv},
+		Relations: []analyzer.Relation{
+			{Type: &postgres, Interface: &repo},
+			{Type: &postgres, Interface: &handler},
+			{Type: &srv, Interface: &handler},
+		},
+	}
+
+	slides := diagram.BuildSlides(result, diagram.DiagramOptions{}, split.NewByPackage(split.DefaultOptions()), diagram.SlideOptions{Threshold: 1})
+
+	require.Equal(t, 3, len(slides), "package map + one slide per package")
+	assert.Equal(t, "Package Map", slides[0].Title)
+	assert.Equal(t, "api", slides[1].Title)
+	assert.Equal(t, "store", slides[2].Title)
+
+	// The cross-package Postgres -> Handler edge is drawn on both slides.
+	for _, slide := range slides[1:] {
+		assert.Contains(t, slide.Mermaid, "store_Postgres --|> api_Handler", "slide %s", slide.Title)
+	}
+	assert.Contains(t, slides[1].Mermaid, "api_Server")
+	assert.NotContains(t, slides[1].Mermaid, "store_Repo")
+	assert.Contains(t, slides[2].Mermaid, "store_Repo")
+	assert.NotContains(t, slides[2].Mermaid, "api_Server")
+}
+
+func TestFilterExclude(t *testing.T) {
+	repo := analyzer.InterfaceDef{Name: "Repo", PkgPath: "example.com/app/store", PkgName: "store"}
+	handler := analyzer.InterfaceDef{Name: "Handler", PkgPath: "example.com/app/api", PkgName: "api"}
+	marker := analyzer.InterfaceDef{Name: "Fake", PkgPath: "example.com/app/store/mocks", PkgName: "mocks", IsMarker: true}
+	postgres := analyzer.TypeDef{Name: "Postgres", PkgPath: "example.com/app/store", PkgName: "store"}
+	mockRepo := analyzer.TypeDef{Name: "MockRepo", PkgPath: "example.com/app/store/mocks", PkgName: "mocks"}
+	srv := analyzer.TypeDef{Name: "Server", PkgPath: "example.com/app/api", PkgName: "api"}
+	fixture := analyzer.TypeDef{Name: "Fixture", PkgPath: "example.com/app/testdata", PkgName: "testdata"}
+	// An alias in an excluded package of a kept type
+	alias := analyzer.TypeDef{Name: "DB", PkgPath: "example.com/app/store/mocks", PkgName: "mocks", AliasOf: "example.com/app/store.Postgres"}
+	result := &analyzer.Result{
+		ModulePath: "example.com/app",
+		Interfaces: []analyzer.InterfaceDef{repo, handler, marker},
+		Types:      []analyzer.TypeDef{postgres, mockRepo, srv, fixture, alias},
+		Relations: []analyzer.Relation{
+			{Type: &postgres, Interface: &repo},
+			{Type: &mockRepo, Interface: &repo},
+			{Type: &srv, Interface: &handler},
+			{Type: &fixture, Interface: &handler},
+		},
+	}
+	names := func(r *analyzer.Result) []string {
+		var out []string
+		for _, iface := range r.Interfaces {
+			out = append(out, iface.Name)
+		}
+		for _, typ := range r.Types {
+			out = append(out, typ.Name)
+		}
+		return out
+	}
+
+	opts := analyzer.AnalyzeOptions{Exclude: []string{"**/mocks/**", "testdata"}, IncludeMarkers: true}
+	filtered := analyzer.Filter(result, opts)
+	assert.Equal(t, []string{"Repo", "Handler", "Postgres", "Server"}, names(filtered),
+		"excluded packages lose their interfaces, types, markers and aliases")
+	assert.Len(t, filtered.Relations, 2)
+
+	for _, node := range diagram.PreparePackageMapData(filtered) {
+		assert.NotContains(t, node.PkgPath, "mocks")
+		assert.NotContains(t, node.PkgPath, "testdata")
+	}
+	assert.NotContains(t, diagram.GeneratePackageMapMermaid(filtered, diagram.DiagramOptions{}), "mocks")
+	slides := diagram.BuildSlides(filtered, diagram.DiagramOptions{}, split.NewByPackage(split.DefaultOptions()), diagram.SlideOptions{Threshold: 1})
+	require.Len(t, slides, 3, "package map + api + store")
+	for _, slide := range slides {
+		assert.NotContains(t, slide.Mermaid, "mocks", "slide %s", slide.Title)
+	}
+
+	// Exclusion runs after the -filter prefix: excluding the filtered package
+	// leaves nothing
+	prefixed := analyzer.Filter(result, analyzer.AnalyzeOptions{Filter: "example.com/app/store", Exclude: []string{"mocks"}})
+	assert.Equal(t, []string{"Repo", "Postgres"}, names(prefixed))
+	empty := analyzer.Filter(result, analyzer.AnalyzeOptions{Filter: "example.com/app/api", Exclude: []string{"**/api"}})
+	assert.Empty(t, empty.Relations)
+	assert.Empty(t, names(empty))
+
+	unfiltered := analyzer.Filter(result, analyzer.AnalyzeOptions{IncludeMarkers: true})
+	assert.Len(t, unfiltered.Relations, 4)
+	assert.Contains(t, names(unfiltered), "DB")
+}
+
+func TestShowTypeMethods(t *testing.T) {
+	stringer := analyzer.InterfaceDef{Name: "Stringer", PkgPath: "fmt", PkgName: "fmt",
+		Methods: []analyzer.MethodSig{{Name: "String", Signature: "String() string"}}}
+	id := analyzer.TypeDef{Name: "ID", PkgPath: "example.com/app/model", PkgName: "model",
+		Methods: []analyzer.MethodSig{
+			{Name: "Bytes", Signature: "Bytes() []byte"},
+			{Name: "Fields", Signature: "Fields() map[string]interface{}"},
+			{Name: "String", Signature: "String() string"},
+		}}
+	empty := analyzer.TypeDef{Name: "Marker", PkgPath: "example.com/app/model", PkgName: "model"}
+	result := &analyzer.Result{
+		Interfaces: []analyzer.InterfaceDef{stringer},
+		Types:      []analyzer.TypeDef{id, empty},
+		Relations:  []analyzer.Relation{{Type: &id, Interface: &stringer}},
+	}
+
+	plain := diagram.GenerateMermaid(result, diagram.DiagramOptions{MaxMethodsPerBox: 2})
+	assert.Contains(t, plain, "    class model_ID {\n    }")
+	assert.NotContains(t, plain, "+Bytes")
+
+	got := diagram.GenerateMermaid(result, diagram.DiagramOptions{MaxMethodsPerBox: 2, ShowTypeMethods: true})
+	assert.Contains(t, got, "    class model_ID {\n        +Bytes() []byte\n        +Fields() map[string]any\n        ... (3 total)\n    }")
+	assert.Contains(t, got, "    class model_Marker {\n    }")
+
+	unlimited := diagram.GenerateMermaid(result, diagram.DiagramOptions{ShowTypeMethods: true})
+	assert.Contains(t, unlimited, "    class model_ID {\n        +Bytes() []byte\n        +Fields() map[string]any\n        +String() string\n    }")
+	assert.NotContains(t, unlimited, "...")
+}
+
+func TestGroupByPackage(t *testing.T) {
+	repo := analyzer.InterfaceDef{Name: "Repository", PkgPath: "example.com/app/store", PkgName: "store",
+		Methods: []analyzer.MethodSig{{Name: "Get", Signature: "Get(id string) error"}}}
+	pg := analyzer.TypeDef{Name: "Postgres", PkgPath: "example.com/app/store", PkgName: "store"}
+	mem := analyzer.TypeDef{Name: "Memory", PkgPath: "example.com/app/cache", PkgName: "cache"}
+	legacy := analyzer.TypeDef{Name: "Legacy", PkgPath: "example.com/app/legacy/store", PkgName: "store"}
+	result := &analyzer.Result{
+		Interfaces: []analyzer.InterfaceDef{repo},
+		Types:      []analyzer.TypeDef{pg, mem, legacy},
+		Relations: []analyzer.Relation{
+			{Type: &pg, Interface: &repo},
+			{Type: &mem, Interface: &repo},
+			{Type: &legacy, Interface: &repo},
+		},
+	}
+
+	flat := diagram.GenerateMermaid(result, diagram.DefaultDiagramOptions())
+	assert.NotContains(t, flat, "namespace")
+
+	opts := diagram.DefaultDiagramOptions()
+	opts.GroupByPackage = true
+	opts.QualifiedIDs = true
+	got := diagram.GenerateMermaid(result, opts)
+
+	assert.Contains(t, got, "\n    namespace cache {\n        class example_com_app_cache_Memory {\n        }\n    }")
+	assert.Contains(t, got, "\n    namespace example_com_app_legacy_store {\n        class example_com_app_legacy_store_Legacy {\n        }\n    }",
+		"packages sharing a short name get namespaces named after their paths")
+	assert.Contains(t, got, "\n    namespace example_com_app_store {\n        class example_com_app_store_Repository {\n            <<interface>>\n            +Get(id string) error\n        }\n        class example_com_app_store_Postgres {\n        }\n    }")
+	assert.Less(t, strings.Index(got, "namespace cache"), strings.Index(got, "namespace example_com_app_legacy_store"))
+
+	// Relations and styles are emitted after the last namespace block.
+	lastBlock := strings.LastIndex(got, "\n    }")
+	assert.Greater(t, strings.Index(got, "example_com_app_store_Postgres --|> example_com_app_store_Repository"), lastBlock)
+	assert.Greater(t, strings.Index(got, `cssClass "example_com_app_store_Postgres" implStyle`), lastBlock)
+
+	// Node IDs keep their package prefix inside the blocks.
+	short := diagram.GenerateMermaid(result, diagram.DiagramOptions{GroupByPackage: true})
+	assert.Contains(t, short, "namespace cache {\n        class cache_Memory {")
+}
+
+func TestPointerReceiverRelations(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/ptr\n\ngo 1.21\n"), 0o644))
+	src := `package ptr
+
+type Namer interface {
+	Name() string
+}
+
+type Renamer interface {
+	Name() string
+	Rename(n string)
+}
+
+type File struct{ name string }
+
+func (f File) Name() string       { return f.name }
+func (f *File) Rename(n string)   { f.name = n }
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ptr.go"), []byte(src), 0o644))
+
+	result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+
+	// File satisfies Namer through both T and *T: one value relation, no
+	// duplicate pointer one. Renamer needs *File.
+	assert.ElementsMatch(t, []string{
+		"File -> Namer (ptr=false)",
+		"File -> Renamer (ptr=true)",
+	}, relationKeys(result))
+
+	got := diagram.GenerateMermaid(result, diagram.DefaultDiagramOptions())
+	assert.Contains(t, got, "ptr_File --|> ptr_Renamer : *")
+	assert.Contains(t, got, "ptr_File --|> ptr_Namer\n")
+	assert.NotContains(t, got, "ptr_File --|> ptr_Namer : *")
+
+	data := diagram.PrepareInteractiveData(result, diagram.DefaultDiagramOptions())
+	viaPointer := make(map[string]bool)
+	for _, rel := range data.Relations {
+		viaPointer[rel.InterfaceID] = rel.ViaPointer
+	}
+	assert.Equal(t, map[string]bool{"ptr_Namer": false, "ptr_Renamer": true}, viaPointer)
+}
+
+func TestAnalyzeWorkspace(t *testing.T) {
+	// Workspace mode rejects -mod=mod, which some environments set globally.
+	t.Setenv("GOFLAGS", "")
+
+	ws := t.TempDir()
+	for name, content := range map[string]string{
+		"go.work":       "go 1.21\n\nuse (\n\t./speech\n\t./pets\n)\n",
+		"speech/go.mod": "module example.com/ws/speech\n\ngo 1.21\n",
+		"speech/speaker.go": `package speech
+
+type Speaker interface {
+	Speak() string
+}
+`,
+		"pets/go.mod": "module example.com/ws/pets\n\ngo 1.21\n",
+		"pets/dog.go": `package pets
+
+type Dog struct{}
+
+func (Dog) Speak() string { return "woof" }
+`,
+	} {
+		path := filepath.Join(ws, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	result, err := analyzer.Analyze(context.Background(), ws, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+	assert.Equal(t, "example.com/ws", result.ModulePath)
+
+	// Dog (module pets) implements Speaker (module speech).
+	assert.ElementsMatch(t, []string{"Dog -> Speaker (ptr=false)"}, relationKeys(result))
+	for _, iface := range result.Interfaces {
+		if iface.Name == "Speaker" {
+			assert.Equal(t, filepath.Join("speech", "speaker.go"), iface.SourceFile)
+		}
+	}
+	for _, typ := range result.Types {
+		if typ.Name == "Dog" {
+			assert.Equal(t, filepath.Join("pets", "dog.go"), typ.SourceFile)
+		}
+	}
+
+	// Both modules are local, so the filter keeps the cross-module relation.
+	filtered := analyzer.Filter(result, analyzer.AnalyzeOptions{})
+	assert.ElementsMatch(t, []string{"Dog -> Speaker (ptr=false)"}, relationKeys(filtered))
+}
+
+func TestResultJSONRoundTrip(t *testing.T) {
+	dir := testdataDir("11_produces")
+	result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+
+	data, err := analyzer.MarshalResult(result)
+	require.NoError(t, err)
+	var envelope struct {
+		SchemaVersion int              `json:"schemaVersion"`
+		Interfaces    []map[string]any `json:"interfaces"`
+		Types         []map[string]any `json:"types"`
+		Relations     []map[string]any `json:"relations"`
+	}
+	require.NoError(t, json.Unmarshal(data, &envelope))
+	assert.Equal(t, analyzer.ResultSchemaVersion, envelope.SchemaVersion)
+	assert.Len(t, envelope.Interfaces, len(result.Interfaces))
+	assert.Len(t, envelope.Types, len(result.Types))
+	assert.Len(t, envelope.Relations, len(result.Relations))
+	assert.Contains(t, envelope.Types[0], "isStruct")
+	assert.Contains(t, envelope.Relations[0], "viaPointer")
+
+	back, err := analyzer.UnmarshalResult(data)
+	require.NoError(t, err)
+	again, err := analyzer.MarshalResult(back)
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(again), "round trip is lossless")
+	methods := make(map[string][]analyzer.MethodSig)
+	for _, iface := range result.Interfaces {
+		methods[iface.PkgPath+"."+iface.Name] = iface.Methods
+	}
+	for _, iface := range back.Interfaces {
+		assert.Equal(t, methods[iface.PkgPath+"."+iface.Name], iface.Methods, "signatures are re-sanitized on decode")
+	}
+	for _, rel := range back.Relations {
+		assert.Contains(t, back.Interfaces, *rel.Interface, "relations point into the rebuilt result")
+	}
+}
+
+func TestResultJSONDeterministic(t *testing.T) {
+	reader := analyzer.InterfaceDef{Name: "Reader", PkgPath: "example.com/m", PkgName: "m",
+		Methods: []analyzer.MethodSig{{Name: "Read", Signature: "Read() <-chan struct{}"}},
+		Embeds:  []string{"example.com/m.Z", "example.com/m.A"}}
+	closer := analyzer.InterfaceDef{Name: "Closer", PkgPath: "example.com/m", PkgName: "m"}
+	file := analyzer.TypeDef{Name: "File", PkgPath: "example.com/m", PkgName: "m", IsStruct: true, SourceFile: "file.go"}
+	base := analyzer.TypeDef{Name: "Base", PkgPath: "example.com/m", PkgName: "m", IsStruct: true}
+	// build lists every slice in the given order (or reversed), with the
+	// relations pointing at the same definitions either way.
+	build := func(reverse bool) *analyzer.Result {
+		r := &analyzer.Result{
+			ModulePath: "example.com/m",
+			Interfaces: []analyzer.InterfaceDef{reader, closer},
+			Types:      []analyzer.TypeDef{file, base},
+		}
+		iReader, iCloser, tFile, tBase := 0, 1, 0, 1
+		if reverse {
+			r.Interfaces = []analyzer.InterfaceDef{closer, reader}
+			r.Types = []analyzer.TypeDef{base, file}
+			iReader, iCloser, tFile, tBase = 1, 0, 1, 0
+		}
+		r.Relations = []analyzer.Relation{
+			{Type: &r.Types[tFile], Interface: &r.Interfaces[iReader], ViaPointer: true},
+			{Type: &r.Types[tFile], Interface: &r.Interfaces[iCloser]},
+		}
+		r.Embeds = []analyzer.Relation{
+			{Type: &r.Types[tFile], Interface: &r.Interfaces[iCloser], Kind: analyzer.RelationEmbeds, ViaPointer: true},
+			{Type: &r.Types[tFile], Embedded: &r.Types[tBase], Kind: analyzer.RelationEmbeds},
+		}
+		if reverse {
+			r.Relations[0], r.Relations[1] = r.Relations[1], r.Relations[0]
+			r.Embeds[0], r.Embeds[1] = r.Embeds[1], r.Embeds[0]
+		}
+		return r
+	}
+
+	a, err := analyzer.MarshalResult(build(false))
+	require.NoError(t, err)
+	b, err := analyzer.MarshalResult(build(true))
+	require.NoError(t, err)
+	assert.Equal(t, string(a), string(b), "input order does not matter")
+
+	rj := analyzer.NewResultJSON(build(false))
+	assert.Equal(t, "example.com/m.Closer", rj.Interfaces[0].ID)
+	assert.Equal(t, []string{"example.com/m.A", "example.com/m.Z"}, rj.Interfaces[1].Embeds)
+	assert.Equal(t, "example.com/m.Base", rj.Types[0].ID)
+	assert.Equal(t, []analyzer.RelationJSON{
+		{Type: "example.com/m.File", Interface: "example.com/m.Closer"},
+		{Type: "example.com/m.File", Interface: "example.com/m.Reader", ViaPointer: true},
+	}, rj.Relations)
+	assert.Equal(t, []analyzer.EmbedJSON{
+		{Type: "example.com/m.File", Embedded: "example.com/m.Base"},
+		{Type: "example.com/m.File", Interface: "example.com/m.Closer", ViaPointer: true},
+	}, rj.Embeds)
+
+	back, err := analyzer.UnmarshalResult(a)
+	require.NoError(t, err)
+	require.Len(t, back.Embeds, 2)
+	assert.Equal(t, analyzer.RelationEmbeds, back.Embeds[0].Kind)
+	again, err := analyzer.MarshalResult(back)
+	require.NoError(t, err)
+	assert.Equal(t, string(a), string(again))
+}
+
+func TestResultJSONErrors(t *testing.T) {
+	_, err := analyzer.UnmarshalResult([]byte(`{"schemaVersion":2,"interfaces":[],"types":[],"relations":[],"embeds":[]}`))
+	require.ErrorIs(t, err, analyzer.ErrUnsupportedSchema)
+
+	_, err = analyzer.UnmarshalResult([]byte(`{"schemaVersion":1,"relations":[{"type":"m.T","interface":"m.I"}]}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "m.T -> m.I")
+
+	_, err = analyzer.UnmarshalResult([]byte(`not json`))
+	require.Error(t, err)
+}
+
+func TestFilterByMinConnections(t *testing.T) {
+	store := analyzer.InterfaceDef{Name: "Store", PkgPath: "example.com/m", PkgName: "m"}
+	closer := analyzer.InterfaceDef{Name: "Closer", PkgPath: "example.com/m", PkgName: "m"}
+	unused := analyzer.InterfaceDef{Name: "Unused", PkgPath: "example.com/m", PkgName: "m"}
+	mem := analyzer.TypeDef{Name: "Mem", PkgPath: "example.com/m", PkgName: "m"}
+	disk := analyzer.TypeDef{Name: "Disk", PkgPath: "example.com/m", PkgName: "m"}
+	lone := analyzer.TypeDef{Name: "Lone", PkgPath: "example.com/m", PkgName: "m"}
+	result := &analyzer.Result{
+		Interfaces: []analyzer.InterfaceDef{store, closer, unused},
+		Types:      []analyzer.TypeDef{mem, disk, lone},
+		Relations: []analyzer.Relation{
+			{Type: &mem, Interface: &store},
+			{Type: &mem, Interface: &closer},
+			{Type: &disk, Interface: &store},
+		},
+	}
+	names := func(r *analyzer.Result) []string {
+		var out []string
+		for _, iface := range r.Interfaces {
+			out = append(out, iface.Name)
+		}
+		for _, typ := range r.Types {
+			out = append(out, typ.Name)
+		}
+		return out
+	}
+
+	assert.Same(t, result, analyzer.FilterByMinConnections(result, 0))
+
+	one := analyzer.FilterByMinConnections(result, 1)
+	assert.Equal(t, []string{"Store", "Closer", "Mem", "Disk"}, names(one), "only isolated nodes go")
+	assert.Len(t, one.Relations, 3, "every connected node keeps all its relations")
+
+	// Closer and Disk have one relation each; their edges go with them.
+	two := analyzer.FilterByMinConnections(result, 2)
+	assert.Equal(t, []string{"Store", "Mem"}, names(two))
+	require.Len(t, two.Relations, 1)
+	assert.Equal(t, "Mem", two.Relations[0].Type.Name)
+
+	assert.Empty(t, names(analyzer.FilterByMinConnections(result, 3)))
+	assert.Len(t, result.Relations, 3, "input is not modified")
+}
+
+func TestFilterByMinImplementers(t *testing.T) {
+	store := analyzer.InterfaceDef{Name: "Store", PkgPath: "example.com/m/store", PkgName: "store"}
+	closer := analyzer.InterfaceDef{Name: "Closer", PkgPath: "example.com/m/io", PkgName: "io"}
+	mem := analyzer.TypeDef{Name: "Mem", PkgPath: "example.com/m/store", PkgName: "store"}
+	disk := analyzer.TypeDef{Name: "Disk", PkgPath: "example.com/m/store", PkgName: "store"}
+	file := analyzer.TypeDef{Name: "File", PkgPath: "example.com/m/io", PkgName: "io"}
+	result := &analyzer.Result{
+		Interfaces: []analyzer.InterfaceDef{store, closer},
+		Types:      []analyzer.TypeDef{mem, disk, file},
+		Relations: []analyzer.Relation{
+			{Type: &mem, Interface: &store},
+			{Type: &disk, Interface: &store},
+			{Type: &mem, Interface: &closer},
+			// File implements Closer through both receivers: still one implementer.
+			{Type: &file, Interface: &closer},
+			{Type: &file, Interface: &closer, ViaPointer: true},
+		},
+	}
+
+	assert.Same(t, result, analyzer.FilterByMinImplementers(result, 0))
+	assert.Len(t, analyzer.FilterByMinImplementers(result, 2).Interfaces, 2)
+
+	// Closer has three edges but only two implementers.
+	three := analyzer.FilterByMinImplementers(result, 3)
+	assert.Empty(t, three.Interfaces)
+	assert.Empty(t, three.Types)
+
+	// Without Disk, Store has one implementer: it and its edge go, while Mem
+	// stays as an implementer of Closer.
+	noDisk := &analyzer.Result{
+		Interfaces: result.Interfaces,
+		Types:      []analyzer.TypeDef{mem, file},
+		Relations:  []analyzer.Relation{result.Relations[0], result.Relations[2], result.Relations[3], result.Relations[4]},
+	}
+	kept := analyzer.FilterByMinImplementers(noDisk, 2)
+	require.Len(t, kept.Interfaces, 1)
+	assert.Equal(t, "Closer", kept.Interfaces[0].Name)
+	assert.Len(t, kept.Types, 2, "Mem still implements Closer")
+	assert.Len(t, kept.Relations, 3)
+	assert.Len(t, noDisk.Relations, 4, "input is not modified")
+
+	// Dropped interfaces no longer count in the package map.
+	counts := make(map[string]int)
+	var walk func([]*diagram.PackageMapNode)
+	walk = func(nodes []*diagram.PackageMapNode) {
+		for _, n := range nodes {
+			counts[n.PkgPath] = n.Interfaces
+			walk(n.Children)
+		}
+	}
+	walk(diagram.PreparePackageMapData(kept))
+	assert.Zero(t, counts["example.com/m/store"], "Store was dropped")
+	assert.Equal(t, 1, counts["example.com/m/io"])
+}
+
+func TestFilterByMinConnectionsAfterFilter(t *testing.T) {
+	dir := testdataDir("09_unexported")
+	result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+
+	// Only the relations Filter keeps are counted: without unexported
+	// names, Runner and Cat have one relation each.
+	exported := analyzer.Filter(result, analyzer.AnalyzeOptions{})
+	assert.Equal(t, []string{"Cat -> Runner (ptr=false)"}, relationKeys(analyzer.FilterByMinConnections(exported, 1)))
+	assert.Empty(t, analyzer.FilterByMinConnections(exported, 2).Relations)
+
+	// With them, Runner and dog reach two.
+	all := analyzer.Filter(result, analyzer.AnalyzeOptions{IncludeUnexported: true})
+	assert.Len(t, analyzer.FilterByMinConnections(all, 1).Relations, len(all.Relations))
+	assert.Equal(t, []string{"dog -> Runner (ptr=false)"}, relationKeys(analyzer.FilterByMinConnections(all, 2)))
+}
+
+func TestAnalyzeSubdir(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":               "module example.com/app\n\ngo 1.21\n",
+		"service/store.go":     "package service\n\ntype Store interface {\n\tGet() int\n}\n\ntype Mem struct{}\n\nfunc (Mem) Get() int { return 0 }\n",
+		"service/cache/lru.go": "package cache\n\ntype LRU struct{}\n\nfunc (LRU) Get() int { return 0 }\n",
+		"other/disk/disk.go":   "package disk\n\ntype Disk struct{}\n\nfunc (Disk) Get() int { return 0 }\n\ntype Getter interface {\n\tGet() int\n}\n",
+	}
+	for name, src := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(src), 0o644))
+	}
+
+	result, err := analyzer.Analyze(context.Background(), root, analyzer.AnalyzeOptions{Subdir: "service"}, testLogger())
+	require.NoError(t, err)
+	result = analyzer.Filter(result, analyzer.AnalyzeOptions{})
+
+	sourceFiles := make(map[string]string)
+	for _, iface := range result.Interfaces {
+		sourceFiles[iface.Name] = iface.SourceFile
+	}
+	for _, typ := range result.Types {
+		sourceFiles[typ.Name] = typ.SourceFile
+	}
+	assert.Equal(t, map[string]string{
+		"Store": "service/store.go",
+		"Mem":   "service/store.go",
+		"LRU":   "service/cache/lru.go",
+	}, sourceFiles, "only service and below are loaded; paths stay relative to the module root")
+	assert.Equal(t, "example.com/app", result.ModulePath)
+}
+
+func TestLoadTheme(t *testing.T) {
+	def, err := diagram.LoadTheme("")
+	require.NoError(t, err)
+	assert.Equal(t, diagram.DefaultPalette(), def)
+	for _, name := range diagram.ThemeNames() {
+		p, err := diagram.LoadTheme(name)
+		require.NoError(t, err, name)
+		assert.NoError(t, p.Validate(), name)
+	}
+
+	_, err = diagram.LoadTheme("neon")
+	assert.ErrorIs(t, err, diagram.ErrUnknownTheme)
+	assert.Contains(t, err.Error(), "colorblind, default, grayscale")
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+
+	// Colors the file leaves out keep their default
+	p, err := diagram.LoadTheme(write("partial.json", `{"interface": {"fill": "#ff6600", "stroke": "#cc5200", "text": "#000"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, diagram.NodeColors{Fill: "#ff6600", Stroke: "#cc5200", Text: "#000"}, p.Interface)
+	assert.Equal(t, diagram.DefaultPalette().Impl, p.Impl)
+	assert.Equal(t, diagram.DefaultPalette().Packages, p.Packages)
+
+	p, err = diagram.LoadTheme(write("packages.json", `{"packages": [{"fill": "#eeeeee", "stroke": "#999999", "text": "#111111"}]}`))
+	require.NoError(t, err)
+	assert.Len(t, p.Packages, 1)
+
+	for name, content := range map[string]string{
+		"named.json":   `{"impl": {"fill": "green", "stroke": "#357a50", "text": "#fff"}}`,
+		"inject.json":  `{"impl": {"fill": "#fff,stroke:#000", "stroke": "#357a50", "text": "#fff"}}`,
+		"package.json": `{"packages": [{"fill": "#eeeeee", "stroke": "#99999", "text": "#111"}]}`,
+		"empty.json":   `{"packages": []}`,
+		"unknown.json": `{"interfaces": {}}`,
+		"notjson.json": `{`,
+	} {
+		_, err := diagram.LoadTheme(write(name, content))
+		assert.ErrorIs(t, err, diagram.ErrInvalidPalette, name)
+	}
+	_, err = diagram.LoadTheme(write("named2.json", `{"impl": {"fill": "green", "stroke": "#357a50", "text": "#fff"}}`))
+	assert.Contains(t, err.Error(), `impl.fill: "green" is not a #rgb or #rrggbb color`)
+	_, err = diagram.LoadTheme(write("package2.json", `{"packages": [{"fill": "#eee", "stroke": "#99999", "text": "#111"}]}`))
+	assert.Contains(t, err.Error(), "packages[0].stroke")
+
+	_, err = diagram.LoadTheme(filepath.Join(dir, "missing.json"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestPaletteInDiagrams(t *testing.T) {
+	dir := testdataDir("03_multi_iface")
+	result, err := analyzer.Analyze(context.Background(), dir, analyzer.AnalyzeOptions{}, testLogger())
+	require.NoError(t, err)
+	result = analyzer.Filter(result, analyzer.AnalyzeOptions{})
+
+	// The default palette keeps the original colors
+	opts := diagram.DefaultDiagramOptions()
+	assert.Contains(t, diagram.GenerateMermaid(result, opts), "classDef interfaceStyle fill:#2374ab,stroke:#1a5a8a,color:#ffffff")
+	assert.Contains(t, diagram.GeneratePackageMapMermaid(result, opts), "classDef pkgColor0 fill:#e8f4fd,stroke:#b8d4e8,color:#333333")
+
+	palette, err := diagram.LoadTheme("grayscale")
+	require.NoError(t, err)
+	opts.Palette = &palette
+	opts.MarkExternal = true
+	opts.MarkPorts = true
+	mermaid := diagram.GenerateMermaid(result, opts)
+	assert.Contains(t, mermaid, "classDef interfaceStyle fill:#404040,stroke:#1a1a1a,color:#ffffff,")
+	assert.Contains(t, mermaid, "classDef implStyle fill:#d9d9d9,stroke:#808080,color:#000000,")
+	assert.Contains(t, mermaid, "classDef externalImplStyle fill:#e8e8e8,stroke:#808080,")
+	assert.Contains(t, mermaid, "classDef portStyle fill:#404040,stroke:#f0a500,color:#ffffff,")
+	assert.NotContains(t, mermaid, "#2374ab")
+
+	pkgMap := diagram.GeneratePackageMapMermaid(result, opts)
+	assert.Contains(t, pkgMap, "classDef pkgColor1 fill:#e0e0e0,stroke:#9e9e9e,color:#333333")
+	assert.NotContains(t, pkgMap, "pkgColor2", "the package colors cycle through the palette's list")
+
+	assert.Contains(t, diagram.GenerateDOT(result, opts), `fillcolor="#d9d9d9", color="#808080", fontcolor="#000000"`)
+	assert.Contains(t, diagram.GeneratePlantUML(result, opts), "skinparam interface {\n  BackgroundColor #404040\n")
+
+	data := diagram.PrepareInteractiveData(result, opts)
+	require.NotNil(t, data.Palette)
+	assert.Equal(t, palette, *data.Palette)
+}
+
+func TestRelationScoreLabels(t *testing.T) {
+	const pkg = "example.com/app/store"
+	// Store embeds Closer, which Mermaid draws as a dotted "..|> : embeds"
+	store := analyzer.InterfaceDef{Name: "Store", PkgPath: pkg, PkgName: "store", Embeds: []string{pkg + ".Closer"}}
+	closer := analyzer.InterfaceDef{Name: "Closer", PkgPath: pkg, PkgName: "store"}
+	errIface := analyzer.InterfaceDef{Name: "error", PkgPath: "builtin", PkgName: "builtin"}
+	mem := analyzer.TypeDef{Name: "Mem", PkgPath: pkg, PkgName: "store"}
+	disk := analyzer.TypeDef{Name: "Disk", PkgPath: pkg, PkgName: "store"}
+	notFound := analyzer.TypeDef{Name: "NotFound", PkgPath: pkg, PkgName: "store"}
+	conflict := analyzer.TypeDef{Name: "Conflict", PkgPath: pkg, PkgName: "store"}
+	result := &analyzer.Result{
+		Interfaces: []analyzer.InterfaceDef{store, closer, errIface},
+		Types:      []analyzer.TypeDef{mem, disk, notFound, conflict},
+		Relations: []analyzer.Relation{
+			{Type: &mem, Interface: &store},
+			{Type: &disk, Interface: &store},
+			{Type: &disk, Interface: &closer, ViaPointer: true},
+			{Type: &notFound, Interface: &errIface},
+			{Type: &conflict, Interface: &errIface},
+		},
+	}
+	// Indexed into result.Relations, as enricher.Scorer returns them;
+	// Conflict is left unscored
+	scores := map[int]float64{0: 0.1, 1: 1, 2: 0.3, 3: 2}
+	relScores := diagram.ScoresByRelation(result, scores)
+	require.Len(t, relScores, 4)
+	assert.Nil(t, diagram.ScoresByRelation(result, nil))
+	assert.Len(t, diagram.ScoresByRelation(result, map[int]float64{7: 1}), 0, "out-of-range indices are dropped")
+
+	got := diagram.GenerateMermaid(result, diagram.DiagramOptions{RelationScores: relScores})
+	assert.Contains(t, got, "store_Mem --|> store_Store : 0.10\n", "low scores keep the implements arrow")
+	assert.Contains(t, got, "store_Disk --|> store_Store : 1.00\n")
+	assert.Contains(t, got, "store_Disk --|> store_Closer : * 0.30\n", "the pointer label is kept")
+	assert.Contains(t, got, "store_NotFound --|> builtin_error : 2.00\n")
+	assert.Contains(t, got, "store_Conflict --|> builtin_error\n", "unscored relations are unlabeled")
+	assert.Contains(t, got, "store_Store ..|> store_Closer : embeds")
+	assert.Equal(t, 1, strings.Count(got, "..|>"), "only the embed is dotted")
+	assert.NotContains(t, got, "linkStyle", "classDiagram has no linkStyle")
+
+	plain := diagram.GenerateMermaid(result, diagram.DiagramOptions{})
+	assert.Contains(t, plain, "store_Mem --|> store_Store\n", "without scores relations are unlabeled")
+	assert.Contains(t, plain, "store_Disk --|> store_Closer : *\n")
+
+	dot := diagram.GenerateDOT(result, diagram.DiagramOptions{RelationScores: relScores})
+	assert.Contains(t, dot, `"store_Mem" -> "store_Store" [style=dashed, arrowhead=empty, penwidth=1.2];`)
+	assert.Contains(t, dot, `"store_NotFound" -> "builtin_error" [style=dashed, arrowhead=empty, penwidth=3.0];`, "scores above 1 are clamped")
+	assert.Contains(t, dot, `"store_Conflict" -> "builtin_error" [style=dashed, arrowhead=empty];`)
+
+	data := diagram.PrepareInteractiveData(result, diagram.DiagramOptions{RelationScores: relScores})
+	require.Len(t, data.Relations, 5)
+	scored := 0
+	for _, rel := range data.Relations {
+		if rel.Score != nil {
+			scored++
+		}
+	}
+	assert.Equal(t, 4, scored)
+	assert.Nil(t, diagram.PrepareInteractiveData(result, diagram.DiagramOptions{}).Relations[0].Score)
+}
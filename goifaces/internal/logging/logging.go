@@ -1,27 +1,60 @@
 package logging
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
 )
 
-// Setup configures slog to write JSONL to both stderr and a log file.
+// Formats accepted in Options.Format.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// ErrUnknownFormat is returned (wrapped) by Setup for an Options.Format other
+// than FormatJSON or FormatText.
+var ErrUnknownFormat = errors.New("unknown log format")
+
+// Options configures Setup.
+type Options struct {
+	File   string     // log file path; parent directories are created
+	Level  slog.Level // minimum level written
+	Quiet  bool       // write to the file only, keeping stderr for errors the caller prints
+	Format string     // FormatJSON (JSONL, the default when empty) or FormatText
+	Stdout bool       // write to stdout instead of stderr, e.g. for container log collectors
+}
+
+// Setup configures slog to write to both stderr (stdout with Options.Stdout)
+// and a log file, or only to the file with Options.Quiet. Lines are JSON
+// objects (JSONL) unless Options.Format is FormatText.
 // Returns a logger and a cleanup function to close the file handle.
-func Setup(logFile string, level slog.Level) (*slog.Logger, func(), error) {
-	if err := os.MkdirAll(filepath.Dir(logFile), 0o755); err != nil {
+func Setup(opts Options) (*slog.Logger, func(), error) {
+	if opts.Format != "" && opts.Format != FormatJSON && opts.Format != FormatText {
+		return nil, nil, fmt.Errorf("%w: %q (valid: %s, %s)", ErrUnknownFormat, opts.Format, FormatJSON, FormatText)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(opts.File), 0o755); err != nil {
 		return nil, nil, err
 	}
 
-	f, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	w := io.MultiWriter(os.Stderr, f)
-	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})
-	logger := slog.New(handler)
+	var w io.Writer = f
+	if !opts.Quiet {
+		var stream io.Writer = os.Stderr
+		if opts.Stdout {
+			stream = os.Stdout
+		}
+		w = io.MultiWriter(stream, f)
+	}
+	logger := slog.New(newHandler(w, opts))
 
 	cleanup := func() {
 		_ = f.Close()
@@ -29,3 +62,12 @@ func Setup(logFile string, level slog.Level) (*slog.Logger, func(), error) {
 
 	return logger, cleanup, nil
 }
+
+// newHandler returns the slog handler for opts.Format writing to w.
+func newHandler(w io.Writer, opts Options) slog.Handler {
+	handlerOpts := &slog.HandlerOptions{Level: opts.Level}
+	if opts.Format == FormatText {
+		return slog.NewTextHandler(w, handlerOpts)
+	}
+	return slog.NewJSONHandler(w, handlerOpts)
+}
@@ -0,0 +1,48 @@
+package logging
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupWritesJSONLFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "goifaces.log")
+	logger, cleanup, err := Setup(Options{File: path, Level: slog.LevelInfo, Quiet: true})
+	require.NoError(t, err)
+	logger.Debug("dropped")
+	logger.Info("kept", "component", "test")
+	cleanup()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 1)
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "kept", entry["msg"])
+	assert.Equal(t, "test", entry["component"])
+}
+
+func TestSetupTextFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "goifaces.log")
+	logger, cleanup, err := Setup(Options{File: path, Quiet: true, Format: FormatText})
+	require.NoError(t, err)
+	logger.Info("kept", "request_id", "abc")
+	cleanup()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "msg=kept request_id=abc")
+}
+
+func TestSetupUnknownFormat(t *testing.T) {
+	_, _, err := Setup(Options{File: filepath.Join(t.TempDir(), "goifaces.log"), Format: "xml"})
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
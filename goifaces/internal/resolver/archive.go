@@ -0,0 +1,187 @@
+package resolver
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// isArchive reports whether input names a source archive Resolve extracts:
+// a .zip, .tar.gz or .tgz file.
+func isArchive(input string) bool {
+	lower := strings.ToLower(input)
+	for _, ext := range []string{".zip", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveArchive extracts the archive at path into a fresh temporary
+// directory and returns the module root found in it. Unlike a cached clone
+// the extraction is not kept: the returned cleanup removes it, and so does
+// any failure here, including ctx being cancelled mid-extraction.
+func resolveArchive(ctx context.Context, path string, logger *slog.Logger) (string, func(), error) {
+	noop := func() {}
+
+	tmp, err := os.MkdirTemp("", "goifaces-archive-*")
+	if err != nil {
+		return "", noop, fmt.Errorf("creating extraction dir: %w", err)
+	}
+	cleanup := func() {
+		if err := os.RemoveAll(tmp); err != nil {
+			logger.Warn("failed to remove extracted archive", "dir", tmp, "error", err)
+			return
+		}
+		logger.Debug("removed extracted archive", "dir", tmp)
+	}
+
+	logger.Info("extracting archive", "path", path, "dest", tmp)
+	if strings.HasSuffix(strings.ToLower(path), ".zip") {
+		err = extractZip(ctx, path, tmp, logger)
+	} else {
+		err = extractTarGz(ctx, path, tmp, logger)
+	}
+	if err != nil {
+		cleanup()
+		return "", noop, fmt.Errorf("extracting %s: %w", path, err)
+	}
+
+	modRoot, err := findModuleRootRecursive(tmp)
+	if err != nil {
+		cleanup()
+		return "", noop, fmt.Errorf("no go.mod found in archive: %w", err)
+	}
+
+	logger.Info("found module root", "module_root", modRoot)
+
+	if err := goModDownload(ctx, modRoot, logger); err != nil {
+		logger.Warn("go mod download failed", "error", err)
+	}
+
+	return modRoot, cleanup, nil
+}
+
+// archiveTarget returns where the archive entry name is extracted under dir,
+// failing with ErrUnsafeArchive when it is absolute or its ".." elements
+// lead outside dir.
+func archiveTarget(dir, name string) (string, error) {
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "/") || strings.HasPrefix(name, `\`) {
+		return "", fmt.Errorf("%w: %q is absolute", ErrUnsafeArchive, name)
+	}
+	target := filepath.Join(dir, name)
+	rel, err := filepath.Rel(dir, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %q escapes the extraction dir", ErrUnsafeArchive, name)
+	}
+	return target, nil
+}
+
+func extractZip(ctx context.Context, path, dir string, logger *slog.Logger) error {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		target, err := archiveTarget(dir, f.Name)
+		if err != nil {
+			return err
+		}
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			err = writeArchiveFile(target, rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			// Symlinks could point outside dir; the analysis does not need them
+			logger.Debug("skipping archive entry", "name", f.Name, "mode", mode)
+		}
+	}
+	return nil
+}
+
+func extractTarGz(ctx context.Context, path, dir string, logger *slog.Logger) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target, err := archiveTarget(dir, hdr.Name)
+		if err != nil {
+			return err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeArchiveFile(target, tr); err != nil {
+				return err
+			}
+		default:
+			// Links could point outside dir; the analysis does not need them
+			logger.Debug("skipping archive entry", "name", hdr.Name, "type", string(hdr.Typeflag))
+		}
+	}
+}
+
+// writeArchiveFile creates target, and any missing parent directories, with
+// the contents of r.
+func writeArchiveFile(target string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
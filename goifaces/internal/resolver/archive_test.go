@@ -0,0 +1,141 @@
+package resolver
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsArchive(t *testing.T) {
+	for input, want := range map[string]bool{
+		"repo.zip":        true,
+		"repo.tar.gz":     true,
+		"REPO.TGZ":        true,
+		"repo.tar":        false,
+		"./src":           false,
+		"repo.zip/nested": false,
+	} {
+		if got := isArchive(input); got != want {
+			t.Errorf("isArchive(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
+
+func TestResolveArchive(t *testing.T) {
+	files := map[string]string{
+		"repo-main/":               "",
+		"repo-main/README.md":      "# repo\n",
+		"repo-main/go.mod":         "module example.com/repo\n\ngo 1.24\n",
+		"repo-main/store/store.go": "package store\n",
+	}
+	for _, name := range []string{"repo.zip", "repo.tar.gz"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			writeArchive(t, path, files)
+
+			dir, cleanup, err := Resolve(context.Background(), path, Options{}, slog.New(slog.DiscardHandler))
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			if filepath.Base(dir) != "repo-main" {
+				t.Errorf("module root = %s, want the repo-main directory", dir)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "store", "store.go")); err != nil {
+				t.Errorf("store.go not extracted: %v", err)
+			}
+
+			cleanup()
+			if _, err := os.Stat(filepath.Dir(dir)); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("extraction dir still exists after cleanup: %v", err)
+			}
+		})
+	}
+}
+
+func TestResolveArchive_Unsafe(t *testing.T) {
+	for _, entry := range []string{"../evil.go", "repo/../../evil.go", "/tmp/evil.go"} {
+		for _, name := range []string{"bad.zip", "bad.tar.gz"} {
+			t.Run(name+" "+entry, func(t *testing.T) {
+				path := filepath.Join(t.TempDir(), name)
+				writeArchive(t, path, map[string]string{
+					"repo/go.mod": "module example.com/repo\n",
+					entry:         "package evil\n",
+				})
+
+				_, _, err := Resolve(context.Background(), path, Options{}, slog.New(slog.DiscardHandler))
+				if !errors.Is(err, ErrUnsafeArchive) {
+					t.Fatalf("err = %v, want ErrUnsafeArchive", err)
+				}
+				if _, err := os.Stat(filepath.Join(filepath.Dir(path), "evil.go")); err == nil {
+					t.Error("entry was written outside the extraction dir")
+				}
+			})
+		}
+	}
+}
+
+func TestResolveArchive_NoGoMod(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "docs.zip")
+	writeArchive(t, path, map[string]string{"docs/README.md": "# docs\n"})
+
+	_, _, err := Resolve(context.Background(), path, Options{}, slog.New(slog.DiscardHandler))
+	if !errors.Is(err, ErrNoGoMod) {
+		t.Fatalf("err = %v, want ErrNoGoMod", err)
+	}
+}
+
+// writeArchive writes files, keyed by entry name, to a zip or tar.gz archive
+// at path depending on its extension. Names ending in "/" are directories.
+func writeArchive(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if strings.HasSuffix(path, ".zip") {
+		zw := zip.NewWriter(f)
+		for name, content := range files {
+			w, err := zw.Create(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write([]byte(content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if strings.HasSuffix(name, "/") {
+			hdr = &tar.Header{Name: name, Mode: 0o755, Typeflag: tar.TypeDir}
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,299 @@
+package resolver
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCacheMaxSize is the default upper bound for the clone cache (5 GB).
+const DefaultCacheMaxSize int64 = 5 << 30
+
+// Options controls where Resolve and the cache functions keep cloned
+// repositories, and how Resolve authenticates to private ones.
+type Options struct {
+	CacheDir string // clone cache directory; "" = DefaultCacheDir()
+	GitToken string // sent with git clone, fetch and ls-remote over HTTPS; "" = no auth
+	// GitHosts are hosts (host or host:port, e.g. a GitHub Enterprise or
+	// self-hosted GitLab server) whose repository URLs Resolve clones and
+	// sends GitToken to, besides github.com and gitlab.com.
+	GitHosts []string
+}
+
+// LogValue masks the git token when the options are logged via slog.
+func (o Options) LogValue() slog.Value {
+	token := ""
+	if o.GitToken != "" {
+		token = "[REDACTED]"
+	}
+	return slog.GroupValue(
+		slog.String("cache_dir", o.CacheDir),
+		slog.String("git_token", token),
+		slog.Any("git_hosts", o.GitHosts),
+	)
+}
+
+// DefaultCacheDir returns the default clone cache directory,
+// ~/.cache/goifaces/repos.
+func DefaultCacheDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("getting home dir: %w", err)
+	}
+	return filepath.Join(home, ".cache", "goifaces", "repos"), nil
+}
+
+// cacheRoot returns the directory holding all cached clones.
+func (o Options) cacheRoot() (string, error) {
+	if o.CacheDir != "" {
+		return filepath.Clean(o.CacheDir), nil
+	}
+	return DefaultCacheDir()
+}
+
+// Subdirectories of the cache directory besides the clones: cached analysis
+// results, which PruneCache and ClearCache skip, and match caches, which
+// they evict and remove like a clone.
+const (
+	analysisDirName = "analysis"
+	matchesDirName  = "matches"
+)
+
+// AnalysisCacheDir returns the directory for cached analysis results,
+// "analysis" inside the cache directory (~/.cache/goifaces/repos/analysis
+// by default), so that a -cache-dir holds every cache goifaces keeps.
+// Pruning and clearing clones leave it alone.
+func AnalysisCacheDir(opts Options) (string, error) {
+	root, err := opts.cacheRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, analysisDirName), nil
+}
+
+// MatchCacheDir returns the directory for the match caches of -match-cache,
+// "matches" inside the cache directory (~/.cache/goifaces/repos/matches by
+// default). ClearCache removes it, and PruneCache may evict it whole.
+func MatchCacheDir(opts Options) (string, error) {
+	root, err := opts.cacheRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, matchesDirName), nil
+}
+
+// cacheEntry describes one cached clone directory.
+type cacheEntry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// PruneCache evicts least-recently-used clones from the cache until its total
+// size is at or below maxBytes. A maxBytes of 0 or less disables the limit.
+func PruneCache(opts Options, maxBytes int64, logger *slog.Logger) error {
+	root, err := opts.cacheRoot()
+	if err != nil {
+		return err
+	}
+	return pruneCacheDir(root, maxBytes, logger)
+}
+
+// ClearCache removes every cached clone and then the cache directory itself.
+// A missing directory is not an error. Since the directory may be
+// user-supplied (-cache-dir), only clone directories are removed; anything
+// else found there is left in place, together with the directory. The match
+// caches (MatchCacheDir) go with the clones; the analysis cache
+// (AnalysisCacheDir) is kept.
+func ClearCache(opts Options, logger *slog.Logger) error {
+	root, err := opts.cacheRoot()
+	if err != nil {
+		return err
+	}
+	return clearCacheDir(root, logger)
+}
+
+func clearCacheDir(root string, logger *slog.Logger) error {
+	logger.Info("clearing clone cache", "dir", root)
+	dirEntries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("clearing cache: %w", err)
+	}
+
+	kept, analysis := 0, false
+	for _, de := range dirEntries {
+		if de.IsDir() && de.Name() == analysisDirName {
+			analysis = true
+			continue
+		}
+		if !de.IsDir() || (!isCacheEntryName(de.Name()) && de.Name() != matchesDirName) {
+			kept++
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(root, de.Name())); err != nil {
+			return fmt.Errorf("clearing cache: %w", err)
+		}
+	}
+	if kept > 0 {
+		logger.Warn("cache dir holds files that are not clones, leaving it in place", "dir", root, "entries", kept)
+		return nil
+	}
+	if analysis {
+		return nil
+	}
+	if err := os.Remove(root); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("clearing cache: %w", err)
+	}
+	return nil
+}
+
+// isCacheEntryName reports whether name has the form cacheDir gives clone
+// directories: 16 lowercase hex digits.
+func isCacheEntryName(name string) bool {
+	if len(name) != 16 {
+		return false
+	}
+	for _, c := range name {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// pruneCacheDir evicts the oldest entries (by directory mtime) under root
+// until the combined size fits within maxBytes.
+func pruneCacheDir(root string, maxBytes int64, logger *slog.Logger) error {
+	if maxBytes <= 0 {
+		return nil
+	}
+
+	entries, err := listCacheEntries(root)
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for _, e := range entries {
+		total += e.size
+	}
+	logger.Debug("clone cache size", "dir", root, "bytes", total, "limit", maxBytes)
+	if total <= maxBytes {
+		return nil
+	}
+
+	// Oldest first
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].modTime.Before(entries[j].modTime)
+	})
+
+	for _, e := range entries {
+		if total <= maxBytes {
+			break
+		}
+		if err := os.RemoveAll(e.path); err != nil {
+			logger.Warn("failed to evict cached clone", "dir", e.path, "error", err)
+			continue
+		}
+		total -= e.size
+		logger.Info("evicted cached clone", "dir", e.path, "bytes", e.size, "last_used", e.modTime)
+	}
+	return nil
+}
+
+// listCacheEntries returns one entry per top-level directory under root,
+// except the analysis cache. A missing root yields no entries.
+func listCacheEntries(root string) ([]cacheEntry, error) {
+	dirEntries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading cache dir: %w", err)
+	}
+
+	var entries []cacheEntry
+	for _, de := range dirEntries {
+		if !de.IsDir() || de.Name() == analysisDirName {
+			continue
+		}
+		path := filepath.Join(root, de.Name())
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, cacheEntry{
+			path:    path,
+			size:    dirSize(path),
+			modTime: info.ModTime(),
+		})
+	}
+	return entries, nil
+}
+
+// dirSize returns the total size of regular files under dir.
+func dirSize(dir string) int64 {
+	var size int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+// touchCacheEntry marks a cached clone as recently used.
+func touchCacheEntry(dir string) {
+	now := time.Now()
+	_ = os.Chtimes(dir, now, now)
+}
+
+// ParseByteSize parses a human-readable size such as "500MB", "5GB" or
+// "1048576" into bytes. Units are binary (1KB = 1024 bytes).
+func ParseByteSize(s string) (int64, error) {
+	s = strings.TrimSpace(strings.ToUpper(s))
+	if s == "" {
+		return 0, fmt.Errorf("empty size")
+	}
+
+	multipliers := []struct {
+		suffix string
+		mult   int64
+	}{
+		{"TB", 1 << 40},
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	}
+
+	mult := int64(1)
+	for _, m := range multipliers {
+		if strings.HasSuffix(s, m.suffix) {
+			mult = m.mult
+			s = strings.TrimSpace(strings.TrimSuffix(s, m.suffix))
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (examples: 500MB, 5GB)", s)
+	}
+	return int64(n * float64(mult)), nil
+}
@@ -0,0 +1,29 @@
+package resolver
+
+import "errors"
+
+// Sentinel errors returned (wrapped) by Resolve. Check with errors.Is.
+var (
+	// ErrNotADirectory means the input path exists but is not a directory.
+	ErrNotADirectory = errors.New("not a directory")
+	// ErrNoGoMod means no go.mod file could be located for the input.
+	ErrNoGoMod = errors.New("no go.mod found")
+	// ErrCloneFailed means the remote repository could not be cloned.
+	ErrCloneFailed = errors.New("git clone")
+	// ErrInvalidRef means the @ref or #ref suffix of a repository URL is
+	// not a well-formed branch, tag or commit name.
+	ErrInvalidRef = errors.New("invalid git ref")
+	// ErrAuthFailed means the remote rejected the git request for lack of
+	// valid credentials: the repository is private and no token was given,
+	// or the token is invalid or cannot read it.
+	ErrAuthFailed = errors.New("git authentication failed")
+	// ErrRefNotFound means the remote repository has no such branch, tag or
+	// commit.
+	ErrRefNotFound = errors.New("git ref not found")
+	// ErrUnsafeArchive means a source archive has an entry with an absolute
+	// path or one that would be extracted outside its directory.
+	ErrUnsafeArchive = errors.New("unsafe archive entry")
+	// ErrInvalidSubdir means a "//subdir" selector or -subdir value does not
+	// name a directory with Go files inside the module (see CheckSubdir).
+	ErrInvalidSubdir = errors.New("invalid subdirectory")
+)
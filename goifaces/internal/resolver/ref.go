@@ -0,0 +1,57 @@
+package resolver
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// commitSHA matches a full SHA-1 or SHA-256 commit hash. Abbreviated hashes
+// cannot be fetched from a remote and are treated as branch or tag names.
+var commitSHA = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// isCommitSHA reports whether ref is a full commit hash.
+func isCommitSHA(ref string) bool {
+	return commitSHA.MatchString(ref)
+}
+
+// splitRepoRef splits a repository URL carrying a ref suffix into the clone
+// URL and the ref: "https://github.com/foo/bar@v1.2.0" and
+// "https://github.com/foo/bar#main" name a tag and a branch. A URL without a
+// suffix yields an empty ref (the default branch).
+func splitRepoRef(input string) (url, ref string, err error) {
+	url = input
+	if i := strings.LastIndex(input, "#"); i >= 0 {
+		url, ref = input[:i], input[i+1:]
+	} else if scheme := strings.Index(input, "://"); scheme >= 0 {
+		// Only look for '@' in the path, after the host.
+		if slash := strings.Index(input[scheme+3:], "/"); slash >= 0 {
+			path := scheme + 3 + slash
+			if at := strings.LastIndex(input[path:], "@"); at >= 0 {
+				url, ref = input[:path+at], input[path+at+1:]
+			}
+		}
+	}
+	if url != input && !validRef(ref) {
+		return "", "", fmt.Errorf("%w %q in %s", ErrInvalidRef, ref, input)
+	}
+	return url, ref, nil
+}
+
+// validRef reports whether ref is a well-formed branch, tag or commit name,
+// following the rules of git check-ref-format. It also rejects a leading '-'
+// so a ref can never be read as a git option.
+func validRef(ref string) bool {
+	if ref == "" || ref == "@" || strings.HasPrefix(ref, "-") ||
+		strings.HasPrefix(ref, "/") || strings.HasSuffix(ref, "/") ||
+		strings.HasSuffix(ref, ".") || strings.HasSuffix(ref, ".lock") ||
+		strings.Contains(ref, "..") || strings.Contains(ref, "@{") || strings.Contains(ref, "//") {
+		return false
+	}
+	for _, r := range ref {
+		if r <= ' ' || r == 0x7f || strings.ContainsRune(`~^:?*[\`, r) {
+			return false
+		}
+	}
+	return true
+}
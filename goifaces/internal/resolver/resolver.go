@@ -1,9 +1,12 @@
 package resolver
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/exec"
@@ -12,13 +15,31 @@ import (
 	"strings"
 )
 
-// Resolve takes an input (local dir, sub-package path, or GitHub URL) and returns
-// a local directory ready for analysis, plus a cleanup function.
-func Resolve(ctx context.Context, input string, logger *slog.Logger) (dir string, cleanup func(), err error) {
+// Resolve takes an input (local dir, sub-package path, source archive, or
+// repository URL on GitHub, GitLab or one of opts.GitHosts) and returns a
+// local directory ready for analysis, plus a cleanup function. A repository
+// URL may pin a branch, tag or full commit SHA with an "@ref" or "#ref"
+// suffix; it is cloned into the cache directory selected by opts, sending
+// opts.GitToken only when the URL is https. A .zip, .tar.gz or .tgz file is
+// extracted into a temporary directory that cleanup removes.
+func Resolve(ctx context.Context, input string, opts Options, logger *slog.Logger) (dir string, cleanup func(), err error) {
 	cleanup = func() {} // default no-op
 
-	if isGitHubURL(input) {
-		return fetchRepo(ctx, input, logger)
+	if opts.isRepoURL(input) {
+		url, ref, err := splitRepoRef(input)
+		if err != nil {
+			return "", cleanup, err
+		}
+		root, err := opts.cacheRoot()
+		if err != nil {
+			return "", cleanup, err
+		}
+		token := opts.GitToken
+		if token != "" && !strings.HasPrefix(url, "https://") {
+			logger.Warn("not sending the git token over plain http", "url", redactURL(url))
+			token = ""
+		}
+		return fetchRepo(ctx, url, ref, root, token, logger)
 	}
 
 	// Local path
@@ -32,11 +53,14 @@ func Resolve(ctx context.Context, input string, logger *slog.Logger) (dir string
 		return "", cleanup, fmt.Errorf("stat %s: %w", absPath, err)
 	}
 
+	if !info.IsDir() && isArchive(absPath) {
+		return resolveArchive(ctx, absPath, logger)
+	}
 	if !info.IsDir() {
-		return "", cleanup, fmt.Errorf("%s is not a directory", absPath)
+		return "", cleanup, fmt.Errorf("%s is %w", absPath, ErrNotADirectory)
 	}
 
-	// Find module root (nearest go.mod) — optional
+	// Find module root (nearest go.work or go.mod) — optional
 	modRoot, err := findModuleRoot(absPath)
 	if err != nil {
 		// No go.mod found — use the input directory directly.
@@ -55,58 +79,61 @@ func Resolve(ctx context.Context, input string, logger *slog.Logger) (dir string
 	return modRoot, cleanup, nil
 }
 
-func isGitHubURL(input string) bool {
-	return strings.Contains(input, "github.com") &&
-		(strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://"))
-}
-
-// cacheDir returns a stable directory for caching a cloned repo.
-// Uses ~/.cache/goifaces/repos/<hash> where hash is derived from the URL.
-func cacheDir(url string) (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("getting home dir: %w", err)
+// cacheDir returns a stable directory under root for caching a cloned repo:
+// root/<hash>, where hash is derived from the URL and, when set, the ref, so
+// each ref gets its own clone.
+func cacheDir(root, url, ref string) string {
+	key := url
+	if ref != "" {
+		key += "@" + ref
 	}
-	h := sha256.Sum256([]byte(url))
+	h := sha256.Sum256([]byte(key))
 	name := fmt.Sprintf("%x", h[:8])
-	return filepath.Join(home, ".cache", "goifaces", "repos", name), nil
+	return filepath.Join(root, name)
 }
 
-// fetchRepo either pulls an existing cached clone or does a fresh clone.
+// fetchRepo either updates an existing cached clone to ref (the default
+// branch when empty) or does a fresh clone, under the cache directory root,
+// which is created if missing. A non-empty token authenticates the git
+// requests to url's origin (see gitEnv).
 // Returns the module root directory and a no-op cleanup (cache is persistent).
-func fetchRepo(ctx context.Context, url string, logger *slog.Logger) (string, func(), error) {
+func fetchRepo(ctx context.Context, url, ref, root, token string, logger *slog.Logger) (string, func(), error) {
 	noop := func() {}
 
-	dir, err := cacheDir(url)
-	if err != nil {
-		return "", noop, err
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return "", noop, fmt.Errorf("creating cache dir: %w", err)
 	}
+	dir := cacheDir(root, url, ref)
 
 	gitDir := filepath.Join(dir, ".git")
 	if _, err := os.Stat(gitDir); err == nil {
-		// Cached clone exists — pull latest
-		logger.Info("updating cached repository", "url", url, "dir", dir)
-		cmd := exec.CommandContext(ctx, "git", "fetch", "--depth=1", "origin")
-		cmd.Dir = dir
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
+		// Cached clone exists — fetch the ref and reset to it
+		logger.Info("updating cached repository", "url", redactURL(url), "ref", ref, "dir", dir)
+		fetchArgs := []string{"fetch", "--depth=1", "origin"}
+		target := "origin/HEAD"
+		if ref != "" {
+			fetchArgs = append(fetchArgs, ref)
+			target = "FETCH_HEAD"
+		}
+		if err := runGit(ctx, dir, url, token, fetchArgs...); err != nil {
+			if errors.Is(err, ErrAuthFailed) {
+				// A re-clone would be rejected too; keep the cached clone
+				return "", noop, fmt.Errorf("git fetch %s: %w", redactURL(url), err)
+			}
 			logger.Warn("git fetch failed, will re-clone", "error", err)
 			_ = os.RemoveAll(dir)
-			return cloneRepo(ctx, url, dir, logger)
+			return cloneRepo(ctx, url, ref, dir, token, logger)
 		}
-		// Reset to fetched HEAD
-		cmd = exec.CommandContext(ctx, "git", "reset", "--hard", "origin/HEAD")
-		cmd.Dir = dir
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
+		if err := runGit(ctx, dir, "", "", "reset", "--hard", target); err != nil {
 			logger.Warn("git reset failed, will re-clone", "error", err)
 			_ = os.RemoveAll(dir)
-			return cloneRepo(ctx, url, dir, logger)
+			return cloneRepo(ctx, url, ref, dir, token, logger)
 		}
+		touchCacheEntry(dir)
 		logger.Info("repository updated", "dir", dir)
 	} else {
 		// Fresh clone
-		return cloneRepo(ctx, url, dir, logger)
+		return cloneRepo(ctx, url, ref, dir, token, logger)
 	}
 
 	// Find module root
@@ -124,20 +151,49 @@ func fetchRepo(ctx context.Context, url string, logger *slog.Logger) (string, fu
 	return modRoot, noop, nil
 }
 
-func cloneRepo(ctx context.Context, url, dir string, logger *slog.Logger) (string, func(), error) {
+// cloneRepo clones url into dir. A branch or tag ref is checked out with
+// git clone --branch after confirming the remote has it; a commit SHA is
+// fetched and checked out after a default clone. A missing ref fails with
+// ErrRefNotFound instead of falling back to the default branch. Rejected
+// credentials fail with ErrAuthFailed (wrapped in ErrCloneFailed).
+func cloneRepo(ctx context.Context, url, ref, dir, token string, logger *slog.Logger) (string, func(), error) {
 	noop := func() {}
 
 	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
 		return "", noop, fmt.Errorf("creating cache dir: %w", err)
 	}
 
-	logger.Info("cloning repository", "url", url, "dest", dir)
+	args := []string{"clone", "--depth=1"}
+	if ref != "" && !isCommitSHA(ref) {
+		found, err := remoteHasRef(ctx, url, ref, token)
+		switch {
+		case errors.Is(err, ErrAuthFailed):
+			return "", noop, fmt.Errorf("%w %s: %w", ErrCloneFailed, redactURL(url), err)
+		case err != nil:
+			logger.Warn("git ls-remote failed, trying clone anyway", "error", err)
+		case !found:
+			return "", noop, fmt.Errorf("%w: %q in %s", ErrRefNotFound, ref, redactURL(url))
+		}
+		args = append(args, "--branch", ref)
+	}
+	args = append(args, "--", url, dir)
+
+	logger.Info("cloning repository", "url", redactURL(url), "ref", ref, "dest", dir, "authenticated", token != "")
 
-	cmd := exec.CommandContext(ctx, "git", "clone", "--depth=1", url, dir)
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	if err := runGit(ctx, "", url, token, args...); err != nil {
 		_ = os.RemoveAll(dir)
-		return "", noop, fmt.Errorf("git clone: %w", err)
+		return "", noop, fmt.Errorf("%w: %w", ErrCloneFailed, err)
+	}
+
+	if isCommitSHA(ref) {
+		if err := runGit(ctx, dir, url, token, "fetch", "--depth=1", "origin", ref); err != nil {
+			_ = os.RemoveAll(dir)
+			return "", noop, fmt.Errorf("%w: commit %s in %s: %w", ErrRefNotFound, ref, redactURL(url), err)
+		}
+		if err := runGit(ctx, dir, "", "", "checkout", "--detach", ref); err != nil {
+			_ = os.RemoveAll(dir)
+			return "", noop, fmt.Errorf("git checkout %s: %w", ref, err)
+		}
 	}
 
 	logger.Info("clone complete", "dest", dir)
@@ -158,26 +214,64 @@ func cloneRepo(ctx context.Context, url, dir string, logger *slog.Logger) (strin
 	return modRoot, noop, nil
 }
 
+// remoteHasRef reports whether url has a branch or tag named ref.
+func remoteHasRef(ctx context.Context, url, ref, token string) (bool, error) {
+	err := runGit(ctx, "", url, token, "ls-remote", "--exit-code", "--", url,
+		"refs/heads/"+ref, "refs/tags/"+ref)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 2 {
+		return false, nil // --exit-code: no matching refs
+	}
+	return err == nil, err
+}
+
+// runGit runs git with args in dir (the current directory when empty),
+// authenticating requests to remote's origin with token when set and
+// forwarding its stderr. A failure that git reports as rejected credentials
+// is wrapped in ErrAuthFailed.
+func runGit(ctx context.Context, dir, remote, token string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = gitEnv(remote, token)
+	var stderr bytes.Buffer
+	cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	err := cmd.Run()
+	if err != nil && isAuthFailure(stderr.String(), token != "") {
+		return fmt.Errorf("%w: %w", ErrAuthFailed, err)
+	}
+	return err
+}
+
+// findModuleRoot searches upward from dir for the nearest directory holding a
+// go.work or go.mod file. Pointing at a workspace root (or a directory under
+// it that is not inside a module) thus analyzes all of its modules.
 func findModuleRoot(dir string) (string, error) {
 	current := dir
 	for {
-		goMod := filepath.Join(current, "go.mod")
-		if _, err := os.Stat(goMod); err == nil {
+		if hasModuleFile(current) {
 			return current, nil
 		}
 		parent := filepath.Dir(current)
 		if parent == current {
-			return "", fmt.Errorf("no go.mod found in %s or any parent directory", dir)
+			return "", fmt.Errorf("%w in %s or any parent directory", ErrNoGoMod, dir)
 		}
 		current = parent
 	}
 }
 
-// findModuleRootRecursive searches downward from root for the shallowest go.mod file.
-// This is used for cloned repos where go.mod may be in a subdirectory.
+// SkipDir reports whether directory walks over a source tree skip the
+// directory named name: vendored and node dependencies and hidden
+// directories such as .git.
+func SkipDir(name string) bool {
+	return name == "vendor" || name == "node_modules" || strings.HasPrefix(name, ".")
+}
+
+// findModuleRootRecursive searches downward from root for the shallowest go.mod
+// (or go.work) file. This is used for cloned repos where go.mod may be in a
+// subdirectory.
 func findModuleRootRecursive(root string) (string, error) {
 	// Check root first (most common case)
-	if _, err := os.Stat(filepath.Join(root, "go.mod")); err == nil {
+	if hasModuleFile(root) {
 		return root, nil
 	}
 
@@ -197,11 +291,11 @@ func findModuleRootRecursive(root string) (string, error) {
 					continue
 				}
 				name := entry.Name()
-				if name == ".git" || name == "vendor" || name == "node_modules" || strings.HasPrefix(name, ".") {
+				if SkipDir(name) {
 					continue
 				}
 				subdir := filepath.Join(dir, name)
-				if _, err := os.Stat(filepath.Join(subdir, "go.mod")); err == nil {
+				if hasModuleFile(subdir) {
 					candidates = append(candidates, subdir)
 				} else {
 					nextLevel = append(nextLevel, subdir)
@@ -216,7 +310,17 @@ func findModuleRootRecursive(root string) (string, error) {
 		queue = nextLevel
 	}
 
-	return "", fmt.Errorf("no go.mod found in %s or any subdirectory", root)
+	return "", fmt.Errorf("%w in %s or any subdirectory", ErrNoGoMod, root)
+}
+
+// hasModuleFile reports whether dir holds a go.work or go.mod file.
+func hasModuleFile(dir string) bool {
+	for _, name := range []string{"go.work", "go.mod"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
 }
 
 func goModDownload(ctx context.Context, dir string, logger *slog.Logger) error {
@@ -2,10 +2,15 @@ package resolver
 
 import (
 	"context"
+	"errors"
+	"io/fs"
 	"log/slog"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestFindModuleRootRecursive(t *testing.T) {
@@ -125,7 +130,7 @@ func writeFile(t *testing.T, path, content string) {
 func TestResolve_NoGoMod(t *testing.T) {
 	dir := t.TempDir()
 
-	got, cleanup, err := Resolve(context.Background(), dir, slog.Default())
+	got, cleanup, err := Resolve(context.Background(), dir, Options{}, slog.Default())
 	defer cleanup()
 
 	if err != nil {
@@ -141,7 +146,7 @@ func TestResolve_WithGoMod(t *testing.T) {
 	dir := t.TempDir()
 	writeFile(t, filepath.Join(dir, "go.mod"), "module example\n\ngo 1.21\n")
 
-	got, cleanup, err := Resolve(context.Background(), dir, slog.Default())
+	got, cleanup, err := Resolve(context.Background(), dir, Options{}, slog.Default())
 	defer cleanup()
 
 	if err != nil {
@@ -156,7 +161,7 @@ func TestResolve_WithGoMod(t *testing.T) {
 func TestResolve_NonExistentPath(t *testing.T) {
 	nonexistent := filepath.Join(t.TempDir(), "does-not-exist")
 
-	_, cleanup, err := Resolve(context.Background(), nonexistent, slog.Default())
+	_, cleanup, err := Resolve(context.Background(), nonexistent, Options{}, slog.Default())
 	defer cleanup()
 
 	if err == nil {
@@ -169,10 +174,485 @@ func TestResolve_FileNotDir(t *testing.T) {
 	filePath := filepath.Join(dir, "notadir.txt")
 	writeFile(t, filePath, "hello")
 
-	_, cleanup, err := Resolve(context.Background(), filePath, slog.Default())
+	_, cleanup, err := Resolve(context.Background(), filePath, Options{}, slog.Default())
 	defer cleanup()
 
 	if err == nil {
 		t.Fatal("expected error for file path, got nil")
 	}
+	if !errors.Is(err, ErrNotADirectory) {
+		t.Errorf("expected ErrNotADirectory, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "is not a directory") {
+		t.Errorf("error message should stay human-readable, got %q", err.Error())
+	}
+}
+
+func TestResolve_NonExistentPathIsNotExist(t *testing.T) {
+	nonexistent := filepath.Join(t.TempDir(), "does-not-exist")
+
+	_, cleanup, err := Resolve(context.Background(), nonexistent, Options{}, slog.Default())
+	defer cleanup()
+
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestFindModuleRoot_ErrNoGoMod(t *testing.T) {
+	root := t.TempDir()
+
+	if _, err := findModuleRootRecursive(root); !errors.Is(err, ErrNoGoMod) {
+		t.Errorf("findModuleRootRecursive: expected ErrNoGoMod, got %v", err)
+	}
+	if _, err := findModuleRoot(string(filepath.Separator)); !errors.Is(err, ErrNoGoMod) {
+		t.Errorf("findModuleRoot: expected ErrNoGoMod, got %v", err)
+	}
+}
+
+func TestPruneCacheDir_EvictsOldest(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now()
+
+	// Three fake clones of 100 bytes each, with staggered last-used times.
+	entries := []struct {
+		name string
+		age  time.Duration
+	}{
+		{"oldest", 3 * time.Hour},
+		{"middle", 2 * time.Hour},
+		{"newest", 1 * time.Hour},
+	}
+	for _, e := range entries {
+		dir := filepath.Join(root, e.name)
+		mkdirAll(t, dir)
+		writeFile(t, filepath.Join(dir, "data"), strings.Repeat("x", 100))
+		mtime := now.Add(-e.age)
+		if err := os.Chtimes(dir, mtime, mtime); err != nil {
+			t.Fatalf("Chtimes(%s): %v", dir, err)
+		}
+	}
+
+	if err := pruneCacheDir(root, 150, slog.Default()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, wantExists := range map[string]bool{"oldest": false, "middle": false, "newest": true} {
+		_, err := os.Stat(filepath.Join(root, name))
+		if exists := err == nil; exists != wantExists {
+			t.Errorf("%s: exists=%v, want %v", name, exists, wantExists)
+		}
+	}
+}
+
+func TestPruneCacheDir_KeepsAnalysisCache(t *testing.T) {
+	root := t.TempDir()
+	analysis := filepath.Join(root, analysisDirName)
+	mkdirAll(t, analysis)
+	writeFile(t, filepath.Join(analysis, "result.json"), strings.Repeat("x", 100))
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(analysis, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pruneCacheDir(root, 1, slog.Default()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(analysis); err != nil {
+		t.Errorf("analysis cache evicted: %v", err)
+	}
+}
+
+func TestPruneCacheDir_UnderLimit(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "repo")
+	mkdirAll(t, dir)
+	writeFile(t, filepath.Join(dir, "data"), strings.Repeat("x", 100))
+
+	if err := pruneCacheDir(root, 1000, slog.Default()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("entry under limit should be kept: %v", err)
+	}
+}
+
+func TestPruneCacheDir_MissingRoot(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "missing")
+	if err := pruneCacheDir(root, 1, slog.Default()); err != nil {
+		t.Fatalf("missing cache dir should not error: %v", err)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{"1024", 1024, false},
+		{"10B", 10, false},
+		{"2KB", 2048, false},
+		{"500MB", 500 << 20, false},
+		{"5GB", 5 << 30, false},
+		{"1.5gb", 3 << 29, false},
+		{"0", 0, false},
+		{"", 0, true},
+		{"lots", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseByteSize(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseByteSize(%q): expected error", tt.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseByteSize(%q): unexpected error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseByteSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSplitRepoRef(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantURL string
+		wantRef string
+		wantErr bool
+	}{
+		{"https://github.com/foo/bar", "https://github.com/foo/bar", "", false},
+		{"https://github.com/foo/bar@v1.2.0", "https://github.com/foo/bar", "v1.2.0", false},
+		{"https://github.com/foo/bar#release/2.x", "https://github.com/foo/bar", "release/2.x", false},
+		{"https://github.com/foo/bar@0123456789abcdef0123456789abcdef01234567", "https://github.com/foo/bar", "0123456789abcdef0123456789abcdef01234567", false},
+		{"https://user@github.com/foo/bar", "https://user@github.com/foo/bar", "", false},
+		{"https://github.com/foo/bar@", "", "", true},
+		{"https://github.com/foo/bar#--upload-pack=evil", "", "", true},
+		{"https://github.com/foo/bar@a..b", "", "", true},
+		{"https://github.com/foo/bar#has space", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			url, ref, err := splitRepoRef(tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRef) {
+					t.Fatalf("err = %v, want ErrInvalidRef", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if url != tt.wantURL || ref != tt.wantRef {
+				t.Errorf("got (%q, %q), want (%q, %q)", url, ref, tt.wantURL, tt.wantRef)
+			}
+		})
+	}
+}
+
+func TestCacheDir_PerRef(t *testing.T) {
+	root := t.TempDir()
+	url := "https://github.com/foo/bar"
+
+	plain := cacheDir(root, url, "")
+	v1 := cacheDir(root, url, "v1")
+	v2 := cacheDir(root, url, "v2")
+	if plain == v1 || v1 == v2 {
+		t.Errorf("refs share a cache dir: %s, %s, %s", plain, v1, v2)
+	}
+	if again := cacheDir(root, url, "v1"); again != v1 {
+		t.Errorf("cacheDir not stable: %s != %s", again, v1)
+	}
+	if filepath.Dir(v1) != root || !isCacheEntryName(filepath.Base(v1)) {
+		t.Errorf("cacheDir(%q) = %s, want a clone entry directly under the root", root, v1)
+	}
+}
+
+func TestCacheRoot(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	got, err := Options{}.cacheRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(home, ".cache", "goifaces", "repos"); got != want {
+		t.Errorf("default cacheRoot = %s, want %s", got, want)
+	}
+
+	custom := filepath.Join(t.TempDir(), "clones")
+	got, err = Options{CacheDir: custom + "/"}.cacheRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != custom {
+		t.Errorf("cacheRoot with CacheDir = %s, want %s", got, custom)
+	}
+}
+
+func TestClearCacheDir(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "cache")
+	mkdirAll(t, filepath.Join(root, cacheDir("", "https://github.com/foo/bar", ""), ".git"))
+	mkdirAll(t, filepath.Join(root, cacheDir("", "https://github.com/foo/bar", "v1")))
+
+	if err := clearCacheDir(root, slog.Default()); err != nil {
+		t.Fatalf("clearCacheDir: %v", err)
+	}
+	if _, err := os.Stat(root); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("cache dir still present: %v", err)
+	}
+
+	// Clearing again, with the directory gone, is a no-op.
+	if err := clearCacheDir(root, slog.Default()); err != nil {
+		t.Errorf("clearCacheDir on a missing dir: %v", err)
+	}
+}
+
+func TestClearCacheDir_KeepsForeignFiles(t *testing.T) {
+	root := t.TempDir()
+	clone := cacheDir(root, "https://github.com/foo/bar", "")
+	mkdirAll(t, clone)
+	mkdirAll(t, filepath.Join(root, "projects"))
+	writeFile(t, filepath.Join(root, "notes.txt"), "keep\n")
+
+	if err := clearCacheDir(root, slog.Default()); err != nil {
+		t.Fatalf("clearCacheDir: %v", err)
+	}
+	if _, err := os.Stat(clone); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("clone still present: %v", err)
+	}
+	for _, name := range []string{"projects", "notes.txt"} {
+		if _, err := os.Stat(filepath.Join(root, name)); err != nil {
+			t.Errorf("%s removed: %v", name, err)
+		}
+	}
+}
+
+func TestAnalysisCacheDir(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "cache")
+	got, err := AnalysisCacheDir(Options{CacheDir: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(root, "analysis"); got != want {
+		t.Errorf("AnalysisCacheDir = %s, want %s inside the cache dir", got, want)
+	}
+
+	// Clearing removes the clones but keeps the analysis cache and its parent
+	clone := cacheDir(root, "https://github.com/foo/bar", "")
+	mkdirAll(t, clone)
+	mkdirAll(t, got)
+	if err := clearCacheDir(root, slog.Default()); err != nil {
+		t.Fatalf("clearCacheDir: %v", err)
+	}
+	if _, err := os.Stat(clone); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("clone still present: %v", err)
+	}
+	if _, err := os.Stat(got); err != nil {
+		t.Errorf("analysis cache removed: %v", err)
+	}
+}
+
+func TestMatchCacheDir(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "cache")
+	got, err := MatchCacheDir(Options{CacheDir: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(root, "matches"); got != want {
+		t.Errorf("MatchCacheDir = %s, want %s inside the cache dir", got, want)
+	}
+
+	// Clearing removes the match caches along with the clones
+	mkdirAll(t, got)
+	writeFile(t, filepath.Join(got, "0123456789abcdef.json"), "{}")
+	mkdirAll(t, cacheDir(root, "https://github.com/foo/bar", ""))
+	if err := clearCacheDir(root, slog.Default()); err != nil {
+		t.Fatalf("clearCacheDir: %v", err)
+	}
+	if _, err := os.Stat(root); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("cache dir still present: %v", err)
+	}
+
+	// Pruning counts them and may evict them
+	mkdirAll(t, got)
+	writeFile(t, filepath.Join(got, "0123456789abcdef.json"), strings.Repeat("x", 100))
+	if err := pruneCacheDir(root, 1, slog.Default()); err != nil {
+		t.Fatalf("pruneCacheDir: %v", err)
+	}
+	if _, err := os.Stat(got); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("match caches not evicted: %v", err)
+	}
+}
+
+// gitRepo creates a local repository with a go.mod and returns its file://
+// URL plus a function that commits a new file and returns the commit SHA.
+func gitRepo(t *testing.T) (url string, commit func(name string) string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q", "-b", "main")
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example\n\ngo 1.21\n")
+	commit = func(name string) string {
+		writeFile(t, filepath.Join(dir, name), "package example\n")
+		git("add", "-A")
+		git("commit", "-q", "-m", name)
+		return git("rev-parse", "HEAD")
+	}
+	commit("a.go")
+	git("tag", "v1")
+	return "file://" + dir, commit
+}
+
+func TestCloneRepo_Refs(t *testing.T) {
+	url, commit := gitRepo(t)
+	first := commit("b.go")
+	commit("c.go")
+	ctx := context.Background()
+
+	tests := []struct {
+		ref   string
+		files map[string]bool
+	}{
+		{"", map[string]bool{"a.go": true, "b.go": true, "c.go": true}},
+		{"v1", map[string]bool{"a.go": true, "b.go": false, "c.go": false}},
+		{"main", map[string]bool{"a.go": true, "b.go": true, "c.go": true}},
+		{first, map[string]bool{"a.go": true, "b.go": true, "c.go": false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "clone")
+			got, _, err := cloneRepo(ctx, url, tt.ref, dir, "", slog.Default())
+			if err != nil {
+				t.Fatalf("cloneRepo: %v", err)
+			}
+			for name, want := range tt.files {
+				_, err := os.Stat(filepath.Join(got, name))
+				if (err == nil) != want {
+					t.Errorf("%s present = %v, want %v", name, err == nil, want)
+				}
+			}
+		})
+	}
+
+	t.Run("missing ref", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "clone")
+		_, _, err := cloneRepo(ctx, url, "no-such-branch", dir, "", slog.Default())
+		if !errors.Is(err, ErrRefNotFound) {
+			t.Fatalf("err = %v, want ErrRefNotFound", err)
+		}
+		if _, statErr := os.Stat(dir); !errors.Is(statErr, fs.ErrNotExist) {
+			t.Errorf("clone dir left behind: %v", statErr)
+		}
+	})
+
+	t.Run("missing commit", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "clone")
+		_, _, err := cloneRepo(ctx, url, strings.Repeat("0", 40), dir, "", slog.Default())
+		if !errors.Is(err, ErrRefNotFound) {
+			t.Fatalf("err = %v, want ErrRefNotFound", err)
+		}
+	})
+}
+
+func TestFetchRepo_UpdatesCachedRef(t *testing.T) {
+	url, commit := gitRepo(t)
+	root := filepath.Join(t.TempDir(), "missing", "cache")
+	ctx := context.Background()
+
+	dir, _, err := fetchRepo(ctx, url, "main", root, "", slog.Default())
+	if err != nil {
+		t.Fatalf("first fetchRepo: %v", err)
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		t.Fatalf("cache dir not created: %v", err)
+	}
+	if perm, want := info.Mode().Perm(), os.FileMode(0o755)&^umask(t); perm != want {
+		t.Errorf("cache dir mode = %o, want %o", perm, want)
+	}
+	if !strings.HasPrefix(dir, root+string(filepath.Separator)) {
+		t.Errorf("clone %s is outside the cache dir %s", dir, root)
+	}
+	commit("b.go")
+
+	again, _, err := fetchRepo(ctx, url, "main", root, "", slog.Default())
+	if err != nil {
+		t.Fatalf("second fetchRepo: %v", err)
+	}
+	if again != dir {
+		t.Errorf("cached clone moved: %s != %s", again, dir)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b.go")); err != nil {
+		t.Errorf("cached clone not reset to the updated ref: %v", err)
+	}
+
+	// A tag gets its own clone, pinned to the tagged commit.
+	tagged, _, err := fetchRepo(ctx, url, "v1", root, "", slog.Default())
+	if err != nil {
+		t.Fatalf("fetchRepo v1: %v", err)
+	}
+	if tagged == dir {
+		t.Error("tag shares the branch's cache dir")
+	}
+	if _, err := os.Stat(filepath.Join(tagged, "b.go")); err == nil {
+		t.Error("tag clone contains a later commit")
+	}
+}
+
+// umask returns the process umask, measured on a fresh directory.
+func umask(t *testing.T) os.FileMode {
+	t.Helper()
+	probe := filepath.Join(t.TempDir(), "probe")
+	if err := os.Mkdir(probe, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(probe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return 0o777 &^ info.Mode().Perm()
+}
+
+func TestFindModuleRoot_GoWork(t *testing.T) {
+	ws := t.TempDir()
+	writeFile(t, filepath.Join(ws, "go.work"), "go 1.21\n\nuse ./a\n")
+	mkdirAll(t, filepath.Join(ws, "a"))
+	writeFile(t, filepath.Join(ws, "a", "go.mod"), "module example.com/ws/a\n")
+	mkdirAll(t, filepath.Join(ws, "docs", "sub"))
+
+	for _, tt := range []struct{ dir, want string }{
+		{ws, ws},
+		{filepath.Join(ws, "docs", "sub"), ws},
+		{filepath.Join(ws, "a"), filepath.Join(ws, "a")},
+	} {
+		got, err := findModuleRoot(tt.dir)
+		if err != nil {
+			t.Fatalf("findModuleRoot(%s): %v", tt.dir, err)
+		}
+		if got != tt.want {
+			t.Errorf("findModuleRoot(%s) = %s, want %s", tt.dir, got, tt.want)
+		}
+	}
+
+	got, err := findModuleRootRecursive(ws)
+	if err != nil || got != ws {
+		t.Errorf("findModuleRootRecursive = %s, %v; want %s", got, err, ws)
+	}
 }
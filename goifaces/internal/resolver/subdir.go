@@ -0,0 +1,93 @@
+package resolver
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SplitSubdir splits a "//subdir" selector off a repository URL:
+// "https://github.com/foo/bar//internal/service" yields the URL of the
+// repository and "internal/service". A ref suffix may come before or after
+// the selector ("bar@v1.2.0//internal/service" or
+// "bar//internal/service@v1.2.0"); it stays on the returned input. Inputs
+// other than repository URLs on the hosts opts allows are returned unchanged
+// with an empty subdir.
+func SplitSubdir(input string, opts Options) (repo, subdir string) {
+	if !opts.isRepoURL(input) {
+		return input, ""
+	}
+	host := strings.Index(input, "://") + 3
+	i := strings.Index(input[host:], "//")
+	if i < 0 {
+		return input, ""
+	}
+	repo, subdir = input[:host+i], input[host+i+2:]
+	if j := strings.IndexAny(subdir, "@#"); j >= 0 {
+		repo += subdir[j:]
+		subdir = subdir[:j]
+	}
+	return repo, subdir
+}
+
+// CheckSubdir validates subdir, a slash-separated path relative to the module
+// root returned by Resolve, and returns it cleaned ("" for the root itself).
+// It fails with ErrInvalidSubdir when subdir leaves the root, does not exist,
+// is a separate module, or holds no Go files, so a typo is reported instead
+// of producing an empty diagram.
+func CheckSubdir(root, subdir string) (string, error) {
+	if subdir == "" {
+		return "", nil
+	}
+	clean := path.Clean(filepath.ToSlash(subdir))
+	if clean == "." {
+		return "", nil
+	}
+	if path.IsAbs(clean) || filepath.IsAbs(subdir) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("%w %q: must be a path inside the module root", ErrInvalidSubdir, subdir)
+	}
+	dir := filepath.Join(root, filepath.FromSlash(clean))
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("%w %q: no such directory in %s", ErrInvalidSubdir, subdir, root)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%w %q: %s is %w", ErrInvalidSubdir, subdir, dir, ErrNotADirectory)
+	}
+	if hasModuleFile(dir) {
+		return "", fmt.Errorf("%w %q: it is a separate module; analyze it directly", ErrInvalidSubdir, subdir)
+	}
+	if !hasGoFiles(dir) {
+		return "", fmt.Errorf("%w %q: no Go files under %s", ErrInvalidSubdir, subdir, dir)
+	}
+	return clean, nil
+}
+
+// errFound stops hasGoFiles' walk at the first Go file.
+var errFound = errors.New("found")
+
+// hasGoFiles reports whether dir or a directory below it that the go command
+// would load (not testdata, "_" or SkipDir ones) holds a .go file.
+func hasGoFiles(dir string) bool {
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if p != dir && (SkipDir(name) || name == "testdata" || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(d.Name(), ".go") {
+			return errFound
+		}
+		return nil
+	})
+	return errors.Is(err, errFound)
+}
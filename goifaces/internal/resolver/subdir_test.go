@@ -0,0 +1,76 @@
+package resolver
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitSubdir(t *testing.T) {
+	tests := []struct {
+		input      string
+		wantRepo   string
+		wantSubdir string
+	}{
+		{"https://github.com/foo/bar", "https://github.com/foo/bar", ""},
+		{"https://github.com/foo/bar//internal/service", "https://github.com/foo/bar", "internal/service"},
+		{"https://github.com/foo/bar//internal/service@v1.2.0", "https://github.com/foo/bar@v1.2.0", "internal/service"},
+		{"https://github.com/foo/bar@v1.2.0//internal/service", "https://github.com/foo/bar@v1.2.0", "internal/service"},
+		{"https://github.com/foo/bar//internal/service#main", "https://github.com/foo/bar#main", "internal/service"},
+		{"https://gitlab.com/foo/bar//cmd", "https://gitlab.com/foo/bar", "cmd"},
+		{"https://git.example.com/foo/bar//cmd", "https://git.example.com/foo/bar", "cmd"},
+		{"https://evil.example/github.com/foo//cmd", "https://evil.example/github.com/foo//cmd", ""},
+		{"./internal/service", "./internal/service", ""},
+		{"repo//internal", "repo//internal", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			repo, subdir := SplitSubdir(tt.input, Options{GitHosts: []string{"git.example.com"}})
+			if repo != tt.wantRepo || subdir != tt.wantSubdir {
+				t.Errorf("got (%q, %q), want (%q, %q)", repo, subdir, tt.wantRepo, tt.wantSubdir)
+			}
+		})
+	}
+}
+
+func TestCheckSubdir(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n")
+	mkdirAll(t, filepath.Join(root, "internal", "service", "store"))
+	writeFile(t, filepath.Join(root, "internal", "service", "store", "store.go"), "package store\n")
+	mkdirAll(t, filepath.Join(root, "docs", "testdata"))
+	writeFile(t, filepath.Join(root, "docs", "testdata", "x.go"), "package x\n")
+	writeFile(t, filepath.Join(root, "README.md"), "# app\n")
+	mkdirAll(t, filepath.Join(root, "tools"))
+	writeFile(t, filepath.Join(root, "tools", "go.mod"), "module example.com/app/tools\n")
+	writeFile(t, filepath.Join(root, "tools", "main.go"), "package main\n")
+
+	for subdir, want := range map[string]string{
+		"":                   "",
+		".":                  "",
+		"internal/service":   "internal/service",
+		"./internal/service": "internal/service",
+		"internal/service/":  "internal/service",
+	} {
+		got, err := CheckSubdir(root, subdir)
+		if err != nil || got != want {
+			t.Errorf("CheckSubdir(%q) = %q, %v; want %q", subdir, got, err, want)
+		}
+	}
+
+	for subdir, wantMsg := range map[string]string{
+		"internal/servce": "no such directory",
+		"../outside":      "inside the module root",
+		"internal/../..":  "inside the module root",
+		"/etc":            "inside the module root",
+		"README.md":       "not a directory",
+		"docs":            "no Go files",
+		"tools":           "separate module",
+	} {
+		_, err := CheckSubdir(root, subdir)
+		if !errors.Is(err, ErrInvalidSubdir) || !strings.Contains(err.Error(), wantMsg) {
+			t.Errorf("CheckSubdir(%q) error = %v, want ErrInvalidSubdir mentioning %q", subdir, err, wantMsg)
+		}
+	}
+}
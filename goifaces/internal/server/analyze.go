@@ -17,6 +17,7 @@ type AnalysisConfig struct {
 	Filter            string
 	IncludeStdlib     bool
 	IncludeUnexported bool
+	CacheDir          string // clone cache for GitHub inputs; "" = resolver default
 }
 
 // RunAnalysis executes the full resolve → analyze → filter → enrich → prepare
@@ -26,7 +27,7 @@ func RunAnalysis(ctx context.Context, cfg AnalysisConfig, logger *slog.Logger) (
 
 	// Step 1: Resolve input to local directory.
 	logger.Info("resolving input", "input", cfg.Input)
-	dir, cleanup, err := resolver.Resolve(ctx, cfg.Input, logger)
+	dir, cleanup, err := resolver.Resolve(ctx, cfg.Input, resolver.Options{CacheDir: cfg.CacheDir}, logger)
 	if err != nil {
 		return diagram.InteractiveData{}, func() {}, fmt.Errorf("resolve: %w", err)
 	}
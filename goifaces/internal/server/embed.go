@@ -0,0 +1,62 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidFrameOrigin is returned (wrapped) by ParseFrameOrigins for an
+// entry that is not an http(s) origin.
+var ErrInvalidFrameOrigin = errors.New("invalid frame origin")
+
+// originHostPattern keeps origins to host names, IPv4 addresses and ports,
+// so nothing else reaches the Content-Security-Policy header.
+var originHostPattern = regexp.MustCompile(`^[a-zA-Z0-9.-]+(:[0-9]+)?$`)
+
+// ParseFrameOrigins parses a comma-separated list of origins
+// ("https://docs.example.com") allowed to frame the embed page, in the form
+// the CSP frame-ancestors directive expects. "*" allows any origin. Blank
+// entries are skipped.
+func ParseFrameOrigins(list string) ([]string, error) {
+	var origins []string
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			origins = append(origins, entry)
+			continue
+		}
+		u, err := url.Parse(entry)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || !originHostPattern.MatchString(u.Host) ||
+			(u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+			return nil, fmt.Errorf("%w: %q (want scheme://host[:port], e.g. https://docs.example.com)", ErrInvalidFrameOrigin, entry)
+		}
+		origins = append(origins, u.Scheme+"://"+u.Host)
+	}
+	return origins, nil
+}
+
+// isEmbedRequest reports whether r gets the embed page: always with
+// ServeOptions.Embed, otherwise when it asks for it with ?embed=1.
+func isEmbedRequest(r *http.Request, opts ServeOptions) bool {
+	return opts.Embed || r.URL.Query().Get("embed") == "1"
+}
+
+// setFrameHeaders controls who may put the page in an iframe. The full page
+// may only be framed by the server itself; the embed page also by
+// ServeOptions.FrameOrigins. X-Frame-Options cannot list other origins, so
+// the embed page relies on CSP frame-ancestors alone.
+func setFrameHeaders(w http.ResponseWriter, embed bool, origins []string) {
+	if !embed {
+		w.Header().Set("X-Frame-Options", "SAMEORIGIN")
+		w.Header().Set("Content-Security-Policy", "frame-ancestors 'self'")
+		return
+	}
+	w.Header().Set("Content-Security-Policy", strings.Join(append([]string{"frame-ancestors 'self'"}, origins...), " "))
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"syscall"
+)
+
+// PortFallbackAttempts is how many ports after ServeOptions.Port are tried,
+// in order, when it is already in use (8080 falls back to 8081…8090).
+const PortFallbackAttempts = 10
+
+// ErrPortInUse means the requested port, and with fallback every port tried
+// after it, is already in use.
+var ErrPortInUse = errors.New("port in use")
+
+// listen binds the interactive server's TCP listener on port. A busy port
+// moves on to the next, up to PortFallbackAttempts times, unless strict is
+// set; any other bind error is returned at once. The caller reads the port
+// actually bound from the listener's address.
+func listen(port int, strict bool, logger *slog.Logger) (net.Listener, error) {
+	last := port + PortFallbackAttempts
+	if strict || port == 0 {
+		last = port
+	}
+	for p := port; ; p++ {
+		l, err := net.Listen("tcp", fmt.Sprintf(":%d", p))
+		if err == nil {
+			return l, nil
+		}
+		if !errors.Is(err, syscall.EADDRINUSE) {
+			return nil, fmt.Errorf("listening on port %d: %w", p, err)
+		}
+		if p == last {
+			if strict {
+				return nil, fmt.Errorf("%w: %d (drop -strict-port to try the next ports): %w", ErrPortInUse, port, err)
+			}
+			return nil, fmt.Errorf("%w: %d through %d: %w", ErrPortInUse, port, last, err)
+		}
+		logger.Warn("port in use, trying the next one", "port", p, "next", p+1)
+	}
+}
+
+// listenerPort returns the TCP port l is bound to.
+func listenerPort(l net.Listener) int {
+	if addr, ok := l.Addr().(*net.TCPAddr); ok {
+		return addr.Port
+	}
+	return 0
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/olehluchkiv/goifaces/internal/diagram"
+)
+
+// liveData is the state behind the interactive server's handlers: the page
+// template and its data, the /api/data JSON and the metrics. With
+// ServeOptions.Updates it is replaced on every re-analysis, and with
+// ServeOptions.Load on every POST /api/load; the pages subscribed to /events
+// are then told to reload.
+type liveData struct {
+	opts   ServeOptions
+	logger *slog.Logger
+
+	mu       sync.RWMutex
+	tmpl     *template.Template
+	tmplData interactiveData
+	apiData  []byte
+	metrics  []byte
+	empty    bool // no interfaces or types: "/" serves the landing page when loading is enabled
+	subs     map[chan struct{}]struct{}
+}
+
+func newLiveData(data diagram.InteractiveData, opts ServeOptions, logger *slog.Logger) (*liveData, error) {
+	l := &liveData{opts: opts, logger: logger, subs: make(map[chan struct{}]struct{})}
+	if err := l.set(data); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// set renders everything the handlers serve from data and swaps it in.
+func (l *liveData) set(data diagram.InteractiveData) error {
+	tmpl, tmplData, err := newInteractivePage(data, l.opts)
+	if err != nil {
+		return err
+	}
+	apiData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshaling API data to JSON: %w", err)
+	}
+	var metrics []byte
+	if l.opts.Metrics {
+		metrics = renderMetrics(data)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tmpl, l.tmplData, l.apiData, l.metrics = tmpl, tmplData, apiData, metrics
+	l.empty = len(data.Interfaces) == 0 && len(data.Types) == 0
+	return nil
+}
+
+// follow applies every update until the channel is closed, then notifies the
+// subscribed pages. An update that fails to render keeps the previous data.
+func (l *liveData) follow(updates <-chan diagram.InteractiveData) {
+	for data := range updates {
+		if err := l.set(data); err != nil {
+			l.logger.Error("failed to apply updated data", "error", err)
+			continue
+		}
+		l.logger.Info("data updated, reloading pages", "pages", l.notify())
+	}
+}
+
+// notify wakes every subscriber and returns how many there were. A
+// subscriber that has not consumed its previous notification is not
+// notified twice.
+func (l *liveData) notify() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for ch := range l.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	return len(l.subs)
+}
+
+func (l *liveData) subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	l.mu.Lock()
+	l.subs[ch] = struct{}{}
+	l.mu.Unlock()
+	return ch, func() {
+		l.mu.Lock()
+		delete(l.subs, ch)
+		l.mu.Unlock()
+	}
+}
+
+// serveEvents streams Server-Sent Events to a page: a "reload" event each
+// time the data is replaced. It returns when the client disconnects or the
+// server shuts down.
+func (l *liveData) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	updates, unsubscribe := l.subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-updates:
+			if _, err := fmt.Fprint(w, "event: reload\ndata: {}\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/olehluchkiv/goifaces/internal/diagram"
+)
+
+// DefaultLoadTimeout bounds a POST /api/load analysis when
+// ServeOptions.LoadTimeout is not set.
+const DefaultLoadTimeout = 5 * time.Minute
+
+// Loader analyzes the Go code at path (a directory, archive or repository
+// URL, as accepted on the command line) and returns the page data for it.
+type Loader func(ctx context.Context, path string) (diagram.InteractiveData, error)
+
+// loadRequest is the body of POST /api/load.
+type loadRequest struct {
+	Path string `json:"path"`
+}
+
+// loadResponse is the JSON answer of POST /api/load: the counts of the new
+// data on success, Error otherwise.
+type loadResponse struct {
+	Interfaces int    `json:"interfaces,omitempty"`
+	Types      int    `json:"types,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// loadHandler serves POST /api/load: it runs load for the requested path
+// under timeout and swaps the result into live, so the page can reload to
+// show it. One load runs at a time; requests arriving meanwhile get 409.
+// Only loopback clients may load, since the path is read on this machine,
+// and only with a JSON body from the server's own page: requiring
+// application/json forces a CORS preflight on other pages, and a browser's
+// Origin or Sec-Fetch-Site header naming another site is refused outright.
+type loadHandler struct {
+	live    *liveData
+	load    Loader
+	timeout time.Duration
+	logger  *slog.Logger
+
+	busy sync.Mutex
+}
+
+func (h *loadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := requestLogger(r, h.logger)
+	if !isLoopback(r.RemoteAddr) {
+		writeLoadResponse(w, http.StatusForbidden, loadResponse{Error: "loading is only allowed from this machine"})
+		return
+	}
+	if isCrossOrigin(r) {
+		logger.Warn("refusing cross-origin load", "origin", r.Header.Get("Origin"), "sec_fetch_site", r.Header.Get("Sec-Fetch-Site"))
+		writeLoadResponse(w, http.StatusForbidden, loadResponse{Error: "loading is only allowed from the goifaces page"})
+		return
+	}
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		writeLoadResponse(w, http.StatusUnsupportedMediaType, loadResponse{Error: "Content-Type must be application/json"})
+		return
+	}
+	var req loadRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		writeLoadResponse(w, http.StatusBadRequest, loadResponse{Error: fmt.Sprintf("invalid request body: %v", err)})
+		return
+	}
+	req.Path = strings.TrimSpace(req.Path)
+	if req.Path == "" {
+		writeLoadResponse(w, http.StatusBadRequest, loadResponse{Error: "path is required"})
+		return
+	}
+	if !h.busy.TryLock() {
+		writeLoadResponse(w, http.StatusConflict, loadResponse{Error: "another analysis is already running; try again when it finishes"})
+		return
+	}
+	defer h.busy.Unlock()
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+	start := time.Now()
+	logger.Info("loading repository", "path", req.Path, "timeout", h.timeout)
+	data, err := h.load(ctx, req.Path)
+	if err == nil {
+		err = h.live.set(data)
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		logger.Error("load timed out", "path", req.Path, "timeout", h.timeout)
+		writeLoadResponse(w, http.StatusGatewayTimeout, loadResponse{Error: fmt.Sprintf("analysis of %s did not finish within %s", req.Path, h.timeout)})
+		return
+	case err != nil:
+		logger.Error("load failed", "path", req.Path, "error", err)
+		writeLoadResponse(w, http.StatusUnprocessableEntity, loadResponse{Error: err.Error()})
+		return
+	}
+
+	logger.Info("repository loaded", "path", req.Path, "interfaces", len(data.Interfaces), "types", len(data.Types),
+		"duration", time.Since(start), "pages", h.live.notify())
+	writeLoadResponse(w, http.StatusOK, loadResponse{Interfaces: len(data.Interfaces), Types: len(data.Types)})
+}
+
+func writeLoadResponse(w http.ResponseWriter, status int, resp loadResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// isLoopback reports whether the request address addr (host:port) is on
+// this machine.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// isCrossOrigin reports whether a browser sent r from a page of another
+// origin: Sec-Fetch-Site other than same-origin (or none, for requests the
+// user typed), or an Origin whose host is not the one r was sent to.
+// Clients that send neither, such as curl, are not browsers and pass.
+func isCrossOrigin(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "", "same-origin", "none":
+	default:
+		return true
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	u, err := url.Parse(origin)
+	return err != nil || u.Host != r.Host
+}
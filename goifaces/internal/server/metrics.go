@@ -0,0 +1,55 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/olehluchkiv/goifaces/internal/diagram"
+)
+
+// metricsContentType is the Prometheus text exposition format, version 0.0.4.
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// renderMetrics formats the architecture size of data as Prometheus gauges:
+// top-level interface, type and relation totals plus per-package counts
+// labeled by package path. It is rendered once per data set, not per scrape.
+func renderMetrics(data diagram.InteractiveData) []byte {
+	var b strings.Builder
+	writeGauge(&b, "goifaces_interfaces_total", "Number of interfaces in the analyzed result.", len(data.Interfaces))
+	writeGauge(&b, "goifaces_types_total", "Number of concrete types in the analyzed result.", len(data.Types))
+	writeGauge(&b, "goifaces_relations_total", "Number of type-implements-interface relations.", len(data.Relations))
+
+	ifacesPerPkg := make(map[string]int)
+	for _, iface := range data.Interfaces {
+		ifacesPerPkg[iface.PkgPath]++
+	}
+	typesPerPkg := make(map[string]int)
+	for _, t := range data.Types {
+		typesPerPkg[t.PkgPath]++
+	}
+	writePackageGauge(&b, "goifaces_package_interfaces", "Number of interfaces declared in a package.", ifacesPerPkg)
+	writePackageGauge(&b, "goifaces_package_types", "Number of concrete types declared in a package.", typesPerPkg)
+	return []byte(b.String())
+}
+
+func writeGauge(b *strings.Builder, name, help string, value int) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s gauge\n%s %d\n", name, help, name, name, value)
+}
+
+func writePackageGauge(b *strings.Builder, name, help string, counts map[string]int) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	pkgs := make([]string, 0, len(counts))
+	for pkg := range counts {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	for _, pkg := range pkgs {
+		fmt.Fprintf(b, "%s{package=\"%s\"} %d\n", name, escapeLabelValue(pkg), counts[pkg])
+	}
+}
+
+// escapeLabelValue escapes a label value per the exposition format.
+func escapeLabelValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
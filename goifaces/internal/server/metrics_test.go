@@ -0,0 +1,80 @@
+package server
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/olehluchkiv/goifaces/internal/diagram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func metricsTestData() diagram.InteractiveData {
+	return diagram.InteractiveData{
+		Interfaces: []diagram.InteractiveInterface{
+			{ID: "store_Store", Name: "Store", PkgPath: "example.com/app/store"},
+			{ID: "http_Handler", Name: "Handler", PkgPath: "example.com/app/http"},
+		},
+		Types: []diagram.InteractiveType{
+			{ID: "store_Mem", Name: "Mem", PkgPath: "example.com/app/store"},
+			{ID: "store_Disk", Name: "Disk", PkgPath: "example.com/app/store"},
+			{ID: "http_Server", Name: "Server", PkgPath: "example.com/app/http"},
+		},
+		Relations: []diagram.InteractiveRelation{
+			{TypeID: "store_Mem", InterfaceID: "store_Store"},
+			{TypeID: "store_Disk", InterfaceID: "store_Store"},
+			{TypeID: "http_Server", InterfaceID: "http_Handler"},
+		},
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mux, err := newInteractiveMux(metricsTestData(), ServeOptions{Metrics: true}, logger)
+	require.NoError(t, err)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, metricsContentType, resp.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	out := string(body)
+	for _, want := range []string{
+		"# TYPE goifaces_interfaces_total gauge\ngoifaces_interfaces_total 2\n",
+		"# TYPE goifaces_types_total gauge\ngoifaces_types_total 3\n",
+		"# TYPE goifaces_relations_total gauge\ngoifaces_relations_total 3\n",
+		"# HELP goifaces_package_interfaces ",
+		`goifaces_package_interfaces{package="example.com/app/http"} 1`,
+		`goifaces_package_interfaces{package="example.com/app/store"} 1`,
+		`goifaces_package_types{package="example.com/app/http"} 1`,
+		`goifaces_package_types{package="example.com/app/store"} 2`,
+	} {
+		assert.Contains(t, out, want)
+	}
+}
+
+func TestMetricsEndpointDisabled(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mux, err := newInteractiveMux(metricsTestData(), ServeOptions{}, logger)
+	require.NoError(t, err)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	// Without -metrics-endpoint the path falls through to the page handler.
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/html")
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	assert.Equal(t, `a\\b\"c\nd`, escapeLabelValue("a\\b\"c\nd"))
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// requestIDHeader carries the request id back to the client, so a failing
+// request can be matched to its log lines.
+const requestIDHeader = "X-Request-Id"
+
+type loggerKey struct{}
+
+// withRequestLog gives every request an id: it is set as the X-Request-Id
+// response header, attached to the logger handlers get from requestLogger,
+// and included in the access log line written once the handler returns.
+// The /healthz and /readyz probes are served without an access line, so
+// frequent polling does not drown the log.
+func withRequestLog(next http.Handler, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := newRequestID()
+		reqLogger := logger.With("request_id", id)
+		w.Header().Set(requestIDHeader, id)
+		r = r.WithContext(context.WithValue(r.Context(), loggerKey{}, reqLogger))
+
+		if r.URL.Path == "/healthz" || r.URL.Path == "/readyz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		reqLogger.Info("request", "method", r.Method, "path", r.URL.Path, "status", rec.status,
+			"bytes", rec.bytes, "duration", time.Since(start), "remote", r.RemoteAddr)
+	})
+}
+
+// requestLogger returns the logger withRequestLog attached to r, or fallback
+// when r did not pass through it.
+func requestLogger(r *http.Request, fallback *slog.Logger) *slog.Logger {
+	if logger, ok := r.Context().Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return fallback
+}
+
+// newRequestID returns 16 random hex digits.
+func newRequestID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:]) // crypto/rand.Read does not fail
+	return hex.EncodeToString(b[:])
+}
+
+// statusRecorder remembers the status code and body size of a response for
+// the access log. It passes Flush through, which the /events stream needs.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+func (rec *statusRecorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"log/slog"
+	"net"
 	"net/http"
 	"os/exec"
 	"runtime"
@@ -20,7 +22,7 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
 <head>
   <meta charset="UTF-8">
   <meta name="viewport" content="width=device-width, initial-scale=1.0">
-  <title>goifaces — {{.RepoAddress}}</title>
+  <title>{{.Title}} — {{.RepoAddress}}{{if .Version}} (goifaces {{.Version}}){{end}}</title>
   <style>
     *, *::before, *::after { box-sizing: border-box; margin: 0; padding: 0; }
 
@@ -71,6 +73,10 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
       .placeholder-msg {
         color: #888;
       }
+      .minimap {
+        background: rgba(45,45,68,0.92);
+        border-color: #444;
+      }
       .entity-list-actions {
         border-bottom-color: #444;
         background-color: #2d2d44;
@@ -87,14 +93,44 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
         border-color: #444;
         background-color: #2d2d44;
       }
-      .sidebar-section-actions button {
+      .sidebar-section-actions button,
+      .pattern-participants button {
         background-color: #333;
         color: #e0e0e0;
         border-color: #555;
       }
-      .sidebar-section-actions button:hover {
+      .sidebar-section-actions button:hover,
+      .pattern-participants button:hover {
         background-color: #444;
       }
+      .sidebar-search input[type="search"] {
+        background-color: #2d2d44;
+        color: #e0e0e0;
+        border-color: #444;
+      }
+      .sidebar-search-options {
+        color: #aaa;
+      }
+      .sidebar-section-body label.method-match,
+      .sidebar-section-body label.method-match:hover {
+        background-color: #5c4b14;
+      }
+      .pattern-card {
+        background-color: #2d2d44;
+        border-color: #444;
+      }
+      .pattern-card p,
+      .patterns-empty {
+        color: #aaa;
+      }
+      .pattern-participants a.interface {
+        background-color: #1f3a52;
+        color: #9cc9ee;
+      }
+      .pattern-participants a.impl {
+        background-color: #1f3d2b;
+        color: #9fd8b2;
+      }
     }
 
     h1 {
@@ -104,6 +140,22 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
       text-align: center;
     }
 
+    .header-logo {
+      height: 1.6rem;
+      margin-right: 0.5rem;
+      vertical-align: middle;
+    }
+
+    .build-version {
+      position: fixed;
+      left: 0.5rem;
+      bottom: 0.25rem;
+      font-size: 0.7rem;
+      opacity: 0.6;
+      pointer-events: none;
+      z-index: 30;
+    }
+
     .tab-bar {
       display: flex;
       gap: 0.25rem;
@@ -152,6 +204,29 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
       transition: background-color 0.15s;
     }
 
+    body.embed {
+      padding: 0.25rem;
+    }
+
+    body.embed .tab-bar {
+      margin: 0.25rem 0;
+    }
+
+    body.embed .tab-bar button {
+      padding: 0.25rem 0.8rem;
+      font-size: 0.8rem;
+    }
+
+    body.embed .controls {
+      gap: 0.25rem;
+      margin-bottom: 0.25rem;
+    }
+
+    body.embed .controls button {
+      padding: 0.2rem 0.6rem;
+      font-size: 0.8rem;
+    }
+
     .controls button:hover {
       background-color: #e9ecef;
     }
@@ -251,6 +326,23 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
       font-size: 0.75rem;
     }
 
+    .entity-list .func-tag,
+    .sidebar-section-body .func-tag {
+      margin-right: 0.3rem;
+      padding: 0 0.25rem;
+      border: 1px solid #4a9c6d;
+      border-radius: 3px;
+      color: #357a50;
+      font-size: 0.7rem;
+      font-family: monospace;
+    }
+
+    .entity-list label.external,
+    .sidebar-section-body label.external {
+      color: #888;
+      font-style: italic;
+    }
+
     .sidebar-section {
       border: 1px solid #ccc;
       border-radius: 6px;
@@ -279,7 +371,8 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
       display: flex;
       gap: 0.25rem;
     }
-    .sidebar-section-actions button {
+    .sidebar-section-actions button,
+    .pattern-participants button {
       padding: 0.15rem 0.4rem;
       font-size: 0.7rem;
       border: 1px solid #ccc;
@@ -289,13 +382,98 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
       cursor: pointer;
       transition: background-color 0.15s;
     }
-    .sidebar-section-actions button:hover {
+    .sidebar-section-actions button:hover,
+    .pattern-participants button:hover {
       background-color: #e9ecef;
     }
     .sidebar-section-body {
       padding: 0 0 0.3rem 0;
     }
 
+    /* Method search sits above both sections, whatever their order */
+    .sidebar-search {
+      order: -1;
+      display: flex;
+      flex-direction: column;
+      gap: 0.25rem;
+    }
+    .sidebar-search input[type="search"] {
+      width: 100%;
+      box-sizing: border-box;
+      padding: 0.35rem 0.5rem;
+      font-size: 0.85rem;
+      border: 1px solid #ccc;
+      border-radius: 4px;
+    }
+    .sidebar-search-options {
+      display: flex;
+      align-items: center;
+      justify-content: space-between;
+      font-size: 0.75rem;
+      color: #555;
+    }
+    .sidebar-search-options label {
+      display: flex;
+      align-items: center;
+      gap: 0.25rem;
+      cursor: pointer;
+    }
+    .sidebar-section-body label.method-match,
+    .sidebar-section-body label.method-match:hover {
+      background-color: #fff3bf;
+    }
+
+    /* Patterns tab: one card per detected design pattern */
+    .patterns-list {
+      width: 100%;
+      max-width: 900px;
+      display: flex;
+      flex-direction: column;
+      gap: 0.75rem;
+    }
+    .pattern-card {
+      border: 1px solid #ccc;
+      border-radius: 6px;
+      background-color: #fff;
+      padding: 0.75rem 1rem;
+    }
+    .pattern-card h2 {
+      font-size: 1rem;
+      margin-bottom: 0.3rem;
+    }
+    .pattern-card p {
+      font-size: 0.85rem;
+      color: #555;
+      margin-bottom: 0.5rem;
+    }
+    .pattern-participants {
+      display: flex;
+      flex-wrap: wrap;
+      align-items: center;
+      gap: 0.35rem;
+    }
+    .pattern-participants a {
+      font-size: 0.8rem;
+      padding: 0.15rem 0.5rem;
+      border-radius: 4px;
+      text-decoration: none;
+    }
+    .pattern-participants a:hover {
+      text-decoration: underline;
+    }
+    .pattern-participants a.interface {
+      background-color: #e3eef7;
+      color: #1a5a8a;
+    }
+    .pattern-participants a.impl {
+      background-color: #e6f2ea;
+      color: #357a50;
+    }
+    .patterns-empty {
+      color: #666;
+      font-size: 0.9rem;
+    }
+
     .diagram-viewport {
       flex: 1;
       overflow: auto;
@@ -311,6 +489,39 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
       transition: transform 0.2s ease;
     }
 
+    /* Minimap overlay for large Structures diagrams */
+    #panel-structures {
+      position: relative;
+    }
+
+    .minimap {
+      position: absolute;
+      right: 1rem;
+      bottom: 1rem;
+      width: 180px;
+      height: 120px;
+      background: rgba(255,255,255,0.92);
+      border: 1px solid #ccc;
+      border-radius: 4px;
+      box-shadow: 0 2px 6px rgba(0,0,0,0.15);
+      overflow: hidden;
+      cursor: pointer;
+      z-index: 20;
+    }
+
+    .minimap img {
+      position: absolute;
+      pointer-events: none;
+    }
+
+    .minimap-viewport {
+      position: absolute;
+      border: 2px solid #1976d2;
+      background: rgba(25,118,210,0.12);
+      box-sizing: border-box;
+      cursor: move;
+    }
+
     .placeholder-msg {
       color: #666;
       font-size: 1rem;
@@ -333,26 +544,26 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
 
     /* Color coding: interface blocks (blue) */
     .mermaid svg g.node.interfaceStyle > g:first-child > path:first-child {
-      fill: #2374ab !important;
+      fill: {{.Palette.Interface.Fill}} !important;
     }
     .mermaid svg g.node.interfaceStyle > g:first-child > path:nth-child(2) {
-      stroke: #1a5a8a !important;
+      stroke: {{.Palette.Interface.Stroke}} !important;
       stroke-width: 2px !important;
     }
     .mermaid svg g.node.interfaceStyle .nodeLabel {
-      color: #fff !important;
+      color: {{.Palette.Interface.Text}} !important;
     }
 
     /* Color coding: implementation blocks (green) */
     .mermaid svg g.node.implStyle > g:first-child > path:first-child {
-      fill: #4a9c6d !important;
+      fill: {{.Palette.Impl.Fill}} !important;
     }
     .mermaid svg g.node.implStyle > g:first-child > path:nth-child(2) {
-      stroke: #357a50 !important;
+      stroke: {{.Palette.Impl.Stroke}} !important;
       stroke-width: 2px !important;
     }
     .mermaid svg g.node.implStyle .nodeLabel {
-      color: #fff !important;
+      color: {{.Palette.Impl.Text}} !important;
     }
 
     /* Treemap styles */
@@ -461,6 +672,18 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
       display: none;
     }
 
+    .mermaid svg path.relation-hit {
+      fill: none;
+      stroke: transparent;
+      stroke-width: 12px;
+      cursor: pointer;
+      pointer-events: stroke;
+    }
+
+    .mermaid svg path.relation-hover {
+      stroke-width: 3px !important;
+    }
+
     .treemap-node[data-clickable] {
       cursor: pointer;
     }
@@ -518,6 +741,41 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
       font-size: 0.85rem;
       border-bottom: 1px solid #eee;
       margin-bottom: 4px;
+      display: flex;
+      align-items: center;
+      gap: 6px;
+    }
+
+    .treemap-overlay-title {
+      flex: 1;
+      overflow: hidden;
+      text-overflow: ellipsis;
+      white-space: nowrap;
+    }
+
+    .treemap-overlay-actions {
+      display: flex;
+      gap: 4px;
+      flex-shrink: 0;
+    }
+
+    .treemap-overlay-actions button {
+      padding: 1px 6px;
+      font-size: 0.7rem;
+      border: 1px solid #ccc;
+      border-radius: 4px;
+      background-color: #f8f9fa;
+      color: #212529;
+      cursor: pointer;
+    }
+
+    .treemap-overlay-actions button:hover:not(:disabled) {
+      background-color: #e9ecef;
+    }
+
+    .treemap-overlay-actions button:disabled {
+      opacity: 0.45;
+      cursor: default;
     }
 
     .treemap-overlay-section {
@@ -592,6 +850,14 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
       .treemap-overlay-section {
         color: #999;
       }
+      .treemap-overlay-actions button {
+        background-color: #3d3d5c;
+        border-color: #555;
+        color: #e0e0e0;
+      }
+      .treemap-overlay-actions button:hover:not(:disabled) {
+        background-color: #4d4d6c;
+      }
       .treemap-overlay-item {
         color: #e0e0e0;
       }
@@ -600,19 +866,29 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
       }
     }
   </style>
+  {{- if .CustomCSS}}
+  <style id="custom-style">
+{{.CustomCSS}}
+  </style>
+  {{- end}}
 </head>
-<body>
-  <h1>goifaces — {{.RepoAddress}}</h1>
+<body{{if .Embed}} class="embed"{{end}}>
+  {{- if not .Embed}}
+  <h1>{{if .LogoURL}}<img class="header-logo" src="{{.LogoURL}}" alt="">{{end}}{{.Title}} — {{.RepoAddress}}</h1>
+  {{- end}}
 
   <div class="tab-bar">
-    <button class="tab-btn active" data-tab="pkgmap-html">Package Map</button>
-    <button class="tab-btn" data-tab="structures">Structures</button>
+    <button class="tab-btn active" data-tab="pkgmap-html" title="Package Map (1)">Package Map</button>
+    <button class="tab-btn" data-tab="structures" title="Structures (2)">Structures</button>
+    {{- if .Patterns}}
+    <button class="tab-btn" data-tab="patterns" title="Patterns (3)">Patterns</button>
+    {{- end}}
   </div>
 
   <div class="controls">
-    <button id="zoom-in" title="Zoom In">+ Zoom In</button>
-    <button id="zoom-out" title="Zoom Out">- Zoom Out</button>
-    <button id="zoom-reset" title="Reset Zoom">Reset</button>
+    <button id="zoom-in" title="Zoom In (+)">+ Zoom In</button>
+    <button id="zoom-out" title="Zoom Out (-)">- Zoom Out</button>
+    <button id="zoom-reset" title="Reset Zoom (0) and selection (Esc)">Reset</button>
     <button id="copy-src" title="Copy Source">Copy Source</button>
   </div>
 
@@ -625,9 +901,23 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
 
   <div class="treemap-tooltip" id="treemap-tooltip"></div>
 
+  {{- if .Patterns}}
+  <!-- Patterns tab: only when pattern detection ran (-enrich) -->
+  <div class="tab-panel full-width" id="panel-patterns">
+    <div class="patterns-list" id="patterns-list"></div>
+  </div>
+  {{- end}}
+
   <!-- Structures tab -->
   <div class="tab-panel" id="panel-structures">
     <div class="sidebar-col" id="structures-list">
+      <div class="sidebar-search">
+        <input type="search" id="sidebar-search" placeholder="Find interfaces by method, e.g. Close" aria-label="Find interfaces by method signature" autocomplete="off">
+        <div class="sidebar-search-options">
+          <label title="Select exactly the matching interfaces as you type"><input type="checkbox" id="method-search-select"> Select matches</label>
+          <span id="method-search-count" aria-live="polite"></span>
+        </div>
+      </div>
       <details class="sidebar-section" open style="order:1">
         <summary class="sidebar-section-header">
           Implementations
@@ -649,12 +939,16 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
         <div class="sidebar-section-body" id="ifaces-list"></div>
       </details>
     </div>
-    <div class="diagram-viewport">
+    <div class="diagram-viewport" id="structures-viewport">
       <div class="diagram-container" id="structures-diagram-container">
         <div class="placeholder-msg" id="structures-placeholder">Select items from the list to view their relationships</div>
         <pre class="mermaid" id="structures-mermaid" style="display:none;"></pre>
       </div>
     </div>
+    <div class="minimap" id="structures-minimap" style="display:none;" title="Click or drag to navigate">
+      <img id="minimap-image" alt="">
+      <div class="minimap-viewport" id="minimap-viewport"></div>
+    </div>
   </div>
 
   <script src="https://cdn.jsdelivr.net/npm/mermaid@11/dist/mermaid.min.js"></script>
@@ -683,19 +977,13 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
       var selectedIfaceIDs = {};  // { [id]: true }
       var updatingUI = false;     // re-entrancy guard for updateSelectionUI
 
-      // Pastel palette matching Go-side colors
-      var treemapPalette = [
-        {fill: '#e8f4fd', stroke: '#b8d4e8', text: '#333333'},
-        {fill: '#e8f5e9', stroke: '#b8d8ba', text: '#333333'},
-        {fill: '#fff3e0', stroke: '#e8c9a0', text: '#333333'},
-        {fill: '#f3e5f5', stroke: '#d1b3d8', text: '#333333'},
-        {fill: '#fce4ec', stroke: '#e8b0bf', text: '#333333'},
-        {fill: '#e0f2f1', stroke: '#b0d4d1', text: '#333333'},
-        {fill: '#fff9c4', stroke: '#e8dea0', text: '#333333'},
-        {fill: '#e8eaf6', stroke: '#b8bce8', text: '#333333'},
-        {fill: '#efebe9', stroke: '#c8b8ad', text: '#333333'},
-        {fill: '#f1f8e9', stroke: '#c4dba0', text: '#333333'}
-      ];
+      // Selections whose encoded hash would exceed this many characters are
+      // not written to the URL; browsers and chat tools truncate long links.
+      var maxSelectionHashLength = 2000;
+
+      // Node colors, the same palette the Go side renders with (-theme)
+      var palette = data.palette;
+      var treemapPalette = palette.packages;
 
       // Squarified treemap algorithm
       function squarify(nodes, rect) {
@@ -816,7 +1104,7 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
       function flattenTree(nodes, maxDepth) {
         if (!nodes) return [];
         return nodes.map(function(n) {
-          var clone = {name: n.name, relPath: n.relPath, pkgPath: n.pkgPath, interfaces: n.interfaces, types: n.types, value: n.value};
+          var clone = {name: n.name, relPath: n.relPath, pkgPath: n.pkgPath, interfaces: n.interfaces, types: n.types, value: n.value, aggregated: n.aggregated, replaced: n.replaced};
           if (n.children && n.children.length > 0) {
             if (maxDepth <= 1) {
               clone.children = null;
@@ -850,10 +1138,50 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
         pkgTypes[t.pkgPath].push(t);
       });
 
+      // Restore a shared selection from the URL before the sidebar lists are
+      // built, so the right boxes come up checked.
+      var knownTypeIDs = {};
+      var knownIfaceIDs = {};
+      data.types.forEach(function(t) { knownTypeIDs[t.id] = true; });
+      data.interfaces.forEach(function(iface) { knownIfaceIDs[iface.id] = true; });
+      var restoredFromHash = readSelectionHash();
+
       // Overlay state
       var activeOverlay = null;
       var selectedNode = null;
 
+      // setPackageSelection selects (or clears) every interface and type of
+      // one package in the shared selection state.
+      function setPackageSelection(ifaces, types, selected) {
+        ifaces.forEach(function(iface) {
+          if (selected) {
+            selectedIfaceIDs[iface.id] = true;
+          } else {
+            delete selectedIfaceIDs[iface.id];
+          }
+        });
+        types.forEach(function(t) {
+          if (selected) {
+            selectedTypeIDs[t.id] = true;
+          } else {
+            delete selectedTypeIDs[t.id];
+          }
+        });
+        updateSelectionUI();
+      }
+
+      // updateOverlayActions disables "Select all" when every item in the
+      // overlay is already selected and "Clear" when none is.
+      function updateOverlayActions(overlay) {
+        var boxes = overlay.querySelectorAll('input[type="checkbox"]');
+        var checked = 0;
+        boxes.forEach(function(cb) { if (cb.checked) checked++; });
+        var selectAllBtn = overlay.querySelector('.treemap-overlay-select-all');
+        var clearBtn = overlay.querySelector('.treemap-overlay-clear');
+        if (selectAllBtn) selectAllBtn.disabled = checked === boxes.length;
+        if (clearBtn) clearBtn.disabled = checked === 0;
+      }
+
       function showPackageOverlay(nodeEl, d) {
         dismissOverlay();
         var ifaces = pkgInterfaces[d.pkgPath] || [];
@@ -865,7 +1193,34 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
 
         var header = document.createElement('div');
         header.className = 'treemap-overlay-header';
-        header.textContent = d.relPath ? d.relPath : d.name;
+        var title = document.createElement('span');
+        title.className = 'treemap-overlay-title';
+        title.textContent = d.relPath ? d.relPath : d.name;
+        title.title = title.textContent;
+        header.appendChild(title);
+
+        // Bulk selection scoped to this package, like the sidebar All/Clear
+        var actions = document.createElement('div');
+        actions.className = 'treemap-overlay-actions';
+        var selectAllBtn = document.createElement('button');
+        selectAllBtn.type = 'button';
+        selectAllBtn.className = 'treemap-overlay-select-all';
+        selectAllBtn.textContent = 'Select all';
+        selectAllBtn.addEventListener('click', function(e) {
+          e.stopPropagation();
+          setPackageSelection(ifaces, types, true);
+        });
+        var clearBtn = document.createElement('button');
+        clearBtn.type = 'button';
+        clearBtn.className = 'treemap-overlay-clear';
+        clearBtn.textContent = 'Clear';
+        clearBtn.addEventListener('click', function(e) {
+          e.stopPropagation();
+          setPackageSelection(ifaces, types, false);
+        });
+        actions.appendChild(selectAllBtn);
+        actions.appendChild(clearBtn);
+        header.appendChild(actions);
         overlay.appendChild(header);
 
         if (ifaces.length > 0) {
@@ -876,6 +1231,7 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
           ifaces.forEach(function(iface) {
             var itemLabel = document.createElement('label');
             itemLabel.className = 'treemap-overlay-item';
+            if (iface.methods && iface.methods.length) itemLabel.title = iface.methods.join('\n');
             var cb = document.createElement('input');
             cb.type = 'checkbox';
             cb.checked = !!selectedIfaceIDs[iface.id];
@@ -905,6 +1261,7 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
           types.forEach(function(t) {
             var itemLabel = document.createElement('label');
             itemLabel.className = 'treemap-overlay-item';
+            if (t.methods && t.methods.length) itemLabel.title = t.methods.join('\n');
             var cb = document.createElement('input');
             cb.type = 'checkbox';
             cb.checked = !!selectedTypeIDs[t.id];
@@ -926,6 +1283,8 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
           });
         }
 
+        updateOverlayActions(overlay);
+
         // Position overlay near the clicked block
         var viewport = document.getElementById('pkgmap-html-viewport');
         var vpRect = viewport.getBoundingClientRect();
@@ -1073,6 +1432,8 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
         var parts = [];
         if (d.interfaces > 0) parts.push(d.interfaces + ' iface' + (d.interfaces > 1 ? 's' : ''));
         if (d.types > 0) parts.push(d.types + ' type' + (d.types > 1 ? 's' : ''));
+        if (d.aggregated > 0) parts.push('in ' + d.aggregated + ' packages');
+        if (d.replaced) parts.push('replaced');
         return parts.join(', ') || '(empty)';
       }
 
@@ -1135,13 +1496,24 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
           cb.type = 'checkbox';
           cb.value = t.id;
           cb.className = 'impl-cb';
+          cb.checked = !!selectedTypeIDs[t.id];
           cb.addEventListener('change', onSelectionChange);
           var span = document.createElement('span');
           span.appendChild(document.createTextNode(t.name + ' '));
+          // Named function types (HandlerFunc-style adapters) are tagged,
+          // matching their <<func>> stereotype in the diagram
+          if (t.isFunc) {
+            var tag = document.createElement('span');
+            tag.className = 'func-tag';
+            tag.textContent = 'func';
+            tag.title = 'named function type';
+            span.appendChild(tag);
+          }
           var pkg = document.createElement('span');
           pkg.className = 'pkg-name';
           pkg.textContent = t.pkgName;
           span.appendChild(pkg);
+          if (t.external) label.className = 'external';
           label.appendChild(cb);
           label.appendChild(span);
           implsFrag.appendChild(label);
@@ -1155,6 +1527,7 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
           cb.type = 'checkbox';
           cb.value = iface.id;
           cb.className = 'iface-cb';
+          cb.checked = !!selectedIfaceIDs[iface.id];
           cb.addEventListener('change', onSelectionChange);
           var span = document.createElement('span');
           span.appendChild(document.createTextNode(iface.name + ' '));
@@ -1162,13 +1535,66 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
           pkg.className = 'pkg-name';
           pkg.textContent = iface.pkgName;
           span.appendChild(pkg);
+          if (iface.external) label.className = 'external';
           label.appendChild(cb);
           label.appendChild(span);
           ifacesFrag.appendChild(label);
         });
         ifacesList.appendChild(ifacesFrag);
+
+        if (restoredFromHash) switchTab('structures');
+        // The browser may restore a query typed before a reload
+        if (methodSearch.value) highlightMethodMatches(methodSearch.value);
       }, 0);
 
+      // Method search: highlight the interfaces whose rendered method
+      // signatures contain the query, case-insensitively. With "Select
+      // matches" the interface selection follows the matches; selected
+      // types are left alone.
+      var methodSearch = document.getElementById('sidebar-search');
+      var methodSearchSelect = document.getElementById('method-search-select');
+      var methodSearchCount = document.getElementById('method-search-count');
+      var methodSearchTimer = null;
+
+      function highlightMethodMatches(query) {
+        query = query.trim().toLowerCase();
+        var matched = {};
+        var count = 0;
+        if (query) {
+          data.interfaces.forEach(function(iface) {
+            var hit = (iface.methods || []).some(function(m) {
+              return m.toLowerCase().indexOf(query) !== -1;
+            });
+            if (hit) {
+              matched[iface.id] = true;
+              count++;
+            }
+          });
+        }
+        document.querySelectorAll('.iface-cb').forEach(function(cb) {
+          cb.parentNode.classList.toggle('method-match', !!matched[cb.value]);
+        });
+        methodSearchCount.textContent = query ? count + (count === 1 ? ' interface' : ' interfaces') : '';
+        if (count > 0) ifacesList.parentNode.open = true;
+        if (query && methodSearchSelect.checked) {
+          document.querySelectorAll('.iface-cb').forEach(function(cb) {
+            cb.checked = !!matched[cb.value];
+          });
+          onSelectionChange();
+        }
+        return matched;
+      }
+
+      methodSearch.addEventListener('input', function() {
+        clearTimeout(methodSearchTimer);
+        methodSearchTimer = setTimeout(function() {
+          highlightMethodMatches(methodSearch.value);
+        }, 150);
+      });
+      methodSearchSelect.addEventListener('change', function() {
+        highlightMethodMatches(methodSearch.value);
+      });
+
       // Bulk selection: Implementations
       document.getElementById('impls-all').addEventListener('click', function() {
         document.querySelectorAll('.impl-cb').forEach(function(cb) { cb.checked = true; });
@@ -1189,6 +1615,73 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
         onSelectionChange();
       });
 
+      // Patterns tab: each participant link selects that node in the
+      // Structures tab, "Select all" selects the whole pattern.
+      var patternsList = document.getElementById('patterns-list');
+      if (patternsList && data.patterns) renderPatterns();
+
+      function renderPatterns() {
+        if (data.patterns.length === 0) {
+          var empty = document.createElement('p');
+          empty.className = 'patterns-empty';
+          empty.textContent = 'No design patterns detected among the nodes in this diagram.';
+          patternsList.appendChild(empty);
+          return;
+        }
+        data.patterns.forEach(function(p) {
+          var card = document.createElement('section');
+          card.className = 'pattern-card';
+          var title = document.createElement('h2');
+          title.textContent = p.name;
+          card.appendChild(title);
+          if (p.description) {
+            var desc = document.createElement('p');
+            desc.textContent = p.description;
+            card.appendChild(desc);
+          }
+          var parts = document.createElement('div');
+          parts.className = 'pattern-participants';
+          p.participants.forEach(function(node) {
+            var link = document.createElement('a');
+            link.href = '#';
+            link.className = node.interface ? 'interface' : 'impl';
+            link.textContent = node.name;
+            link.title = 'Show ' + node.name + ' in Structures';
+            link.addEventListener('click', function(e) {
+              e.preventDefault();
+              selectPatternNodes([node]);
+            });
+            parts.appendChild(link);
+          });
+          if (p.participants.length > 1) {
+            var all = document.createElement('button');
+            all.className = 'pattern-select-all';
+            all.textContent = 'Select all';
+            all.title = 'Show every participant of ' + p.name + ' in Structures';
+            all.addEventListener('click', function() {
+              selectPatternNodes(p.participants);
+            });
+            parts.appendChild(all);
+          }
+          card.appendChild(parts);
+          patternsList.appendChild(card);
+        });
+      }
+
+      function selectPatternNodes(nodes) {
+        selectedTypeIDs = {};
+        selectedIfaceIDs = {};
+        nodes.forEach(function(node) {
+          if (node.interface) {
+            selectedIfaceIDs[node.id] = true;
+          } else {
+            selectedTypeIDs[node.id] = true;
+          }
+        });
+        updateSelectionUI();
+        switchTab('structures');
+      }
+
       // Accordion: only one sidebar section open at a time, collapsed on top
       document.querySelectorAll('.sidebar-section').forEach(function(details) {
         details.addEventListener('toggle', function() {
@@ -1357,15 +1850,75 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
               cb.checked = !!selectedTypeIDs[id];
             }
           });
+          updateOverlayActions(activeOverlay);
         }
 
         updatePackageMapHighlights();
         updatePackageMapBadges();
 
+        writeSelectionHash();
+
         updatingUI = false;
         triggerDiagramUpdate();
       }
 
+      // parseIDList decodes a comma-separated hash value, keeping only IDs
+      // present in known so stale or hand-edited links are ignored.
+      function parseIDList(value, known) {
+        var ids = {};
+        value.split(',').forEach(function(raw) {
+          var id;
+          try {
+            id = decodeURIComponent(raw);
+          } catch (e) {
+            return;
+          }
+          if (Object.prototype.hasOwnProperty.call(known, id)) ids[id] = true;
+        });
+        return ids;
+      }
+
+      // readSelectionHash loads the selection from a URL hash of the form
+      // #types=a,b&ifaces=c. It reports whether anything was selected.
+      function readSelectionHash() {
+        selectedTypeIDs = {};
+        selectedIfaceIDs = {};
+        var hash = window.location.hash.replace(/^#/, '');
+        hash.split('&').forEach(function(part) {
+          var eq = part.indexOf('=');
+          if (eq < 0) return;
+          var key = part.slice(0, eq);
+          var value = part.slice(eq + 1);
+          if (key === 'types') selectedTypeIDs = parseIDList(value, knownTypeIDs);
+          else if (key === 'ifaces') selectedIfaceIDs = parseIDList(value, knownIfaceIDs);
+        });
+        return Object.keys(selectedTypeIDs).length > 0 || Object.keys(selectedIfaceIDs).length > 0;
+      }
+
+      // writeSelectionHash mirrors the selection into the URL without adding
+      // a history entry. An empty or oversized selection clears the hash.
+      function writeSelectionHash() {
+        var parts = [];
+        var typeIDs = Object.keys(selectedTypeIDs).sort();
+        var ifaceIDs = Object.keys(selectedIfaceIDs).sort();
+        if (typeIDs.length > 0) parts.push('types=' + typeIDs.map(encodeURIComponent).join(','));
+        if (ifaceIDs.length > 0) parts.push('ifaces=' + ifaceIDs.map(encodeURIComponent).join(','));
+        var hash = parts.length > 0 ? '#' + parts.join('&') : '';
+        if (hash.length > maxSelectionHashLength) {
+          console.warn('goifaces: selection too large to share in the URL (' + hash.length + ' characters)');
+          hash = '';
+        }
+        if (hash === window.location.hash) return;
+        history.replaceState(null, '', window.location.pathname + window.location.search + hash);
+      }
+
+      // A pasted or edited link replaces the current selection.
+      window.addEventListener('hashchange', function() {
+        readSelectionHash();
+        dismissOverlay();
+        updateSelectionUI();
+      });
+
       function onSelectionChange() {
         if (updatingUI) return;
         // Rebuild shared state from sidebar checkboxes
@@ -1383,8 +1936,100 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
       function showPlaceholder() {
         document.getElementById('structures-placeholder').style.display = 'block';
         document.getElementById('structures-mermaid').style.display = 'none';
+        hideMinimap();
+      }
+
+      // Error cluster: clicking the collapsed node expands the individual
+      // error implementers; clicking the error interface collapses them again.
+      var errorClusterNodeID = 'builtin_error_cluster';
+      var errorClusterExpanded = false;
+
+      function attachErrorClusterToggle(pre) {
+        if (!data.errorInterfaceId) return;
+        var targetID = errorClusterExpanded ? data.errorInterfaceId : errorClusterNodeID;
+        var node = pre.querySelector('g[id*="classId-' + targetID + '-"]');
+        if (!node) return;
+        node.style.cursor = 'pointer';
+        node.addEventListener('click', function() {
+          errorClusterExpanded = !errorClusterExpanded;
+          triggerDiagramUpdate();
+        });
+      }
+
+      // Annotated class boxes (-enrich) show their description as a
+      // native SVG tooltip.
+      function attachAnnotationTooltips(pre) {
+        data.interfaces.concat(data.types).forEach(function(n) {
+          if (!n.annotation) return;
+          var node = pre.querySelector('g[id*="classId-' + n.id + '-"]');
+          if (!node) return;
+          var title = document.createElementNS('http://www.w3.org/2000/svg', 'title');
+          title.textContent = n.annotation;
+          node.insertBefore(title, node.firstChild);
+        });
+      }
+
+      // Implementation edges list the methods of the type that satisfy the
+      // interface: in the tooltip while hovered, pinned there when clicked
+      // until the next click or Esc. Mermaid draws edges as thin paths with
+      // ids of the form id_<from>_<to>_<n>, so each gets a wider invisible
+      // copy to hover.
+      var pinnedRelation = null;
+
+      function relationMethodsText(rel) {
+        var names = {};
+        data.interfaces.concat(data.types).forEach(function(n) { names[n.id] = n.name; });
+        return (names[rel.typeId] || rel.typeId) + (rel.viaPointer ? ' (as *T)' : '') +
+          ' satisfies ' + (names[rel.interfaceId] || rel.interfaceId) + ' with:\n  ' +
+          rel.methods.join('\n  ');
       }
 
+      function showRelationTooltip(rel, e) {
+        tooltip.textContent = relationMethodsText(rel);
+        tooltip.style.whiteSpace = 'pre';
+        tooltip.style.left = (e.clientX + 12) + 'px';
+        tooltip.style.top = (e.clientY + 12) + 'px';
+        tooltip.style.display = 'block';
+      }
+
+      function hideRelationTooltip() {
+        pinnedRelation = null;
+        tooltip.style.display = 'none';
+      }
+
+      function attachRelationMethods(pre) {
+        pinnedRelation = null;
+        (data.relations || []).forEach(function(rel) {
+          if (!rel.methods || !rel.methods.length) return;
+          var path = pre.querySelector('path[id*="_' + rel.typeId + '_' + rel.interfaceId + '_"]');
+          if (!path) return;
+          var hit = document.createElementNS('http://www.w3.org/2000/svg', 'path');
+          hit.setAttribute('d', path.getAttribute('d'));
+          hit.setAttribute('class', 'relation-hit');
+          hit.addEventListener('mouseenter', function(e) {
+            path.classList.add('relation-hover');
+            if (!pinnedRelation) showRelationTooltip(rel, e);
+          });
+          hit.addEventListener('mousemove', function(e) {
+            if (!pinnedRelation) showRelationTooltip(rel, e);
+          });
+          hit.addEventListener('mouseleave', function() {
+            path.classList.remove('relation-hover');
+            if (!pinnedRelation) tooltip.style.display = 'none';
+          });
+          hit.addEventListener('click', function(e) {
+            e.stopPropagation();
+            pinnedRelation = rel;
+            showRelationTooltip(rel, e);
+          });
+          path.parentNode.insertBefore(hit, path.nextSibling);
+        });
+      }
+
+      document.getElementById('structures-viewport').addEventListener('click', function() {
+        if (pinnedRelation) hideRelationTooltip();
+      });
+
       function renderSelectionDiagram(src) {
         var placeholder = document.getElementById('structures-placeholder');
         var pre = document.getElementById('structures-mermaid');
@@ -1397,6 +2042,10 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
         try {
           mermaid.run({ nodes: [pre] }).then(function() {
             fixSvgWidth(pre);
+            updateMinimap();
+            attachErrorClusterToggle(pre);
+            attachAnnotationTooltips(pre);
+            attachRelationMethods(pre);
           }).catch(function(err) {
             pre.textContent = src;
             pre.style.whiteSpace = 'pre-wrap';
@@ -1407,6 +2056,15 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
         }
       }
 
+      // relationLabel mirrors DiagramOptions.relationLabel: "*" for pointer
+      // receivers, then the score with two decimals.
+      function relationLabel(rel) {
+        var parts = [];
+        if (rel.viaPointer) parts.push('*');
+        if (rel.score !== undefined && rel.score !== null) parts.push(rel.score.toFixed(2));
+        return parts.length ? ' : ' + parts.join(' ') : '';
+      }
+
       function buildMermaid(typeIDList, ifaceIDList) {
         var typeSet = {};
         typeIDList.forEach(function(id) { typeSet[id] = true; });
@@ -1426,12 +2084,69 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
           }
         });
 
+        // Collapse error implementers into one cluster node (-cluster-error)
+        var errorCluster = null;
+        if (data.errorInterfaceId && !errorClusterExpanded) {
+          var errorImpls = {};
+          var otherLinked = {};
+          var keptRels = [];
+          filteredRels.forEach(function(rel) {
+            if (rel.interfaceId === data.errorInterfaceId) {
+              errorImpls[rel.typeId] = true;
+            } else {
+              keptRels.push(rel);
+              otherLinked[rel.typeId] = true;
+            }
+          });
+          var errorCount = Object.keys(errorImpls).length;
+          if (errorCount >= 2) {
+            errorCluster = {count: errorCount};
+            filteredRels = keptRels;
+            relatedTypeIDs = otherLinked;
+          }
+        }
+
         // Build lookup maps
         var ifaceMap = {};
         data.interfaces.forEach(function(iface) { ifaceMap[iface.id] = iface; });
         var typeMap = {};
         data.types.forEach(function(t) { typeMap[t.id] = t; });
 
+        // Merge interfaces with a single implementer into one block
+        // (-collapse-single-impl). Implementers are counted over all
+        // relations, each type once, as GenerateMermaid does.
+        var collapsedLabels = {};
+        if (data.collapseSingleImpl) {
+          var implsByIface = {};
+          data.relations.forEach(function(rel) {
+            var impls = implsByIface[rel.interfaceId] || (implsByIface[rel.interfaceId] = {});
+            impls[rel.typeId] = rel.typeId in impls ? impls[rel.typeId] && !!rel.viaPointer : !!rel.viaPointer;
+          });
+          var collapsedTypes = {};
+          var stillLinked = {};
+          var unmergedRels = [];
+          filteredRels.forEach(function(rel) {
+            var impls = implsByIface[rel.interfaceId];
+            var iface = ifaceMap[rel.interfaceId];
+            var t = typeMap[rel.typeId];
+            if (Object.keys(impls).length === 1 && iface && t) {
+              collapsedLabels[iface.id] = iface.pkgName + '.' + iface.name + ' (impl: ' +
+                (impls[t.id] ? '*' : '') + t.pkgName + '.' + t.name + ')';
+              collapsedTypes[t.id] = true;
+            } else {
+              unmergedRels.push(rel);
+              stillLinked[rel.typeId] = true;
+            }
+          });
+          filteredRels = unmergedRels;
+          Object.keys(collapsedTypes).forEach(function(id) {
+            if (!stillLinked[id]) {
+              delete typeSet[id];
+              delete relatedTypeIDs[id];
+            }
+          });
+        }
+
         // Collect included items
         var includedIfaces = [];
         var includedTypes = [];
@@ -1448,19 +2163,34 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
           }
         });
 
+        // Third-party nodes (-mark-external) get a gray, dashed style
+        var hasExternal = includedIfaces.some(function(i) { return i.external; }) ||
+          includedTypes.some(function(t) { return t.external; });
+
         // Build Mermaid classDiagram
         var lines = ['classDiagram'];
         if (includedIfaces.length > 0 || includedTypes.length > 0) {
-          lines.push('    direction LR');
-          lines.push('    classDef interfaceStyle fill:#2374ab,stroke:#1a5a8a,color:#fff,stroke-width:2px,font-weight:bold');
-          lines.push('    classDef implStyle fill:#4a9c6d,stroke:#357a50,color:#fff,stroke-width:2px');
+          lines.push('    direction ' + (data.direction || 'LR'));
+          lines.push('    classDef interfaceStyle fill:' + palette.interface.fill + ',stroke:' + palette.interface.stroke + ',color:' + palette.interface.text + ',stroke-width:2px,font-weight:bold');
+          lines.push('    classDef implStyle fill:' + palette.impl.fill + ',stroke:' + palette.impl.stroke + ',color:' + palette.impl.text + ',stroke-width:2px');
+          if (hasExternal) {
+            lines.push('    classDef externalInterfaceStyle fill:#e8e8e8,stroke:' + palette.interface.stroke + ',color:#333,stroke-width:2px,stroke-dasharray:5 5,font-weight:bold');
+            lines.push('    classDef externalImplStyle fill:#e8e8e8,stroke:' + palette.impl.stroke + ',color:#333,stroke-width:2px,stroke-dasharray:5 5');
+          }
+          if (includedIfaces.some(function(i) { return i.port; })) {
+            lines.push('    classDef portStyle fill:' + palette.interface.fill + ',stroke:#f0a500,color:' + palette.interface.text + ',stroke-width:4px,font-weight:bold');
+          }
+          if (errorCluster) {
+            lines.push('    classDef clusterStyle fill:#eeeeee,stroke:#999999,color:#333,stroke-width:2px,stroke-dasharray:5 5');
+          }
         }
 
         // Interface blocks
         includedIfaces.forEach(function(iface) {
           lines.push('');
-          lines.push('    class ' + iface.id + ' {');
-          lines.push('        <<interface>>');
+          var label = collapsedLabels[iface.id] ? '["' + collapsedLabels[iface.id] + '"]' : '';
+          lines.push('    class ' + iface.id + label + ' {');
+          lines.push(iface.isMarker ? '        <<marker>>' : '        <<interface>>');
           if (iface.sourceFile) {
             lines.push('        %% file: ' + iface.sourceFile);
           }
@@ -1468,6 +2198,9 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
             iface.methods.forEach(function(m) {
               lines.push('        +' + m);
             });
+            if (iface.methodCount > iface.methods.length) {
+              lines.push('        ... (' + iface.methodCount + ' total)');
+            }
           }
           lines.push('    }');
         });
@@ -1479,6 +2212,9 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
         includedTypes.forEach(function(t) {
           lines.push('');
           lines.push('    class ' + t.id + ' {');
+          if (t.isFunc) {
+            lines.push('        <<func>>');
+          }
           if (t.sourceFile) {
             lines.push('        %% file: ' + t.sourceFile);
           }
@@ -1491,18 +2227,29 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
         }
         filteredRels.forEach(function(rel) {
           lines.push('');
-          lines.push('    ' + rel.typeId + ' --|> ' + rel.interfaceId);
+          lines.push('    ' + rel.typeId + ' --|> ' + rel.interfaceId + relationLabel(rel));
         });
+        if (errorCluster) {
+          lines.push('');
+          lines.push('    class ' + errorClusterNodeID + '["' + errorCluster.count + ' error implementations"] {');
+          lines.push('        <<cluster>>');
+          lines.push('    }');
+          lines.push('    ' + errorClusterNodeID + ' ..|> ' + data.errorInterfaceId);
+        }
 
         // CSS class assignments
         if (includedIfaces.length > 0 || includedTypes.length > 0) {
           lines.push('');
           includedIfaces.forEach(function(iface) {
-            lines.push('    cssClass "' + iface.id + '" interfaceStyle');
+            var ifaceStyle = iface.external ? 'externalInterfaceStyle' : (iface.port ? 'portStyle' : 'interfaceStyle');
+            lines.push('    cssClass "' + iface.id + '" ' + ifaceStyle);
           });
           includedTypes.forEach(function(t) {
-            lines.push('    cssClass "' + t.id + '" implStyle');
+            lines.push('    cssClass "' + t.id + '" ' + (t.external ? 'externalImplStyle' : 'implStyle'));
           });
+          if (errorCluster) {
+            lines.push('    cssClass "' + errorClusterNodeID + '" clusterStyle');
+          }
         }
 
         return lines.join('\n');
@@ -1521,25 +2268,178 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
 
       function applyZoom() {
         getActiveContainer().style.transform = 'scale(' + scale + ')';
+        if (currentTab === 'structures') updateMinimap();
+      }
+
+      // Minimap: scaled-down snapshot of the Structures SVG with a rectangle
+      // tracking the scroll/zoom position of the diagram viewport.
+      var minimapEl = document.getElementById('structures-minimap');
+      var minimapImg = document.getElementById('minimap-image');
+      var minimapRect = document.getElementById('minimap-viewport');
+      var structuresViewport = document.getElementById('structures-viewport');
+      var minimapScale = 1;
+
+      function hideMinimap() {
+        minimapEl.style.display = 'none';
+      }
+
+      function updateMinimap() {
+        var pre = document.getElementById('structures-mermaid');
+        var svg = pre.querySelector('svg');
+        if (!svg || pre.style.display === 'none') {
+          hideMinimap();
+          return;
+        }
+        minimapImg.src = 'data:image/svg+xml;charset=utf-8,' +
+          encodeURIComponent(new XMLSerializer().serializeToString(svg));
+        minimapEl.style.display = '';
+        positionMinimap();
+      }
+
+      // Maps the viewport's full scroll area onto the minimap and places the
+      // SVG snapshot and the visible-area rectangle accordingly.
+      function positionMinimap() {
+        var svg = document.querySelector('#structures-mermaid svg');
+        if (!svg || minimapEl.style.display === 'none') return;
+        var vp = structuresViewport;
+        var totalW = Math.max(vp.scrollWidth, 1);
+        var totalH = Math.max(vp.scrollHeight, 1);
+        minimapScale = Math.min(minimapEl.clientWidth / totalW, minimapEl.clientHeight / totalH);
+
+        var vpRect = vp.getBoundingClientRect();
+        var svgRect = svg.getBoundingClientRect();
+        minimapImg.style.left = ((svgRect.left - vpRect.left + vp.scrollLeft) * minimapScale) + 'px';
+        minimapImg.style.top = ((svgRect.top - vpRect.top + vp.scrollTop) * minimapScale) + 'px';
+        minimapImg.style.width = (svgRect.width * minimapScale) + 'px';
+        minimapImg.style.height = (svgRect.height * minimapScale) + 'px';
+
+        minimapRect.style.left = (vp.scrollLeft * minimapScale) + 'px';
+        minimapRect.style.top = (vp.scrollTop * minimapScale) + 'px';
+        minimapRect.style.width = (Math.min(vp.clientWidth, totalW) * minimapScale) + 'px';
+        minimapRect.style.height = (Math.min(vp.clientHeight, totalH) * minimapScale) + 'px';
       }
 
-      document.getElementById('zoom-in').addEventListener('click', function() {
+      // Scrolls the diagram viewport so that minimap point (x, y) is centered.
+      function minimapJumpTo(x, y) {
+        var vp = structuresViewport;
+        vp.scrollLeft = x / minimapScale - vp.clientWidth / 2;
+        vp.scrollTop = y / minimapScale - vp.clientHeight / 2;
+      }
+
+      structuresViewport.addEventListener('scroll', positionMinimap);
+      document.getElementById('structures-diagram-container').addEventListener('transitionend', positionMinimap);
+
+      // Click jumps to a point; dragging (from the rectangle or after a jump) pans.
+      var minimapDrag = null;
+      minimapEl.addEventListener('mousedown', function(e) {
+        e.preventDefault();
+        if (e.target !== minimapRect) {
+          var box = minimapEl.getBoundingClientRect();
+          minimapJumpTo(e.clientX - box.left, e.clientY - box.top);
+        }
+        minimapDrag = {x: e.clientX, y: e.clientY, left: structuresViewport.scrollLeft, top: structuresViewport.scrollTop};
+      });
+      document.addEventListener('mousemove', function(e) {
+        if (!minimapDrag) return;
+        structuresViewport.scrollLeft = minimapDrag.left + (e.clientX - minimapDrag.x) / minimapScale;
+        structuresViewport.scrollTop = minimapDrag.top + (e.clientY - minimapDrag.y) / minimapScale;
+      });
+      document.addEventListener('mouseup', function() {
+        minimapDrag = null;
+      });
+
+      function zoomIn() {
         scale = Math.min(maxScale, scale + step);
         applyZoom();
-      });
-      document.getElementById('zoom-out').addEventListener('click', function() {
+      }
+      function zoomOut() {
         scale = Math.max(minScale, scale - step);
         applyZoom();
-      });
-      document.getElementById('zoom-reset').addEventListener('click', function() {
+      }
+      function resetZoom() {
         scale = 1;
         applyZoom();
-        // Clear all selections
+      }
+      function clearSelection() {
         selectedTypeIDs = {};
         selectedIfaceIDs = {};
         document.querySelectorAll('.impl-cb, .iface-cb').forEach(function(cb) { cb.checked = false; });
         dismissOverlay();
         updateSelectionUI();
+      }
+
+      document.getElementById('zoom-in').addEventListener('click', zoomIn);
+      document.getElementById('zoom-out').addEventListener('click', zoomOut);
+      document.getElementById('zoom-reset').addEventListener('click', function() {
+        resetZoom();
+        clearSelection();
+      });
+
+      // Keyboard shortcuts: 1/2 and the left/right arrows switch tabs,
+      // +/-/0 zoom, / focuses the sidebar search, Esc dismisses the overlay
+      // and clears the selection. Keys typed into form fields are left alone.
+      function isTypingTarget(el) {
+        if (!el) return false;
+        var tag = el.tagName;
+        return tag === 'INPUT' || tag === 'TEXTAREA' || tag === 'SELECT' || el.isContentEditable;
+      }
+
+      // switchTabBy moves delta tabs along the tab bar, wrapping around.
+      function switchTabBy(delta) {
+        var tabs = Array.prototype.map.call(document.querySelectorAll('.tab-btn'), function(b) {
+          return b.getAttribute('data-tab');
+        });
+        var i = tabs.indexOf(currentTab);
+        switchTab(tabs[(i + delta + tabs.length) % tabs.length]);
+      }
+
+      document.addEventListener('keydown', function(e) {
+        if (e.ctrlKey || e.metaKey || e.altKey) return;
+        if (isTypingTarget(e.target)) {
+          if (e.key === 'Escape') e.target.blur();
+          return;
+        }
+        switch (e.key) {
+          case '1':
+            switchTab('pkgmap-html');
+            break;
+          case '2':
+            switchTab('structures');
+            break;
+          case '3':
+            if (!patternsList) return;
+            switchTab('patterns');
+            break;
+          case 'ArrowLeft':
+          case 'ArrowRight':
+            if (e.shiftKey) return; // extends a text selection
+            switchTabBy(e.key === 'ArrowLeft' ? -1 : 1);
+            break;
+          case '+':
+          case '=':
+            zoomIn();
+            break;
+          case '-':
+          case '_':
+            zoomOut();
+            break;
+          case '0':
+            resetZoom();
+            break;
+          case '/':
+            var search = document.getElementById('sidebar-search');
+            if (!search) return;
+            switchTab('structures');
+            search.focus();
+            break;
+          case 'Escape':
+            hideRelationTooltip();
+            clearSelection();
+            break;
+          default:
+            return;
+        }
+        e.preventDefault();
       });
 
       document.getElementById('copy-src').addEventListener('click', function() {
@@ -1582,6 +2482,20 @@ const interactiveHTMLTemplate = `<!DOCTYPE html>
       if (vp) resizeObs.observe(vp);
     })();
   </script>
+  {{- if .LiveReload}}
+  <script>
+    // -watch or /api/load: the server sends "reload" when the data is
+    // replaced; the selection survives in the URL hash.
+    if (window.EventSource) {
+      new EventSource('/events').addEventListener('reload', function() {
+        window.location.reload();
+      });
+    }
+  </script>
+  {{- end}}
+  {{- if and .Version (not .Embed)}}
+  <footer class="build-version">goifaces {{.Version}}</footer>
+  {{- end}}
 </body>
 </html>
 `
@@ -1686,7 +2600,7 @@ const landingHTMLTemplate = `<!DOCTYPE html>
       status.className = '';
       fetch('/api/load', {method:'POST', headers:{'Content-Type':'application/json'}, body: JSON.stringify({path: val})})
         .then(function(resp) {
-          if (!resp.ok) return resp.text().then(function(t) { throw new Error(t); });
+          if (!resp.ok) return resp.json().then(function(j) { throw new Error(j.error); });
           window.location.reload();
         })
         .catch(function(err) {
@@ -1700,75 +2614,96 @@ const landingHTMLTemplate = `<!DOCTYPE html>
 </html>
 `
 
+// defaultTitle is the page header when no Style overrides it.
+const defaultTitle = "goifaces"
+
 // interactiveData holds all data passed to the interactive HTML template.
 type interactiveData struct {
 	DataJSON       template.JS
 	PackageMapJSON template.JS
+	Palette        diagram.Palette // node colors for the page's CSS; also in DataJSON for the JS
 	RepoAddress    string
+	Title          string
+	LogoURL        template.URL
+	CustomCSS      template.CSS
+	Version        string
+	LiveReload     bool
+	Embed          bool // minimal page for iframes: no header or footer, thinner controls
+	Patterns       bool // show the Patterns tab: detection ran, even if it found nothing
+}
+
+// ServeOptions controls the interactive HTTP server.
+type ServeOptions struct {
+	Port        int
+	StrictPort  bool // fail when Port is busy instead of trying the next PortFallbackAttempts ports
+	OpenBrowser bool
+	Style       *Style // optional page branding
+	Metrics     bool   // expose GET /metrics in Prometheus text format
+	Version     string // goifaces build version shown in the page title and footer
+
+	// Embed serves the minimal page meant for iframes at "/"; without it a
+	// request asks for that page with ?embed=1. FrameOrigins lists the
+	// origins, besides the server's own, allowed to frame it.
+	Embed        bool
+	FrameOrigins []string
+
+	// Updates, when set, replaces the served data with each value received
+	// (-watch) and tells open pages to reload through the /events stream.
+	Updates <-chan diagram.InteractiveData
+
+	// Load, when set, registers POST /api/load, which analyzes another
+	// path and swaps in its data; the landing page (served while the data is
+	// empty) uses it. LoadTimeout bounds each load (DefaultLoadTimeout when
+	// zero).
+	Load        Loader
+	LoadTimeout time.Duration
+
+	// OnListen, when set, is called with the page URL once the listener is
+	// bound, before the browser opens; the port may differ from Port after
+	// a fallback.
+	OnListen func(url string)
 }
 
 // ServeInteractive starts the HTTP server with interactive tabbed UI.
-// It blocks until the context is cancelled.
-func ServeInteractive(ctx context.Context, data diagram.InteractiveData, port int, openBrowser bool, logger *slog.Logger) error {
+// It blocks until the context is cancelled. When opts.Port is busy it
+// serves on the first free one of the next PortFallbackAttempts ports,
+// unless opts.StrictPort is set; the listener is bound before the browser
+// is opened, so the page URL always points at a live port.
+func ServeInteractive(ctx context.Context, data diagram.InteractiveData, opts ServeOptions, logger *slog.Logger) error {
 	logger = logger.With("component", "server")
-	tmpl, err := template.New("interactive").Parse(interactiveHTMLTemplate)
-	if err != nil {
-		return fmt.Errorf("parsing interactive HTML template: %w", err)
-	}
-
-	jsonBytes, err := json.Marshal(struct {
-		Interfaces []diagram.InteractiveInterface `json:"interfaces"`
-		Types      []diagram.InteractiveType      `json:"types"`
-		Relations  []diagram.InteractiveRelation  `json:"relations"`
-	}{
-		Interfaces: data.Interfaces,
-		Types:      data.Types,
-		Relations:  data.Relations,
-	})
+	mux, err := newInteractiveMux(data, opts, logger)
 	if err != nil {
-		return fmt.Errorf("marshaling interactive data to JSON: %w", err)
+		return err
 	}
 
-	pkgMapBytes, err := json.Marshal(data.PackageMapNodes)
+	l, err := listen(opts.Port, opts.StrictPort, logger)
 	if err != nil {
-		return fmt.Errorf("marshaling package map data to JSON: %w", err)
-	}
-
-	templateData := interactiveData{
-		DataJSON:       template.JS(jsonBytes),   //nolint:gosec // JSON is generated from trusted internal data, not user input
-		PackageMapJSON: template.JS(pkgMapBytes), //nolint:gosec // JSON is generated from trusted internal data, not user input
-		RepoAddress:    data.RepoAddress,
+		return err
 	}
-
-	mux := http.NewServeMux()
-
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		logger.Debug("request received", "method", r.Method, "path", r.URL.Path)
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		if err := tmpl.Execute(w, templateData); err != nil {
-			logger.Error("failed to render interactive template", "error", err)
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		}
-	})
-
-	addr := fmt.Sprintf(":%d", port)
+	port := listenerPort(l)
 	srv := &http.Server{
-		Addr:    addr,
-		Handler: mux,
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: withRequestLog(mux, logger),
+		// Request contexts end with ctx, so open /events streams do not
+		// hold up the shutdown.
+		BaseContext: func(net.Listener) context.Context { return ctx },
 	}
 
 	url := fmt.Sprintf("http://localhost:%d", port)
-	logger.Info("starting HTTP server (interactive mode)", "addr", url)
+	logger.Info("starting HTTP server (interactive mode)", "addr", url, "requested_port", opts.Port)
+	if opts.OnListen != nil {
+		opts.OnListen(url)
+	}
 
 	errCh := make(chan error, 1)
 	go func() {
-		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			errCh <- fmt.Errorf("HTTP server error: %w", err)
 		}
 		close(errCh)
 	}()
 
-	if openBrowser {
+	if opts.OpenBrowser {
 		openInBrowser(url, logger)
 	}
 
@@ -1786,6 +2721,171 @@ func ServeInteractive(ctx context.Context, data diagram.InteractiveData, port in
 	}
 }
 
+// newInteractiveMux builds the handlers of the interactive server: the page
+// at "/" (the embed variant with opts.Embed or ?embed=1), the data as JSON at
+// "/api/data", the /healthz and /readyz probes (ready once there is data),
+// with opts.Metrics the Prometheus endpoint at "/metrics", with opts.Load the
+// loader at "/api/load" and, with opts.Updates or opts.Load, the reload
+// stream at "/events".
+func newInteractiveMux(data diagram.InteractiveData, opts ServeOptions, logger *slog.Logger) (*http.ServeMux, error) {
+	live, err := newLiveData(data, opts, logger)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Style != nil {
+		logger.Info("custom style applied", "title", live.tmplData.Title, "css_bytes", len(live.tmplData.CustomCSS))
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		live.mu.RLock()
+		tmpl, templateData, empty := live.tmpl, live.tmplData, live.empty
+		live.mu.RUnlock()
+		templateData.Embed = isEmbedRequest(r, opts)
+		setFrameHeaders(w, templateData.Embed, opts.FrameOrigins)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if empty && opts.Load != nil {
+			_, _ = io.WriteString(w, landingHTMLTemplate)
+			return
+		}
+		if err := tmpl.Execute(w, templateData); err != nil {
+			requestLogger(r, logger).Error("failed to render interactive template", "error", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		}
+	})
+
+	// Probes for reverse proxies and orchestrators: cheap, and not logged
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = io.WriteString(w, "ok\n")
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		live.mu.RLock()
+		empty := live.empty
+		live.mu.RUnlock()
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if empty {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = io.WriteString(w, "no data loaded\n")
+			return
+		}
+		_, _ = io.WriteString(w, "ok\n")
+	})
+
+	mux.HandleFunc("GET /api/data", func(w http.ResponseWriter, r *http.Request) {
+		live.mu.RLock()
+		apiData := live.apiData
+		live.mu.RUnlock()
+		setCORSHeaders(w)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(apiData)
+	})
+	mux.HandleFunc("OPTIONS /api/data", func(w http.ResponseWriter, r *http.Request) {
+		setCORSHeaders(w)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if opts.Metrics {
+		mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+			live.mu.RLock()
+			metrics := live.metrics
+			live.mu.RUnlock()
+			w.Header().Set("Content-Type", metricsContentType)
+			_, _ = w.Write(metrics)
+		})
+		logger.Info("metrics endpoint enabled", "path", "/metrics")
+	}
+
+	if opts.Load != nil {
+		timeout := opts.LoadTimeout
+		if timeout <= 0 {
+			timeout = DefaultLoadTimeout
+		}
+		mux.Handle("POST /api/load", &loadHandler{live: live, load: opts.Load, timeout: timeout, logger: logger})
+		logger.Info("load endpoint enabled", "path", "/api/load", "timeout", timeout)
+	}
+
+	if opts.Updates != nil || opts.Load != nil {
+		mux.HandleFunc("GET /events", live.serveEvents)
+		logger.Info("live reload enabled", "path", "/events")
+	}
+	if opts.Updates != nil {
+		go live.follow(opts.Updates)
+	}
+	return mux, nil
+}
+
+// setCORSHeaders lets pages on any origin read the read-only API.
+func setCORSHeaders(w http.ResponseWriter) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+}
+
+// newInteractivePage parses the interactive template and prepares its data,
+// applying opts.Style (which may be nil) and opts.Version.
+func newInteractivePage(data diagram.InteractiveData, opts ServeOptions) (*template.Template, interactiveData, error) {
+	tmpl, err := template.New("interactive").Parse(interactiveHTMLTemplate)
+	if err != nil {
+		return nil, interactiveData{}, fmt.Errorf("parsing interactive HTML template: %w", err)
+	}
+
+	palette := diagram.DefaultPalette()
+	if data.Palette != nil {
+		palette = *data.Palette
+	}
+	jsonBytes, err := json.Marshal(struct {
+		Interfaces         []diagram.InteractiveInterface `json:"interfaces"`
+		Types              []diagram.InteractiveType      `json:"types"`
+		Relations          []diagram.InteractiveRelation  `json:"relations"`
+		ErrorInterfaceID   string                         `json:"errorInterfaceId,omitempty"`
+		Direction          string                         `json:"direction"`
+		CollapseSingleImpl bool                           `json:"collapseSingleImpl,omitempty"`
+		Patterns           []diagram.DetectedPattern      `json:"patterns"`
+		Palette            diagram.Palette                `json:"palette"`
+	}{
+		Interfaces:         data.Interfaces,
+		Types:              data.Types,
+		Relations:          data.Relations,
+		ErrorInterfaceID:   data.ErrorInterfaceID,
+		Direction:          data.Direction,
+		CollapseSingleImpl: data.CollapseSingleImpl,
+		Patterns:           data.Patterns,
+		Palette:            palette,
+	})
+	if err != nil {
+		return nil, interactiveData{}, fmt.Errorf("marshaling interactive data to JSON: %w", err)
+	}
+
+	pkgMapBytes, err := json.Marshal(data.PackageMapNodes)
+	if err != nil {
+		return nil, interactiveData{}, fmt.Errorf("marshaling package map data to JSON: %w", err)
+	}
+
+	templateData := interactiveData{
+		DataJSON:       template.JS(jsonBytes),   //nolint:gosec // JSON is generated from trusted internal data, not user input
+		PackageMapJSON: template.JS(pkgMapBytes), //nolint:gosec // JSON is generated from trusted internal data, not user input
+		Palette:        palette,
+		RepoAddress:    data.RepoAddress,
+		Title:          defaultTitle,
+		Version:        opts.Version,
+		LiveReload:     opts.Updates != nil || opts.Load != nil,
+		Patterns:       data.Patterns != nil,
+	}
+	if style := opts.Style; style != nil {
+		if err := style.validate(); err != nil {
+			return nil, interactiveData{}, err
+		}
+		if style.Title != "" {
+			templateData.Title = style.Title
+		}
+		templateData.LogoURL = template.URL(style.Logo) //nolint:gosec // validated: https:// or data:image/ only
+		templateData.CustomCSS = style.stylesheet()
+	}
+	return tmpl, templateData, nil
+}
+
 // openInBrowser opens the given URL in the default system browser.
 func openInBrowser(url string, logger *slog.Logger) {
 	var cmd *exec.Cmd
@@ -1,10 +1,23 @@
 package server
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/olehluchkiv/goifaces/internal/diagram"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLandingPageTemplateExists(t *testing.T) {
@@ -641,3 +654,843 @@ func TestSwitchTabPkgMapHTMLBranchIsSeparateFromInitialRender(t *testing.T) {
 	assert.Contains(t, elseIfBranch, "updatePackageMapBadges()",
 		"re-visit pkgmap-html branch must call updatePackageMapBadges")
 }
+
+func TestStructuresMinimapElement(t *testing.T) {
+	// Minimap overlay lives in the Structures tab, hidden until a diagram renders.
+	assert.Contains(t, interactiveHTMLTemplate,
+		`<div class="minimap" id="structures-minimap" style="display:none;"`,
+		"minimap container should exist and start hidden")
+	assert.Contains(t, interactiveHTMLTemplate, `<img id="minimap-image" alt="">`,
+		"minimap should hold a scaled-down SVG snapshot")
+	assert.Contains(t, interactiveHTMLTemplate, `<div class="minimap-viewport" id="minimap-viewport"></div>`,
+		"minimap should have a viewport rectangle")
+	assert.Contains(t, interactiveHTMLTemplate, `<div class="diagram-viewport" id="structures-viewport">`,
+		"structures diagram viewport needs an id for scroll tracking")
+	assert.Contains(t, interactiveHTMLTemplate, ".minimap {\n      position: absolute;",
+		"minimap should be an absolutely positioned overlay")
+
+	panelIdx := strings.Index(interactiveHTMLTemplate, `id="panel-structures"`)
+	minimapIdx := strings.Index(interactiveHTMLTemplate, `id="structures-minimap"`)
+	assert.Greater(t, panelIdx, 0)
+	assert.Greater(t, minimapIdx, panelIdx, "minimap should be inside the Structures panel")
+}
+
+func TestStructuresMinimapWiring(t *testing.T) {
+	// Snapshot refreshes after Mermaid renders and on zoom.
+	assert.Contains(t, interactiveHTMLTemplate, "fixSvgWidth(pre);\n            updateMinimap();",
+		"minimap should refresh after the selection diagram renders")
+	assert.Contains(t, interactiveHTMLTemplate, "if (currentTab === 'structures') updateMinimap();",
+		"applyZoom should refresh the minimap on the Structures tab")
+	assert.Contains(t, interactiveHTMLTemplate, "new XMLSerializer().serializeToString(svg)",
+		"minimap snapshot should be serialized from the rendered SVG")
+
+	// Viewport rectangle follows scrolling and zoom transitions.
+	assert.Contains(t, interactiveHTMLTemplate, "structuresViewport.addEventListener('scroll', positionMinimap);",
+		"minimap rectangle should track viewport scroll")
+	assert.Contains(t, interactiveHTMLTemplate,
+		"getElementById('structures-diagram-container').addEventListener('transitionend', positionMinimap);",
+		"minimap rectangle should update after the zoom transition")
+	assert.Contains(t, interactiveHTMLTemplate, "minimapRect.style.left = (vp.scrollLeft * minimapScale) + 'px';",
+		"rectangle position should be derived from scroll state")
+
+	// Click to jump, drag to pan.
+	assert.Contains(t, interactiveHTMLTemplate, "function minimapJumpTo(x, y)",
+		"minimap click should jump to a position")
+	assert.Contains(t, interactiveHTMLTemplate, "minimapEl.addEventListener('mousedown'",
+		"minimap should handle mousedown for click and drag")
+	assert.Contains(t, interactiveHTMLTemplate,
+		"structuresViewport.scrollLeft = minimapDrag.left + (e.clientX - minimapDrag.x) / minimapScale;",
+		"dragging should pan the viewport")
+
+	// Hidden again when the placeholder is shown.
+	placeholderIdx := strings.Index(interactiveHTMLTemplate, "function showPlaceholder()")
+	if !assert.Greater(t, placeholderIdx, 0) {
+		return
+	}
+	assert.Contains(t, interactiveHTMLTemplate[placeholderIdx:placeholderIdx+300], "hideMinimap();",
+		"showPlaceholder should hide the minimap")
+}
+
+func TestErrorClusterCollapsedInStructures(t *testing.T) {
+	// The JS cluster node ID must match the Go-side constant.
+	assert.Contains(t, interactiveHTMLTemplate, "var errorClusterNodeID = '"+diagram.ErrorClusterID+"';",
+		"JS cluster ID should match diagram.ErrorClusterID")
+	assert.Contains(t, interactiveHTMLTemplate, "if (data.errorInterfaceId && !errorClusterExpanded) {",
+		"buildMermaid should collapse error implementers unless expanded")
+	assert.Contains(t, interactiveHTMLTemplate, "if (rel.interfaceId === data.errorInterfaceId) {",
+		"error relations should be split from the others")
+	assert.Contains(t, interactiveHTMLTemplate, "'[\"' + errorCluster.count + ' error implementations\"] {'",
+		"cluster node should be labeled with the implementer count")
+	assert.Contains(t, interactiveHTMLTemplate, "errorClusterNodeID + ' ..|> ' + data.errorInterfaceId",
+		"cluster should have a single edge to the error interface")
+}
+
+func TestErrorClusterToggleOnClick(t *testing.T) {
+	assert.Contains(t, interactiveHTMLTemplate, "function attachErrorClusterToggle(pre)",
+		"cluster toggle function should exist")
+	assert.Contains(t, interactiveHTMLTemplate, "attachErrorClusterToggle(pre);",
+		"toggle should be attached after each render")
+	assert.Contains(t, interactiveHTMLTemplate, "errorClusterExpanded = !errorClusterExpanded;\n          triggerDiagramUpdate();",
+		"clicking should flip the expanded state and re-render")
+}
+
+func TestAnnotationTooltipsInStructures(t *testing.T) {
+	assert.Contains(t, interactiveHTMLTemplate, "function attachAnnotationTooltips(pre)",
+		"tooltip function should exist")
+	assert.Contains(t, interactiveHTMLTemplate, "attachAnnotationTooltips(pre);",
+		"tooltips should be attached after each render")
+	assert.Contains(t, interactiveHTMLTemplate, "title.textContent = n.annotation;",
+		"annotations should be set as text, not markup")
+}
+
+func TestFuncTypesTaggedInTypeList(t *testing.T) {
+	assert.Contains(t, interactiveHTMLTemplate, ".sidebar-section-body .func-tag {",
+		"func tag should be styled")
+	assert.Contains(t, interactiveHTMLTemplate, "if (t.isFunc) {\n            var tag = document.createElement('span');\n            tag.className = 'func-tag';",
+		"named function types should be tagged in the type list")
+	assert.Contains(t, interactiveHTMLTemplate, "lines.push('        <<func>>');",
+		"named function types should keep the <<func>> stereotype in Structures")
+}
+
+func TestMethodSearchHighlightsInterfaces(t *testing.T) {
+	assert.Contains(t, interactiveHTMLTemplate, `<input type="search" id="sidebar-search"`,
+		"the Structures sidebar should have a method search box, which / focuses")
+	assert.Contains(t, interactiveHTMLTemplate, `<input type="checkbox" id="method-search-select">`,
+		"auto-selecting the matches should be optional")
+
+	idx := strings.Index(interactiveHTMLTemplate, "function highlightMethodMatches(query) {")
+	if !assert.Greater(t, idx, 0, "highlightMethodMatches should exist") {
+		return
+	}
+	body := interactiveHTMLTemplate[idx:]
+	body = body[:strings.Index(body, "\n      }\n")]
+	assert.Contains(t, body, "query = query.trim().toLowerCase();")
+	assert.Contains(t, body, "return m.toLowerCase().indexOf(query) !== -1;",
+		"matching should be a case-insensitive substring search of the signatures")
+	assert.Contains(t, body, "cb.parentNode.classList.toggle('method-match', !!matched[cb.value]);")
+	assert.Contains(t, body, "if (query && methodSearchSelect.checked) {")
+	assert.Contains(t, interactiveHTMLTemplate, ".sidebar-section-body label.method-match,",
+		"matching labels should be highlighted")
+}
+
+func TestStructuresDiagramDirection(t *testing.T) {
+	assert.Contains(t, interactiveHTMLTemplate, "lines.push('    direction ' + (data.direction || 'LR'));",
+		"the Structures diagram should use the direction from the page data")
+	assert.NotContains(t, interactiveHTMLTemplate, "lines.push('    direction LR');")
+
+	_, page, err := newInteractivePage(diagram.InteractiveData{Direction: diagram.DirectionTB}, ServeOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, string(page.DataJSON), `"direction":"TB"`, "the direction should reach the page data")
+}
+
+func TestPaletteReachesCSSAndScript(t *testing.T) {
+	assert.Contains(t, interactiveHTMLTemplate, "var treemapPalette = palette.packages;",
+		"the package map should use the palette from the page data")
+	assert.NotContains(t, interactiveHTMLTemplate, "#2374ab", "node colors should come from the palette")
+
+	render := func(data diagram.InteractiveData) (string, string) {
+		tmpl, page, err := newInteractivePage(data, ServeOptions{})
+		require.NoError(t, err)
+		var b strings.Builder
+		require.NoError(t, tmpl.Execute(&b, page))
+		return b.String(), string(page.DataJSON)
+	}
+
+	html, dataJSON := render(diagram.InteractiveData{})
+	assert.Contains(t, html, "fill: #2374ab !important;", "no palette means the default one")
+	assert.Contains(t, dataJSON, `"fill":"#e8f4fd"`)
+
+	palette := diagram.DefaultPalette()
+	palette.Interface = diagram.NodeColors{Fill: "#123456", Stroke: "#654321", Text: "#000"}
+	palette.Packages = []diagram.NodeColors{{Fill: "#abcdef", Stroke: "#fedcba", Text: "#111"}}
+	html, dataJSON = render(diagram.InteractiveData{Palette: &palette})
+	assert.Contains(t, html, "fill: #123456 !important;")
+	assert.Contains(t, html, "stroke: #654321 !important;")
+	assert.Contains(t, html, "fill: #4a9c6d !important;")
+	assert.Contains(t, dataJSON, `"interface":{"fill":"#123456","stroke":"#654321","text":"#000"}`)
+	assert.Contains(t, dataJSON, `"packages":[{"fill":"#abcdef","stroke":"#fedcba","text":"#111"}]`)
+}
+
+func TestCollapseSingleImplInStructures(t *testing.T) {
+	assert.Contains(t, interactiveHTMLTemplate, "if (data.collapseSingleImpl) {",
+		"buildMermaid should merge single-implementer interfaces when asked to")
+	assert.Contains(t, interactiveHTMLTemplate, "if (Object.keys(impls).length === 1 && iface && t) {",
+		"implementers should be counted per distinct type")
+	assert.Contains(t, interactiveHTMLTemplate, "lines.push('    class ' + iface.id + label + ' {');")
+
+	_, page, err := newInteractivePage(diagram.InteractiveData{CollapseSingleImpl: true}, ServeOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, string(page.DataJSON), `"collapseSingleImpl":true`)
+	_, page, err = newInteractivePage(diagram.InteractiveData{}, ServeOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, string(page.DataJSON), "collapseSingleImpl")
+}
+
+func TestExternalNodesStyledInStructures(t *testing.T) {
+	assert.Contains(t, interactiveHTMLTemplate, "classDef externalInterfaceStyle",
+		"buildMermaid should define the external interface style")
+	assert.Contains(t, interactiveHTMLTemplate, "classDef externalImplStyle",
+		"buildMermaid should define the external implementation style")
+	assert.Contains(t, interactiveHTMLTemplate, "iface.external ? 'externalInterfaceStyle' : (iface.port ? 'portStyle' : 'interfaceStyle')",
+		"external interfaces should get the external style")
+	assert.Contains(t, interactiveHTMLTemplate, "(t.external ? 'externalImplStyle' : 'implStyle')",
+		"external types should get the external style")
+	assert.Contains(t, interactiveHTMLTemplate, "label.external",
+		"sidebar entries for external nodes should be styled")
+}
+
+func TestPortInterfacesStyledInStructures(t *testing.T) {
+	assert.Contains(t, interactiveHTMLTemplate, "classDef portStyle",
+		"buildMermaid should define the port style")
+	assert.Contains(t, interactiveHTMLTemplate, "(iface.port ? 'portStyle' : 'interfaceStyle')",
+		"port interfaces should get the port style unless external")
+}
+
+func TestOverlayBulkSelectionButtons(t *testing.T) {
+	assert.Contains(t, interactiveHTMLTemplate, ".treemap-overlay-actions button {",
+		"overlay action buttons should be styled")
+	assert.Contains(t, interactiveHTMLTemplate, "selectAllBtn.className = 'treemap-overlay-select-all';",
+		"overlay header should have a Select all button")
+	assert.Contains(t, interactiveHTMLTemplate, "clearBtn.className = 'treemap-overlay-clear';",
+		"overlay header should have a Clear button")
+	assert.Contains(t, interactiveHTMLTemplate, "header.appendChild(actions);",
+		"buttons should live in the overlay header")
+	assert.Contains(t, interactiveHTMLTemplate, "setPackageSelection(ifaces, types, true);",
+		"Select all should select the package's interfaces and types")
+	assert.Contains(t, interactiveHTMLTemplate, "setPackageSelection(ifaces, types, false);",
+		"Clear should deselect the package's interfaces and types")
+}
+
+func TestOverlayBulkSelectionHandlers(t *testing.T) {
+	idx := strings.Index(interactiveHTMLTemplate, "function setPackageSelection(ifaces, types, selected) {")
+	if !assert.Greater(t, idx, 0, "setPackageSelection should exist") {
+		return
+	}
+	body := interactiveHTMLTemplate[idx:]
+	body = body[:strings.Index(body, "\n      }\n")]
+	assert.Contains(t, body, "selectedIfaceIDs[iface.id] = true;")
+	assert.Contains(t, body, "delete selectedIfaceIDs[iface.id];")
+	assert.Contains(t, body, "selectedTypeIDs[t.id] = true;")
+	assert.Contains(t, body, "delete selectedTypeIDs[t.id];")
+	assert.Contains(t, body, "updateSelectionUI();", "bulk changes should go through the shared sync")
+
+	assert.Contains(t, interactiveHTMLTemplate, "function updateOverlayActions(overlay) {",
+		"button state should track the overlay selection")
+	assert.Contains(t, interactiveHTMLTemplate, "selectAllBtn.disabled = checked === boxes.length;")
+	assert.Contains(t, interactiveHTMLTemplate, "clearBtn.disabled = checked === 0;")
+	assert.Contains(t, interactiveHTMLTemplate, "updateOverlayActions(activeOverlay);",
+		"updateSelectionUI should refresh the overlay buttons")
+}
+
+func TestOverlayItemMethodTooltip(t *testing.T) {
+	assert.Contains(t, interactiveHTMLTemplate, "if (t.methods && t.methods.length) itemLabel.title = t.methods.join('\\n');",
+		"overlay type items should list their methods on hover")
+	assert.Contains(t, interactiveHTMLTemplate, "if (iface.methods && iface.methods.length) itemLabel.title = iface.methods.join('\\n');",
+		"overlay interface items should list their methods on hover")
+}
+
+func TestKeyboardShortcutHandler(t *testing.T) {
+	idx := strings.Index(interactiveHTMLTemplate, "document.addEventListener('keydown', function(e) {")
+	if !assert.Greater(t, idx, 0, "a global keydown listener should exist") {
+		return
+	}
+	body := interactiveHTMLTemplate[idx:]
+	body = body[:strings.Index(body, "\n      });\n")]
+
+	// Typing in form fields and modified keys (browser shortcuts) are not hijacked.
+	assert.Contains(t, body, "if (e.ctrlKey || e.metaKey || e.altKey) return;")
+	assert.Contains(t, body, "if (isTypingTarget(e.target)) {")
+	assert.Contains(t, interactiveHTMLTemplate, "return tag === 'INPUT' || tag === 'TEXTAREA' || tag === 'SELECT' || el.isContentEditable;")
+	assert.Contains(t, body, "e.preventDefault();")
+}
+
+func TestKeyboardShortcutMappings(t *testing.T) {
+	idx := strings.Index(interactiveHTMLTemplate, "document.addEventListener('keydown', function(e) {")
+	if !assert.Greater(t, idx, 0) {
+		return
+	}
+	body := interactiveHTMLTemplate[idx:]
+	body = body[:strings.Index(body, "\n      });\n")]
+
+	for key, action := range map[string]string{
+		"'1'":          "switchTab('pkgmap-html');",
+		"'2'":          "switchTab('structures');",
+		"'='":          "zoomIn();",
+		"'_'":          "zoomOut();",
+		"'0'":          "resetZoom();",
+		"'/'":          "search.focus();",
+		"'ArrowRight'": "switchTabBy(e.key === 'ArrowLeft' ? -1 : 1);",
+		"'Escape'":     "clearSelection();",
+	} {
+		caseIdx := strings.Index(body, "case "+key+":")
+		if !assert.GreaterOrEqual(t, caseIdx, 0, "missing mapping for %s", key) {
+			continue
+		}
+		assert.Contains(t, body[caseIdx:], action, "%s should trigger %s", key, action)
+	}
+	assert.Contains(t, body, "case '+':")
+	assert.Contains(t, body, "case 'ArrowLeft':")
+	assert.Contains(t, interactiveHTMLTemplate, "switchTab(tabs[(i + delta + tabs.length) % tabs.length]);", "arrows wrap around the tab bar")
+	assert.Contains(t, body, "case '-':")
+
+	// Esc and the Reset button share the clearing logic, which dismisses the overlay.
+	clearIdx := strings.Index(interactiveHTMLTemplate, "function clearSelection() {")
+	if !assert.Greater(t, clearIdx, 0) {
+		return
+	}
+	clearBody := interactiveHTMLTemplate[clearIdx:]
+	clearBody = clearBody[:strings.Index(clearBody, "\n      }\n")]
+	assert.Contains(t, clearBody, "dismissOverlay();")
+	assert.Contains(t, clearBody, "updateSelectionUI();")
+
+	assert.Contains(t, interactiveHTMLTemplate, `title="Zoom In (+)"`, "buttons should advertise their shortcuts")
+}
+
+func TestSelectionHashRestoredBeforeSidebar(t *testing.T) {
+	readIdx := strings.Index(interactiveHTMLTemplate, "var restoredFromHash = readSelectionHash();")
+	buildIdx := strings.Index(interactiveHTMLTemplate, "// Build checkbox lists")
+	if !assert.Greater(t, readIdx, 0, "the selection hash should be read on load") {
+		return
+	}
+	assert.Less(t, readIdx, buildIdx, "the hash must be parsed before the checkbox lists are built")
+	assert.Contains(t, interactiveHTMLTemplate, "cb.checked = !!selectedTypeIDs[t.id];")
+	assert.Contains(t, interactiveHTMLTemplate, "cb.checked = !!selectedIfaceIDs[iface.id];")
+	assert.Contains(t, interactiveHTMLTemplate, "if (restoredFromHash) switchTab('structures');")
+
+	// Unknown IDs are dropped rather than selected.
+	assert.Contains(t, interactiveHTMLTemplate, "if (Object.prototype.hasOwnProperty.call(known, id)) ids[id] = true;")
+	assert.Contains(t, interactiveHTMLTemplate, "if (key === 'types') selectedTypeIDs = parseIDList(value, knownTypeIDs);")
+	assert.Contains(t, interactiveHTMLTemplate, "else if (key === 'ifaces') selectedIfaceIDs = parseIDList(value, knownIfaceIDs);")
+}
+
+func TestSelectionHashWrittenOnChange(t *testing.T) {
+	idx := strings.Index(interactiveHTMLTemplate, "function updateSelectionUI() {")
+	if !assert.Greater(t, idx, 0) {
+		return
+	}
+	body := interactiveHTMLTemplate[idx:]
+	body = body[:strings.Index(body, "\n      }\n")]
+	assert.Contains(t, body, "writeSelectionHash();\n\n        updatingUI = false;")
+
+	// Oversized selections clear the hash instead of producing an unusable link,
+	// and rewriting it does not pollute the browser history.
+	assert.Contains(t, interactiveHTMLTemplate, "var maxSelectionHashLength = 2000;")
+	assert.Contains(t, interactiveHTMLTemplate, "if (hash.length > maxSelectionHashLength) {")
+	assert.Contains(t, interactiveHTMLTemplate, "history.replaceState(null, '', window.location.pathname + window.location.search + hash);")
+	assert.Contains(t, interactiveHTMLTemplate, "window.addEventListener('hashchange', function() {")
+}
+
+func TestAPIDataEndpoint(t *testing.T) {
+	data := metricsTestData()
+	data.RepoAddress = "./app"
+	data.PackageMapNodes = []*diagram.PackageMapNode{{Name: "store", PkgPath: "example.com/app/store", Interfaces: 1, Types: 2, Value: 3}}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mux, err := newInteractiveMux(data, ServeOptions{}, logger)
+	require.NoError(t, err)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/data")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "*", resp.Header.Get("Access-Control-Allow-Origin"))
+
+	var got map[string]json.RawMessage
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	for _, key := range []string{"interfaces", "types", "relations", "packageMapNodes", "repoAddress"} {
+		assert.Contains(t, got, key)
+	}
+
+	var decoded diagram.InteractiveData
+	body, err := json.Marshal(got)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(body, &decoded))
+	assert.Equal(t, data, decoded)
+
+	// CORS preflight from a separate frontend.
+	req, err := http.NewRequest(http.MethodOptions, srv.URL+"/api/data", nil)
+	require.NoError(t, err)
+	req.Header.Set("Origin", "http://dashboard.example.com")
+	pre, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer pre.Body.Close()
+	assert.Equal(t, http.StatusNoContent, pre.StatusCode)
+	assert.Equal(t, "*", pre.Header.Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, pre.Header.Get("Access-Control-Allow-Methods"), "GET")
+}
+
+func TestLiveReloadEvents(t *testing.T) {
+	updates := make(chan diagram.InteractiveData)
+	defer close(updates)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mux, err := newInteractiveMux(metricsTestData(), ServeOptions{Metrics: true, Updates: updates}, logger)
+	require.NoError(t, err)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	page := getBody(t, srv.URL+"/")
+	assert.Contains(t, page, "new EventSource('/events').addEventListener('reload', function() {")
+
+	resp, err := http.Get(srv.URL + "/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	stream := bufio.NewReader(resp.Body)
+	line, err := stream.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, ": connected\n", line)
+	_, err = stream.ReadString('\n') // end of the comment event
+	require.NoError(t, err)
+
+	data := metricsTestData()
+	data.Interfaces = append(data.Interfaces, diagram.InteractiveInterface{ID: "store_Cache", Name: "Cache", PkgPath: "example.com/app/store"})
+	updates <- data
+
+	line, err = stream.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "event: reload\n", line)
+	assert.Contains(t, getBody(t, srv.URL+"/api/data"), `"store_Cache"`, "the API serves the new data")
+	assert.Contains(t, getBody(t, srv.URL+"/metrics"), "goifaces_interfaces_total 3")
+}
+
+func TestNoLiveReloadWithoutUpdates(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mux, err := newInteractiveMux(metricsTestData(), ServeOptions{}, logger)
+	require.NoError(t, err)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	assert.NotContains(t, getBody(t, srv.URL+"/"), "EventSource")
+}
+
+func getBody(t *testing.T, url string) string {
+	t.Helper()
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestBuildMermaidMarksPointerRelations(t *testing.T) {
+	assert.Contains(t, interactiveHTMLTemplate,
+		"lines.push('    ' + rel.typeId + ' --|> ' + rel.interfaceId + relationLabel(rel));",
+		"pointer-receiver implementations get the same '*' label as GenerateMermaid")
+}
+
+func TestBuildMermaidLabelsScoredRelations(t *testing.T) {
+	assert.Contains(t, interactiveHTMLTemplate,
+		"if (rel.score !== undefined && rel.score !== null) parts.push(rel.score.toFixed(2));",
+		"scores are shown like GenerateMermaid's")
+	assert.NotContains(t, interactiveHTMLTemplate, "' ..|> ' : ' --|> '", "dotted arrows mean embedding")
+	assert.NotContains(t, interactiveHTMLTemplate, "linkStyle", "classDiagram has no linkStyle")
+
+	score := 0.5
+	data := diagram.InteractiveData{Relations: []diagram.InteractiveRelation{
+		{TypeID: "app_Mem", InterfaceID: "app_Store", Score: &score},
+		{TypeID: "app_Disk", InterfaceID: "app_Store"},
+	}}
+	_, page, err := newInteractivePage(data, ServeOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, string(page.DataJSON), `"interfaceId":"app_Store","score":0.5`)
+	assert.Equal(t, 1, strings.Count(string(page.DataJSON), `"score"`), "unscored relations carry no score")
+}
+
+func TestPatternsTab(t *testing.T) {
+	render := func(patterns []diagram.DetectedPattern) (string, string) {
+		tmpl, page, err := newInteractivePage(diagram.InteractiveData{RepoAddress: "./app", Patterns: patterns}, ServeOptions{})
+		require.NoError(t, err)
+		var b strings.Builder
+		require.NoError(t, tmpl.Execute(&b, page))
+		return b.String(), string(page.DataJSON)
+	}
+
+	html, _ := render(nil)
+	assert.NotContains(t, html, `data-tab="patterns"`, "without pattern detection the tab should be hidden")
+	assert.NotContains(t, html, `id="panel-patterns"`)
+
+	html, _ = render([]diagram.DetectedPattern{})
+	assert.Contains(t, html, `data-tab="patterns"`, "detection that found nothing should still show the tab")
+
+	html, dataJSON := render([]diagram.DetectedPattern{{
+		Name:        "Strategy",
+		Description: "Interchangeable stores",
+		Participants: []diagram.PatternParticipant{
+			{ID: "store_Store", Name: "store.Store", Interface: true},
+			{ID: "store_MemStore", Name: "store.MemStore"},
+		},
+	}})
+	assert.Contains(t, html, `<div class="tab-panel full-width" id="panel-patterns">`)
+	assert.Contains(t, dataJSON, `"patterns":[{"name":"Strategy","description":"Interchangeable stores","participants":[{"id":"store_Store","name":"store.Store","interface":true},{"id":"store_MemStore","name":"store.MemStore","interface":false}]}]`)
+
+	assert.Contains(t, interactiveHTMLTemplate, "selectPatternNodes([node]);",
+		"each participant link should select that node")
+	assert.Contains(t, interactiveHTMLTemplate, "selectedIfaceIDs[node.id] = true;")
+	assert.Contains(t, interactiveHTMLTemplate, "switchTab('structures');\n      }\n\n      // Accordion",
+		"selecting participants should switch to the Structures tab")
+}
+
+func TestListenFallsBackFromBusyPort(t *testing.T) {
+	busy, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	defer busy.Close()
+	port := listenerPort(busy)
+
+	l, err := listen(port, false, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Skipf("no free port after %d: %v", port, err)
+	}
+	defer l.Close()
+	got := listenerPort(l)
+	assert.Greater(t, got, port)
+	assert.LessOrEqual(t, got, port+PortFallbackAttempts)
+}
+
+func TestListenStrictPort(t *testing.T) {
+	busy, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	defer busy.Close()
+
+	_, err = listen(listenerPort(busy), true, slog.New(slog.DiscardHandler))
+	require.ErrorIs(t, err, ErrPortInUse)
+	assert.ErrorContains(t, err, "-strict-port")
+}
+
+func postLoad(t *testing.T, url, body string) (int, loadResponse) {
+	t.Helper()
+	resp, err := http.Post(url+"/api/load", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	var got loadResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	return resp.StatusCode, got
+}
+
+func TestLoadEndpoint(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	var loaded []string
+	load := func(ctx context.Context, path string) (diagram.InteractiveData, error) {
+		loaded = append(loaded, path)
+		if path == "./broken" {
+			return diagram.InteractiveData{}, errors.New("no go.mod found in ./broken")
+		}
+		data := metricsTestData()
+		data.RepoAddress = path
+		return data, nil
+	}
+	mux, err := newInteractiveMux(diagram.InteractiveData{}, ServeOptions{Load: load}, logger)
+	require.NoError(t, err)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	assert.Contains(t, getBody(t, srv.URL+"/"), `fetch('/api/load'`, "empty data serves the landing page")
+
+	status, got := postLoad(t, srv.URL, `{"path": " "}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "path is required", got.Error)
+	status, got = postLoad(t, srv.URL, `{`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, got.Error, "invalid request body")
+
+	status, got = postLoad(t, srv.URL, `{"path": "./broken"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Equal(t, "no go.mod found in ./broken", got.Error)
+	assert.Contains(t, getBody(t, srv.URL+"/"), `fetch('/api/load'`, "a failed load keeps the previous data")
+
+	status, got = postLoad(t, srv.URL, `{"path": "./app"}`)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, loadResponse{Interfaces: 2, Types: 3}, got)
+	assert.Equal(t, []string{"./broken", "./app"}, loaded)
+
+	page := getBody(t, srv.URL+"/")
+	assert.NotContains(t, page, `id="repo-path"`)
+	assert.Contains(t, page, "new EventSource('/events')")
+	assert.Contains(t, getBody(t, srv.URL+"/api/data"), `"repoAddress":"./app"`)
+}
+
+func TestLoadEndpointRejectsConcurrentLoads(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	started, release := make(chan struct{}), make(chan struct{})
+	load := func(ctx context.Context, path string) (diagram.InteractiveData, error) {
+		close(started)
+		<-release
+		return metricsTestData(), nil
+	}
+	mux, err := newInteractiveMux(diagram.InteractiveData{}, ServeOptions{Load: load}, logger)
+	require.NoError(t, err)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	first := make(chan int)
+	go func() {
+		status, _ := postLoad(t, srv.URL, `{"path": "./a"}`)
+		first <- status
+	}()
+	<-started
+	status, got := postLoad(t, srv.URL, `{"path": "./b"}`)
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Contains(t, got.Error, "already running")
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-first)
+}
+
+func TestLoadEndpointTimeout(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	load := func(ctx context.Context, path string) (diagram.InteractiveData, error) {
+		<-ctx.Done()
+		return diagram.InteractiveData{}, fmt.Errorf("analyzing %s: %w", path, ctx.Err())
+	}
+	mux, err := newInteractiveMux(diagram.InteractiveData{}, ServeOptions{Load: load, LoadTimeout: 20 * time.Millisecond}, logger)
+	require.NoError(t, err)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	status, got := postLoad(t, srv.URL, `{"path": "./slow"}`)
+	assert.Equal(t, http.StatusGatewayTimeout, status)
+	assert.Equal(t, "analysis of ./slow did not finish within 20ms", got.Error)
+}
+
+func TestLoadEndpointLoopbackOnly(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	load := func(ctx context.Context, path string) (diagram.InteractiveData, error) {
+		t.Error("load must not run for remote clients")
+		return diagram.InteractiveData{}, nil
+	}
+	mux, err := newInteractiveMux(diagram.InteractiveData{}, ServeOptions{Load: load}, logger)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/load", strings.NewReader(`{"path": "/etc"}`))
+	req.RemoteAddr = "203.0.113.7:40000"
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestLoadEndpointRefusesCrossOrigin(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	var loaded []string
+	load := func(ctx context.Context, path string) (diagram.InteractiveData, error) {
+		loaded = append(loaded, path)
+		return metricsTestData(), nil
+	}
+	mux, err := newInteractiveMux(diagram.InteractiveData{}, ServeOptions{Load: load}, logger)
+	require.NoError(t, err)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	post := func(contentType string, headers map[string]string) int {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/api/load", strings.NewReader(`{"path": "/home"}`))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// A simple cross-site form or fetch needs no preflight
+	assert.Equal(t, http.StatusForbidden, post("text/plain", map[string]string{"Origin": "https://evil.example", "Sec-Fetch-Site": "cross-site"}))
+	assert.Equal(t, http.StatusForbidden, post("application/json", map[string]string{"Origin": "https://evil.example"}))
+	assert.Equal(t, http.StatusForbidden, post("application/json", map[string]string{"Origin": "null"}))
+	assert.Equal(t, http.StatusForbidden, post("application/json", map[string]string{"Sec-Fetch-Site": "same-site"}))
+	assert.Equal(t, http.StatusUnsupportedMediaType, post("text/plain", nil))
+	assert.Equal(t, http.StatusUnsupportedMediaType, post("application/x-www-form-urlencoded", nil))
+	assert.Empty(t, loaded, "no refused request may load")
+
+	assert.Equal(t, http.StatusOK, post("application/json; charset=utf-8", map[string]string{"Origin": srv.URL, "Sec-Fetch-Site": "same-origin"}),
+		"the page's own request is accepted")
+	assert.Equal(t, []string{"/home"}, loaded)
+}
+
+func TestNoLoadEndpointWithoutLoader(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mux, err := newInteractiveMux(diagram.InteractiveData{}, ServeOptions{}, logger)
+	require.NoError(t, err)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/api/load", "application/json", strings.NewReader(`{"path": "./app"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.NotEqual(t, "application/json", resp.Header.Get("Content-Type"), "the page handler answers instead")
+	assert.NotContains(t, getBody(t, srv.URL+"/"), `id="repo-path"`)
+}
+
+func probe(t *testing.T, url string) int {
+	t.Helper()
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestHealthProbes(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mux, err := newInteractiveMux(metricsTestData(), ServeOptions{}, logger)
+	require.NoError(t, err)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	assert.Equal(t, http.StatusOK, probe(t, srv.URL+"/healthz"))
+	assert.Equal(t, http.StatusOK, probe(t, srv.URL+"/readyz"))
+}
+
+func TestReadinessFlipsAfterLoad(t *testing.T) {
+	var logs strings.Builder
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	load := func(ctx context.Context, path string) (diagram.InteractiveData, error) {
+		return metricsTestData(), nil
+	}
+	mux, err := newInteractiveMux(diagram.InteractiveData{}, ServeOptions{Load: load}, logger)
+	require.NoError(t, err)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	assert.Equal(t, http.StatusOK, probe(t, srv.URL+"/healthz"), "live while on the landing page")
+	assert.Equal(t, http.StatusServiceUnavailable, probe(t, srv.URL+"/readyz"))
+
+	status, _ := postLoad(t, srv.URL, `{"path": "./app"}`)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, http.StatusOK, probe(t, srv.URL+"/readyz"))
+	assert.NotContains(t, logs.String(), "/readyz", "probes are not logged at info level")
+}
+
+func TestEmbedPage(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mux, err := newInteractiveMux(metricsTestData(), ServeOptions{
+		Version:      "v1.2.3",
+		FrameOrigins: []string{"https://docs.example.com"},
+	}, logger)
+	require.NoError(t, err)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	full, err := http.Get(srv.URL + "/")
+	require.NoError(t, err)
+	full.Body.Close()
+	assert.Equal(t, "SAMEORIGIN", full.Header.Get("X-Frame-Options"))
+	assert.Equal(t, "frame-ancestors 'self'", full.Header.Get("Content-Security-Policy"))
+	assert.Contains(t, getBody(t, srv.URL+"/"), "<h1>")
+
+	resp, err := http.Get(srv.URL + "/?embed=1")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Empty(t, resp.Header.Get("X-Frame-Options"))
+	assert.Equal(t, "frame-ancestors 'self' https://docs.example.com", resp.Header.Get("Content-Security-Policy"))
+
+	body := getBody(t, srv.URL+"/?embed=1")
+	assert.Contains(t, body, `<body class="embed">`)
+	assert.NotContains(t, body, "<h1>", "no title in the embed page")
+	assert.NotContains(t, body, `class="build-version"`)
+	assert.Contains(t, body, `data-tab="pkgmap-html"`, "the tabs still work")
+	assert.Contains(t, body, `data-tab="structures"`)
+}
+
+func TestEmbedOption(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mux, err := newInteractiveMux(metricsTestData(), ServeOptions{Embed: true}, logger)
+	require.NoError(t, err)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	assert.NotContains(t, getBody(t, srv.URL+"/"), "<h1>")
+}
+
+func TestParseFrameOrigins(t *testing.T) {
+	origins, err := ParseFrameOrigins(" https://docs.example.com/, http://localhost:3000,, * ")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://docs.example.com", "http://localhost:3000", "*"}, origins)
+
+	origins, err = ParseFrameOrigins("")
+	require.NoError(t, err)
+	assert.Empty(t, origins)
+
+	for _, bad := range []string{"docs.example.com", "ftp://docs.example.com", "https://docs.example.com/wiki", "https://a.example.com; script-src *", "https://a.example.com;x"} {
+		_, err := ParseFrameOrigins(bad)
+		assert.ErrorIs(t, err, ErrInvalidFrameOrigin, bad)
+	}
+}
+
+func TestRequestLogAttachesRequestID(t *testing.T) {
+	var logs strings.Builder
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	load := func(ctx context.Context, path string) (diagram.InteractiveData, error) {
+		return metricsTestData(), nil
+	}
+	mux, err := newInteractiveMux(diagram.InteractiveData{}, ServeOptions{Load: load}, logger)
+	require.NoError(t, err)
+	srv := httptest.NewServer(withRequestLog(mux, logger))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/api/load", "application/json", strings.NewReader(`{"path": "./app"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	id := resp.Header.Get(requestIDHeader)
+	require.Regexp(t, `^[0-9a-f]{16}$`, id)
+
+	var handlerLine, accessLine map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+		switch entry["msg"] {
+		case "repository loaded":
+			handlerLine = entry
+		case "request":
+			accessLine = entry
+		}
+	}
+	require.NotNil(t, handlerLine, "handler logs through the request logger")
+	require.NotNil(t, accessLine, "access line written")
+	assert.Equal(t, id, handlerLine["request_id"])
+	assert.Equal(t, id, accessLine["request_id"])
+	assert.Equal(t, "POST", accessLine["method"])
+	assert.Equal(t, "/api/load", accessLine["path"])
+	assert.EqualValues(t, http.StatusOK, accessLine["status"])
+
+	resp2, err := http.Get(srv.URL + "/api/data")
+	require.NoError(t, err)
+	resp2.Body.Close()
+	assert.NotEqual(t, id, resp2.Header.Get(requestIDHeader), "every request gets its own id")
+
+	logs.Reset()
+	assert.Equal(t, http.StatusOK, probe(t, srv.URL+"/healthz"))
+	assert.Empty(t, logs.String(), "probes get no access line")
+}
+
+func TestRequestLogKeepsStreaming(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	updates := make(chan diagram.InteractiveData)
+	defer close(updates)
+	mux, err := newInteractiveMux(metricsTestData(), ServeOptions{Updates: updates}, logger)
+	require.NoError(t, err)
+	srv := httptest.NewServer(withRequestLog(mux, logger))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "the recorder passes Flush through")
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, ": connected\n", line)
+}
+
+func TestStructuresMarkerStereotype(t *testing.T) {
+	assert.Contains(t, interactiveHTMLTemplate, `lines.push(iface.isMarker ? '        <<marker>>' : '        <<interface>>');`,
+		"marker interfaces get the same stereotype as in GenerateMermaid")
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidStyle is returned (wrapped) by LoadStyle when the style file would
+// break out of the page or uses an unsupported value.
+var ErrInvalidStyle = errors.New("invalid style")
+
+// Style customizes the interactive page, e.g. to brand it for an internal
+// developer portal. It is loaded from a JSON file or a plain CSS file.
+type Style struct {
+	Title   string            `json:"title,omitempty"`   // replaces "goifaces" in the header and page title
+	Logo    string            `json:"logo,omitempty"`    // https:// or data:image/ URL shown next to the title
+	Palette map[string]string `json:"palette,omitempty"` // named colors, see paletteRules
+	CSS     string            `json:"css,omitempty"`     // appended after the built-in stylesheet
+}
+
+// paletteRules maps palette keys to the CSS each one generates; %s is the color.
+var paletteRules = map[string]string{
+	"background": "body { background-color: %s; }",
+	"text":       "body, h1 { color: %s; }",
+	"accent":     ".tab-btn.active, .controls button:hover { background-color: %s; border-color: %s; }",
+}
+
+var colorPattern = regexp.MustCompile(`^(#[0-9a-fA-F]{3,8}|[a-zA-Z]+|(rgb|rgba|hsl|hsla)\([0-9.,%\s]+\))$`)
+
+// LoadStyle reads a style file. Files ending in .css are used verbatim as the
+// custom stylesheet; anything else is parsed as a JSON Style.
+func LoadStyle(path string) (*Style, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading style file: %w", err)
+	}
+	style := &Style{}
+	if strings.EqualFold(filepath.Ext(path), ".css") {
+		style.CSS = string(content)
+	} else if err := json.Unmarshal(content, style); err != nil {
+		return nil, fmt.Errorf("parsing style file %s: %w", path, err)
+	}
+	if err := style.validate(); err != nil {
+		return nil, err
+	}
+	return style, nil
+}
+
+// validate rejects values that could escape their place in the template:
+// markup in CSS, unknown palette keys or non-color values, and logo URLs
+// other than https or inline images.
+func (s *Style) validate() error {
+	if strings.Contains(s.CSS, "<") {
+		return fmt.Errorf("%w: css must not contain '<'", ErrInvalidStyle)
+	}
+	for key, color := range s.Palette {
+		if _, ok := paletteRules[key]; !ok {
+			return fmt.Errorf("%w: unknown palette key %q", ErrInvalidStyle, key)
+		}
+		if !colorPattern.MatchString(strings.TrimSpace(color)) {
+			return fmt.Errorf("%w: palette %s: %q is not a color", ErrInvalidStyle, key, color)
+		}
+	}
+	if s.Logo != "" && !strings.HasPrefix(s.Logo, "https://") && !strings.HasPrefix(s.Logo, "data:image/") {
+		return fmt.Errorf("%w: logo must be an https:// or data:image/ URL", ErrInvalidStyle)
+	}
+	return nil
+}
+
+// stylesheet returns the palette rules followed by the custom CSS.
+func (s *Style) stylesheet() template.CSS {
+	var b strings.Builder
+	keys := make([]string, 0, len(s.Palette))
+	for key := range s.Palette {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		color := strings.TrimSpace(s.Palette[key])
+		rule := paletteRules[key]
+		b.WriteString(strings.ReplaceAll(rule, "%s", color))
+		b.WriteString("\n")
+	}
+	b.WriteString(s.CSS)
+	return template.CSS(b.String()) //nolint:gosec // validated by LoadStyle: no markup, palette values are colors
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/olehluchkiv/goifaces/internal/diagram"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func renderInteractive(t *testing.T, style *Style) string {
+	t.Helper()
+	tmpl, data, err := newInteractivePage(diagram.InteractiveData{RepoAddress: "./app"}, ServeOptions{Style: style})
+	require.NoError(t, err)
+	var b strings.Builder
+	require.NoError(t, tmpl.Execute(&b, data))
+	return b.String()
+}
+
+func writeStyleFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestStyleInjectedIntoPage(t *testing.T) {
+	path := writeStyleFile(t, "brand.json", `{
+		"title": "Acme Portal",
+		"logo": "https://example.com/logo.png",
+		"palette": {"accent": "#ff6600"},
+		"css": ".tab-bar { border-bottom: 3px solid #ff6600; }"
+	}`)
+	style, err := LoadStyle(path)
+	require.NoError(t, err)
+
+	page := renderInteractive(t, style)
+	assert.Contains(t, page, `<style id="custom-style">`)
+	assert.Contains(t, page, ".tab-bar { border-bottom: 3px solid #ff6600; }")
+	assert.Contains(t, page, ".tab-btn.active, .controls button:hover { background-color: #ff6600; border-color: #ff6600; }")
+	assert.Contains(t, page, `<img class="header-logo" src="https://example.com/logo.png" alt="">Acme Portal — ./app</h1>`)
+	assert.Contains(t, page, "<title>Acme Portal — ./app</title>")
+	assert.Less(t, strings.Index(page, "</style>"), strings.Index(page, `<style id="custom-style">`),
+		"custom CSS must come after the built-in stylesheet to override it")
+}
+
+func TestStyleCSSFile(t *testing.T) {
+	style, err := LoadStyle(writeStyleFile(t, "brand.css", "h1 { color: navy; }"))
+	require.NoError(t, err)
+
+	page := renderInteractive(t, style)
+	assert.Contains(t, page, "h1 { color: navy; }")
+	assert.Contains(t, page, "<h1>goifaces — ./app</h1>", "a CSS-only style keeps the default header")
+}
+
+func TestNoStyleLeavesPageUnchanged(t *testing.T) {
+	page := renderInteractive(t, nil)
+	assert.NotContains(t, page, "custom-style")
+	assert.NotContains(t, page, "header-logo\" src")
+	assert.Contains(t, page, "<h1>goifaces — ./app</h1>")
+}
+
+func TestLoadStyleRejectsUnsafeInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"markup in css", "x.css", "</style><script>alert(1)</script>"},
+		{"markup in json css", "x.json", `{"css": "</style><script>alert(1)</script>"}`},
+		{"unknown palette key", "x.json", `{"palette": {"sidebar": "#fff"}}`},
+		{"non-color palette value", "x.json", `{"palette": {"accent": "red; } body { display: none"}}`},
+		{"javascript logo", "x.json", `{"logo": "javascript:alert(1)"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadStyle(writeStyleFile(t, tt.file, tt.content))
+			assert.ErrorIs(t, err, ErrInvalidStyle)
+		})
+	}
+
+	_, err := LoadStyle(writeStyleFile(t, "bad.json", "{not json"))
+	assert.Error(t, err)
+}
+
+func TestVersionInPage(t *testing.T) {
+	tmpl, data, err := newInteractivePage(diagram.InteractiveData{RepoAddress: "./app"}, ServeOptions{Version: "v1.2.0 (0123456)"})
+	require.NoError(t, err)
+	var b strings.Builder
+	require.NoError(t, tmpl.Execute(&b, data))
+	page := b.String()
+
+	assert.Contains(t, page, "<title>goifaces — ./app (goifaces v1.2.0 (0123456))</title>")
+	assert.Contains(t, page, `<footer class="build-version">goifaces v1.2.0 (0123456)</footer>`)
+
+	unversioned := renderInteractive(t, nil)
+	assert.Contains(t, unversioned, "<title>goifaces — ./app</title>")
+	assert.NotContains(t, unversioned, `class="build-version"`)
+}
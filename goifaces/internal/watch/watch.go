@@ -0,0 +1,128 @@
+// Package watch reports changes to the Go sources of a directory tree.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/olehluchkiv/goifaces/internal/resolver"
+)
+
+// DefaultDebounce is how long the tree must be quiet after a change before
+// Watch reports it, so that an editor's save (often several writes and a
+// rename) or a branch switch triggers one re-analysis.
+const DefaultDebounce = 300 * time.Millisecond
+
+// Options controls Watch.
+type Options struct {
+	Debounce time.Duration // 0 = DefaultDebounce
+}
+
+// Watch watches the .go files under dir, skipping the directories that
+// resolver.SkipDir excludes (vendor/, node_modules/, hidden directories), and
+// sends on the returned channel once changes have settled for
+// opts.Debounce. Directories created later are watched as they appear.
+// Changes made while the receiver is busy are coalesced into one pending
+// notification. The channel is closed when ctx is cancelled.
+func Watch(ctx context.Context, dir string, opts Options, logger *slog.Logger) (<-chan struct{}, error) {
+	logger = logger.With("component", "watch")
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultDebounce
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("creating file watcher: %w", err)
+	}
+	n, err := addTree(w, dir)
+	if err != nil {
+		_ = w.Close()
+		return nil, fmt.Errorf("watching %s: %w", dir, err)
+	}
+	logger.Info("watching for changes", "dir", dir, "dirs", n, "debounce", opts.Debounce)
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer close(changes)
+		defer func() { _ = w.Close() }()
+
+		timer := time.NewTimer(opts.Debounce)
+		timer.Stop()
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if ev.Has(fsnotify.Create) && !resolver.SkipDir(filepath.Base(ev.Name)) {
+					// New directories (mkdir -p, git checkout) are added
+					// together with their subdirectories and count as a
+					// change, since files may already be inside; a plain
+					// file fails to add and is handled below.
+					if _, err := addTree(w, ev.Name); err == nil {
+						logger.Debug("watching new directory", "dir", ev.Name)
+						timer.Reset(opts.Debounce)
+						continue
+					}
+				}
+				if !strings.HasSuffix(ev.Name, ".go") || ev.Op == fsnotify.Chmod {
+					continue
+				}
+				logger.Debug("source changed", "file", ev.Name, "op", ev.Op.String())
+				timer.Reset(opts.Debounce)
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				logger.Warn("file watcher error", "error", err)
+			case <-timer.C:
+				select {
+				case changes <- struct{}{}:
+				default: // a notification is already pending
+				}
+			}
+		}
+	}()
+	return changes, nil
+}
+
+// addTree adds root and every directory below it that resolver.SkipDir does
+// not exclude to w, returning how many were added. It fails when root is not
+// a directory.
+func addTree(w *fsnotify.Watcher, root string) (int, error) {
+	n := 0
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil // unreadable subdirectories are skipped
+		}
+		if !d.IsDir() {
+			if path == root {
+				return fmt.Errorf("%s is not a directory", root)
+			}
+			return nil
+		}
+		if path != root && resolver.SkipDir(d.Name()) {
+			return filepath.SkipDir
+		}
+		if err := w.Add(path); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	return n, err
+}
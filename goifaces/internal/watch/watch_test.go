@@ -0,0 +1,110 @@
+package watch_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/olehluchkiv/goifaces/internal/watch"
+	"github.com/stretchr/testify/require"
+)
+
+const testDebounce = 50 * time.Millisecond
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+// startWatch creates a tree with a source file and the directories the
+// watcher must ignore, and watches it until the test ends.
+func startWatch(t *testing.T) (string, <-chan struct{}) {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.go"), "package a\n")
+	writeFile(t, filepath.Join(dir, "vendor", "dep", "dep.go"), "package dep\n")
+	writeFile(t, filepath.Join(dir, ".hidden", "h.go"), "package h\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	changes, err := watch.Watch(ctx, dir, watch.Options{Debounce: testDebounce}, testLogger())
+	require.NoError(t, err)
+	return dir, changes
+}
+
+func requireChange(t *testing.T, changes <-chan struct{}) {
+	t.Helper()
+	select {
+	case _, ok := <-changes:
+		require.True(t, ok, "channel closed")
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change reported")
+	}
+}
+
+func requireQuiet(t *testing.T, changes <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-changes:
+		t.Fatal("unexpected change reported")
+	case <-time.After(4 * testDebounce):
+	}
+}
+
+func TestWatch_DebouncesRapidSaves(t *testing.T) {
+	dir, changes := startWatch(t)
+
+	for i := 0; i < 5; i++ {
+		writeFile(t, filepath.Join(dir, "a.go"), "package a\n// save "+string(rune('0'+i))+"\n")
+	}
+	requireChange(t, changes)
+	requireQuiet(t, changes)
+}
+
+func TestWatch_IgnoresNonSourcesAndSkippedDirs(t *testing.T) {
+	dir, changes := startWatch(t)
+
+	writeFile(t, filepath.Join(dir, "README.md"), "# a\n")
+	writeFile(t, filepath.Join(dir, "vendor", "dep", "dep.go"), "package dep\n// changed\n")
+	writeFile(t, filepath.Join(dir, ".hidden", "h.go"), "package h\n// changed\n")
+	requireQuiet(t, changes)
+}
+
+func TestWatch_NewDirectories(t *testing.T) {
+	dir, changes := startWatch(t)
+
+	writeFile(t, filepath.Join(dir, "sub", "pkg", "b.go"), "package pkg\n")
+	requireChange(t, changes)
+
+	// The new directory is watched from now on.
+	writeFile(t, filepath.Join(dir, "sub", "pkg", "b.go"), "package pkg\n// changed\n")
+	requireChange(t, changes)
+}
+
+func TestWatch_ClosesOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	changes, err := watch.Watch(ctx, t.TempDir(), watch.Options{}, testLogger())
+	require.NoError(t, err)
+
+	cancel()
+	select {
+	case _, ok := <-changes:
+		require.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+}
+
+func TestWatch_MissingDir(t *testing.T) {
+	_, err := watch.Watch(context.Background(), filepath.Join(t.TempDir(), "missing"), watch.Options{}, testLogger())
+	require.Error(t, err)
+}
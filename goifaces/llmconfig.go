@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olehluchkiv/goifaces/internal/enricher/llm"
+)
+
+// Names of the LLM-backed enrichers, the keys of buildLLMClients' map and
+// the suffixes of their override variables.
+const (
+	llmSimplifier = "simplifier"
+	llmGrouper    = "grouper"
+	llmPatterns   = "patterns"
+	llmAnnotator  = "annotator"
+	llmScorer     = "scorer"
+)
+
+var llmEnrichers = []string{llmSimplifier, llmGrouper, llmPatterns, llmAnnotator, llmScorer}
+
+// buildLLMClients returns the client for each LLM enricher, keyed by name.
+// All start from the shared config: format, model and temperature from the
+// flags, endpoint, key and extra headers from GOIFACES_LLM_ENDPOINT,
+// GOIFACES_LLM_API_KEY and GOIFACES_LLM_HEADERS. An enricher can override
+// any of them with GOIFACES_LLM_<SETTING>_<ENRICHER>
+// (GOIFACES_LLM_MODEL_SCORER, say); enrichers without overrides share one
+// client. Each effective config is logged, with the key and secret-looking
+// headers masked by llm.Config.LogValue. lookup is os.LookupEnv outside of
+// tests.
+func buildLLMClients(format llm.APIFormat, model string, temperature float64, lookup func(string) (string, bool), logger *slog.Logger) (map[string]*llm.Client, error) {
+	apiKey, _ := lookup("GOIFACES_LLM_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("GOIFACES_LLM_API_KEY environment variable is required when --enrich is enabled")
+	}
+	endpoint, _ := lookup("GOIFACES_LLM_ENDPOINT")
+	headerList, _ := lookup("GOIFACES_LLM_HEADERS")
+	headers, err := llm.ParseHeaders(headerList)
+	if err != nil {
+		return nil, fmt.Errorf("GOIFACES_LLM_HEADERS: %w", err)
+	}
+
+	// An empty endpoint or model selects the format's default
+	base := llm.Config{
+		APIFormat:   format,
+		Endpoint:    endpoint,
+		APIKey:      apiKey,
+		Model:       model,
+		Temperature: configTemperature(temperature),
+		Timeout:     30 * time.Second,
+		Headers:     headers,
+	}
+	var shared *llm.Client
+	clients := make(map[string]*llm.Client, len(llmEnrichers))
+	for _, name := range llmEnrichers {
+		cfg, overridden, err := llmOverrides(base, name, lookup)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case overridden:
+			clients[name] = llm.NewClient(cfg, logger)
+		case shared == nil:
+			shared = llm.NewClient(base, logger)
+			clients[name] = shared
+		default:
+			clients[name] = shared
+		}
+		logger.Info("LLM enricher configured", "enricher", name, "overridden", overridden, "config", clients[name].Config())
+	}
+	return clients, nil
+}
+
+// configTemperature maps a temperature set on the command line or in the
+// environment to llm.Config.Temperature, where 0 means unset: an explicit 0
+// becomes a negative value, which the client sends as 0.
+func configTemperature(t float64) float64 {
+	if t == 0 {
+		return -1
+	}
+	return t
+}
+
+// llmOverrides applies the GOIFACES_LLM_*_<NAME> variables set for the
+// enricher name to base, reporting whether any was set. A blank value counts
+// as unset.
+func llmOverrides(base llm.Config, name string, lookup func(string) (string, bool)) (llm.Config, bool, error) {
+	cfg := base
+	overridden := false
+	get := func(setting string) (string, string, bool) {
+		key := "GOIFACES_LLM_" + setting + "_" + strings.ToUpper(name)
+		v, ok := lookup(key)
+		v = strings.TrimSpace(v)
+		if ok && v != "" {
+			overridden = true
+		}
+		return key, v, ok && v != ""
+	}
+
+	if key, v, ok := get("FORMAT"); ok {
+		f, err := llm.ParseAPIFormat(v)
+		if err != nil {
+			return cfg, false, fmt.Errorf("%s: %w", key, err)
+		}
+		cfg.APIFormat = f
+	}
+	if _, v, ok := get("ENDPOINT"); ok {
+		cfg.Endpoint = v
+	}
+	if _, v, ok := get("API_KEY"); ok {
+		cfg.APIKey = v
+	}
+	if _, v, ok := get("MODEL"); ok {
+		cfg.Model = v
+	}
+	if key, v, ok := get("TEMPERATURE"); ok {
+		t, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return cfg, false, fmt.Errorf("%s: invalid temperature %q", key, v)
+		}
+		cfg.Temperature = configTemperature(t)
+	}
+	// Headers are added to the shared ones rather than replacing them
+	if key, v, ok := get("HEADERS"); ok {
+		headers, err := llm.ParseHeaders(v)
+		if err != nil {
+			return cfg, false, fmt.Errorf("%s: %w", key, err)
+		}
+		cfg.Headers = maps.Clone(base.Headers)
+		maps.Copy(cfg.Headers, headers)
+	}
+	return cfg, overridden, nil
+}
+
+// llmUsage sums the token usage of clients, counting a client shared by
+// several enrichers once.
+func llmUsage(clients map[string]*llm.Client) llm.Usage {
+	var total llm.Usage
+	seen := make(map[*llm.Client]bool, len(clients))
+	for _, c := range clients {
+		if !seen[c] {
+			seen[c] = true
+			total = total.Add(c.Usage())
+		}
+	}
+	return total
+}
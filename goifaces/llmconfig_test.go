@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/olehluchkiv/goifaces/internal/enricher/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildLLMClients_Overrides(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	clients, err := buildLLMClients(llm.FormatOpenAI, "gpt-4o-mini", 0.2, envLookup(map[string]string{
+		"GOIFACES_LLM_API_KEY":             "sk-shared",
+		"GOIFACES_LLM_ENDPOINT":            "https://llm.example.com/v1",
+		"GOIFACES_LLM_MODEL_SCORER":        "gpt-4o",
+		"GOIFACES_LLM_API_KEY_SCORER":      "sk-scorer",
+		"GOIFACES_LLM_FORMAT_ANNOTATOR":    "anthropic",
+		"GOIFACES_LLM_ENDPOINT_ANNOTATOR":  "https://api.anthropic.com/v1",
+		"GOIFACES_LLM_MODEL_ANNOTATOR":     "claude-3-5-haiku-latest",
+		"GOIFACES_LLM_TEMPERATURE_GROUPER": "   ", // blank: not an override
+	}), logger)
+	require.NoError(t, err)
+	require.Len(t, clients, len(llmEnrichers))
+
+	scorer := clients[llmScorer].Config()
+	assert.Equal(t, "gpt-4o", scorer.Model)
+	assert.Equal(t, "sk-scorer", scorer.APIKey)
+	assert.Equal(t, "https://llm.example.com/v1", scorer.Endpoint, "unset overrides fall back to the shared config")
+	assert.Equal(t, 0.2, scorer.Temperature)
+
+	annotator := clients[llmAnnotator].Config()
+	assert.Equal(t, llm.FormatAnthropic, annotator.APIFormat)
+	assert.Equal(t, "claude-3-5-haiku-latest", annotator.Model)
+	assert.Equal(t, "sk-shared", annotator.APIKey)
+
+	grouper := clients[llmGrouper].Config()
+	assert.Equal(t, "gpt-4o-mini", grouper.Model)
+	assert.Same(t, clients[llmGrouper], clients[llmSimplifier], "enrichers without overrides share a client")
+
+	assert.Contains(t, logs.String(), "enricher=scorer overridden=true")
+	assert.Contains(t, logs.String(), "config.model=gpt-4o ")
+	assert.NotContains(t, logs.String(), "sk-scorer")
+	assert.NotContains(t, logs.String(), "sk-shared")
+}
+
+func TestBuildLLMClients_Errors(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+
+	_, err := buildLLMClients(llm.FormatOpenAI, "", 0.2, envLookup(nil), logger)
+	assert.ErrorContains(t, err, "GOIFACES_LLM_API_KEY")
+
+	_, err = buildLLMClients(llm.FormatOpenAI, "", 0.2, envLookup(map[string]string{
+		"GOIFACES_LLM_API_KEY":       "sk",
+		"GOIFACES_LLM_FORMAT_SCORER": "gemini",
+	}), logger)
+	assert.ErrorContains(t, err, "GOIFACES_LLM_FORMAT_SCORER")
+
+	_, err = buildLLMClients(llm.FormatOpenAI, "", 0.2, envLookup(map[string]string{
+		"GOIFACES_LLM_API_KEY":             "sk",
+		"GOIFACES_LLM_TEMPERATURE_GROUPER": "hot",
+	}), logger)
+	assert.ErrorContains(t, err, `GOIFACES_LLM_TEMPERATURE_GROUPER: invalid temperature "hot"`)
+}
+
+func TestBuildLLMClients_ZeroTemperature(t *testing.T) {
+	clients, err := buildLLMClients(llm.FormatOpenAI, "", 0, envLookup(map[string]string{
+		"GOIFACES_LLM_API_KEY":             "sk",
+		"GOIFACES_LLM_TEMPERATURE_SCORER":  "0.7",
+		"GOIFACES_LLM_TEMPERATURE_GROUPER": "0",
+	}), slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+
+	assert.Equal(t, 0.0, clients[llmAnnotator].Config().Temperature, "-llm-temperature 0 is not the default")
+	assert.Equal(t, 0.7, clients[llmScorer].Config().Temperature)
+	assert.Equal(t, 0.0, clients[llmGrouper].Config().Temperature)
+}
+
+func TestBuildLLMClients_Headers(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	clients, err := buildLLMClients(llm.FormatOpenAI, "", 0.2, envLookup(map[string]string{
+		"GOIFACES_LLM_API_KEY":        "sk-shared",
+		"GOIFACES_LLM_HEADERS":        "OpenAI-Organization=org-123,X-Route=eu",
+		"GOIFACES_LLM_HEADERS_SCORER": "X-Route=us,X-Api-Token=gw-s3cret",
+	}), logger)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"Openai-Organization": "org-123", "X-Route": "eu"}, clients[llmGrouper].Config().Headers)
+	assert.Equal(t, map[string]string{"Openai-Organization": "org-123", "X-Route": "us", "X-Api-Token": "gw-s3cret"},
+		clients[llmScorer].Config().Headers, "enricher headers are merged over the shared ones")
+	assert.NotContains(t, logs.String(), "gw-s3cret")
+
+	_, err = buildLLMClients(llm.FormatOpenAI, "", 0.2, envLookup(map[string]string{
+		"GOIFACES_LLM_API_KEY": "sk",
+		"GOIFACES_LLM_HEADERS": "Content-Type=text/plain",
+	}), logger)
+	assert.ErrorIs(t, err, llm.ErrInvalidHeader)
+	assert.ErrorContains(t, err, "GOIFACES_LLM_HEADERS")
+
+	_, err = buildLLMClients(llm.FormatOpenAI, "", 0.2, envLookup(map[string]string{
+		"GOIFACES_LLM_API_KEY":         "sk",
+		"GOIFACES_LLM_HEADERS_GROUPER": "no-equals",
+	}), logger)
+	assert.ErrorContains(t, err, "GOIFACES_LLM_HEADERS_GROUPER")
+}
+
+func TestLLMUsage_CountsSharedClientOnce(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"{}"}}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`))
+	}))
+	defer server.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	clients, err := buildLLMClients(llm.FormatOpenAI, "", 0.2, envLookup(map[string]string{
+		"GOIFACES_LLM_API_KEY":        "sk-shared",
+		"GOIFACES_LLM_ENDPOINT":       server.URL,
+		"GOIFACES_LLM_API_KEY_SCORER": "sk-scorer",
+	}), logger)
+	require.NoError(t, err)
+
+	for _, name := range llmEnrichers {
+		_, err := clients[name].Complete(context.Background(), "s", "u")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, llm.Usage{PromptTokens: 15, CompletionTokens: 5, TotalTokens: 20}, llmUsage(clients))
+}
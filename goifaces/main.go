@@ -2,23 +2,28 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 
 	"time"
 
 	"github.com/olehluchkiv/goifaces/internal/analyzer"
 	"github.com/olehluchkiv/goifaces/internal/diagram"
+	"github.com/olehluchkiv/goifaces/internal/diagram/split"
 	"github.com/olehluchkiv/goifaces/internal/enricher"
 	"github.com/olehluchkiv/goifaces/internal/enricher/llm"
 	"github.com/olehluchkiv/goifaces/internal/logging"
 	"github.com/olehluchkiv/goifaces/internal/resolver"
 	"github.com/olehluchkiv/goifaces/internal/server"
+	"github.com/olehluchkiv/goifaces/internal/watch"
 )
 
 func main() {
@@ -30,19 +35,114 @@ func main() {
 
 	fs := flag.NewFlagSet("goifaces", flag.ExitOnError)
 	pathFlag := fs.String("path", "", "path or GitHub URL to analyze (alternative to positional argument)")
+	subdirFlag := fs.String("subdir", "", "analyze only the packages in this directory (and below) of the module, e.g. internal/service; same as a URL's //subdir selector")
 	port := fs.Int("port", 8080, "HTTP server port")
+	strictPort := fs.Bool("strict-port", false, fmt.Sprintf("fail when -port is in use instead of trying the next %d ports", server.PortFallbackAttempts))
+	loadTimeout := fs.Duration("load-timeout", server.DefaultLoadTimeout, "deadline for analyzing a path submitted through the page's /api/load endpoint")
+	sourceName := fs.String("source", sourceGo, "analysis source that collects interfaces and types: go")
 	filter := fs.String("filter", "", "package path prefix filter")
+	var excludes stringList
+	fs.Var(&excludes, "exclude", "after -filter, drop packages whose path contains this substring or matches this glob (\"**\" spans path segments, e.g. **/mocks/**); repeatable")
 	includeStdlib := fs.Bool("include-stdlib", false, "include standard library interfaces")
 	includeUnexported := fs.Bool("include-unexported", false, "include unexported types and interfaces")
+	includeMarkers := fs.Bool("include-markers", false, "show marker interfaces (no methods, e.g. interface{} or any aliases) as <<marker>> nodes without relations")
+	publicInterfaces := fs.Bool("public-interfaces", false, "keep only exported interfaces but all their implementers, including unexported ones")
+	includeTests := fs.Bool("include-tests", false, "analyze _test.go files too (test helpers, mocks and fakes)")
+	funcTypes := fs.Bool("func-types", true, "include named function types (e.g. HandlerFunc) that implement interfaces")
 	output := fs.String("output", "", "write Mermaid diagram to file instead of serving")
+	format := fs.String("format", formatMermaid, "file output format: mermaid, json (versioned analyzer result), graphjson (renderer-agnostic nodes/edges JSON), dot (Graphviz), d2, plantuml, graphml (yEd, Gephi), svg (rendered with a locally installed mermaid-cli, mmdc), or matrix-csv / matrix-md (type × interface implementation table); all but mermaid require -output")
+	matrixEmpty := fs.Bool("matrix-empty", false, "with -format matrix-csv or matrix-md, keep types and interfaces without implementations as empty rows and columns")
+	matrixPointer := fs.Bool("matrix-pointer", false, "with -format matrix-csv or matrix-md, mark implementations only *T satisfies as ✓*")
+	packageMap := fs.String("package-map", packageMapMermaid, "package map in Markdown book output: mermaid (flowchart) or text (indented list)")
+	splitStrategy := fs.String("split-strategy", splitHubSpoke, "how Markdown book output splits detail slides: hub-spoke (by connectivity), by-package (one slide per package) or components (one slide per disconnected subsystem)")
+	slidesFlag := fs.Bool("slides", false, "with -output naming a file, write the slide deck (package map plus detail slides split by -split-strategy) as one Markdown file with a Mermaid block per slide")
+	hubThreshold := fs.Int("hub-threshold", split.DefaultOptions().HubThreshold, "hub-spoke splitting: interfaces with at least this many relationships repeat on every detail slide (non-positive uses the default)")
+	chunkSize := fs.Int("chunk-size", split.DefaultOptions().ChunkSize, "hub-spoke and components splitting: max implementations per detail slide (non-positive uses the default)")
+	styleFile := fs.String("style-file", "", "JSON style (title, logo, palette, css) or .css file to brand the interactive page")
+	embed := fs.Bool("embed", false, "serve the minimal page for iframes (no header, thinner controls) at /; without it, request it with ?embed=1")
+	embedOrigin := fs.String("embed-origin", "", "comma-separated origins (e.g. https://docs.example.com, or *) allowed to frame the embed page besides the server itself")
+	metricsEndpoint := fs.Bool("metrics-endpoint", false, "expose GET /metrics with architecture-size gauges in Prometheus text format")
 	noBrowser := fs.Bool("no-browser", false, "skip auto-opening browser")
+	watchFlag := fs.Bool("watch", false, "in server mode, re-analyze when .go files under the input change and reload open pages")
 	logFile := fs.String("log-file", "logs/goifaces.log", "log file path")
 	logLevel := fs.String("log-level", "info", "log level (debug, info, warn, error)")
+	logFormat := fs.String("log-format", logging.FormatJSON, "log line format: json (JSONL) or text (key=value)")
+	logStdout := fs.Bool("log-stdout", false, "write logs to stdout instead of stderr (still also to -log-file), e.g. for container log collectors")
+	quiet := fs.Bool("quiet", false, "log progress and summary lines to -log-file instead of printing them; only errors reach the terminal")
 	enrichFlag := fs.Bool("enrich", false, "enable LLM-backed enrichment (requires GOIFACES_LLM_API_KEY env var)")
+	enrichTimeout := fs.Duration("enrich-timeout", 2*time.Minute, "shared deadline for the enricher pipeline (0 = no deadline)")
+	llmFormat := fs.String("llm-format", string(llm.FormatOpenAI), "LLM API spoken with -enrich: openai (chat completions, any compatible endpoint) or anthropic (messages API)")
+	llmModel := fs.String("llm-model", "", "model identifier sent to the LLM endpoint with -enrich (default "+llm.DefaultOpenAIModel+", or "+llm.DefaultAnthropicModel+" with -llm-format anthropic)")
+	llmTemperature := fs.Float64("llm-temperature", llm.DefaultTemperature, "LLM sampling temperature with -enrich, clamped to [0,2]")
+	minRelationScore := fs.Float64("min-relation-score", enricher.DefaultMinRelationScore, "with -enrich, drop relations the LLM scores below this importance (0-1) and the nodes left without relations; 0 disables pruning")
+	grouperName := fs.String("grouper", grouperPackage, "how the enricher pipeline groups interfaces and types: package (by package name) or heuristic (architectural layers from naming conventions, offline); with -enrich it is the LLM grouper's fallback")
+	enrichConcurrency := fs.Int("enrich-concurrency", enricher.DefaultConcurrency, "max enrichers running concurrently")
+	treemapMaxNodes := fs.Int("treemap-max-nodes", 500, "max package map nodes sent to the browser; smallest packages are folded into \"(other)\" (0 = unlimited)")
+	cacheMaxSize := fs.String("cache-max-size", "5GB", "max total size of the clone cache before evicting least-recently-used repos (0 disables)")
+	gitToken := fs.String("git-token", "", "token for cloning private GitHub or GitLab repos, sent as an HTTP header to https URLs on the git hosts only and never logged (prefer GOIFACES_GIT_TOKEN: flags are visible in the process list)")
+	var gitHosts stringList
+	fs.Var(&gitHosts, "git-host", "extra git host (host or host:port, e.g. a GitHub Enterprise or self-hosted GitLab server) whose https URLs are cloned and receive -git-token, besides github.com and gitlab.com; repeatable")
+	cacheDir := fs.String("cache-dir", "", "directory for cached clones of GitHub repos, created if missing (default ~/.cache/goifaces/repos)")
+	showProduces := fs.Bool("show-produces", false, "draw dependency edges from interfaces to the analyzed types their methods return")
+	qualifiedIDs := fs.Bool("qualified-ids", false, "build Mermaid node IDs from full package paths (stable, collision-free)")
+	markExternal := fs.Bool("mark-external", false, "style types and interfaces outside the analyzed module (stdlib, dependencies) as third-party")
+	showTypeMethods := fs.Bool("show-type-methods", false, "list methods inside concrete type boxes, not only interface boxes")
+	direction := fs.String("direction", diagram.DirectionLR, "layout direction of diagrams and package maps: LR, TB, RL or BT (invalid values fall back to LR)")
+	theme := fs.String("theme", diagram.DefaultTheme, "node colors of diagrams, package maps and the interactive page: "+strings.Join(diagram.ThemeNames(), ", ")+", or a .json palette file")
+	groupByPackage := fs.Bool("group-by-package", false, "wrap each package's interfaces and types in a Mermaid namespace block")
+	collapseSingleImpl := fs.Bool("collapse-single-impl", false, "draw an interface with exactly one implementing type and that type as one \"Iface (impl: Type)\" box")
+	markPorts := fs.Bool("mark-ports", false, "style interfaces implemented only outside their own package (ports-and-adapters ports)")
+	clusterError := fs.Bool("cluster-error", false, "collapse implementers of the builtin error interface into a single cluster node")
+	whatImplements := fs.String("what-implements", "", "print every interface the given type (Name, pkg.Name or import/path.Name) satisfies, then exit")
+	coverageList := fs.String("coverage", "", "comma-separated interfaces (Name, pkg.Name or import/path.Name) to report implementer coverage for, then exit")
+	coverageFile := fs.String("coverage-file", "", "file listing coverage target interfaces, one per line ('#' comments); combined with -coverage")
+	coverageJSON := fs.Bool("coverage-json", false, "print the coverage report as JSON instead of text")
+	report := fs.String("report", "", "print a report instead of a diagram, then exit: unimplemented (interfaces no type implements, with their source files) or metrics (nodes with the most implementation relations)")
+	reportTop := fs.Int("report-top", 20, "with -report metrics, how many nodes to list (0 = all)")
+	requireImplementers := fs.Bool("require-implementers", false, "exit with status 1 when a coverage target has no non-test implementer")
+	failOnEmpty := fs.Bool("fail-on-empty", false, "exit with status 2 instead of 0 when no implementation relationships are left after filtering (analysis errors exit with 1)")
+	goos := fs.String("goos", "", "analyze as for this target OS, so //go:build and _GOOS.go files match it (default: host)")
+	goarch := fs.String("goarch", "", "analyze as for this target architecture (default: host)")
+	var buildFlags stringList
+	fs.Var(&buildFlags, "build-flag", "extra flag passed verbatim to the go command when loading packages (repeatable, e.g. -build-flag=-gcflags=all=-N)")
+	maxPackages := fs.Int("max-packages", 0, "abort right after loading when more packages than this are loaded (under -filter, if set), before types are collected; 0 disables the guard")
+	maxAnalyzeNodes := fs.Int("max-analyze-nodes", defaultMaxAnalyzeNodes, "abort before matching when more interfaces+types than this are found (under -filter, if set); 0 disables the guard")
+	minConnections := fs.Int("min-connections", 0, "after filtering, hide interfaces and types with fewer than this many implementation relationships (1 hides isolated nodes; 0 disables)")
+	minImplementers := fs.Int("min-implementers", 0, "after filtering, keep only interfaces implemented by at least this many distinct types, and the types implementing them (0 disables)")
+	focus := fs.String("focus", "", "after filtering, diagram only this interface or type (import/path.Name) and the nodes within -focus-depth implementation hops of it")
+	focusDepth := fs.Int("focus-depth", 1, "with -focus, how many implementation hops to expand (0 = the node alone)")
+	noCache := fs.Bool("no-cache", false, "analyze afresh instead of reusing the cached result of an unchanged module (kept in \"analysis\" next to the clone cache)")
+	matchCache := fs.Bool("match-cache", false, "reuse interface matches from the previous run for unchanged packages (stored in matches/ under -cache-dir, removed by -cache-clear)")
+	cacheClear := fs.Bool("cache-clear", false, "remove all cached clones (under -cache-dir), then exit; cannot be combined with an input")
+	fs.BoolVar(cacheClear, "clear-cache", false, "alias of -cache-clear")
+	showVersion := fs.Bool("version", false, "print the version, commit and build date, then exit")
+	configPath := fs.String("config", "", "YAML file of flag defaults, keyed by flag name (default "+defaultConfigFile+" in the working directory, if present)")
 
 	if err := fs.Parse(flags); err != nil {
 		os.Exit(1)
 	}
+	if *showVersion {
+		fmt.Println(versionString())
+		os.Exit(0)
+	}
+	// Flags not given on the command line fall back to GOIFACES_* env vars,
+	// then to the config file
+	if err := applyEnvDefaults(fs, os.LookupEnv); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	cfgFile := *configPath
+	if cfgFile == "" {
+		cfgFile = defaultConfigFile
+	}
+	cfg, err := loadConfig(fs, cfgFile, *configPath != "")
+	if err == nil {
+		err = applyConfigDefaults(fs, cfg, cfgFile)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 	// Collect any remaining args from flag parsing + our positional args
 	positional = append(positional, fs.Args()...)
 
@@ -54,11 +154,127 @@ func main() {
 	if input == "" {
 		input = *pathFlag
 	}
-	if input == "" {
+	// Without -output or a report the run ends in the interactive server. With
+	// no input (or nothing found) it serves the landing page, which analyzes
+	// a path through /api/load; -watch and -fail-on-empty need an input.
+	serverMode := *output == "" && *report == "" && *whatImplements == "" && *coverageList == "" && *coverageFile == ""
+	if input == "" && !*cacheClear && (!serverMode || *watchFlag || *failOnEmpty) {
 		fmt.Fprintln(os.Stderr, "Usage: goifaces [flags] <path-or-url>")
 		fs.PrintDefaults()
 		os.Exit(1)
 	}
+	if input != "" && *cacheClear {
+		fmt.Fprintln(os.Stderr, "Error: -cache-clear clears the cache and exits; run it without an input path")
+		os.Exit(1)
+	}
+	cacheOpts := resolver.Options{CacheDir: *cacheDir, GitToken: *gitToken, GitHosts: gitHosts}
+	repoInput, subdir := resolver.SplitSubdir(input, cacheOpts)
+	if subdir != "" && *subdirFlag != "" {
+		fmt.Fprintln(os.Stderr, "Error: give the subdirectory either as a //subdir selector or with -subdir, not both")
+		os.Exit(1)
+	}
+	if *subdirFlag != "" {
+		subdir = *subdirFlag
+	}
+
+	switch *format {
+	case formatMermaid:
+	case formatJSON, formatGraphJSON, formatDOT, formatD2, formatPlantUML, formatGraphML, formatSVG, formatMatrixCSV, formatMatrixMD:
+		if *output == "" || isDirOutput(*output) {
+			fmt.Fprintf(os.Stderr, "Error: -format %s requires -output naming a file\n", *format)
+			os.Exit(1)
+		}
+		// Fail before analysis rather than after it
+		if *format == formatSVG {
+			if _, err := diagram.FindMermaidCLI(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: -format svg: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Invalid format %q: want %s, %s, %s, %s, %s, %s, %s, %s, %s or %s\n", *format, formatMermaid, formatJSON, formatGraphJSON, formatDOT, formatD2, formatPlantUML, formatGraphML, formatSVG, formatMatrixCSV, formatMatrixMD)
+		os.Exit(1)
+	}
+
+	if *slidesFlag && (*output == "" || *format != formatMermaid) {
+		fmt.Fprintln(os.Stderr, "Error: -slides writes Markdown; it needs -output and the mermaid format")
+		os.Exit(1)
+	}
+
+	if *watchFlag && *output != "" {
+		fmt.Fprintln(os.Stderr, "Error: -watch only applies to server mode; drop -output")
+		os.Exit(1)
+	}
+
+	if *logStdout && *quiet {
+		fmt.Fprintln(os.Stderr, "Error: -log-stdout and -quiet conflict; -quiet keeps logs out of the terminal")
+		os.Exit(1)
+	}
+	if *logStdout && *coverageJSON {
+		fmt.Fprintln(os.Stderr, "Error: -log-stdout would mix log lines into the -coverage-json output; drop one of them")
+		os.Exit(1)
+	}
+	if *embed && *output != "" {
+		fmt.Fprintln(os.Stderr, "Error: -embed only applies to server mode; drop -output")
+		os.Exit(1)
+	}
+	frameOrigins, err := server.ParseFrameOrigins(*embedOrigin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -embed-origin: %v\n", err)
+		os.Exit(1)
+	}
+
+	if _, err := newSource(*sourceName, analyzer.AnalyzeOptions{}, nil); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if _, err := newSplitter(*splitStrategy, split.DefaultOptions()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if _, err := newGrouper(*grouperName); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	apiFormat, err := llm.ParseAPIFormat(*llmFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *report != "" && *report != reportUnimplemented && *report != reportMetrics {
+		fmt.Fprintf(os.Stderr, "Invalid report %q: want %s or %s\n", *report, reportUnimplemented, reportMetrics)
+		os.Exit(1)
+	}
+	if *reportTop < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid -report-top %d: must be 0 or more\n", *reportTop)
+		os.Exit(1)
+	}
+	if *minConnections < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid -min-connections %d: must be 0 or more\n", *minConnections)
+		os.Exit(1)
+	}
+	if *maxPackages < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid -max-packages %d: must be 0 or more\n", *maxPackages)
+		os.Exit(1)
+	}
+	if *minImplementers < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid -min-implementers %d: must be 0 or more\n", *minImplementers)
+		os.Exit(1)
+	}
+	for _, pattern := range excludes {
+		if err := analyzer.ValidateExclude(pattern); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid -exclude: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *focusDepth < 0 {
+		fmt.Fprintf(os.Stderr, "Invalid -focus-depth %d: must be 0 or more\n", *focusDepth)
+		os.Exit(1)
+	}
+	if *packageMap != packageMapMermaid && *packageMap != packageMapText {
+		fmt.Fprintf(os.Stderr, "Invalid package map %q: want %s or %s\n", *packageMap, packageMapMermaid, packageMapText)
+		os.Exit(1)
+	}
 
 	// Parse log level
 	level, err := parseLogLevel(*logLevel)
@@ -67,14 +283,29 @@ func main() {
 		os.Exit(1)
 	}
 
+	maxCacheBytes, err := resolver.ParseByteSize(*cacheMaxSize)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid cache size %q: %v\n", *cacheMaxSize, err)
+		os.Exit(1)
+	}
+
 	// Setup logging
-	logger, logCleanup, err := logging.Setup(*logFile, level)
+	logger, logCleanup, err := logging.Setup(logging.Options{
+		File: *logFile, Level: level, Quiet: *quiet, Format: *logFormat, Stdout: *logStdout,
+	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to setup logging: %v\n", err)
 		os.Exit(1)
 	}
 	defer logCleanup()
 
+	// Keep stdout clean for machine-readable reports
+	var progressOut io.Writer = os.Stdout
+	if *coverageJSON {
+		progressOut = os.Stderr
+	}
+	progress := newProgressWriter(progressOut, *quiet, logger)
+
 	// Setup signal handling with context cancellation
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -87,104 +318,714 @@ func main() {
 		cancel()
 	}()
 
+	// Step 0: Manage the clone cache
+	if *cacheClear {
+		if err := resolver.ClearCache(cacheOpts, logger); err != nil {
+			logger.Error("failed to clear cache", "error", err)
+			fmt.Fprintf(os.Stderr, "Error clearing cache: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(progress, "Cleared clone cache")
+		return
+	}
+	if input == "" {
+		fmt.Fprintln(progress, "No input given: open the page to load a path")
+	}
+	if err := resolver.PruneCache(cacheOpts, maxCacheBytes, logger); err != nil {
+		logger.Warn("failed to prune clone cache", "error", err)
+	}
+
+	// Load the page style up front so a bad file fails before analysis
+	var style *server.Style
+	if *styleFile != "" {
+		var styleErr error
+		style, styleErr = server.LoadStyle(*styleFile)
+		if styleErr != nil {
+			logger.Error("failed to load style file", "path", *styleFile, "error", styleErr)
+			fmt.Fprintf(os.Stderr, "Error loading style file: %v\n", styleErr)
+			os.Exit(1)
+		}
+	}
+
+	palette, err := diagram.LoadTheme(*theme)
+	if err != nil {
+		logger.Error("failed to load theme", "theme", *theme, "error", err)
+		fmt.Fprintf(os.Stderr, "Error loading theme: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Step 1: Resolve input to local directory
-	fmt.Println("Resolving input...")
-	dir, resolverCleanup, err := resolver.Resolve(ctx, input, logger)
+	dir, resolverCleanup := "", func() {}
+	if input != "" {
+		fmt.Fprintln(progress, "Resolving input...")
+		dir, resolverCleanup, err = resolver.Resolve(ctx, repoInput, cacheOpts, logger)
+	}
 	if err != nil {
 		logger.Error("failed to resolve input", "error", err)
 		fmt.Fprintf(os.Stderr, "Error resolving input: %v\n", err)
+		if errors.Is(err, resolver.ErrAuthFailed) {
+			if *gitToken == "" {
+				fmt.Fprintln(os.Stderr, "The repository may be private: set GOIFACES_GIT_TOKEN to a token that can read it.")
+			} else {
+				fmt.Fprintln(os.Stderr, "The git token was rejected or cannot read this repository.")
+			}
+		}
 		os.Exit(1)
 	}
-	defer resolverCleanup()
+	// os.Exit skips deferred calls, so exits from here on go through exit,
+	// which first removes an extracted archive. A signal cancels ctx, which
+	// ends the run through one of these exits or a normal return.
+	cleanup := sync.OnceFunc(resolverCleanup)
+	defer cleanup()
+	exit := func(code int) {
+		cleanup()
+		os.Exit(code)
+	}
+
+	if input != "" {
+		subdir, err = resolver.CheckSubdir(dir, subdir)
+		if err != nil {
+			logger.Error("failed to resolve input", "error", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+	}
 
 	// Step 2: Analyze
-	fmt.Println("Loading packages...")
 	opts := analyzer.AnalyzeOptions{
 		Filter:            *filter,
+		Exclude:           excludes,
 		IncludeStdlib:     *includeStdlib,
 		IncludeUnexported: *includeUnexported,
+		PublicInterfaces:  *publicInterfaces,
+		IncludeMarkers:    *includeMarkers,
+		Subdir:            subdir,
+		ExcludeFuncTypes:  !*funcTypes,
+		IncludeTests:      *includeTests,
+		MaxNodes:          *maxAnalyzeNodes,
+		MaxPackages:       *maxPackages,
+		BuildFlags:        buildFlags,
+		GOOS:              *goos,
+		GOARCH:            *goarch,
+		Progress:          analysisProgress(progress, !*quiet && isTerminal(progressOut)),
+	}
+
+	// -what-implements reads the type objects, which cached results lack
+	if !*noCache && *whatImplements == "" {
+		opts.ResultCacheDir, err = resolver.AnalysisCacheDir(cacheOpts)
+		if err != nil {
+			logger.Warn("result cache disabled", "error", err)
+		}
+	}
+
+	var matchCachePath string
+	if *matchCache && input != "" {
+		matchCacheDir, err := resolver.MatchCacheDir(cacheOpts)
+		if err != nil {
+			logger.Warn("match cache disabled", "error", err)
+		} else {
+			matchCachePath = analyzer.MatchCachePath(matchCacheDir, dir)
+			opts.MatchCache, err = analyzer.LoadMatchCache(matchCachePath)
+			if err != nil {
+				logger.Warn("discarding match cache", "path", matchCachePath, "error", err)
+			}
+		}
 	}
 
-	result, err := analyzer.Analyze(ctx, dir, opts, logger)
+	source, err := newSource(*sourceName, opts, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
+	}
+	// Without input the server starts empty, on the landing page
+	result := &analyzer.Result{}
+	if input != "" {
+		result, err = source.Collect(ctx, dir)
+	}
+	if errors.Is(err, analyzer.ErrNoPackages) {
+		logger.Warn("no Go packages found", "dir", dir)
+		if !serverMode || *failOnEmpty {
+			fmt.Fprintln(progress, "No interfaces or implementations found — nothing to diagram.")
+			if *failOnEmpty {
+				exit(exitEmpty)
+			}
+			exit(0)
+		}
+		result, err = &analyzer.Result{}, nil
+	}
+	if errors.Is(err, analyzer.ErrTooManyNodes) {
+		logger.Error("analysis aborted", "error", err, "max_analyze_nodes", *maxAnalyzeNodes)
+		fmt.Fprintf(os.Stderr, "Error: %v (or raise -max-analyze-nodes)\n", err)
+		exit(1)
+	}
+	if errors.Is(err, analyzer.ErrTooManyPackages) {
+		logger.Error("analysis aborted", "error", err, "max_packages", *maxPackages)
+		fmt.Fprintf(os.Stderr, "Error: %v (or raise -max-packages)\n", err)
+		exit(1)
+	}
 	if err != nil {
 		logger.Error("analysis failed", "error", err)
 		fmt.Fprintf(os.Stderr, "Error analyzing packages: %v\n", err)
-		os.Exit(1)
+		exit(1)
+	}
+
+	if opts.MatchCache != nil {
+		if err := opts.MatchCache.Save(matchCachePath); err != nil {
+			logger.Warn("failed to save match cache", "path", matchCachePath, "error", err)
+		}
+	}
+
+	// Report mode: answer the query from the unfiltered result and exit
+	if *whatImplements != "" {
+		typ, err := analyzer.FindType(result, *whatImplements)
+		if err != nil {
+			logger.Error("what-implements query failed", "type", *whatImplements, "error", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		sats := analyzer.WhatImplements(result, typ, opts)
+		logger.Info("what-implements report", "type", typ.PkgPath+"."+typ.Name, "interfaces", len(sats))
+		writeWhatImplements(os.Stdout, typ, sats)
+		return
+	}
+
+	if *coverageList != "" || *coverageFile != "" {
+		targets, err := coverageTargets(*coverageList, *coverageFile)
+		if err != nil {
+			logger.Error("coverage report failed", "error", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		report := analyzer.Coverage(result, targets)
+		logger.Info("coverage report", "covered", report.Covered, "total", report.Total, "pass", report.Pass)
+		if err := writeCoverageReport(os.Stdout, report, *coverageJSON); err != nil {
+			logger.Error("failed to write coverage report", "error", err)
+			exit(1)
+		}
+		if *requireImplementers && !report.Pass {
+			exit(1)
+		}
+		return
+	}
+
+	// Unimplemented interfaces and unused exports are orphans that Filter
+	// prunes, so find them first
+	unimplemented := unimplementedInScope(result, opts)
+	if *report == reportUnimplemented {
+		logger.Info("unimplemented interfaces report", "interfaces", len(unimplemented))
+		writeUnimplementedReport(os.Stdout, unimplemented)
+		return
+	}
+
+	var unusedExports []string
+	for _, key := range analyzer.UnusedExports(result) {
+		if strings.HasPrefix(key, opts.Filter) && !analyzer.Excluded(key[:strings.LastIndex(key, ".")], opts.Exclude) {
+			unusedExports = append(unusedExports, key)
+		}
 	}
 
 	// Step 3: Filter
 	result = analyzer.Filter(result, opts)
 
-	fmt.Printf("Found %d interfaces, %d types, %d relationships\n",
-		len(result.Interfaces), len(result.Types), len(result.Relations))
+	if input != "" {
+		fmt.Fprintf(progress, "Found %d interfaces, %d unimplemented, %d types, %d relationships\n",
+			len(result.Interfaces), len(unimplemented), len(result.Types), len(result.Relations))
+	}
 
-	if len(result.Interfaces) == 0 && len(result.Types) == 0 {
-		fmt.Println("No interfaces or implementations found — nothing to diagram.")
-		os.Exit(0)
+	if *minConnections > 0 {
+		kept := analyzer.FilterByMinConnections(result, *minConnections)
+		hidden := len(result.Interfaces) + len(result.Types) - len(kept.Interfaces) - len(kept.Types)
+		logger.Info("applied minimum connections", "min_connections", *minConnections, "hidden", hidden)
+		fmt.Fprintf(progress, "Hid %d interfaces and types with fewer than %d relationships\n", hidden, *minConnections)
+		result = kept
+	}
+	if *minImplementers > 0 {
+		kept := analyzer.FilterByMinImplementers(result, *minImplementers)
+		hiddenIfaces := len(result.Interfaces) - len(kept.Interfaces)
+		hiddenTypes := len(result.Types) - len(kept.Types)
+		logger.Info("applied minimum implementers", "min_implementers", *minImplementers, "hidden_interfaces", hiddenIfaces, "hidden_types", hiddenTypes)
+		fmt.Fprintf(progress, "Hid %d interfaces with fewer than %d implementations and %d types left without one\n", hiddenIfaces, *minImplementers, hiddenTypes)
+		result = kept
+	}
+	if *focus != "" {
+		focused, err := diagram.FocusSubgraph(result, *focus, *focusDepth)
+		if err != nil {
+			logger.Error("focus failed", "focus", *focus, "error", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		logger.Info("applied focus", "focus", *focus, "depth", *focusDepth,
+			"interfaces", len(focused.Interfaces), "types", len(focused.Types))
+		fmt.Fprintf(progress, "Focused on %s: %d interfaces and %d types within %d hops\n",
+			*focus, len(focused.Interfaces), len(focused.Types), *focusDepth)
+		result = focused
+	}
+
+	if *report == reportMetrics {
+		metrics := analyzer.Metrics(result)
+		ids := diagram.NodeIDs(result, diagram.DiagramOptions{QualifiedIDs: *qualifiedIDs})
+		logger.Info("metrics report", "nodes", len(metrics), "top", *reportTop)
+		writeMetricsReport(os.Stdout, topNodeMetrics(metrics, ids, *reportTop), len(metrics))
+		return
+	}
+
+	empty := len(result.Interfaces) == 0 && len(result.Types) == 0
+	if empty && input != "" {
+		fmt.Fprintln(progress, "No interfaces or implementations found — nothing to diagram.")
+		if *failOnEmpty {
+			exit(exitEmpty)
+		}
+		if !serverMode {
+			exit(0)
+		}
+		if !*watchFlag {
+			fmt.Fprintln(progress, "Open the page to load another path")
+		}
+	}
+	// Markers and aliases can be left without a single relationship
+	if *failOnEmpty && len(result.Relations) == 0 {
+		logger.Warn("no implementation relationships found", "interfaces", len(result.Interfaces), "types", len(result.Types))
+		fmt.Fprintln(progress, "No implementation relationships found — failing because of -fail-on-empty.")
+		exit(exitEmpty)
+	}
+
+	if !empty {
+		writePortSummary(progress, analyzer.ClassifyPorts(result))
+		writeUnusedSummary(progress, unusedExports)
 	}
 
 	// Step 4: Run enricher pipeline
-	var enrichers []enricher.Enricher
+	var llmClients map[string]*llm.Client
 	if *enrichFlag {
-		llmClient, llmErr := buildLLMClient(logger)
-		if llmErr != nil {
-			logger.Error("failed to configure LLM client", "error", llmErr)
-			fmt.Fprintf(os.Stderr, "Error: %v\n", llmErr)
-			os.Exit(1)
+		llmClients, err = buildLLMClients(apiFormat, *llmModel, *llmTemperature, os.LookupEnv, logger)
+		if err != nil {
+			logger.Error("failed to configure LLM client", "error", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
 		}
-		fmt.Println("LLM enrichment enabled")
-		enrichers = []enricher.Enricher{
-			enricher.NewLLMGrouper(ctx, llmClient, enricher.NewDefaultGrouper(), logger),
-			enricher.NewLLMSimplifier(ctx, llmClient, enricher.NewDefaultSimplifier(), logger),
+		fmt.Fprintln(progress, "LLM enrichment enabled")
+	}
+	grouper, _ := newGrouper(*grouperName) // validated at startup
+	// enrich runs the pipeline under its own -enrich-timeout deadline, so
+	// that -watch re-analyses get a fresh one. The returned Result has the
+	// low-score relations pruned.
+	enrich := func(ctx context.Context, result *analyzer.Result) *enricher.Enriched {
+		if *enrichTimeout > 0 {
+			var cancelEnrich context.CancelFunc
+			ctx, cancelEnrich = context.WithTimeout(ctx, *enrichTimeout)
+			defer cancelEnrich()
 		}
-	} else {
-		enrichers = []enricher.Enricher{
-			enricher.NewDefaultGrouper(),
-			enricher.NewDefaultSimplifier(),
+		pipeline := enricher.NewPipeline(enricher.PipelineOptions{Concurrency: *enrichConcurrency}, logger)
+		if llmClients != nil {
+			pipeline.Transforms = []enricher.Enricher{
+				enricher.NewLLMSimplifier(ctx, llmClients[llmSimplifier], enricher.NewDefaultSimplifier(), logger),
+			}
+			pipeline.Grouper = enricher.NewLLMGrouper(ctx, llmClients[llmGrouper], grouper, logger)
+			pipeline.PatternDetector = enricher.NewLLMPatternDetector(ctx, llmClients[llmPatterns], enricher.NewDefaultPatternDetector(), logger)
+			pipeline.Annotator = enricher.NewLLMAnnotator(ctx, llmClients[llmAnnotator], enricher.NewDefaultAnnotator(), logger)
+			pipeline.Scorer = enricher.NewLLMScorer(ctx, llmClients[llmScorer], enricher.NewDefaultScorer(), logger)
+		} else {
+			pipeline.Transforms = []enricher.Enricher{
+				enricher.NewDefaultSimplifier(),
+			}
+			pipeline.Grouper = grouper
+		}
+		enriched := pipeline.Run(ctx, result)
+		pruned := enricher.PruneByScore(enriched.Result, enriched.Scores, *minRelationScore)
+		if dropped := len(enriched.Result.Relations) - len(pruned.Relations); dropped > 0 {
+			logger.Info("pruned low-score relations", "dropped", dropped, "min_score", *minRelationScore)
+			fmt.Fprintf(progress, "Pruned %d relationships scored below %.2f\n", dropped, *minRelationScore)
+		}
+		enriched.Scores = enricher.ReindexScores(enriched.Result, pruned, enriched.Scores)
+		enriched.Result = pruned
+		return enriched
+	}
+	// An empty result is only served, as the landing page: nothing to enrich
+	enriched := &enricher.Enriched{Result: result}
+	if !empty {
+		enriched = enrich(ctx, result)
+	}
+	result = enriched.Result
+	if llmClients != nil {
+		usage := llmUsage(llmClients)
+		logger.Info("LLM token usage", "prompt_tokens", usage.PromptTokens, "completion_tokens", usage.CompletionTokens,
+			"total_tokens", usage.TotalTokens, "unreported_responses", usage.Unreported)
+		fmt.Fprintf(progress, "LLM tokens used: %d (%d prompt, %d completion)\n", usage.TotalTokens, usage.PromptTokens, usage.CompletionTokens)
+		if usage.Unreported > 0 {
+			fmt.Fprintf(progress, "  %d LLM responses reported no usage and are not counted\n", usage.Unreported)
 		}
 	}
-	for _, e := range enrichers {
-		result = e.Enrich(result)
+	// -min-relation-score may have pruned the last relationships
+	if *failOnEmpty && len(result.Relations) == 0 {
+		logger.Warn("no implementation relationships left after enrichment", "min_relation_score", *minRelationScore)
+		fmt.Fprintln(progress, "No implementation relationships left after pruning — failing because of -fail-on-empty.")
+		exit(exitEmpty)
 	}
 
 	// Step 5: Generate Mermaid diagram
 	diagramOpts := diagram.DefaultDiagramOptions()
+	diagramOpts.ShowProduces = *showProduces
+	diagramOpts.QualifiedIDs = *qualifiedIDs
+	diagramOpts.ClusterError = *clusterError
+	diagramOpts.MarkExternal = *markExternal
+	diagramOpts.MarkPorts = *markPorts
+	diagramOpts.ShowTypeMethods = *showTypeMethods
+	diagramOpts.GroupByPackage = *groupByPackage
+	diagramOpts.CollapseSingleImpl = *collapseSingleImpl
+	diagramOpts.Direction, err = diagram.ParseDirection(*direction)
+	if err != nil {
+		logger.Warn("invalid direction, using LR", "error", err)
+	}
+	diagramOpts.Annotations = enriched.Annotations
+	diagramOpts.Palette = &palette
+	// Without -enrich every relation scores 1.0: keep the uniform edges
+	if llmClients != nil {
+		diagramOpts.RelationScores = diagram.ScoresByRelation(result, enriched.Scores)
+	}
+	for _, c := range diagram.NodeIDCollisions(result, diagramOpts) {
+		logger.Warn("node IDs collide, disambiguating with package path hashes (or use -qualified-ids)", "id", c.ID, "nodes", c.Nodes)
+	}
+
+	// buildSlides splits the result for Markdown book and -slides output.
+	// Non-positive -hub-threshold and -chunk-size fall back to the defaults.
+	buildSlides := func(result *analyzer.Result, diagramOpts diagram.DiagramOptions) []diagram.Slide {
+		splitOpts := split.Options{HubThreshold: *hubThreshold, ChunkSize: *chunkSize}
+		if effective := splitOpts.WithDefaults(); effective != splitOpts {
+			logger.Warn("non-positive split option, using the default", "hub_threshold", effective.HubThreshold, "chunk_size", effective.ChunkSize)
+			splitOpts = effective
+		}
+		splitter, _ := newSplitter(*splitStrategy, splitOpts) // validated at startup
+		slideOpts := diagram.DefaultSlideOptions()
+		slideOpts.PackageMapText = *packageMap == packageMapText
+		return diagram.BuildSlides(result, diagramOpts, splitter, slideOpts)
+	}
+
+	// writeOutput writes one rendered format to -output and reports it as
+	// "Wrote <what> to <path>"; a write failure ends the run.
+	writeOutput := func(data []byte, what string) {
+		if err := os.WriteFile(*output, data, 0o644); err != nil {
+			logger.Error("failed to write output file", "error", err)
+			fmt.Fprintf(os.Stderr, "Error writing to %s: %v\n", *output, err)
+			exit(1)
+		}
+		fmt.Fprintf(progress, "Wrote %s to %s\n", what, *output)
+	}
 
 	// Step 6: Output or serve
-	if *output != "" {
+	if *format == formatJSON {
+		resultJSON, err := analyzer.MarshalResult(result)
+		if err != nil {
+			logger.Error("failed to generate result JSON", "error", err)
+			fmt.Fprintf(os.Stderr, "Error generating result JSON: %v\n", err)
+			exit(1)
+		}
+		writeOutput(resultJSON, fmt.Sprintf("result JSON (schema version %d)", analyzer.ResultSchemaVersion))
+	} else if *format == formatGraphJSON {
+		graphJSON, err := diagram.GenerateGraphJSON(result)
+		if err != nil {
+			logger.Error("failed to generate graph JSON", "error", err)
+			fmt.Fprintf(os.Stderr, "Error generating graph JSON: %v\n", err)
+			exit(1)
+		}
+		writeOutput(graphJSON, "graph JSON")
+	} else if *format == formatDOT {
+		dot := diagram.GenerateDOT(result, diagramOpts)
+		writeOutput([]byte(dot), "Graphviz DOT")
+	} else if *format == formatD2 {
+		d2 := diagram.GenerateD2(result, diagramOpts)
+		writeOutput([]byte(d2), "D2")
+	} else if *format == formatPlantUML {
+		puml := diagram.GeneratePlantUML(result, diagramOpts)
+		writeOutput([]byte(puml), "PlantUML")
+	} else if *format == formatGraphML {
+		graphML := diagram.GenerateGraphML(result)
+		writeOutput([]byte(graphML), "GraphML")
+	} else if *format == formatMatrixCSV || *format == formatMatrixMD {
+		matrixFormat := diagram.MatrixCSV
+		if *format == formatMatrixMD {
+			matrixFormat = diagram.MatrixMarkdown
+		}
+		matrix := diagram.GenerateMatrix(result, matrixFormat, diagram.MatrixOptions{
+			IncludeEmpty: *matrixEmpty,
+			MarkPointer:  *matrixPointer,
+		})
+		writeOutput([]byte(matrix), "implementation matrix")
+	} else if *format == formatSVG {
+		fmt.Fprintln(progress, "Rendering SVG with mermaid-cli...")
+		svg, err := diagram.GenerateSVG(ctx, result, diagramOpts)
+		if err != nil {
+			logger.Error("failed to render SVG", "error", err)
+			fmt.Fprintf(os.Stderr, "Error rendering SVG: %v\n", err)
+			exit(1)
+		}
+		writeOutput(svg, "SVG")
+	} else if *output != "" && isDirOutput(*output) {
+		// Directory output: paginated Markdown book built from slides
+		diagramOpts.IncludeInit = true
+		pages := diagram.BuildBook(buildSlides(result, diagramOpts))
+		if err := diagram.WriteBook(*output, pages); err != nil {
+			logger.Error("failed to write book", "error", err)
+			fmt.Fprintf(os.Stderr, "Error writing book to %s: %v\n", *output, err)
+			exit(1)
+		}
+		logger.Info("wrote markdown book", "dir", *output, "pages", len(pages))
+		fmt.Fprintf(progress, "Wrote %d pages to %s\n", len(pages), *output)
+	} else if *slidesFlag {
+		// Slide deck: one Markdown file with a section per slide
+		diagramOpts.IncludeInit = true
+		slides := buildSlides(result, diagramOpts)
+		writeOutput([]byte(diagram.BuildSlideDeck(slides)), fmt.Sprintf("%d slides", len(slides)))
+		logger.Info("wrote slide deck", "file", *output, "slides", len(slides))
+	} else if *output != "" {
 		// File output: include %%{init:}%% for standalone .mmd rendering
 		diagramOpts.IncludeInit = true
 		mermaidContent := diagram.GenerateMermaid(result, diagramOpts)
-		if err := os.WriteFile(*output, []byte(mermaidContent), 0o644); err != nil {
-			logger.Error("failed to write output file", "error", err)
-			fmt.Fprintf(os.Stderr, "Error writing to %s: %v\n", *output, err)
-			os.Exit(1)
-		}
-		fmt.Printf("Wrote diagram to %s\n", *output)
+		writeOutput([]byte(mermaidContent), "diagram")
 	} else {
 		// Server mode: interactive tabbed UI
-		interactiveData := diagram.PrepareInteractiveData(result, diagramOpts)
-		interactiveData.PackageMapNodes = diagram.PreparePackageMapData(result)
-		interactiveData.RepoAddress = input
+		prepare := func(enriched *enricher.Enriched) diagram.InteractiveData {
+			prepOpts := diagramOpts
+			prepOpts.Annotations = enriched.Annotations
+			if llmClients != nil {
+				prepOpts.Patterns = diagramPatterns(enriched.Patterns)
+				prepOpts.RelationScores = diagram.ScoresByRelation(enriched.Result, enriched.Scores)
+			}
+			data := diagram.PrepareInteractiveData(enriched.Result, prepOpts)
+			data.PackageMapNodes = diagram.LimitPackageMapNodes(diagram.PreparePackageMapData(enriched.Result), *treemapMaxNodes)
+			data.RepoAddress = input
+			return data
+		}
+		interactiveData := prepare(enriched)
 
-		openBrowser := !*noBrowser
-		fmt.Printf("Starting server on http://localhost:%d\n", *port)
-		if err := server.ServeInteractive(ctx, interactiveData, *port, openBrowser, logger); err != nil {
+		serveOpts := server.ServeOptions{
+			Port:         *port,
+			StrictPort:   *strictPort,
+			OpenBrowser:  !*noBrowser,
+			Style:        style,
+			Metrics:      *metricsEndpoint,
+			Version:      shortVersion(),
+			Embed:        *embed,
+			FrameOrigins: frameOrigins,
+			OnListen: func(url string) {
+				fmt.Fprintf(progress, "Starting server on %s\n", url)
+			},
+		}
+		// rebuild re-runs the analysis and enrichment of a resolved directory
+		// with the same options, focused on focusKey unless it is empty
+		rebuild := func(ctx context.Context, source analyzer.Source, dir, focusKey string) (diagram.InteractiveData, error) {
+			result, err := source.Collect(ctx, dir)
+			if err != nil {
+				return diagram.InteractiveData{}, err
+			}
+			result = analyzer.FilterByMinConnections(analyzer.Filter(result, opts), *minConnections)
+			result = analyzer.FilterByMinImplementers(result, *minImplementers)
+			if focusKey != "" {
+				if result, err = diagram.FocusSubgraph(result, focusKey, *focusDepth); err != nil {
+					return diagram.InteractiveData{}, err
+				}
+			}
+			return prepare(enrich(ctx, result)), nil
+		}
+		if *watchFlag {
+			updates, err := watchSources(ctx, dir, func(ctx context.Context) (diagram.InteractiveData, error) {
+				return rebuild(ctx, source, dir, *focus)
+			}, progress, logger)
+			if err != nil {
+				logger.Error("failed to watch sources", "dir", dir, "error", err)
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(1)
+			}
+			serveOpts.Updates = updates
+			fmt.Fprintf(progress, "Watching %s for changes\n", dir)
+		} else {
+			// The landing page analyzes another path through /api/load. Not
+			// with -watch, whose next rebuild would bring the old one back.
+			serveOpts.LoadTimeout = *loadTimeout
+			serveOpts.Load = func(ctx context.Context, path string) (diagram.InteractiveData, error) {
+				repo, subdir := resolver.SplitSubdir(path, cacheOpts)
+				loadDir, loadCleanup, err := resolver.Resolve(ctx, repo, cacheOpts, logger)
+				if err != nil {
+					return diagram.InteractiveData{}, err
+				}
+				defer loadCleanup()
+				// -subdir belongs to the first input; a loaded path brings its own
+				loadOpts := opts
+				if loadOpts.Subdir, err = resolver.CheckSubdir(loadDir, subdir); err != nil {
+					return diagram.InteractiveData{}, err
+				}
+				loadSource, err := newSource(*sourceName, loadOpts, logger)
+				if err != nil {
+					return diagram.InteractiveData{}, err
+				}
+				// -focus names a node of the first input, so a loaded path is shown whole
+				data, err := rebuild(ctx, loadSource, loadDir, "")
+				if err != nil {
+					return diagram.InteractiveData{}, err
+				}
+				data.RepoAddress = path
+				return data, nil
+			}
+		}
+		if err := server.ServeInteractive(ctx, interactiveData, serveOpts, logger); err != nil {
 			logger.Error("server error", "error", err)
 			fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 	}
 }
 
+// watchSources re-runs rebuild each time the Go sources under dir change
+// and sends the new data for the server to swap in. A failed re-analysis
+// (typically code that does not compile mid-edit) is logged and the page
+// keeps the previous data. The channel is closed when ctx is cancelled.
+func watchSources(ctx context.Context, dir string, rebuild func(context.Context) (diagram.InteractiveData, error), progress io.Writer, logger *slog.Logger) (<-chan diagram.InteractiveData, error) {
+	changes, err := watch.Watch(ctx, dir, watch.Options{}, logger)
+	if err != nil {
+		return nil, err
+	}
+	updates := make(chan diagram.InteractiveData)
+	go func() {
+		defer close(updates)
+		for range changes {
+			fmt.Fprintln(progress, "Sources changed, re-analyzing...")
+			data, err := rebuild(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				logger.Warn("re-analysis failed, keeping previous diagram", "error", err)
+				fmt.Fprintf(os.Stderr, "Re-analysis failed: %v\n", err)
+				continue
+			}
+			logger.Info("re-analysis complete", "interfaces", len(data.Interfaces), "types", len(data.Types), "relations", len(data.Relations))
+			select {
+			case updates <- data:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return updates, nil
+}
+
+// defaultMaxAnalyzeNodes is the -max-analyze-nodes default: generous for
+// real projects, but stops runaway analysis of whole monorepos.
+const defaultMaxAnalyzeNodes = 5000
+
+// Output formats accepted by -format.
+const (
+	formatMermaid   = "mermaid"
+	formatJSON      = "json"
+	formatGraphJSON = "graphjson"
+	formatDOT       = "dot"
+	formatD2        = "d2"
+	formatPlantUML  = "plantuml"
+	formatGraphML   = "graphml"
+	formatSVG       = "svg"
+	formatMatrixCSV = "matrix-csv"
+	formatMatrixMD  = "matrix-md"
+)
+
+// sourceGo is the default -source: Go packages analyzed with go/packages.
+const sourceGo = "go"
+
+// newSource returns the analyzer.Source selected by -source.
+func newSource(name string, opts analyzer.AnalyzeOptions, logger *slog.Logger) (analyzer.Source, error) {
+	switch name {
+	case sourceGo:
+		return analyzer.NewGoSource(opts, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown source %q: want %s", name, sourceGo)
+	}
+}
+
+// Splitting strategies accepted by -split-strategy.
+const (
+	splitHubSpoke   = "hub-spoke"
+	splitByPackage  = "by-package"
+	splitComponents = "components"
+)
+
+// newSplitter returns the split.Splitter selected by -split-strategy,
+// configured with opts (-hub-threshold, -chunk-size).
+func newSplitter(name string, opts split.Options) (split.Splitter, error) {
+	switch name {
+	case splitHubSpoke:
+		return split.NewHubAndSpoke(opts), nil
+	case splitByPackage:
+		return split.NewByPackage(opts), nil
+	case splitComponents:
+		return split.NewConnectedComponents(opts), nil
+	default:
+		return nil, fmt.Errorf("unknown split strategy %q: want %s, %s or %s", name, splitHubSpoke, splitByPackage, splitComponents)
+	}
+}
+
+// Grouping strategies accepted by -grouper.
+const (
+	grouperPackage   = "package"
+	grouperHeuristic = "heuristic"
+)
+
+// newGrouper returns the enricher.Grouper selected by -grouper.
+func newGrouper(name string) (enricher.Grouper, error) {
+	switch name {
+	case grouperPackage:
+		return enricher.NewDefaultGrouper(), nil
+	case grouperHeuristic:
+		return enricher.NewHeuristicGrouper(), nil
+	default:
+		return nil, fmt.Errorf("unknown grouper %q: want %s or %s", name, grouperPackage, grouperHeuristic)
+	}
+}
+
+// diagramPatterns converts the detected patterns for DiagramOptions.Patterns.
+// The result is never nil, so the Patterns tab is shown even when nothing was
+// detected.
+func diagramPatterns(patterns []enricher.DetectedPattern) []diagram.Pattern {
+	out := make([]diagram.Pattern, 0, len(patterns))
+	for _, p := range patterns {
+		out = append(out, diagram.Pattern{Name: p.Name, Description: p.Description, Participants: p.Participants})
+	}
+	return out
+}
+
+// exitEmpty is the exit status with -fail-on-empty when no implementation
+// relationships are left to diagram, kept apart from the status 1 of
+// analysis errors.
+const exitEmpty = 2
+
+// Package map renderings accepted by -package-map.
+const (
+	packageMapMermaid = "mermaid"
+	packageMapText    = "text"
+)
+
+// isDirOutput reports whether -output names a directory (trailing slash or an
+// existing directory), which selects multi-file Markdown book output.
+func isDirOutput(path string) bool {
+	if strings.HasSuffix(path, "/") || strings.HasSuffix(path, string(os.PathSeparator)) {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
 // reorderArgs separates flags and positional arguments so flags can appear
 // in any position (before or after the positional path argument).
 // Flags that take a value (e.g., -output file.md) consume the next arg.
 func reorderArgs(args []string) (flags, positional []string) {
 	// Set of flags that take a value argument
 	valueFlagSet := map[string]bool{
-		"-path": true, "-port": true, "-filter": true,
+		"-path": true, "-subdir": true, "-port": true, "-load-timeout": true, "-filter": true, "-exclude": true,
 		"-output": true, "-log-file": true, "-log-level": true,
+		"-cache-max-size": true, "-cache-dir": true, "-git-token": true, "-git-host": true, "-treemap-max-nodes": true,
+		"-enrich-timeout": true, "-enrich-concurrency": true,
+		"-llm-format": true, "-llm-model": true, "-llm-temperature": true, "-min-relation-score": true,
+		"-what-implements": true, "-report": true, "-report-top": true, "-style-file": true, "-embed-origin": true, "-log-format": true, "-format": true, "-package-map": true, "-source": true,
+		"-max-analyze-nodes": true, "-max-packages": true, "-min-connections": true, "-min-implementers": true, "-coverage": true, "-coverage-file": true,
+		"-build-flag": true, "-goos": true, "-goarch": true, "-split-strategy": true, "-hub-threshold": true, "-chunk-size": true, "-grouper": true, "-config": true, "-direction": true,
+		"-theme": true, "-focus": true, "-focus-depth": true,
 	}
 
 	for i := 0; i < len(args); i++ {
@@ -203,27 +1044,14 @@ func reorderArgs(args []string) (flags, positional []string) {
 	return flags, positional
 }
 
-func buildLLMClient(logger *slog.Logger) (*llm.Client, error) {
-	endpoint := os.Getenv("GOIFACES_LLM_ENDPOINT")
-	if endpoint == "" {
-		endpoint = "https://api.openai.com/v1"
-	}
-	apiKey := os.Getenv("GOIFACES_LLM_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("GOIFACES_LLM_API_KEY environment variable is required when --enrich is enabled")
-	}
-	model := os.Getenv("GOIFACES_LLM_MODEL")
-	if model == "" {
-		model = "gpt-4o-mini"
-	}
+// stringList is a repeatable string flag.
+type stringList []string
 
-	cfg := llm.Config{
-		Endpoint: endpoint,
-		APIKey:   apiKey,
-		Model:    model,
-		Timeout:  30 * time.Second,
-	}
-	return llm.NewClient(cfg, logger), nil
+func (l *stringList) String() string { return strings.Join(*l, " ") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
 }
 
 func parseLogLevel(s string) (slog.Level, error) {
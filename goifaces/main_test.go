@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runMainEnv makes the test binary run main instead of the tests, so that a
+// test can drive the CLI end to end, exit status included.
+const runMainEnv = "RUN_GOIFACES_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// goifacesCmd returns the CLI run with args, logging and caching into a
+// temporary directory.
+func goifacesCmd(t *testing.T, args ...string) *exec.Cmd {
+	t.Helper()
+	tmp := t.TempDir()
+	args = append([]string{"-log-file", filepath.Join(tmp, "goifaces.log"), "-cache-dir", filepath.Join(tmp, "cache"), "-no-cache"}, args...)
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), runMainEnv+"=1")
+	return cmd
+}
+
+// writeModule writes a module with one interface and one implementation.
+// It imports nothing, so loading needs no standard library export data.
+func writeModule(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/loaded\n\ngo 1.21\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "store.go"), []byte(`package store
+
+type Store interface {
+	Get() int
+}
+
+type Mem struct{}
+
+func (Mem) Get() int { return 0 }
+`), 0o644))
+	return dir
+}
+
+func TestServeLandingPageWithoutInput(t *testing.T) {
+	cmd := goifacesCmd(t, "-no-browser", "-port", "0")
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	urls := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			if url, ok := strings.CutPrefix(scanner.Text(), "Starting server on "); ok {
+				urls <- url
+			}
+		}
+		_, _ = io.Copy(io.Discard, stdout)
+	}()
+	var url string
+	select {
+	case url = <-urls:
+	case <-time.After(30 * time.Second):
+		t.Fatal("server did not start")
+	}
+
+	get := func() string {
+		resp, err := http.Get(url + "/")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+	require.Contains(t, get(), "fetch('/api/load'", "with no input the landing page is served")
+
+	dir := writeModule(t)
+	body := `{"path":"` + filepath.ToSlash(dir) + `"}`
+
+	// Another site's page may POST text/plain without a preflight
+	req, err := http.NewRequest(http.MethodPost, url+"/api/load", strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("Origin", "https://evil.example")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	require.Contains(t, get(), "fetch('/api/load'", "a cross-origin load is refused")
+
+	resp, err = http.Post(url+"/api/load", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	respBody, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(respBody))
+
+	page := get()
+	assert.NotContains(t, page, "fetch('/api/load'", "the loaded path replaces the landing page")
+	assert.Contains(t, page, "store_Mem")
+}
+
+func TestNoInputOutsideServerMode(t *testing.T) {
+	for _, args := range [][]string{{"-output", "out.mmd"}, {"-watch"}, {"-fail-on-empty"}} {
+		err := goifacesCmd(t, args...).Run()
+		var exitErr *exec.ExitError
+		require.True(t, errors.As(err, &exitErr), "%v: %v", args, err)
+		assert.Equal(t, 1, exitErr.ExitCode(), "%v prints the usage", args)
+	}
+}
+
+func TestCacheClearAlias(t *testing.T) {
+	for _, flag := range []string{"-cache-clear", "-clear-cache"} {
+		out, err := goifacesCmd(t, flag).Output()
+		require.NoError(t, err, "%s without an input clears and exits 0", flag)
+		assert.Contains(t, string(out), "Cleared clone cache", flag)
+	}
+
+	// With an input the flag is a usage error: nothing is cleared or analyzed
+	dir := writeModule(t)
+	cacheDir := t.TempDir()
+	clone := filepath.Join(cacheDir, "clone")
+	require.NoError(t, os.Mkdir(clone, 0o755))
+	output := filepath.Join(t.TempDir(), "out.mmd")
+	out, err := goifacesCmd(t, "-cache-clear", "-cache-dir", cacheDir, "-output", output, dir).CombinedOutput()
+	var exitErr *exec.ExitError
+	require.True(t, errors.As(err, &exitErr), "%v: %s", err, out)
+	assert.Equal(t, 1, exitErr.ExitCode())
+	assert.Contains(t, string(out), "-cache-clear clears the cache and exits")
+	assert.NotContains(t, string(out), "Cleared clone cache")
+	assert.DirExists(t, clone, "the cache is not cleared")
+	assert.NoFileExists(t, output, "the input is not analyzed")
+}
+
+func TestFailOnEmpty(t *testing.T) {
+	dir := writeModule(t)
+	exitCode := func(t *testing.T, cmd *exec.Cmd) int {
+		t.Helper()
+		err := cmd.Run()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		require.NoError(t, err)
+		return 0
+	}
+	output := func(t *testing.T) string { return filepath.Join(t.TempDir(), "out.json") }
+
+	t.Run("relationships found", func(t *testing.T) {
+		assert.Equal(t, 0, exitCode(t, goifacesCmd(t, "-fail-on-empty", "-format", "json", "-output", output(t), dir)))
+	})
+	t.Run("filtered out", func(t *testing.T) {
+		args := []string{"-filter", "example.com/elsewhere", "-format", "json", "-output", output(t), dir}
+		assert.Equal(t, 0, exitCode(t, goifacesCmd(t, args...)), "without the flag an empty result succeeds")
+		assert.Equal(t, exitEmpty, exitCode(t, goifacesCmd(t, append([]string{"-fail-on-empty"}, args...)...)))
+	})
+	t.Run("no packages", func(t *testing.T) {
+		empty := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(empty, "go.mod"), []byte("module example.com/empty\n\ngo 1.21\n"), 0o644))
+		assert.Equal(t, exitEmpty, exitCode(t, goifacesCmd(t, "-fail-on-empty", "-format", "json", "-output", output(t), empty)))
+	})
+	t.Run("pruned by score", func(t *testing.T) {
+		// Every LLM answer scores relation 0 at 0, below -min-relation-score
+		llmServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = io.WriteString(w, `{"choices":[{"message":{"content":"{\"scores\":{\"0\":0}}"}}]}`)
+		}))
+		defer llmServer.Close()
+		run := func(args ...string) int {
+			cmd := goifacesCmd(t, append([]string{"-enrich", "-format", "json", "-output", output(t)}, append(args, dir)...)...)
+			cmd.Env = append(cmd.Env, "GOIFACES_LLM_API_KEY=test", "GOIFACES_LLM_ENDPOINT="+llmServer.URL)
+			return exitCode(t, cmd)
+		}
+		assert.Equal(t, 0, run())
+		assert.Equal(t, exitEmpty, run("-fail-on-empty"))
+	})
+	t.Run("analysis error", func(t *testing.T) {
+		assert.Equal(t, 1, exitCode(t, goifacesCmd(t, "-fail-on-empty", filepath.Join(t.TempDir(), "missing"))))
+	})
+}
+
+func TestMatchCacheUnderCacheDir(t *testing.T) {
+	dir := writeModule(t)
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+	run := func(args ...string) {
+		t.Helper()
+		cmd := goifacesCmd(t, append([]string{"-cache-dir", cacheDir}, args...)...)
+		// A home directory that cannot be written, as on some CI runners
+		cmd.Env = append(cmd.Env, "HOME="+filepath.Join(t.TempDir(), "missing"))
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	run("-match-cache", "-format", "json", "-output", filepath.Join(t.TempDir(), "out.json"), dir)
+	files, err := filepath.Glob(filepath.Join(cacheDir, "matches", "*.json"))
+	require.NoError(t, err)
+	assert.Len(t, files, 1, "the match cache is kept under -cache-dir")
+
+	run("-cache-clear")
+	_, err = os.Stat(filepath.Join(cacheDir, "matches"))
+	assert.True(t, errors.Is(err, os.ErrNotExist), "-cache-clear removes the match cache: %v", err)
+}
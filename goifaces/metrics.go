@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+)
+
+// reportMetrics is the -report mode listing the nodes with the most
+// implementation relations.
+const reportMetrics = "metrics"
+
+// nodeMetric is one line of the -report metrics listing.
+type nodeMetric struct {
+	key     string // "pkgPath.Name"
+	id      string // diagram node ID
+	metrics analyzer.NodeMetrics
+}
+
+// topNodeMetrics pairs metrics with the diagram node IDs in ids and returns
+// the top nodes by degree, ties broken by key; top <= 0 keeps them all.
+func topNodeMetrics(metrics map[string]analyzer.NodeMetrics, ids map[string]string, top int) []nodeMetric {
+	nodes := make([]nodeMetric, 0, len(metrics))
+	for key, m := range metrics {
+		nodes = append(nodes, nodeMetric{key: key, id: ids[key], metrics: m})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if di, dj := nodes[i].metrics.Degree(), nodes[j].metrics.Degree(); di != dj {
+			return di > dj
+		}
+		return nodes[i].key < nodes[j].key
+	})
+	if top > 0 && len(nodes) > top {
+		nodes = nodes[:top]
+	}
+	return nodes
+}
+
+// writeMetricsReport prints the -report metrics listing: per node its degree
+// (implementers of an interface, interfaces implemented by a type), kind,
+// diagram node ID and full name. The builtin error interface is marked.
+func writeMetricsReport(w io.Writer, nodes []nodeMetric, total int) {
+	if len(nodes) == 0 {
+		fmt.Fprintln(w, "No interfaces or types in scope")
+		return
+	}
+	fmt.Fprintf(w, "Top %d of %d nodes by degree (implementers of an interface, interfaces a type implements):\n", len(nodes), total)
+	idWidth := 0
+	for _, n := range nodes {
+		idWidth = max(idWidth, len(n.id))
+	}
+	for _, n := range nodes {
+		kind := "type"
+		if n.metrics.IsInterface {
+			kind = "interface"
+		}
+		fmt.Fprintf(w, "  %4d  %-9s  %-*s  %s", n.metrics.Degree(), kind, idWidth, n.id, n.key)
+		if n.metrics.Builtin {
+			fmt.Fprint(w, "  (builtin)")
+		}
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,18 @@
+package goifaces
+
+import "github.com/olehluchkiv/goifaces/internal/analyzer"
+
+// Sentinel errors returned (wrapped) by Analyze. Check with errors.Is.
+var (
+	// ErrNoPackages means the directory contains no Go packages.
+	ErrNoPackages = analyzer.ErrNoPackages
+	// ErrTooManyNodes means more interfaces and types than Options.MaxNodes
+	// were found.
+	ErrTooManyNodes = analyzer.ErrTooManyNodes
+	// ErrTooManyPackages means more packages than Options.MaxPackages were
+	// loaded.
+	ErrTooManyPackages = analyzer.ErrTooManyPackages
+	// ErrUnsupportedPlatform means Options.GOOS and Options.GOARCH name a
+	// pair the go toolchain cannot target.
+	ErrUnsupportedPlatform = analyzer.ErrUnsupportedPlatform
+)
@@ -0,0 +1,216 @@
+// Package goifaces is the library form of the goifaces command: it finds the
+// interfaces of a Go module, the types that implement them, and renders the
+// result as a Mermaid class diagram.
+//
+//	graph, err := goifaces.Analyze(ctx, "./my-project", goifaces.Options{})
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(graph.Mermaid(goifaces.DefaultDiagramOptions()))
+//
+// Analysis and filtering match the CLI; LLM enrichment, slides and the
+// interactive server are not part of the library.
+package goifaces
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+	"github.com/olehluchkiv/goifaces/internal/diagram"
+)
+
+// Options controls which packages and declarations are analyzed. The zero
+// value matches the CLI defaults. Each field has the same meaning as the
+// CLI flag of the same name.
+type Options struct {
+	Filter            string   // package path prefix (-filter)
+	Exclude           []string // package path substrings or globs dropped after Filter (-exclude)
+	IncludeStdlib     bool     // keep standard library interfaces (-include-stdlib)
+	IncludeUnexported bool     // keep unexported interfaces and types (-include-unexported)
+	PublicInterfaces  bool     // exported interfaces with all their implementers (-public-interfaces)
+	IncludeTests      bool     // analyze _test.go files (-include-tests)
+	ExcludeFuncTypes  bool     // drop named function types such as HandlerFunc (-func-types=false)
+	MaxNodes          int      // fail with ErrTooManyNodes above this many nodes; 0 = no limit
+	MaxPackages       int      // fail with ErrTooManyPackages above this many loaded packages (-max-packages); 0 = no limit
+	BuildFlags        []string // passed verbatim to the go command (-build-flag)
+	GOOS              string   // target OS for build constraints (-goos); "" = host
+	GOARCH            string   // target architecture for build constraints (-goarch); "" = host
+	Logger            *slog.Logger
+	// Progress, when set, is called as Analyze moves through its phases
+	// (PhaseLoading, PhaseCollecting, PhaseMatching), each from done = 0 to
+	// done = total.
+	Progress func(phase string, done, total int)
+}
+
+// Phases reported through Options.Progress, in order.
+const (
+	PhaseLoading    = analyzer.PhaseLoading
+	PhaseCollecting = analyzer.PhaseCollecting
+	PhaseMatching   = analyzer.PhaseMatching
+)
+
+// analyzeOptions maps o onto the analyzer's options.
+func (o Options) analyzeOptions() analyzer.AnalyzeOptions {
+	return analyzer.AnalyzeOptions{
+		Filter:            o.Filter,
+		Exclude:           o.Exclude,
+		IncludeStdlib:     o.IncludeStdlib,
+		IncludeUnexported: o.IncludeUnexported,
+		PublicInterfaces:  o.PublicInterfaces,
+		IncludeTests:      o.IncludeTests,
+		ExcludeFuncTypes:  o.ExcludeFuncTypes,
+		MaxNodes:          o.MaxNodes,
+		MaxPackages:       o.MaxPackages,
+		BuildFlags:        o.BuildFlags,
+		GOOS:              o.GOOS,
+		GOARCH:            o.GOARCH,
+		Progress:          o.Progress,
+	}
+}
+
+// DiagramOptions controls Mermaid rendering; see Graph.Mermaid.
+type DiagramOptions = diagram.DiagramOptions
+
+// Layout directions for DiagramOptions.Direction.
+const (
+	DirectionLR = diagram.DirectionLR
+	DirectionRL = diagram.DirectionRL
+	DirectionTB = diagram.DirectionTB
+	DirectionBT = diagram.DirectionBT
+)
+
+// DefaultDiagramOptions returns the options the CLI renders with.
+func DefaultDiagramOptions() DiagramOptions {
+	return diagram.DefaultDiagramOptions()
+}
+
+// Palette and NodeColors set the node colors through
+// DiagramOptions.Palette.
+type (
+	Palette    = diagram.Palette
+	NodeColors = diagram.NodeColors
+)
+
+// LoadTheme returns a built-in palette by name, or one read from a .json
+// palette file, as the CLI's -theme flag does.
+func LoadTheme(theme string) (Palette, error) {
+	return diagram.LoadTheme(theme)
+}
+
+// Graph is the analysis result: interfaces, concrete types and the
+// implementation relations between them, sorted by package path and name.
+type Graph struct {
+	ModulePath string
+	Interfaces []Interface
+	Types      []Type
+	Relations  []Relation
+
+	result *analyzer.Result
+}
+
+// Interface is an analyzed interface.
+type Interface struct {
+	Name        string
+	Package     string // import path
+	PackageName string
+	Methods     []Method
+	SourceFile  string // relative to the module root
+}
+
+// Type is an analyzed concrete type.
+type Type struct {
+	Name        string
+	Package     string // import path
+	PackageName string
+	IsFunc      bool // a named function type such as http.HandlerFunc
+	Methods     []Method
+	SourceFile  string // relative to the module root
+}
+
+// Method is a method name and its Go signature.
+type Method struct {
+	Name      string
+	Signature string
+}
+
+// Relation records that Type implements Interface. Both are "pkgPath.Name"
+// keys; ViaPointer is set when only *Type has the method set.
+type Relation struct {
+	Type       string
+	Interface  string
+	ViaPointer bool
+}
+
+// Analyze loads the Go module in dir, matches types to interfaces and
+// applies the filters in opts. It returns ErrNoPackages when dir holds no Go
+// packages, ErrTooManyPackages when opts.MaxPackages is exceeded,
+// ErrTooManyNodes when opts.MaxNodes is exceeded and
+// ErrUnsupportedPlatform for an unknown opts.GOOS/GOARCH pair.
+func Analyze(ctx context.Context, dir string, opts Options) (*Graph, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	aopts := opts.analyzeOptions()
+	result, err := analyzer.NewGoSource(aopts, logger).Collect(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	return newGraph(analyzer.Filter(result, aopts)), nil
+}
+
+// Mermaid renders the graph as a Mermaid classDiagram.
+func (g *Graph) Mermaid(opts DiagramOptions) string {
+	return diagram.GenerateMermaid(g.result, opts)
+}
+
+// newGraph copies result into the exported Graph form.
+func newGraph(result *analyzer.Result) *Graph {
+	g := &Graph{ModulePath: result.ModulePath, result: result}
+	for _, iface := range result.Interfaces {
+		g.Interfaces = append(g.Interfaces, Interface{
+			Name: iface.Name, Package: iface.PkgPath, PackageName: iface.PkgName,
+			Methods: methods(iface.Methods), SourceFile: iface.SourceFile,
+		})
+	}
+	for _, typ := range result.Types {
+		g.Types = append(g.Types, Type{
+			Name: typ.Name, Package: typ.PkgPath, PackageName: typ.PkgName, IsFunc: typ.IsFunc,
+			Methods: methods(typ.Methods), SourceFile: typ.SourceFile,
+		})
+	}
+	for _, rel := range result.Relations {
+		g.Relations = append(g.Relations, Relation{
+			Type:       rel.Type.PkgPath + "." + rel.Type.Name,
+			Interface:  rel.Interface.PkgPath + "." + rel.Interface.Name,
+			ViaPointer: rel.ViaPointer,
+		})
+	}
+	sort.Slice(g.Interfaces, func(i, j int) bool {
+		return lessKey(g.Interfaces[i].Package, g.Interfaces[i].Name, g.Interfaces[j].Package, g.Interfaces[j].Name)
+	})
+	sort.Slice(g.Types, func(i, j int) bool {
+		return lessKey(g.Types[i].Package, g.Types[i].Name, g.Types[j].Package, g.Types[j].Name)
+	})
+	sort.Slice(g.Relations, func(i, j int) bool {
+		return lessKey(g.Relations[i].Type, g.Relations[i].Interface, g.Relations[j].Type, g.Relations[j].Interface)
+	})
+	return g
+}
+
+func methods(sigs []analyzer.MethodSig) []Method {
+	out := make([]Method, len(sigs))
+	for i, m := range sigs {
+		out[i] = Method{Name: m.Name, Signature: m.Signature}
+	}
+	return out
+}
+
+func lessKey(aFirst, aSecond, bFirst, bSecond string) bool {
+	if aFirst != bFirst {
+		return aFirst < bFirst
+	}
+	return aSecond < bSecond
+}
@@ -0,0 +1,89 @@
+package goifaces_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/olehluchkiv/goifaces/pkg/goifaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testdataDir(name string) string {
+	return filepath.Join("..", "..", "testdata", name)
+}
+
+func TestAnalyze(t *testing.T) {
+	graph, err := goifaces.Analyze(context.Background(), testdataDir("02_multi_impl"), goifaces.Options{})
+	require.NoError(t, err)
+
+	require.Len(t, graph.Interfaces, 1)
+	speaker := graph.Interfaces[0]
+	assert.Equal(t, "Speaker", speaker.Name)
+	assert.Equal(t, "animals", speaker.PackageName)
+	assert.Equal(t, []goifaces.Method{{Name: "Speak", Signature: "Speak() string"}}, speaker.Methods)
+
+	var typeNames []string
+	for _, typ := range graph.Types {
+		typeNames = append(typeNames, typ.Name)
+	}
+	assert.Equal(t, []string{"Cat", "Dog"}, typeNames)
+
+	key := speaker.Package + ".Speaker"
+	assert.Equal(t, []goifaces.Relation{
+		{Type: graph.Types[0].Package + ".Cat", Interface: key},
+		{Type: graph.Types[1].Package + ".Dog", Interface: key},
+	}, graph.Relations)
+
+	mermaid := graph.Mermaid(goifaces.DefaultDiagramOptions())
+	assert.Contains(t, mermaid, "classDiagram")
+	assert.Contains(t, mermaid, "animals_Dog --|> animals_Speaker")
+	assert.Contains(t, mermaid, "animals_Cat --|> animals_Speaker")
+	assert.NotContains(t, mermaid, "Fish")
+}
+
+func TestAnalyzeMaxNodes(t *testing.T) {
+	_, err := goifaces.Analyze(context.Background(), testdataDir("02_multi_impl"), goifaces.Options{MaxNodes: 1})
+	assert.True(t, errors.Is(err, goifaces.ErrTooManyNodes), "err = %v", err)
+}
+
+func TestAnalyzeProgress(t *testing.T) {
+	var phases []string
+	opts := goifaces.Options{Progress: func(phase string, done, total int) {
+		if done == total {
+			phases = append(phases, phase)
+		}
+	}}
+	_, err := goifaces.Analyze(context.Background(), testdataDir("02_multi_impl"), opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{goifaces.PhaseLoading, goifaces.PhaseCollecting, goifaces.PhaseMatching}, phases)
+}
+
+func TestAnalyzeMaxPackages(t *testing.T) {
+	_, err := goifaces.Analyze(context.Background(), testdataDir("02_multi_impl"), goifaces.Options{MaxPackages: 1})
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod": "module example.com/two\n\ngo 1.21\n",
+		"a/a.go": "package a\n",
+		"b/b.go": "package b\n",
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	_, err = goifaces.Analyze(context.Background(), dir, goifaces.Options{MaxPackages: 1})
+	assert.True(t, errors.Is(err, goifaces.ErrTooManyPackages), "err = %v", err)
+}
+
+func TestAnalyzeNoPackages(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/empty\n\ngo 1.21\n"), 0o644))
+
+	_, err := goifaces.Analyze(context.Background(), dir, goifaces.Options{})
+	assert.True(t, errors.Is(err, goifaces.ErrNoPackages), "err = %v", err)
+}
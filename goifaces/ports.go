@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+)
+
+// maxListedPorts caps how many port names the summary prints inline.
+const maxListedPorts = 10
+
+// writePortSummary prints how the classified interfaces split into ports
+// (implemented only outside their package), same-package and mixed ones,
+// naming the first few ports.
+func writePortSummary(w io.Writer, kinds map[string]analyzer.PortKind) {
+	var ports []string
+	var samePkg, mixed int
+	for key, kind := range kinds {
+		switch kind {
+		case analyzer.PortKindPort:
+			ports = append(ports, key)
+		case analyzer.PortKindSamePackage:
+			samePkg++
+		case analyzer.PortKindMixed:
+			mixed++
+		}
+	}
+	if len(ports)+samePkg+mixed == 0 {
+		return
+	}
+	sort.Strings(ports)
+	fmt.Fprintf(w, "Ports: %d implemented only in other packages, %d only in their own package, %d mixed\n",
+		len(ports), samePkg, mixed)
+	if len(ports) == 0 {
+		return
+	}
+	listed := ports
+	if len(listed) > maxListedPorts {
+		listed = listed[:maxListedPorts]
+	}
+	fmt.Fprintf(w, "  %s", strings.Join(listed, ", "))
+	if extra := len(ports) - len(listed); extra > 0 {
+		fmt.Fprintf(w, " (+%d more)", extra)
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+)
+
+// progressWriter carries the status lines of a run: "Resolving input...",
+// the summary lines and the final "Wrote ... to ..." confirmation. Normally
+// they go to w; with -quiet each complete line is logged at info level
+// instead, so it reaches the log file but not the terminal.
+type progressWriter struct {
+	w      io.Writer
+	quiet  bool
+	logger *slog.Logger
+	buf    bytes.Buffer // partial line awaiting its newline, quiet only
+}
+
+func newProgressWriter(w io.Writer, quiet bool, logger *slog.Logger) *progressWriter {
+	return &progressWriter{w: w, quiet: quiet, logger: logger.With("component", "progress")}
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	if !p.quiet {
+		return p.w.Write(b)
+	}
+	p.buf.Write(b)
+	for {
+		line, err := p.buf.ReadString('\n')
+		if err != nil {
+			// No newline yet: keep the fragment for the next write
+			p.buf.Reset()
+			p.buf.WriteString(line)
+			return len(b), nil
+		}
+		if msg := strings.TrimSpace(line); msg != "" {
+			p.logger.Info(msg)
+		}
+	}
+}
+
+// analysisProgress renders the analyzer's phases (AnalyzeOptions.Progress)
+// on w. Each phase gets a line when it starts; with inPlace (a terminal) the
+// collecting and matching lines then show a percentage, rewritten with a
+// carriage return. Elsewhere only the start lines are written, so pipes and
+// -quiet logs get no partial lines. Concurrent analyses (-watch rebuilds,
+// landing page loads) share it safely.
+func analysisProgress(w io.Writer, inPlace bool) func(phase string, done, total int) {
+	var mu sync.Mutex
+	var label string // of the phase whose line is still open
+	var pct int
+	return func(phase string, done, total int) {
+		mu.Lock()
+		defer mu.Unlock()
+		if done == 0 {
+			if label != "" {
+				fmt.Fprintln(w)
+			}
+			label, pct = phaseLabel(phase, total), -1
+			if !inPlace || phase == analyzer.PhaseLoading {
+				fmt.Fprintln(w, label)
+				label = ""
+				return
+			}
+		}
+		if label == "" {
+			return
+		}
+		p := 100
+		if total > 0 {
+			p = done * 100 / total
+		}
+		if p != pct {
+			pct = p
+			fmt.Fprintf(w, "\r%s %3d%%", label, p)
+		}
+		if done == total {
+			fmt.Fprintln(w)
+			label = ""
+		}
+	}
+}
+
+// phaseLabel is the progress line of an analyzer phase with total steps.
+func phaseLabel(phase string, total int) string {
+	switch phase {
+	case analyzer.PhaseLoading:
+		return "Loading packages..."
+	case analyzer.PhaseCollecting:
+		return fmt.Sprintf("Collecting interfaces and types (%d packages)...", total)
+	case analyzer.PhaseMatching:
+		return fmt.Sprintf("Matching %d types against the interfaces...", total)
+	}
+	return phase + "..."
+}
+
+// isTerminal reports whether w is a character device, such as a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressWriter_PrintsByDefault(t *testing.T) {
+	var out, logs bytes.Buffer
+	p := newProgressWriter(&out, false, slog.New(slog.NewTextHandler(&logs, nil)))
+
+	fmt.Fprintln(p, "Resolving input...")
+	fmt.Fprintf(p, "Wrote diagram to %s\n", "out.mmd")
+
+	assert.Equal(t, "Resolving input...\nWrote diagram to out.mmd\n", out.String())
+	assert.Empty(t, logs.String())
+}
+
+func TestProgressWriter_QuietLogsLines(t *testing.T) {
+	var out, logs bytes.Buffer
+	p := newProgressWriter(&out, true, slog.New(slog.NewTextHandler(&logs, nil)))
+
+	fmt.Fprintln(p, "Resolving input...")
+	// writeUnusedSummary builds its last line from several writes
+	fmt.Fprint(p, "  a.B, a.C")
+	fmt.Fprint(p, " (+2 more)")
+	assert.NotContains(t, logs.String(), "a.B", "partial line logged early")
+	fmt.Fprintln(p)
+	fmt.Fprint(p, "\n")
+
+	assert.Empty(t, out.String())
+	assert.Contains(t, logs.String(), `level=INFO msg="Resolving input..." component=progress`)
+	assert.Contains(t, logs.String(), `msg="a.B, a.C (+2 more)"`)
+	assert.Equal(t, 2, bytes.Count(logs.Bytes(), []byte("\n")), "blank lines are not logged")
+}
+
+func TestAnalysisProgress(t *testing.T) {
+	run := func(inPlace bool) string {
+		var out bytes.Buffer
+		progress := analysisProgress(&out, inPlace)
+		progress(analyzer.PhaseLoading, 0, 1)
+		progress(analyzer.PhaseLoading, 1, 1)
+		progress(analyzer.PhaseCollecting, 0, 2)
+		progress(analyzer.PhaseCollecting, 1, 2)
+		progress(analyzer.PhaseCollecting, 2, 2)
+		progress(analyzer.PhaseMatching, 0, 3)
+		progress(analyzer.PhaseMatching, 1, 3)
+		progress(analyzer.PhaseMatching, 3, 3)
+		return out.String()
+	}
+
+	assert.Equal(t, "Loading packages...\n"+
+		"Collecting interfaces and types (2 packages)...\n"+
+		"Matching 3 types against the interfaces...\n", run(false), "no partial lines off a terminal")
+
+	assert.Equal(t, "Loading packages...\n"+
+		"\rCollecting interfaces and types (2 packages)...   0%"+
+		"\rCollecting interfaces and types (2 packages)...  50%"+
+		"\rCollecting interfaces and types (2 packages)... 100%\n"+
+		"\rMatching 3 types against the interfaces...   0%"+
+		"\rMatching 3 types against the interfaces...  33%"+
+		"\rMatching 3 types against the interfaces... 100%\n", run(true))
+}
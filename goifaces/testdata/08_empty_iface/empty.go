@@ -4,4 +4,14 @@ type Any interface{}
 
 type Marker interface{}
 
+// Aliases of the empty interface are markers too
+type Value = any
+
+type Payload = interface{}
+
+// A constraint without methods is not a marker
+type Number interface {
+	~int | ~float64
+}
+
 type Foo struct{}
@@ -0,0 +1,21 @@
+package factory
+
+type Named interface {
+	Name() string
+}
+
+type Gadget struct{}
+
+func (g *Gadget) Name() string {
+	return "gadget"
+}
+
+type Factory interface {
+	Make() *Gadget
+}
+
+type GadgetFactory struct{}
+
+func (f GadgetFactory) Make() *Gadget {
+	return &Gadget{}
+}
@@ -0,0 +1,24 @@
+package handler
+
+type Request struct {
+	Path string
+}
+
+type ResponseWriter interface {
+	Write(b []byte) (int, error)
+}
+
+type Handler interface {
+	ServeHTTP(w ResponseWriter, r *Request)
+}
+
+// HandlerFunc adapts an ordinary function to the Handler interface.
+type HandlerFunc func(w ResponseWriter, r *Request)
+
+func (f HandlerFunc) ServeHTTP(w ResponseWriter, r *Request) {
+	f(w, r)
+}
+
+type Mux struct{}
+
+func (m *Mux) ServeHTTP(w ResponseWriter, r *Request) {}
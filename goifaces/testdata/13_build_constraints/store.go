@@ -0,0 +1,6 @@
+package store
+
+// Store is implemented by a platform-specific type.
+type Store interface {
+	Path() string
+}
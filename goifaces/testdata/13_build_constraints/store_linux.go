@@ -0,0 +1,6 @@
+package store
+
+// LinuxStore is only built for GOOS=linux.
+type LinuxStore struct{}
+
+func (LinuxStore) Path() string { return "/var/lib/app" }
@@ -0,0 +1,8 @@
+//go:build windows
+
+package store
+
+// WindowsStore is only built for GOOS=windows.
+type WindowsStore struct{}
+
+func (WindowsStore) Path() string { return `C:\ProgramData\app` }
@@ -0,0 +1,23 @@
+package store
+
+// Store is implemented by Mem.
+type Store interface {
+	Get(key string) string
+}
+
+// Mem is an in-memory Store.
+type Mem struct{}
+
+func (Mem) Get(key string) string { return "" }
+
+// Cache is an alias of Mem; it must not become a second implementer.
+type Cache = Mem
+
+// Repository is an alias of the Store interface, not a new interface.
+type Repository = Store
+
+// Bytes aliases an unnamed type, so it has no node to link to.
+type Bytes = []byte
+
+// memAlias is an unexported alias, hidden like other unexported names.
+type memAlias = Mem
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+	"strings"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+)
+
+// reportUnimplemented is the -report mode listing interfaces nothing implements.
+const reportUnimplemented = "unimplemented"
+
+// unimplementedInScope narrows analyzer.UnimplementedInterfaces to what the
+// diagram would show: interfaces under -filter outside the -exclude patterns,
+// and unexported ones only with -include-unexported (never with
+// -public-interfaces). Filter drops these interfaces as orphans, so this runs
+// on the unfiltered result.
+func unimplementedInScope(result *analyzer.Result, opts analyzer.AnalyzeOptions) []analyzer.InterfaceDef {
+	var out []analyzer.InterfaceDef
+	for _, iface := range analyzer.UnimplementedInterfaces(result) {
+		if !strings.HasPrefix(iface.PkgPath, opts.Filter) || analyzer.Excluded(iface.PkgPath, opts.Exclude) {
+			continue
+		}
+		if !token.IsExported(iface.Name) && (!opts.IncludeUnexported || opts.PublicInterfaces) {
+			continue
+		}
+		out = append(out, iface)
+	}
+	return out
+}
+
+// writeUnimplementedReport prints the -report unimplemented listing: one
+// interface per line with the file that declares it.
+func writeUnimplementedReport(w io.Writer, ifaces []analyzer.InterfaceDef) {
+	if len(ifaces) == 0 {
+		fmt.Fprintln(w, "Every interface in scope has an implementation")
+		return
+	}
+	noun := "interfaces have"
+	if len(ifaces) == 1 {
+		noun = "interface has"
+	}
+	fmt.Fprintf(w, "%d %s no implementations:\n", len(ifaces), noun)
+	for _, iface := range ifaces {
+		fmt.Fprintf(w, "  %s.%s", iface.PkgPath, iface.Name)
+		if iface.SourceFile != "" {
+			fmt.Fprintf(w, "  (%s)", iface.SourceFile)
+		}
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxListedUnused caps how many unused export names the summary prints inline.
+const maxListedUnused = 10
+
+// writeUnusedSummary prints the exported types and interfaces nothing in the
+// module refers to. The hint is advisory: callers outside the module are not
+// visible, so a library's public API shows up here too.
+func writeUnusedSummary(w io.Writer, unused []string) {
+	if len(unused) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Unused exports: %d exported types/interfaces are never referenced in the module (advisory: external callers are not visible)\n",
+		len(unused))
+	listed := unused
+	if len(listed) > maxListedUnused {
+		listed = listed[:maxListedUnused]
+	}
+	fmt.Fprintf(w, "  %s", strings.Join(listed, ", "))
+	if extra := len(unused) - len(listed); extra > 0 {
+		fmt.Fprintf(w, " (+%d more)", extra)
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Build information, stamped at link time:
+//
+//	go build -ldflags "-X main.version=v1.2.0 -X main.commit=$(git rev-parse --short HEAD) -X main.date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	version string
+	commit  string
+	date    string
+)
+
+// buildVersion returns the version, commit and build date, falling back to
+// the module version and VCS stamp recorded by the go command (go install,
+// go build in a checkout) for anything not set through -ldflags.
+func buildVersion() (ver, rev, built string) {
+	ver, rev, built = version, commit, date
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if ver == "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			ver = info.Main.Version
+		}
+		for _, s := range info.Settings {
+			switch {
+			case s.Key == "vcs.revision" && rev == "":
+				rev = s.Value
+			case s.Key == "vcs.time" && built == "":
+				built = s.Value
+			}
+		}
+	}
+	if ver == "" {
+		ver = "dev"
+	}
+	if rev == "" {
+		rev = "unknown"
+	}
+	if built == "" {
+		built = "unknown"
+	}
+	return ver, rev, built
+}
+
+// versionString formats the build information for -version.
+func versionString() string {
+	ver, rev, built := buildVersion()
+	return fmt.Sprintf("goifaces %s (commit %s, built %s)", ver, rev, built)
+}
+
+// shortVersion is the version and abbreviated commit, as shown in the
+// interactive page so screenshots can be traced to a build.
+func shortVersion() string {
+	ver, rev, _ := buildVersion()
+	if rev == "unknown" {
+		return ver
+	}
+	if len(rev) > 7 {
+		rev = rev[:7]
+	}
+	return ver + " (" + rev + ")"
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func stampVersion(t *testing.T, ver, rev, built string) {
+	t.Helper()
+	oldVersion, oldCommit, oldDate := version, commit, date
+	version, commit, date = ver, rev, built
+	t.Cleanup(func() { version, commit, date = oldVersion, oldCommit, oldDate })
+}
+
+func TestVersionString_Stamped(t *testing.T) {
+	stampVersion(t, "v1.2.0", "0123456789abcdef", "2026-01-02T03:04:05Z")
+
+	assert.Equal(t, "goifaces v1.2.0 (commit 0123456789abcdef, built 2026-01-02T03:04:05Z)", versionString())
+	assert.Equal(t, "v1.2.0 (0123456)", shortVersion())
+}
+
+func TestVersionString_Unstamped(t *testing.T) {
+	stampVersion(t, "", "", "")
+
+	ver, rev, built := buildVersion()
+	assert.NotEmpty(t, ver)
+	assert.NotEmpty(t, rev)
+	assert.NotEmpty(t, built)
+}
+
+func TestReorderArgs_Version(t *testing.T) {
+	flags, positional := reorderArgs([]string{"./app", "-version", "-output", "out.md"})
+	assert.Equal(t, []string{"-version", "-output", "out.md"}, flags)
+	assert.Equal(t, []string{"./app"}, positional, "-version takes no value")
+}
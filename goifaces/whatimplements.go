@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/olehluchkiv/goifaces/internal/analyzer"
+)
+
+// writeWhatImplements prints the -what-implements report: every interface typ
+// satisfies, whether only *T satisfies it, and the methods that do so.
+func writeWhatImplements(w io.Writer, typ *analyzer.TypeDef, sats []analyzer.Satisfaction) {
+	name := typ.PkgPath + "." + typ.Name
+	if len(sats) == 0 {
+		fmt.Fprintf(w, "%s satisfies no interfaces in scope\n", name)
+		return
+	}
+	noun := "interfaces"
+	if len(sats) == 1 {
+		noun = "interface"
+	}
+	fmt.Fprintf(w, "%s satisfies %d %s:\n", name, len(sats), noun)
+	for _, s := range sats {
+		fmt.Fprintf(w, "\n  %s.%s", s.Interface.PkgPath, s.Interface.Name)
+		if s.ViaPointer {
+			fmt.Fprintf(w, " (via pointer *%s)", typ.Name)
+		}
+		fmt.Fprintln(w)
+		for _, m := range s.Methods {
+			fmt.Fprintf(w, "      %s\n", m.Signature)
+		}
+	}
+}